@@ -4,53 +4,75 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"log"
+	"math"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/bmizerany/pat"
 	"github.com/hybridgroup/gobot"
 	"github.com/hybridgroup/gobot/api/robeaux"
 )
 
-// API represents an API server
+// API represents an API server. If Auth is set, every API request is
+// authenticated and authorized by token. If Audit is set, every command
+// executed through the API is recorded in it. If Limits is set, commands over
+// their limits are rejected with http.StatusTooManyRequests.
+//
+// The server uses TLS if Cert and Key are set, and also requires clients to
+// present a certificate signed by one of the CAs in the ClientCA file if it
+// is set. A zero ReadTimeout, WriteTimeout or IdleTimeout means no timeout.
+// Event streams, GraphQL subscriptions and WebSockets are not cut off by
+// ReadTimeout, and WriteTimeout only limits each of their writes, so that a
+// stream is closed when its client stops reading it.
+//
+// The WebSocket endpoint only accepts handshakes from pages of the API's own
+// origin, from origins matching one of the SocketOrigins patterns, which may
+// contain * wildcards as in AllowRequestsFrom, and from clients which do not
+// send an Origin, such as a remote connection.
 type API struct {
-	gobot    *gobot.Gobot
-	router   *pat.PatternServeMux
-	Host     string
-	Port     string
-	Cert     string
-	Key      string
-	handlers []func(http.ResponseWriter, *http.Request)
-	start    func(*API)
+	gobot         *gobot.Gobot
+	router        *pat.PatternServeMux
+	Host          string
+	Port          string
+	Cert          string
+	Key           string
+	ClientCA      string
+	ReadTimeout   time.Duration
+	WriteTimeout  time.Duration // limits each write of a stream, see above
+	IdleTimeout   time.Duration
+	SocketOrigins []string
+	Metrics       *gobot.Registry
+	Auth          *TokenAuth
+	Audit         AuditSink
+	Limits        *Limiter
+	handlers      []func(http.ResponseWriter, *http.Request)
+	start         func(*API) error
+	mutex         sync.Mutex
+	server        *http.Server
+	listener      net.Listener
+	closing       chan struct{}
+	conns         map[string]net.Conn
 }
 
 // NewAPI returns a new api instance
 func NewAPI(g *gobot.Gobot) *API {
 	return &API{
-		gobot:  g,
-		router: pat.New(),
-		Port:   "3000",
-		start: func(a *API) {
-			log.Println("Initializing API on " + a.Host + ":" + a.Port + "...")
-			http.Handle("/", a)
-
-			go func() {
-				if a.Cert != "" && a.Key != "" {
-					http.ListenAndServeTLS(a.Host+":"+a.Port, a.Cert, a.Key, nil)
-				} else {
-					log.Println("WARNING: API using insecure connection. " +
-						"We recommend using an SSL certificate with Gobot.")
-					http.ListenAndServe(a.Host+":"+a.Port, nil)
-				}
-			}()
-		},
+		gobot:   g,
+		router:  pat.New(),
+		Port:    "3000",
+		Metrics: gobot.DefaultRegistry,
+		start:   (*API).listen,
 	}
 }
 
 // ServeHTTP calls api handlers and then serves request using api router
 func (a *API) ServeHTTP(res http.ResponseWriter, req *http.Request) {
+	res.Header().Set(RequestIDHeader, requestID(req))
 	for _, handler := range a.handlers {
 		rec := httptest.NewRecorder()
 		handler(rec, req)
@@ -62,6 +84,17 @@ func (a *API) ServeHTTP(res http.ResponseWriter, req *http.Request) {
 			return
 		}
 	}
+	if a.Auth != nil {
+		switch a.Auth.authorize(req) {
+		case http.StatusUnauthorized:
+			res.Header().Set("WWW-Authenticate", "Bearer realm=\"Authorization Required\"")
+			http.Error(res, "Not Authorized", http.StatusUnauthorized)
+			return
+		case http.StatusForbidden:
+			http.Error(res, "Forbidden", http.StatusForbidden)
+			return
+		}
+	}
 	a.router.ServeHTTP(res, req)
 }
 
@@ -100,8 +133,10 @@ func (a *API) AddHandler(f func(http.ResponseWriter, *http.Request)) {
 	a.handlers = append(a.handlers, f)
 }
 
-// Start initializes the api by setting up c3pio routes and robeaux
-func (a *API) Start() {
+// Start initializes the api by setting up c3pio routes and robeaux, and
+// starts serving them. It returns an error if the server could not be
+// started, such as when its address is already in use.
+func (a *API) Start() error {
 	mcpCommandRoute := "/api/commands/:command"
 	robotDeviceCommandRoute := "/api/robots/:robot/devices/:device/commands/:command"
 	robotCommandRoute := "/api/robots/:robot/commands/:command"
@@ -115,14 +150,28 @@ func (a *API) Start() {
 	a.Get(robotCommandRoute, a.executeRobotCommand)
 	a.Post(robotCommandRoute, a.executeRobotCommand)
 	a.Get("/api/robots/:robot/devices", a.robotDevices)
+	a.Post("/api/robots/:robot/devices", a.attachRobotDevice)
 	a.Get("/api/robots/:robot/devices/:device", a.robotDevice)
+	a.Delete("/api/robots/:robot/devices/:device", a.detachRobotDevice)
+	a.Post("/api/robots/:robot/devices/:device/start", a.startRobotDevice)
+	a.Post("/api/robots/:robot/devices/:device/halt", a.haltRobotDevice)
 	a.Get("/api/robots/:robot/devices/:device/events/:event", a.robotDeviceEvent)
+	a.Get("/api/robots/:robot/devices/:device/state", a.robotDeviceState)
 	a.Get("/api/robots/:robot/devices/:device/commands", a.robotDeviceCommands)
 	a.Get(robotDeviceCommandRoute, a.executeRobotDeviceCommand)
 	a.Post(robotDeviceCommandRoute, a.executeRobotDeviceCommand)
 	a.Get("/api/robots/:robot/connections", a.robotConnections)
+	a.Post("/api/robots/:robot/connections", a.attachRobotConnection)
 	a.Get("/api/robots/:robot/connections/:connection", a.robotConnection)
+	a.Delete("/api/robots/:robot/connections/:connection", a.detachRobotConnection)
+	a.Post("/api/robots/:robot/connections/:connection/start", a.startRobotConnection)
+	a.Post("/api/robots/:robot/connections/:connection/halt", a.haltRobotConnection)
+	a.Get("/api/socket", a.socketHandler())
+	a.Get("/api/openapi.json", a.openAPI)
+	a.Get("/api/graphql", a.graphql)
+	a.Post("/api/graphql", a.graphql)
 	a.Get("/api/", a.mcp)
+	a.Get("/metrics", a.metrics)
 
 	a.Get("/", func(res http.ResponseWriter, req *http.Request) {
 		http.Redirect(res, req, "/index.html", http.StatusMovedPermanently)
@@ -137,7 +186,7 @@ func (a *API) Start() {
 	a.Get("/css/:a/:b", a.robeaux)
 	a.Get("/partials/:a", a.robeaux)
 
-	a.start(a)
+	return a.start(a)
 }
 
 // robeaux returns handler for robeaux routes.
@@ -160,6 +209,15 @@ func (a *API) robeaux(res http.ResponseWriter, req *http.Request) {
 	res.Write(buf)
 }
 
+// metrics returns the metrics route handler.
+// Writes the metrics of the API Registry in the Prometheus text format
+func (a *API) metrics(res http.ResponseWriter, req *http.Request) {
+	res.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	if err := a.Metrics.WritePrometheus(res); err != nil {
+		a.gobot.Logger.Error("Unable to write metrics", "error", err)
+	}
+}
+
 // mcp returns MCP route handler.
 // Writes JSON with gobot representation
 func (a *API) mcp(res http.ResponseWriter, req *http.Request) {
@@ -229,6 +287,7 @@ func (a *API) robotDevice(res http.ResponseWriter, req *http.Request) {
 func (a *API) robotDeviceEvent(res http.ResponseWriter, req *http.Request) {
 	f, _ := res.(http.Flusher)
 	c, _ := res.(http.CloseNotifier)
+	conn := a.streamConn(req)
 
 	dataChan := make(chan string)
 	closer := c.CloseNotify()
@@ -243,18 +302,28 @@ func (a *API) robotDeviceEvent(res http.ResponseWriter, req *http.Request) {
 	if event := a.gobot.Robot(req.URL.Query().Get(":robot")).
 		Device(req.URL.Query().Get(":device")).(gobot.Eventer).
 		Event(req.URL.Query().Get(":event")); len(event) > 0 {
-		device.(gobot.Eventer).On(event, func(data interface{}) {
+		done := make(chan struct{})
+		defer close(done)
+
+		sub, _ := device.(gobot.Eventer).On(event, func(data interface{}) {
 			d, _ := json.Marshal(data)
-			dataChan <- string(d)
+			select {
+			case dataChan <- string(d):
+			case <-done:
+			}
 		})
+		defer sub.Cancel()
 
 		for {
 			select {
 			case data := <-dataChan:
+				a.extendWriteDeadline(conn)
 				fmt.Fprintf(res, "data: %v\n\n", data)
 				f.Flush()
 			case <-closer:
-				log.Println("Closing connection")
+				a.gobot.Logger.Debug("Closing connection", "event", req.URL.Query().Get(":event"))
+				return
+			case <-a.closingChan():
 				return
 			}
 		}
@@ -275,6 +344,18 @@ func (a *API) robotDeviceCommands(res http.ResponseWriter, req *http.Request) {
 	}
 }
 
+// robotDeviceState returns device state route handler
+// writes JSON with the current state of a device which implements gobot.Stater
+func (a *API) robotDeviceState(res http.ResponseWriter, req *http.Request) {
+	if device, err := a.jsonDeviceFor(req.URL.Query().Get(":robot"), req.URL.Query().Get(":device")); err != nil {
+		a.writeJSON(map[string]interface{}{"error": err.Error()}, res)
+	} else if device.State == nil {
+		a.writeJSON(map[string]interface{}{"error": "No state found for the Device " + device.Name}, res)
+	} else {
+		a.writeJSON(map[string]interface{}{"state": device.State}, res)
+	}
+}
+
 // robotConnections returns connections route handler
 // writes JSON with robot connections representation
 func (a *API) robotConnections(res http.ResponseWriter, req *http.Request) {
@@ -300,9 +381,124 @@ func (a *API) robotConnection(res http.ResponseWriter, req *http.Request) {
 	}
 }
 
+// attachRobotDevice creates a device from the DeviceConfig in the request
+// body using the registered drivers, and attaches it to the robot.
+func (a *API) attachRobotDevice(res http.ResponseWriter, req *http.Request) {
+	robot := a.gobot.Robot(req.URL.Query().Get(":robot"))
+	if robot == nil {
+		a.writeJSON(map[string]interface{}{"error": "No Robot found with the name " + req.URL.Query().Get(":robot")}, res)
+		return
+	}
+
+	config := gobot.DeviceConfig{}
+	if err := json.NewDecoder(req.Body).Decode(&config); err != nil {
+		a.writeJSON(map[string]interface{}{"error": err.Error()}, res)
+		return
+	}
+	connection, err := config.ConnectionFrom(*robot.Connections())
+	if err != nil {
+		a.writeJSON(map[string]interface{}{"error": err.Error()}, res)
+		return
+	}
+	device, err := gobot.NewDriver(connection, config)
+	if err != nil {
+		a.writeJSON(map[string]interface{}{"error": err.Error()}, res)
+		return
+	}
+	if errs := robot.AttachDevice(device); len(errs) > 0 {
+		a.writeJSON(map[string]interface{}{"error": errs[0].Error()}, res)
+		return
+	}
+	a.writeJSON(map[string]interface{}{"device": gobot.NewJSONDevice(device)}, res)
+}
+
+// detachRobotDevice halts and removes a device from the robot
+func (a *API) detachRobotDevice(res http.ResponseWriter, req *http.Request) {
+	a.robotLifecycle(res, req, func(r *gobot.Robot) []error {
+		return r.DetachDevice(req.URL.Query().Get(":device"))
+	})
+}
+
+// startRobotDevice starts a device of a running robot
+func (a *API) startRobotDevice(res http.ResponseWriter, req *http.Request) {
+	a.robotLifecycle(res, req, func(r *gobot.Robot) []error {
+		return r.StartDevice(req.URL.Query().Get(":device"))
+	})
+}
+
+// haltRobotDevice halts a device of a running robot
+func (a *API) haltRobotDevice(res http.ResponseWriter, req *http.Request) {
+	a.robotLifecycle(res, req, func(r *gobot.Robot) []error {
+		return r.HaltDevice(req.URL.Query().Get(":device"))
+	})
+}
+
+// attachRobotConnection creates a connection from the ConnectionConfig in the
+// request body using the registered adaptors, and attaches it to the robot.
+func (a *API) attachRobotConnection(res http.ResponseWriter, req *http.Request) {
+	robot := a.gobot.Robot(req.URL.Query().Get(":robot"))
+	if robot == nil {
+		a.writeJSON(map[string]interface{}{"error": "No Robot found with the name " + req.URL.Query().Get(":robot")}, res)
+		return
+	}
+
+	config := gobot.ConnectionConfig{}
+	if err := json.NewDecoder(req.Body).Decode(&config); err != nil {
+		a.writeJSON(map[string]interface{}{"error": err.Error()}, res)
+		return
+	}
+	connection, err := gobot.NewAdaptor(config)
+	if err != nil {
+		a.writeJSON(map[string]interface{}{"error": err.Error()}, res)
+		return
+	}
+	if errs := robot.AttachConnection(connection); len(errs) > 0 {
+		a.writeJSON(map[string]interface{}{"error": errs[0].Error()}, res)
+		return
+	}
+	a.writeJSON(map[string]interface{}{"connection": gobot.NewJSONConnection(connection)}, res)
+}
+
+// detachRobotConnection finalizes and removes a connection from the robot
+func (a *API) detachRobotConnection(res http.ResponseWriter, req *http.Request) {
+	a.robotLifecycle(res, req, func(r *gobot.Robot) []error {
+		return r.DetachConnection(req.URL.Query().Get(":connection"))
+	})
+}
+
+// startRobotConnection connects a connection of a running robot
+func (a *API) startRobotConnection(res http.ResponseWriter, req *http.Request) {
+	a.robotLifecycle(res, req, func(r *gobot.Robot) []error {
+		return r.StartConnection(req.URL.Query().Get(":connection"))
+	})
+}
+
+// haltRobotConnection finalizes a connection of a running robot
+func (a *API) haltRobotConnection(res http.ResponseWriter, req *http.Request) {
+	a.robotLifecycle(res, req, func(r *gobot.Robot) []error {
+		return r.HaltConnection(req.URL.Query().Get(":connection"))
+	})
+}
+
+// robotLifecycle calls f with the requested robot and writes JSON with the
+// first error f returns, or the robot state if there are none.
+func (a *API) robotLifecycle(res http.ResponseWriter, req *http.Request, f func(*gobot.Robot) []error) {
+	robot := a.gobot.Robot(req.URL.Query().Get(":robot"))
+	if robot == nil {
+		a.writeJSON(map[string]interface{}{"error": "No Robot found with the name " + req.URL.Query().Get(":robot")}, res)
+		return
+	}
+	if errs := f(robot); len(errs) > 0 {
+		a.writeJSON(map[string]interface{}{"error": errs[0].Error()}, res)
+		return
+	}
+	a.writeJSON(map[string]interface{}{"state": robot.State()}, res)
+}
+
 // executeMcpCommand calls a global command associated to requested route
 func (a *API) executeMcpCommand(res http.ResponseWriter, req *http.Request) {
-	a.executeCommand(a.gobot.Command(req.URL.Query().Get(":command")),
+	a.executeCommand(a.gobot,
+		req.URL.Query().Get(":command"),
 		res,
 		req,
 	)
@@ -313,14 +509,16 @@ func (a *API) executeRobotDeviceCommand(res http.ResponseWriter, req *http.Reque
 	if _, err := a.jsonDeviceFor(req.URL.Query().Get(":robot"),
 		req.URL.Query().Get(":device")); err != nil {
 		a.writeJSON(map[string]interface{}{"error": err.Error()}, res)
-	} else {
+	} else if commander, ok := a.gobot.Robot(req.URL.Query().Get(":robot")).
+		Device(req.URL.Query().Get(":device")).(gobot.Commander); ok {
 		a.executeCommand(
-			a.gobot.Robot(req.URL.Query().Get(":robot")).
-				Device(req.URL.Query().Get(":device")).(gobot.Commander).
-				Command(req.URL.Query().Get(":command")),
+			commander,
+			req.URL.Query().Get(":command"),
 			res,
 			req,
 		)
+	} else {
+		a.writeJSON(map[string]interface{}{"error": gobot.ErrUnknownCommand.Error()}, res)
 	}
 }
 
@@ -330,16 +528,18 @@ func (a *API) executeRobotCommand(res http.ResponseWriter, req *http.Request) {
 		a.writeJSON(map[string]interface{}{"error": err.Error()}, res)
 	} else {
 		a.executeCommand(
-			a.gobot.Robot(req.URL.Query().Get(":robot")).
-				Command(req.URL.Query().Get(":command")),
+			a.gobot.Robot(req.URL.Query().Get(":robot")),
+			req.URL.Query().Get(":command"),
 			res,
 			req,
 		)
 	}
 }
 
-// executeCommand writes JSON response with `f` returned value.
-func (a *API) executeCommand(f func(map[string]interface{}) interface{},
+// executeCommand validates the request parameters against the command schema,
+// if any, and writes JSON response with the command's returned value.
+func (a *API) executeCommand(commander gobot.Commander,
+	name string,
 	res http.ResponseWriter,
 	req *http.Request,
 ) {
@@ -347,10 +547,20 @@ func (a *API) executeCommand(f func(map[string]interface{}) interface{},
 	body := make(map[string]interface{})
 	json.NewDecoder(req.Body).Decode(&body)
 
-	if f != nil {
-		a.writeJSON(map[string]interface{}{"result": f(body)}, res)
+	if result, err := a.execute(req, requestID(req), commander,
+		req.URL.Query().Get(":robot"), req.URL.Query().Get(":device"), name, body); err != nil {
+		if limited, ok := err.(*RateLimitError); ok {
+			seconds := math.Ceil(limited.RetryAfter.Seconds())
+			res.Header().Set("Retry-After", strconv.Itoa(int(seconds)))
+			res.Header().Set("Content-Type", "application/json; charset=utf-8")
+			res.WriteHeader(http.StatusTooManyRequests)
+			data, _ := json.Marshal(map[string]interface{}{"error": err.Error(), "retry_after": limited.RetryAfter.Seconds()})
+			res.Write(data)
+			return
+		}
+		a.writeJSON(map[string]interface{}{"error": err.Error()}, res)
 	} else {
-		a.writeJSON(map[string]interface{}{"error": "Unknown Command"}, res)
+		a.writeJSON(map[string]interface{}{"result": result}, res)
 	}
 }
 
@@ -364,7 +574,8 @@ func (a *API) writeJSON(j interface{}, res http.ResponseWriter) {
 // Debug add handler to api that prints each request
 func (a *API) Debug() {
 	a.AddHandler(func(res http.ResponseWriter, req *http.Request) {
-		log.Println(req)
+		a.gobot.Logger.Info("Request", "method", req.Method, "url", req.URL, "remote", req.RemoteAddr,
+			"request_id", req.Header.Get(RequestIDHeader))
 	})
 }
 
@@ -19,7 +19,7 @@ func initTestAPI() *API {
 	log.SetOutput(NullReadWriteCloser{})
 	g := gobot.NewGobot()
 	a := NewAPI(g)
-	a.start = func(m *API) {}
+	a.start = func(m *API) error { return nil }
 	a.Start()
 	a.Debug()
 
@@ -255,6 +255,47 @@ func TestRobotDevice(t *testing.T) {
 	gobottest.Assert(t, body["error"], "No Device found with the name UnknownDevice1")
 }
 
+func TestRobotDeviceState(t *testing.T) {
+	a := initTestAPI()
+	a.gobot.Robot("Robot1").AddDevice(&testStaterDriver{
+		testDriver: newTestDriver(newTestAdaptor("Connection1", "/dev/null"), "Servo1", "3"),
+		angle:      90,
+	})
+
+	request, _ := http.NewRequest("GET", "/api/robots/Robot1/devices/Servo1/state", nil)
+	response := httptest.NewRecorder()
+	a.ServeHTTP(response, request)
+
+	var body map[string]interface{}
+	json.NewDecoder(response.Body).Decode(&body)
+	gobottest.Assert(t, body["state"], map[string]interface{}{"angle": 90.0})
+
+	// the state is part of the device
+	request, _ = http.NewRequest("GET", "/api/robots/Robot1/devices/Servo1", nil)
+	response = httptest.NewRecorder()
+	a.ServeHTTP(response, request)
+
+	json.NewDecoder(response.Body).Decode(&body)
+	gobottest.Assert(t, body["device"].(map[string]interface{})["state"], map[string]interface{}{"angle": 90.0})
+
+	// devices without state
+	request, _ = http.NewRequest("GET", "/api/robots/Robot1/devices/Device1/state", nil)
+	response = httptest.NewRecorder()
+	a.ServeHTTP(response, request)
+
+	body = map[string]interface{}{}
+	json.NewDecoder(response.Body).Decode(&body)
+	gobottest.Assert(t, body["error"], "No state found for the Device Device1")
+
+	// unknown device
+	request, _ = http.NewRequest("GET", "/api/robots/Robot1/devices/UnknownDevice1/state", nil)
+	response = httptest.NewRecorder()
+	a.ServeHTTP(response, request)
+
+	json.NewDecoder(response.Body).Decode(&body)
+	gobottest.Assert(t, body["error"], "No Device found with the name UnknownDevice1")
+}
+
 func TestRobotDeviceCommands(t *testing.T) {
 	a := initTestAPI()
 
@@ -321,6 +362,54 @@ func TestExecuteRobotDeviceCommand(t *testing.T) {
 
 }
 
+func TestExecuteRobotDeviceCommandSchema(t *testing.T) {
+	var body interface{}
+	a := initTestAPI()
+	a.gobot.Robot("Robot1").Device("Device1").(gobot.SchemaCommander).
+		AddCommandWithSchema("Move", gobot.CommandSchema{
+			Params: []gobot.Param{
+				{Name: "angle", Type: gobot.ParamInteger, Required: true, Range: &gobot.Range{Min: 0, Max: 180}},
+			},
+		}, func(params map[string]interface{}) interface{} {
+			return params["angle"].(int) * 2
+		})
+
+	// valid params
+	request, _ := http.NewRequest("POST",
+		"/api/robots/Robot1/devices/Device1/commands/Move",
+		bytes.NewBufferString(`{"angle":45}`),
+	)
+	request.Header.Add("Content-Type", "application/json")
+	response := httptest.NewRecorder()
+	a.ServeHTTP(response, request)
+
+	json.NewDecoder(response.Body).Decode(&body)
+	gobottest.Assert(t, body.(map[string]interface{})["result"], 90.0)
+
+	// invalid params
+	request, _ = http.NewRequest("POST",
+		"/api/robots/Robot1/devices/Device1/commands/Move",
+		bytes.NewBufferString(`{"angle":270}`),
+	)
+	request.Header.Add("Content-Type", "application/json")
+	response = httptest.NewRecorder()
+	a.ServeHTTP(response, request)
+
+	body = nil
+	json.NewDecoder(response.Body).Decode(&body)
+	gobottest.Assert(t, body.(map[string]interface{})["error"], "Parameter \"angle\" must be between 0 and 180")
+
+	// schema is published with the device
+	request, _ = http.NewRequest("GET", "/api/robots/Robot1/devices/Device1", nil)
+	response = httptest.NewRecorder()
+	a.ServeHTTP(response, request)
+
+	body = nil
+	json.NewDecoder(response.Body).Decode(&body)
+	schemas := body.(map[string]interface{})["device"].(map[string]interface{})["command_schemas"]
+	gobottest.Refute(t, schemas.(map[string]interface{})["Move"], nil)
+}
+
 func TestRobotConnections(t *testing.T) {
 	a := initTestAPI()
 
@@ -455,3 +544,163 @@ func TestAPIRouter(t *testing.T) {
 	a.ServeHTTP(response, request)
 	gobottest.Assert(t, response.Code, 200)
 }
+
+func init() {
+	gobot.RegisterAdaptor("api_test", func(config gobot.ConnectionConfig) (gobot.Adaptor, error) {
+		return newTestAdaptor(config.Name, config.Port), nil
+	})
+	gobot.RegisterDriver("api_test", func(c gobot.Connection, config gobot.DeviceConfig) (gobot.Driver, error) {
+		return newTestDriver(c.(*testAdaptor), config.Name, config.Pin), nil
+	})
+}
+
+func TestAttachRobotDeviceDefaultConnection(t *testing.T) {
+	a := initTestAPI()
+	a.gobot.AddRobot(gobot.NewRobot("Robot9",
+		[]gobot.Connection{newTestAdaptor("Connection1", "/dev/null")},
+	))
+	a.gobot.AddRobot(gobot.NewRobot("Robot10",
+		[]gobot.Connection{
+			newTestAdaptor("Connection1", "/dev/null"),
+			newTestAdaptor("Connection2", "/dev/null"),
+		},
+	))
+
+	// the only connection is used when the body names none
+	request, _ := http.NewRequest("POST",
+		"/api/robots/Robot9/devices",
+		bytes.NewBufferString(`{"name":"Device4","driver":"api_test","pin":"4"}`),
+	)
+	response := httptest.NewRecorder()
+	a.ServeHTTP(response, request)
+
+	var body map[string]interface{}
+	json.NewDecoder(response.Body).Decode(&body)
+	gobottest.Assert(t, body["device"].(map[string]interface{})["connection"], "Connection1")
+
+	// but must be named when there are several
+	request, _ = http.NewRequest("POST",
+		"/api/robots/Robot10/devices",
+		bytes.NewBufferString(`{"name":"Device4","driver":"api_test","pin":"4"}`),
+	)
+	response = httptest.NewRecorder()
+	a.ServeHTTP(response, request)
+
+	body = nil
+	json.NewDecoder(response.Body).Decode(&body)
+	gobottest.Assert(t, body["error"], "No Connection found with the name \"\"")
+}
+
+func TestAttachDetachRobotDevice(t *testing.T) {
+	a := initTestAPI()
+	a.gobot.Robot("Robot1").Start()
+
+	// attach
+	request, _ := http.NewRequest("POST",
+		"/api/robots/Robot1/devices",
+		bytes.NewBufferString(`{"name":"Device4","driver":"api_test","connection":"Connection1","pin":"4"}`),
+	)
+	response := httptest.NewRecorder()
+	a.ServeHTTP(response, request)
+
+	var body map[string]interface{}
+	json.NewDecoder(response.Body).Decode(&body)
+	gobottest.Assert(t, body["device"].(map[string]interface{})["name"], "Device4")
+	gobottest.Assert(t, a.gobot.Robot("Robot1").DeviceState("Device4"), gobot.StateRunning)
+
+	// unknown driver
+	request, _ = http.NewRequest("POST",
+		"/api/robots/Robot1/devices",
+		bytes.NewBufferString(`{"name":"Device5","driver":"unknown","connection":"Connection1"}`),
+	)
+	response = httptest.NewRecorder()
+	a.ServeHTTP(response, request)
+
+	body = nil
+	json.NewDecoder(response.Body).Decode(&body)
+	gobottest.Assert(t, body["error"], "Unknown driver \"unknown\"")
+
+	// halt and start
+	request, _ = http.NewRequest("POST", "/api/robots/Robot1/devices/Device4/halt", nil)
+	response = httptest.NewRecorder()
+	a.ServeHTTP(response, request)
+
+	body = nil
+	json.NewDecoder(response.Body).Decode(&body)
+	gobottest.Assert(t, body["state"], "degraded")
+
+	request, _ = http.NewRequest("POST", "/api/robots/Robot1/devices/Device4/start", nil)
+	response = httptest.NewRecorder()
+	a.ServeHTTP(response, request)
+
+	body = nil
+	json.NewDecoder(response.Body).Decode(&body)
+	gobottest.Assert(t, body["state"], "running")
+
+	// detach
+	request, _ = http.NewRequest("DELETE", "/api/robots/Robot1/devices/Device4", nil)
+	response = httptest.NewRecorder()
+	a.ServeHTTP(response, request)
+
+	body = nil
+	json.NewDecoder(response.Body).Decode(&body)
+	gobottest.Assert(t, body["state"], "running")
+	gobottest.Assert(t, a.gobot.Robot("Robot1").Device("Device4"), (gobot.Device)(nil))
+
+	// unknown robot
+	request, _ = http.NewRequest("DELETE", "/api/robots/UnknownRobot1/devices/Device4", nil)
+	response = httptest.NewRecorder()
+	a.ServeHTTP(response, request)
+
+	body = nil
+	json.NewDecoder(response.Body).Decode(&body)
+	gobottest.Assert(t, body["error"], "No Robot found with the name UnknownRobot1")
+}
+
+func TestAttachDetachRobotConnection(t *testing.T) {
+	a := initTestAPI()
+	a.gobot.Robot("Robot1").Start()
+
+	request, _ := http.NewRequest("POST",
+		"/api/robots/Robot1/connections",
+		bytes.NewBufferString(`{"name":"Connection4","adaptor":"api_test","port":"/dev/null"}`),
+	)
+	response := httptest.NewRecorder()
+	a.ServeHTTP(response, request)
+
+	var body map[string]interface{}
+	json.NewDecoder(response.Body).Decode(&body)
+	gobottest.Assert(t, body["connection"].(map[string]interface{})["name"], "Connection4")
+	gobottest.Assert(t, a.gobot.Robot("Robot1").ConnectionState("Connection4"), gobot.StateRunning)
+
+	request, _ = http.NewRequest("DELETE", "/api/robots/Robot1/connections/Connection1", nil)
+	response = httptest.NewRecorder()
+	a.ServeHTTP(response, request)
+
+	body = nil
+	json.NewDecoder(response.Body).Decode(&body)
+	gobottest.Assert(t, body["error"], "Connection \"Connection1\" is used by device \"Device1\"")
+
+	request, _ = http.NewRequest("DELETE", "/api/robots/Robot1/connections/Connection4", nil)
+	response = httptest.NewRecorder()
+	a.ServeHTTP(response, request)
+
+	body = nil
+	json.NewDecoder(response.Body).Decode(&body)
+	gobottest.Assert(t, body["state"], "running")
+	gobottest.Assert(t, a.gobot.Robot("Robot1").Connection("Connection4"), (gobot.Connection)(nil))
+}
+
+func TestMetrics(t *testing.T) {
+	a := initTestAPI()
+	a.Metrics = gobot.NewRegistry()
+	a.Metrics.Counter("test_total", "A test counter.", gobot.Labels{"robot": "Robot1"}).Inc()
+
+	request, _ := http.NewRequest("GET", "/metrics", nil)
+	response := httptest.NewRecorder()
+	a.ServeHTTP(response, request)
+
+	gobottest.Assert(t, response.Code, 200)
+	gobottest.Assert(t, response.Header().Get("Content-Type"), "text/plain; version=0.0.4; charset=utf-8")
+	gobottest.Assert(t, response.Body.String(), "# HELP test_total A test counter.\n# TYPE test_total counter\ntest_total{robot=\"Robot1\"} 1\n")
+}
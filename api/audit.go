@@ -0,0 +1,226 @@
+package api
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/hybridgroup/gobot"
+)
+
+const (
+	// AuditEvent is published by the sink returned by NewAuditEventer with
+	// each AuditRecord
+	AuditEvent = "audit"
+
+	// RequestIDHeader carries the ID of each request. It is set on responses,
+	// and taken from requests which already have one.
+	RequestIDHeader = "X-Request-ID"
+)
+
+// AuditRecord records one command executed through the API.
+type AuditRecord struct {
+	Time      time.Time              `json:"time"`
+	RequestID string                 `json:"request_id"`
+	Caller    string                 `json:"caller,omitempty"`
+	Remote    string                 `json:"remote"`
+	Robot     string                 `json:"robot,omitempty"`
+	Device    string                 `json:"device,omitempty"`
+	Command   string                 `json:"command"`
+	Params    map[string]interface{} `json:"params"`
+	Result    interface{}            `json:"result,omitempty"`
+	Error     string                 `json:"error,omitempty"`
+	Duration  time.Duration          `json:"duration"`
+}
+
+// AuditSink receives a record of every command executed through the API.
+type AuditSink interface {
+	Audit(record AuditRecord)
+}
+
+// AuditFunc is an AuditSink which calls itself with each record.
+type AuditFunc func(record AuditRecord)
+
+// Audit calls f with record
+func (f AuditFunc) Audit(record AuditRecord) { f(record) }
+
+// NewAuditEventer returns an AuditSink which publishes each record as an
+// AuditEvent on e, such as the Eventer of a Gobot.
+func NewAuditEventer(e gobot.Eventer) AuditSink {
+	e.AddEvent(AuditEvent)
+	return AuditFunc(func(record AuditRecord) {
+		e.Publish(AuditEvent, record)
+	})
+}
+
+// AuditFile is an AuditSink which writes each record to a file as a line of
+// JSON. When the file grows beyond MaxSize bytes it is rotated: path is
+// renamed to path.1, path.1 to path.2 and so on, keeping at most Backups old
+// files.
+type AuditFile struct {
+	path    string
+	MaxSize int64
+	Backups int
+	mutex   sync.Mutex
+	file    *os.File
+	size    int64
+}
+
+// NewAuditFile opens or creates the audit file at path.
+func NewAuditFile(path string, maxSize int64, backups int) (*AuditFile, error) {
+	f := &AuditFile{path: path, MaxSize: maxSize, Backups: backups}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (f *AuditFile) open() error {
+	file, err := os.OpenFile(f.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	f.file = file
+	f.size = info.Size()
+	return nil
+}
+
+// Audit writes record to the file, rotating it first if it is full.
+func (f *AuditFile) Audit(record AuditRecord) {
+	line, err := json.Marshal(record)
+	if err != nil {
+		record.Result = fmt.Sprint(record.Result)
+		line, _ = json.Marshal(record)
+	}
+	line = append(line, '\n')
+
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if f.file == nil {
+		return
+	}
+	if f.MaxSize > 0 && f.size > 0 && f.size+int64(len(line)) > f.MaxSize {
+		if err := f.rotate(); err != nil {
+			return
+		}
+	}
+	n, _ := f.file.Write(line)
+	f.size += int64(n)
+}
+
+// rotate moves the full file aside and opens a new one.
+func (f *AuditFile) rotate() error {
+	f.file.Close()
+	f.file = nil
+	if f.Backups > 0 {
+		os.Remove(f.path + "." + strconv.Itoa(f.Backups))
+		for i := f.Backups - 1; i > 0; i-- {
+			os.Rename(f.path+"."+strconv.Itoa(i), f.path+"."+strconv.Itoa(i+1))
+		}
+		os.Rename(f.path, f.path+".1")
+	} else {
+		os.Remove(f.path)
+	}
+	return f.open()
+}
+
+// Close closes the file. Records audited afterwards are discarded.
+func (f *AuditFile) Close() error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if f.file == nil {
+		return nil
+	}
+	err := f.file.Close()
+	f.file = nil
+	return err
+}
+
+// newRequestID returns a random request ID.
+func newRequestID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// requestID returns the ID of req, giving it a new one if it has none.
+func requestID(req *http.Request) string {
+	id := req.Header.Get(RequestIDHeader)
+	if id == "" {
+		id = newRequestID()
+		req.Header.Set(RequestIDHeader, id)
+	}
+	return id
+}
+
+// caller returns the identity of the client of req, if it is known.
+func (a *API) caller(req *http.Request) string {
+	if a.Auth != nil {
+		if subject, err := a.Auth.Subject(req); err == nil {
+			return subject
+		}
+	}
+	if user, _, ok := req.BasicAuth(); ok {
+		return user
+	}
+	return ""
+}
+
+// execute calls a command with the request ID of req in its context if it
+// is within its limits, and records the call in the audit sink if there is
+// one.
+func (a *API) execute(req *http.Request, id string, commander gobot.Commander,
+	robot string, device string, name string, params map[string]interface{},
+) (result interface{}, err error) {
+	ctx := gobot.WithRequestID(req.Context(), id)
+	start := time.Now()
+	release := func() {}
+	if commander.Command(name) == nil {
+		err = gobot.ErrUnknownCommand
+	} else if a.Limits != nil {
+		release, err = a.Limits.AcquireContext(ctx, robot, device, name)
+	}
+	if err == nil {
+		func() {
+			defer release()
+			result, err = gobot.ExecuteCommand(ctx, commander, name, params)
+		}()
+	}
+
+	a.gobot.Logger.Debug("Command executed", "request_id", id, "robot", robot, "device", device, "command", name)
+	if a.Audit == nil {
+		return
+	}
+
+	record := AuditRecord{
+		Time:      start,
+		RequestID: id,
+		Caller:    a.caller(req),
+		Remote:    req.RemoteAddr,
+		Robot:     robot,
+		Device:    device,
+		Command:   name,
+		Params:    params,
+		Result:    result,
+		Duration:  time.Since(start),
+	}
+	if err != nil {
+		record.Error = err.Error()
+	} else if e, ok := result.(error); ok {
+		record.Result = nil
+		record.Error = e.Error()
+	}
+	a.Audit.Audit(record)
+	return
+}
@@ -0,0 +1,124 @@
+package api
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hybridgroup/gobot"
+	"github.com/hybridgroup/gobot/gobottest"
+)
+
+func TestAuditCommands(t *testing.T) {
+	a := initTestAPI()
+	records := []AuditRecord{}
+	a.Audit = AuditFunc(func(record AuditRecord) {
+		records = append(records, record)
+	})
+	a.Auth = NewTokenAuth()
+	a.Auth.UseHMACKey([]byte("secret"))
+	token, _ := a.Auth.IssueToken("eve", []string{"operator"}, 0)
+
+	a.gobot.Robot("Robot1").Device("Device1").(gobot.ContextCommander).
+		AddCommandContext("TakeOff", func(ctx context.Context, params map[string]interface{}) interface{} {
+			return gobot.RequestID(ctx)
+		})
+
+	request, _ := http.NewRequest("POST",
+		"/api/robots/Robot1/devices/Device1/commands/TakeOff",
+		bytes.NewBufferString(`{"height":2}`),
+	)
+	request.Header.Set("Authorization", "Bearer "+token)
+	request.Header.Set(RequestIDHeader, "flight-42")
+	response := httptest.NewRecorder()
+	a.ServeHTTP(response, request)
+
+	var body map[string]interface{}
+	json.NewDecoder(response.Body).Decode(&body)
+	gobottest.Assert(t, body["result"], "flight-42")
+	gobottest.Assert(t, response.Header().Get(RequestIDHeader), "flight-42")
+
+	gobottest.Assert(t, len(records), 1)
+	gobottest.Assert(t, records[0].RequestID, "flight-42")
+	gobottest.Assert(t, records[0].Caller, "eve")
+	gobottest.Assert(t, records[0].Robot, "Robot1")
+	gobottest.Assert(t, records[0].Device, "Device1")
+	gobottest.Assert(t, records[0].Command, "TakeOff")
+	gobottest.Assert(t, records[0].Params, map[string]interface{}{"height": 2.0})
+	gobottest.Assert(t, records[0].Result, "flight-42")
+	gobottest.Assert(t, records[0].Error, "")
+
+	// unknown commands are recorded with their error, and requests without
+	// an ID are given one
+	request, _ = http.NewRequest("POST", "/api/commands/Land", bytes.NewBufferString(`{}`))
+	request.Header.Set("Authorization", "Bearer "+token)
+	response = httptest.NewRecorder()
+	a.ServeHTTP(response, request)
+
+	gobottest.Assert(t, len(records), 2)
+	gobottest.Assert(t, records[1].Robot, "")
+	gobottest.Assert(t, records[1].Error, gobot.ErrUnknownCommand.Error())
+	gobottest.Refute(t, records[1].RequestID, "")
+	gobottest.Assert(t, response.Header().Get(RequestIDHeader), records[1].RequestID)
+}
+
+func TestAuditEventer(t *testing.T) {
+	a := initTestAPI()
+	a.Audit = NewAuditEventer(a.gobot)
+
+	audited := make(chan interface{}, 1)
+	a.gobot.Once(AuditEvent, func(data interface{}) {
+		audited <- data
+	})
+
+	request, _ := http.NewRequest("POST", "/api/commands/TestFunction", bytes.NewBufferString(`{"message":"Beep Boop"}`))
+	request.SetBasicAuth("gort", "klatuu")
+	a.ServeHTTP(httptest.NewRecorder(), request)
+
+	record := (<-audited).(AuditRecord)
+	gobottest.Assert(t, record.Caller, "gort")
+	gobottest.Assert(t, record.Result, "hey Beep Boop")
+}
+
+func TestAuditFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "audit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "audit.log")
+
+	f, err := NewAuditFile(path, 200, 2)
+	gobottest.Assert(t, err, nil)
+	for _, command := range []string{"one", "two", "three", "four"} {
+		f.Audit(AuditRecord{RequestID: "id", Command: command, Params: map[string]interface{}{}})
+	}
+	gobottest.Assert(t, f.Close(), nil)
+
+	readCommands := func(path string) (commands []string) {
+		file, err := os.Open(path)
+		if err != nil {
+			return
+		}
+		defer file.Close()
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			var record AuditRecord
+			json.Unmarshal(scanner.Bytes(), &record)
+			commands = append(commands, record.Command)
+		}
+		return
+	}
+
+	gobottest.Assert(t, readCommands(path), []string{"four"})
+	gobottest.Assert(t, readCommands(path+".1"), []string{"three"})
+	gobottest.Assert(t, readCommands(path+".2"), []string{"two"})
+	gobottest.Assert(t, len(readCommands(path+".3")), 0)
+}
@@ -33,7 +33,93 @@ Example:
     	gbot.Start()
     }
 
+Start returns an error if the server can not listen on its address, and
+Shutdown stops it gracefully:
+
+    ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+    defer cancel()
+    server.Shutdown(ctx)
+
 It follows Common Protocol for Programming Physical Input and Output (CPPP-IO) spec:
 https://github.com/hybridgroup/cppp-io
+
+Every response carries an X-Request-ID header, taken from the request if it
+has one. The ID is passed to commands added with AddCommandContext, and
+every command executed through the API is recorded with its caller, params,
+result and duration if Audit is set:
+
+    audit, _ := api.NewAuditFile("commands.log", 10<<20, 5)
+    server.Audit = audit
+
+Limits restricts the rate and concurrency of commands per device or per
+command. Commands over their limits get a 429 Too Many Requests response
+with a Retry-After header:
+
+    server.Limits = api.NewLimiter(
+    	api.Limit{Robot: "sphero", Device: "sphero", Rate: 10, Serialize: true},
+    	api.Limit{Command: "PwmWrite", Rate: 50, Burst: 10},
+    )
+
+An OpenAPI 3 document describing the routes, including a route for each
+command of the MCP and of every robot and device with its parameter schema,
+is served on /api/openapi.json.
+
+Devices whose drivers implement gobot.Stater, such as LEDs, servos and
+sensors, include their current state in their JSON, and serve it alone on
+/api/robots/:robot/devices/:device/state.
+
+The metrics of gobot.DefaultRegistry, such as events published and command
+latency for each robot and device, are served on /metrics in the Prometheus
+text format.
+
+A WebSocket on /api/socket carries the events and commands of every robot
+and device over one connection. Clients send JSON messages such as
+
+    {"id": "1", "action": "subscribe", "robot": "Eve", "device": "led", "event": "*"}
+    {"id": "2", "action": "unsubscribe", "robot": "Eve", "device": "led", "event": "*"}
+    {"id": "3", "action": "command", "robot": "Eve", "command": "say_hello", "params": {}}
+
+and receive a reply with the same id, as well as a message of type "event"
+for each event published to one of their subscriptions.
+
+Browsers may only open the WebSocket from pages of the API's own origin,
+or of the origins allowed by SocketOrigins:
+
+    api.SocketOrigins = []string{"https://*.example.com"}
+
+A GraphQL endpoint on /api/graphql answers queries for robots, connections,
+devices and commands, executes commands with the execute mutation, and
+streams device events for the event subscription as server-sent events:
+
+    { robot(name: "Eve") { state devices { name driver commands { name } } } }
+    mutation { execute(robot: "Eve", command: "say_hello", params: {}) }
+    subscription { event(robot: "Eve", device: "led", name: "*") { name data } }
+
+Queries may be sent with GET or POST, and mutations only with POST. The
+schema is returned by GraphQLSchema, as introspection is not supported.
+Request bodies are limited to 1MB, and operations to 16 levels of nesting
+and 1000 selections, counting a fragment each time it is spread.
+
+A RemoteRobot mirrors the robots of the API of another Gobot as local
+proxies, whose device commands run on the remote host and whose device
+events are published locally once the robot is started:
+
+    remote := api.NewRemoteRobot("http://pi1.local:3000")
+    remote.Header.Set("X-API-Key", "pi1-key")
+    robots, err := remote.Robots()
+    for _, robot := range robots {
+    	gbot.AddRobot(robot)
+    }
+
+Setting Auth to a TokenAuth requires an API key or a bearer token on every
+request, and limits each client to the rules of its roles. Observers may
+read robots, devices, connections and events but not execute commands:
+
+    auth := api.NewTokenAuth()
+    auth.AddAPIKey("dashboard-key", "observer")
+    auth.UseHMACKey([]byte("secret"))
+    server := api.NewAPI(gbot)
+    server.Auth = auth
+    server.Start()
 */
 package api
@@ -0,0 +1,781 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"reflect"
+	"sort"
+
+	"github.com/hybridgroup/gobot"
+)
+
+// graphqlSchema describes the GraphQL schema served on /api/graphql. It is
+// documented for clients, as the schema is not introspectable.
+const graphqlSchema = `scalar JSON
+
+type Query {
+  robots: [Robot!]!
+  robot(name: String!): Robot
+  commands: [Command!]!
+}
+
+type Mutation {
+  execute(robot: String, device: String, command: String!, params: JSON): JSON
+}
+
+type Subscription {
+  event(robot: String!, device: String, name: String): Event!
+}
+
+type Robot {
+  name: String!
+  state: String!
+  commands: [Command!]!
+  events: [String!]!
+  connections: [Connection!]!
+  connection(name: String!): Connection
+  devices: [Device!]!
+  device(name: String!): Device
+}
+
+type Connection {
+  name: String!
+  adaptor: String!
+  port: String
+  state: String!
+}
+
+type Device {
+  name: String!
+  driver: String!
+  pin: String
+  state: String!
+  snapshot: JSON
+  connection: Connection
+  commands: [Command!]!
+  events: [String!]!
+}
+
+type Command {
+  name: String!
+  description: String
+  params: [Param!]!
+}
+
+type Param {
+  name: String!
+  type: String!
+  description: String
+  required: Boolean!
+  default: JSON
+  min: Float
+  max: Float
+}
+
+type Event {
+  robot: String!
+  device: String
+  name: String!
+  data: JSON
+}
+`
+
+// GraphQLSchema returns the schema of the GraphQL endpoint in the GraphQL
+// schema definition language.
+func GraphQLSchema() string {
+	return graphqlSchema
+}
+
+// gqlRequest is the execution of a GraphQL operation.
+type gqlRequest struct {
+	api       *API
+	req       *http.Request
+	doc       *gqlDocument
+	variables map[string]interface{}
+	errors    []map[string]interface{}
+}
+
+// gqlResolver resolves a field of source.
+type gqlResolver func(r *gqlRequest, source interface{}, args map[string]interface{}) (interface{}, error)
+
+// gqlField is a field of an object type. Type is the object type of the
+// value, or of each element if it is a slice, or empty for a scalar.
+type gqlField struct {
+	typ     string
+	resolve gqlResolver
+}
+
+// gqlRobotConnection and gqlRobotDevice keep the robot of a connection or
+// device, which knows its state.
+type gqlRobotConnection struct {
+	robot      *gobot.Robot
+	connection gobot.Connection
+}
+
+type gqlRobotDevice struct {
+	robot  *gobot.Robot
+	device gobot.Device
+}
+
+type gqlCommand struct {
+	commander gobot.Commander
+	name      string
+}
+
+// gqlEvent is the value of the event subscription field.
+type gqlEvent struct {
+	robot  string
+	device string
+	event  *gobot.Event
+}
+
+var gqlTypes map[string]map[string]gqlField
+
+func init() {
+	scalar := func(f func(source interface{}) interface{}) gqlField {
+		return gqlField{resolve: func(r *gqlRequest, source interface{}, args map[string]interface{}) (interface{}, error) {
+			return f(source), nil
+		}}
+	}
+
+	gqlTypes = map[string]map[string]gqlField{
+		"Query": {
+			"robots": {"Robot", func(r *gqlRequest, source interface{}, args map[string]interface{}) (interface{}, error) {
+				robots := []interface{}{}
+				r.api.gobot.Robots().Each(func(robot *gobot.Robot) {
+					robots = append(robots, robot)
+				})
+				return robots, nil
+			}},
+			"robot": {"Robot", func(r *gqlRequest, source interface{}, args map[string]interface{}) (interface{}, error) {
+				if robot := r.api.gobot.Robot(gqlStringArg(args["name"])); robot != nil {
+					return robot, nil
+				}
+				return nil, nil
+			}},
+			"commands": {"Command", func(r *gqlRequest, source interface{}, args map[string]interface{}) (interface{}, error) {
+				return gqlCommands(r.api.gobot), nil
+			}},
+		},
+		"Mutation": {
+			"execute": {"", func(r *gqlRequest, source interface{}, args map[string]interface{}) (interface{}, error) {
+				return r.execute(gqlStringArg(args["robot"]), gqlStringArg(args["device"]), gqlStringArg(args["command"]), args["params"])
+			}},
+		},
+		"Subscription": {
+			"event": {"Event", func(r *gqlRequest, source interface{}, args map[string]interface{}) (interface{}, error) {
+				return source, nil
+			}},
+		},
+		"Robot": {
+			"name":  scalar(func(s interface{}) interface{} { return s.(*gobot.Robot).Name }),
+			"state": scalar(func(s interface{}) interface{} { return string(s.(*gobot.Robot).State()) }),
+			"commands": {"Command", func(r *gqlRequest, s interface{}, args map[string]interface{}) (interface{}, error) {
+				return gqlCommands(s.(*gobot.Robot)), nil
+			}},
+			"events": scalar(func(s interface{}) interface{} { return gqlEvents(s.(*gobot.Robot)) }),
+			"connections": {"Connection", func(r *gqlRequest, s interface{}, args map[string]interface{}) (interface{}, error) {
+				robot := s.(*gobot.Robot)
+				connections := []interface{}{}
+				robot.Connections().Each(func(c gobot.Connection) {
+					connections = append(connections, &gqlRobotConnection{robot, c})
+				})
+				return connections, nil
+			}},
+			"connection": {"Connection", func(r *gqlRequest, s interface{}, args map[string]interface{}) (interface{}, error) {
+				robot := s.(*gobot.Robot)
+				if c := robot.Connection(gqlStringArg(args["name"])); c != nil {
+					return &gqlRobotConnection{robot, c}, nil
+				}
+				return nil, nil
+			}},
+			"devices": {"Device", func(r *gqlRequest, s interface{}, args map[string]interface{}) (interface{}, error) {
+				robot := s.(*gobot.Robot)
+				devices := []interface{}{}
+				robot.Devices().Each(func(d gobot.Device) {
+					devices = append(devices, &gqlRobotDevice{robot, d})
+				})
+				return devices, nil
+			}},
+			"device": {"Device", func(r *gqlRequest, s interface{}, args map[string]interface{}) (interface{}, error) {
+				robot := s.(*gobot.Robot)
+				if d := robot.Device(gqlStringArg(args["name"])); d != nil {
+					return &gqlRobotDevice{robot, d}, nil
+				}
+				return nil, nil
+			}},
+		},
+		"Connection": {
+			"name":    scalar(func(s interface{}) interface{} { return s.(*gqlRobotConnection).connection.Name() }),
+			"adaptor": scalar(func(s interface{}) interface{} { return reflect.TypeOf(s.(*gqlRobotConnection).connection).String() }),
+			"port": scalar(func(s interface{}) interface{} {
+				if porter, ok := s.(*gqlRobotConnection).connection.(gobot.Porter); ok {
+					return porter.Port()
+				}
+				return nil
+			}),
+			"state": scalar(func(s interface{}) interface{} {
+				c := s.(*gqlRobotConnection)
+				return string(c.robot.ConnectionState(c.connection.Name()))
+			}),
+		},
+		"Device": {
+			"name":   scalar(func(s interface{}) interface{} { return s.(*gqlRobotDevice).device.Name() }),
+			"driver": scalar(func(s interface{}) interface{} { return reflect.TypeOf(s.(*gqlRobotDevice).device).String() }),
+			"pin": scalar(func(s interface{}) interface{} {
+				if pinner, ok := s.(*gqlRobotDevice).device.(gobot.Pinner); ok {
+					return pinner.Pin()
+				}
+				return nil
+			}),
+			"state": scalar(func(s interface{}) interface{} {
+				d := s.(*gqlRobotDevice)
+				return string(d.robot.DeviceState(d.device.Name()))
+			}),
+			"snapshot": scalar(func(s interface{}) interface{} {
+				if stater, ok := s.(*gqlRobotDevice).device.(gobot.Stater); ok {
+					return stater.Snapshot()
+				}
+				return nil
+			}),
+			"connection": {"Connection", func(r *gqlRequest, s interface{}, args map[string]interface{}) (interface{}, error) {
+				d := s.(*gqlRobotDevice)
+				if c := d.device.Connection(); c != nil {
+					return &gqlRobotConnection{d.robot, c}, nil
+				}
+				return nil, nil
+			}},
+			"commands": {"Command", func(r *gqlRequest, s interface{}, args map[string]interface{}) (interface{}, error) {
+				if commander, ok := s.(*gqlRobotDevice).device.(gobot.Commander); ok {
+					return gqlCommands(commander), nil
+				}
+				return []interface{}{}, nil
+			}},
+			"events": scalar(func(s interface{}) interface{} {
+				if eventer, ok := s.(*gqlRobotDevice).device.(gobot.Eventer); ok {
+					return gqlEvents(eventer)
+				}
+				return []string{}
+			}),
+		},
+		"Command": {
+			"name": scalar(func(s interface{}) interface{} { return s.(*gqlCommand).name }),
+			"description": scalar(func(s interface{}) interface{} {
+				if schema := s.(*gqlCommand).schema(); schema != nil && schema.Description != "" {
+					return schema.Description
+				}
+				return nil
+			}),
+			"params": {"Param", func(r *gqlRequest, s interface{}, args map[string]interface{}) (interface{}, error) {
+				params := []interface{}{}
+				if schema := s.(*gqlCommand).schema(); schema != nil {
+					for _, p := range schema.Params {
+						params = append(params, p)
+					}
+				}
+				return params, nil
+			}},
+		},
+		"Param": {
+			"name": scalar(func(s interface{}) interface{} { return s.(gobot.Param).Name }),
+			"type": scalar(func(s interface{}) interface{} { return string(s.(gobot.Param).Type) }),
+			"description": scalar(func(s interface{}) interface{} {
+				if d := s.(gobot.Param).Description; d != "" {
+					return d
+				}
+				return nil
+			}),
+			"required": scalar(func(s interface{}) interface{} { return s.(gobot.Param).Required }),
+			"default":  scalar(func(s interface{}) interface{} { return s.(gobot.Param).Default }),
+			"min": scalar(func(s interface{}) interface{} {
+				if r := s.(gobot.Param).Range; r != nil {
+					return r.Min
+				}
+				return nil
+			}),
+			"max": scalar(func(s interface{}) interface{} {
+				if r := s.(gobot.Param).Range; r != nil {
+					return r.Max
+				}
+				return nil
+			}),
+		},
+		"Event": {
+			"robot": scalar(func(s interface{}) interface{} { return s.(*gqlEvent).robot }),
+			"device": scalar(func(s interface{}) interface{} {
+				if d := s.(*gqlEvent).device; d != "" {
+					return d
+				}
+				return nil
+			}),
+			"name": scalar(func(s interface{}) interface{} { return s.(*gqlEvent).event.Name }),
+			"data": scalar(func(s interface{}) interface{} {
+				data := s.(*gqlEvent).event.Data
+				if err, ok := data.(error); ok {
+					return err.Error()
+				}
+				return data
+			}),
+		},
+	}
+}
+
+func (c *gqlCommand) schema() *gobot.CommandSchema {
+	return gobot.CommandSchemaOf(c.commander, c.name)
+}
+
+// gqlCommands returns the commands of c sorted by name.
+func gqlCommands(c gobot.Commander) []interface{} {
+	names := []string{}
+	for name := range c.Commands() {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	commands := []interface{}{}
+	for _, name := range names {
+		commands = append(commands, &gqlCommand{c, name})
+	}
+	return commands
+}
+
+// gqlEvents returns the event names of e sorted.
+func gqlEvents(e gobot.Eventer) []string {
+	names := []string{}
+	for name := range e.Events() {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func gqlStringArg(v interface{}) string {
+	s, _ := v.(string)
+	return s
+}
+
+// execute executes a command for the execute mutation, checking that the
+// client may execute it.
+func (r *gqlRequest) execute(robot string, device string, command string, params interface{}) (interface{}, error) {
+	if r.api.Auth != nil {
+		roles, _ := r.api.Auth.Authenticate(r.req)
+		if !allowed(roles, resource{access: ExecuteAccess, robot: robot, device: device, command: command}) {
+			return nil, errors.New("Forbidden")
+		}
+	}
+	commander, err := r.api.commanderFor(robot, device)
+	if err != nil {
+		return nil, err
+	}
+	p, _ := params.(map[string]interface{})
+	if p == nil {
+		p = map[string]interface{}{}
+	}
+
+	result, err := r.api.execute(r.req, requestID(r.req), commander, robot, device, command, p)
+	if err != nil {
+		return nil, err
+	}
+	if e, ok := result.(error); ok {
+		return nil, e
+	}
+	return result, nil
+}
+
+// gqlObject is a response object which keeps its fields in query order.
+type gqlObject struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+func newGQLObject() *gqlObject {
+	return &gqlObject{values: map[string]interface{}{}}
+}
+
+func (o *gqlObject) set(key string, value interface{}) {
+	if _, ok := o.values[key]; !ok {
+		o.keys = append(o.keys, key)
+	}
+	o.values[key] = value
+}
+
+// MarshalJSON writes the fields of the object in order
+func (o *gqlObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, key := range o.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		k, _ := json.Marshal(key)
+		buf.Write(k)
+		buf.WriteByte(':')
+		v, err := json.Marshal(o.values[key])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(v)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// gqlFieldGroup is the selections of a field merged from fragments.
+type gqlFieldGroup struct {
+	key        string
+	selections []*gqlSelection
+}
+
+// collectFields returns the fields of selections grouped by response key,
+// expanding fragments and applying @skip and @include.
+func (r *gqlRequest) collectFields(selections []*gqlSelection, groups []*gqlFieldGroup, visited map[string]bool) ([]*gqlFieldGroup, error) {
+	for _, s := range selections {
+		include, err := r.included(s.directives)
+		if err != nil {
+			return nil, err
+		}
+		if !include {
+			continue
+		}
+
+		switch {
+		case s.fragment != "":
+			if visited[s.fragment] {
+				continue
+			}
+			fragment, ok := r.doc.fragments[s.fragment]
+			if !ok {
+				return nil, fmt.Errorf("Unknown fragment %q", s.fragment)
+			}
+			visited[s.fragment] = true
+			if groups, err = r.collectFields(fragment.selections, groups, visited); err != nil {
+				return nil, err
+			}
+		case s.inline:
+			if groups, err = r.collectFields(s.selections, groups, visited); err != nil {
+				return nil, err
+			}
+		default:
+			found := false
+			for _, g := range groups {
+				if g.key == s.responseKey() {
+					g.selections = append(g.selections, s)
+					found = true
+				}
+			}
+			if !found {
+				groups = append(groups, &gqlFieldGroup{key: s.responseKey(), selections: []*gqlSelection{s}})
+			}
+		}
+	}
+	return groups, nil
+}
+
+// included applies the @skip and @include directives.
+func (r *gqlRequest) included(directives []gqlDirective) (bool, error) {
+	for _, d := range directives {
+		if d.name != "skip" && d.name != "include" {
+			continue
+		}
+		v, ok := r.value(d.args["if"]).(bool)
+		if !ok {
+			return false, fmt.Errorf("Directive @%v requires a Boolean if argument", d.name)
+		}
+		if (d.name == "skip") == v {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// value replaces the variables in v by their values.
+func (r *gqlRequest) value(v interface{}) interface{} {
+	switch v := v.(type) {
+	case gqlVar:
+		return r.variables[string(v)]
+	case []interface{}:
+		list := make([]interface{}, len(v))
+		for i, e := range v {
+			list[i] = r.value(e)
+		}
+		return list
+	case map[string]interface{}:
+		object := make(map[string]interface{}, len(v))
+		for k, e := range v {
+			object[k] = r.value(e)
+		}
+		return object
+	}
+	return v
+}
+
+// executeSelections resolves the fields of an object of type typ.
+func (r *gqlRequest) executeSelections(typ string, source interface{}, selections []*gqlSelection, path []interface{}) (*gqlObject, error) {
+	groups, err := r.collectFields(selections, nil, map[string]bool{})
+	if err != nil {
+		return nil, err
+	}
+
+	object := newGQLObject()
+	for _, g := range groups {
+		s := g.selections[0]
+		if s.name == "__typename" {
+			object.set(g.key, typ)
+			continue
+		}
+		field, ok := gqlTypes[typ][s.name]
+		if !ok {
+			return nil, fmt.Errorf("Cannot query field %q on type %q", s.name, typ)
+		}
+
+		subselections := []*gqlSelection{}
+		for _, s := range g.selections {
+			subselections = append(subselections, s.selections...)
+		}
+		fieldPath := append(append([]interface{}{}, path...), g.key)
+		object.set(g.key, r.executeField(field, source, s, subselections, fieldPath))
+	}
+	return object, nil
+}
+
+// executeField resolves a field, recording any error and returning null in
+// its place.
+func (r *gqlRequest) executeField(field gqlField, source interface{}, s *gqlSelection, selections []*gqlSelection, path []interface{}) interface{} {
+	args := map[string]interface{}{}
+	for k, v := range s.args {
+		args[k] = r.value(v)
+	}
+	value, err := field.resolve(r, source, args)
+	if err == nil {
+		value, err = r.complete(field.typ, value, selections, path)
+	}
+	if err != nil {
+		r.errors = append(r.errors, map[string]interface{}{"message": err.Error(), "path": path})
+		return nil
+	}
+	return value
+}
+
+// complete resolves the selections of an object value, or of each element
+// of a list of objects.
+func (r *gqlRequest) complete(typ string, value interface{}, selections []*gqlSelection, path []interface{}) (interface{}, error) {
+	if typ == "" {
+		if len(selections) > 0 {
+			return nil, fmt.Errorf("Field %q must not have a selection", path[len(path)-1])
+		}
+		return value, nil
+	}
+	if len(selections) == 0 {
+		return nil, fmt.Errorf("Field %q of type %q must have a selection", path[len(path)-1], typ)
+	}
+	if value == nil {
+		return nil, nil
+	}
+	if list, ok := value.([]interface{}); ok {
+		results := make([]interface{}, len(list))
+		for i, e := range list {
+			object, err := r.executeSelections(typ, e, selections, append(append([]interface{}{}, path...), i))
+			if err != nil {
+				return nil, err
+			}
+			results[i] = object
+		}
+		return results, nil
+	}
+	return r.executeSelections(typ, value, selections, path)
+}
+
+// response returns the GraphQL response with data and any errors.
+func (r *gqlRequest) response(data interface{}) map[string]interface{} {
+	response := map[string]interface{}{"data": data}
+	if len(r.errors) > 0 {
+		response["errors"] = r.errors
+	}
+	return response
+}
+
+// gqlMaxBodySize is the largest GraphQL request body which is read.
+const gqlMaxBodySize = 1 << 20
+
+// graphqlParams are the parameters of a GraphQL request.
+type graphqlParams struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// graphql returns the GraphQL route handler.
+// Executes queries and mutations and writes JSON with their results, or
+// streams the results of a subscription as server-sent events
+func (a *API) graphql(res http.ResponseWriter, req *http.Request) {
+	params := graphqlParams{}
+	if req.Method == "POST" {
+		body := http.MaxBytesReader(res, req.Body, gqlMaxBodySize)
+		if err := json.NewDecoder(body).Decode(&params); err != nil {
+			a.graphqlError(res, http.StatusBadRequest, err)
+			return
+		}
+	} else {
+		params.Query = req.URL.Query().Get("query")
+		params.OperationName = req.URL.Query().Get("operationName")
+		if v := req.URL.Query().Get("variables"); v != "" {
+			if err := json.Unmarshal([]byte(v), &params.Variables); err != nil {
+				a.graphqlError(res, http.StatusBadRequest, err)
+				return
+			}
+		}
+	}
+
+	doc, err := gqlParse(params.Query)
+	if err != nil {
+		a.graphqlError(res, http.StatusBadRequest, err)
+		return
+	}
+	operation, err := doc.operation(params.OperationName)
+	if err == nil {
+		err = doc.checkLimits(operation)
+	}
+	if err != nil {
+		a.graphqlError(res, http.StatusBadRequest, err)
+		return
+	}
+	if operation.kind == "mutation" && req.Method != "POST" {
+		a.graphqlError(res, http.StatusMethodNotAllowed, errors.New("Mutations must use POST"))
+		return
+	}
+
+	r := &gqlRequest{api: a, req: req, doc: doc, variables: map[string]interface{}{}}
+	for _, v := range operation.variables {
+		value, ok := params.Variables[v.name]
+		if !ok && v.hasDefault {
+			value, ok = v.def, true
+		}
+		if v.nonNull && (!ok || value == nil) {
+			a.graphqlError(res, http.StatusBadRequest, fmt.Errorf("Variable $%v is required", v.name))
+			return
+		}
+		r.variables[v.name] = value
+	}
+
+	if operation.kind == "subscription" {
+		a.graphqlSubscription(res, r, operation)
+		return
+	}
+
+	typ := "Query"
+	if operation.kind == "mutation" {
+		typ = "Mutation"
+	}
+	data, err := r.executeSelections(typ, nil, operation.selections, nil)
+	if err != nil {
+		a.graphqlError(res, http.StatusBadRequest, err)
+		return
+	}
+	a.writeJSON(r.response(data), res)
+}
+
+// graphqlSubscription streams the events of the event subscription field as
+// server-sent events until the client disconnects.
+func (a *API) graphqlSubscription(res http.ResponseWriter, r *gqlRequest, operation *gqlOperation) {
+	groups, err := r.collectFields(operation.selections, nil, map[string]bool{})
+	if err == nil && (len(groups) != 1 || groups[0].selections[0].name != "event") {
+		err = errors.New("Subscriptions must select the event field only")
+	}
+	if err != nil {
+		a.graphqlError(res, http.StatusBadRequest, err)
+		return
+	}
+
+	s := groups[0].selections[0]
+	robot := gqlStringArg(r.value(s.args["robot"]))
+	device := gqlStringArg(r.value(s.args["device"]))
+	name := gqlStringArg(r.value(s.args["name"]))
+	if name == "" {
+		name = "*"
+	}
+	if _, err := path.Match(name, ""); err != nil {
+		a.graphqlError(res, http.StatusBadRequest, err)
+		return
+	}
+	if a.Auth != nil {
+		roles, _ := a.Auth.Authenticate(r.req)
+		if !allowed(roles, resource{access: ReadAccess, robot: robot, device: device}) {
+			a.graphqlError(res, http.StatusForbidden, errors.New("Forbidden"))
+			return
+		}
+	}
+	eventer, err := a.eventerFor(robot, device)
+	if err != nil {
+		a.graphqlError(res, http.StatusOK, err)
+		return
+	}
+
+	events := eventer.Subscribe()
+	defer eventer.Unsubscribe(events)
+
+	res.Header().Set("Content-Type", "text/event-stream")
+	res.Header().Set("Cache-Control", "no-cache")
+	res.Header().Set("Connection", "keep-alive")
+	res.WriteHeader(http.StatusOK)
+	conn := a.streamConn(r.req)
+	f, _ := res.(http.Flusher)
+	if f != nil {
+		f.Flush()
+	}
+
+	for {
+		select {
+		case evt, ok := <-events:
+			if !ok {
+				return
+			}
+			if matched, _ := path.Match(name, evt.Name); !matched {
+				continue
+			}
+			r.errors = nil
+			data := newGQLObject()
+			event := &gqlEvent{robot: robot, device: device, event: evt}
+			data.set(groups[0].key, r.executeField(gqlTypes["Subscription"]["event"], event, s, groups[0].selections[0].selections, []interface{}{groups[0].key}))
+			payload, _ := json.Marshal(r.response(data))
+			a.extendWriteDeadline(conn)
+			fmt.Fprintf(res, "data: %s\n\n", payload)
+			if f != nil {
+				f.Flush()
+			}
+		case <-r.req.Context().Done():
+			return
+		case <-a.closingChan():
+			return
+		}
+	}
+}
+
+// graphqlError writes a GraphQL response with a single error.
+func (a *API) graphqlError(res http.ResponseWriter, status int, err error) {
+	data, _ := json.Marshal(map[string]interface{}{
+		"errors": []interface{}{map[string]interface{}{"message": err.Error()}},
+	})
+	res.Header().Set("Content-Type", "application/json; charset=utf-8")
+	res.WriteHeader(status)
+	res.Write(data)
+}
+
+// operation returns the named operation, or the only operation if name is
+// empty.
+func (d *gqlDocument) operation(name string) (*gqlOperation, error) {
+	if name == "" {
+		if len(d.operations) > 1 {
+			return nil, errors.New("Must provide operation name if query contains multiple operations")
+		}
+		return d.operations[0], nil
+	}
+	for _, operation := range d.operations {
+		if operation.name == name {
+			return operation, nil
+		}
+	}
+	return nil, fmt.Errorf("Unknown operation named %q", name)
+}
@@ -0,0 +1,596 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// gqlDocument is a parsed GraphQL document.
+type gqlDocument struct {
+	operations []*gqlOperation
+	fragments  map[string]*gqlFragment
+}
+
+// gqlOperation is a query, mutation or subscription.
+type gqlOperation struct {
+	kind       string
+	name       string
+	variables  []gqlVariable
+	selections []*gqlSelection
+}
+
+// gqlVariable is a variable definition of an operation.
+type gqlVariable struct {
+	name       string
+	nonNull    bool
+	def        interface{}
+	hasDefault bool
+}
+
+// gqlSelection is a field, a fragment spread or an inline fragment.
+type gqlSelection struct {
+	alias      string
+	name       string
+	args       map[string]interface{}
+	directives []gqlDirective
+	selections []*gqlSelection
+	// fragment is the name of a fragment spread
+	fragment string
+	// inline is true for inline fragments
+	inline bool
+}
+
+// gqlFragment is a named fragment definition.
+type gqlFragment struct {
+	name       string
+	selections []*gqlSelection
+}
+
+// gqlDirective is a directive such as @include(if: $flag).
+type gqlDirective struct {
+	name string
+	args map[string]interface{}
+}
+
+// gqlVar is a reference to a variable in a value.
+type gqlVar string
+
+// checkLimits returns an error if the fields selected by operation, with
+// its fragments spread, are nested more than gqlMaxDepth levels deep or number
+// more than gqlMaxSelections. Unknown and cyclic fragments are left to be
+// reported when the operation is executed.
+func (doc *gqlDocument) checkLimits(operation *gqlOperation) error {
+	count := 0
+	spreading := map[string]bool{}
+	var check func(selections []*gqlSelection, depth int) error
+	check = func(selections []*gqlSelection, depth int) error {
+		if depth > gqlMaxDepth {
+			return fmt.Errorf("Operation is nested more than %d levels deep", gqlMaxDepth)
+		}
+		for _, s := range selections {
+			if count++; count > gqlMaxSelections {
+				return fmt.Errorf("Operation has more than %d selections", gqlMaxSelections)
+			}
+			var err error
+			switch {
+			case s.fragment != "":
+				fragment, ok := doc.fragments[s.fragment]
+				if !ok || spreading[s.fragment] {
+					continue
+				}
+				spreading[s.fragment] = true
+				err = check(fragment.selections, depth)
+				delete(spreading, s.fragment)
+			case s.inline:
+				err = check(s.selections, depth)
+			case len(s.selections) > 0:
+				err = check(s.selections, depth+1)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return check(operation.selections, 1)
+}
+
+// responseKey returns the key of a field in the response.
+func (s *gqlSelection) responseKey() string {
+	if s.alias != "" {
+		return s.alias
+	}
+	return s.name
+}
+
+const (
+	gqlEOF = iota
+	gqlPunct
+	gqlName
+	gqlInt
+	gqlFloat
+	gqlString
+)
+
+type gqlToken struct {
+	kind  int
+	value string
+	pos   int
+}
+
+// gqlLex splits a GraphQL document into tokens, skipping white space,
+// commas and comments.
+func gqlLex(src string) ([]gqlToken, error) {
+	tokens := []gqlToken{}
+	i := 0
+	for i < len(src) {
+		c := src[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == ',':
+			i++
+		case c == '#':
+			for i < len(src) && src[i] != '\n' && src[i] != '\r' {
+				i++
+			}
+		case strings.HasPrefix(src[i:], "..."):
+			tokens = append(tokens, gqlToken{gqlPunct, "...", i})
+			i += 3
+		case strings.IndexByte("!$():=@[]{}|&", c) >= 0:
+			tokens = append(tokens, gqlToken{gqlPunct, string(c), i})
+			i++
+		case c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z'):
+			start := i
+			for i < len(src) && (src[i] == '_' || (src[i] >= 'a' && src[i] <= 'z') ||
+				(src[i] >= 'A' && src[i] <= 'Z') || (src[i] >= '0' && src[i] <= '9')) {
+				i++
+			}
+			tokens = append(tokens, gqlToken{gqlName, src[start:i], start})
+		case c == '-' || (c >= '0' && c <= '9'):
+			start := i
+			kind := gqlInt
+			i++
+			for i < len(src) && strings.IndexByte("0123456789.eE+-", src[i]) >= 0 {
+				if strings.IndexByte(".eE", src[i]) >= 0 {
+					kind = gqlFloat
+				}
+				i++
+			}
+			tokens = append(tokens, gqlToken{kind, src[start:i], start})
+		case strings.HasPrefix(src[i:], `"""`):
+			end := strings.Index(src[i+3:], `"""`)
+			if end < 0 {
+				return nil, fmt.Errorf("Unterminated string at %d", i)
+			}
+			tokens = append(tokens, gqlToken{gqlString, blockString(src[i+3 : i+3+end]), i})
+			i += end + 6
+		case c == '"':
+			start := i
+			i++
+			for i < len(src) && src[i] != '"' && src[i] != '\n' {
+				if src[i] == '\\' && i+1 < len(src) {
+					i++
+				}
+				i++
+			}
+			if i >= len(src) || src[i] != '"' {
+				return nil, fmt.Errorf("Unterminated string at %d", start)
+			}
+			i++
+			// GraphQL strings use the escape sequences of JSON strings
+			var s string
+			if err := json.Unmarshal([]byte(src[start:i]), &s); err != nil {
+				return nil, fmt.Errorf("Invalid string at %d", start)
+			}
+			tokens = append(tokens, gqlToken{gqlString, s, start})
+		default:
+			r, _ := utf8.DecodeRuneInString(src[i:])
+			return nil, fmt.Errorf("Unexpected character %q at %d", r, i)
+		}
+	}
+	return append(tokens, gqlToken{gqlEOF, "", len(src)}), nil
+}
+
+// blockString removes the common indentation and blank first and last lines
+// of a block string.
+func blockString(raw string) string {
+	lines := strings.Split(strings.Replace(raw, `\"""`, `"""`, -1), "\n")
+	indent := -1
+	for _, line := range lines[1:] {
+		trimmed := strings.TrimLeft(line, " \t")
+		if trimmed != "" && (indent < 0 || len(line)-len(trimmed) < indent) {
+			indent = len(line) - len(trimmed)
+		}
+	}
+	for i := 1; i < len(lines) && indent > 0; i++ {
+		if len(lines[i]) >= indent {
+			lines[i] = lines[i][indent:]
+		}
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[0]) == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return strings.Join(lines, "\n")
+}
+
+// gqlMaxDepth is the deepest that selection sets, values and types may be
+// nested in a document, or fields in a response.
+const gqlMaxDepth = 16
+
+// gqlMaxSelections is the most selections an operation may make, counting
+// those of a fragment each time it is spread.
+const gqlMaxSelections = 1000
+
+type gqlParser struct {
+	tokens []gqlToken
+	pos    int
+	depth  int
+}
+
+// gqlParse parses a GraphQL document.
+func gqlParse(src string) (*gqlDocument, error) {
+	tokens, err := gqlLex(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &gqlParser{tokens: tokens}
+	doc := &gqlDocument{fragments: make(map[string]*gqlFragment)}
+
+	for p.peek().kind != gqlEOF {
+		t := p.peek()
+		switch {
+		case t.kind == gqlPunct && t.value == "{":
+			selections, err := p.selectionSet()
+			if err != nil {
+				return nil, err
+			}
+			doc.operations = append(doc.operations, &gqlOperation{kind: "query", selections: selections})
+		case t.kind == gqlName && (t.value == "query" || t.value == "mutation" || t.value == "subscription"):
+			operation, err := p.operation()
+			if err != nil {
+				return nil, err
+			}
+			doc.operations = append(doc.operations, operation)
+		case t.kind == gqlName && t.value == "fragment":
+			fragment, err := p.fragment()
+			if err != nil {
+				return nil, err
+			}
+			doc.fragments[fragment.name] = fragment
+		default:
+			return nil, p.unexpected()
+		}
+	}
+	if len(doc.operations) == 0 {
+		return nil, fmt.Errorf("No operation found")
+	}
+	return doc, nil
+}
+
+func (p *gqlParser) peek() gqlToken {
+	return p.tokens[p.pos]
+}
+
+func (p *gqlParser) next() gqlToken {
+	t := p.tokens[p.pos]
+	if t.kind != gqlEOF {
+		p.pos++
+	}
+	return t
+}
+
+// skip consumes the punctuator value if it is next.
+func (p *gqlParser) skip(value string) bool {
+	if t := p.peek(); t.kind == gqlPunct && t.value == value {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *gqlParser) expect(value string) error {
+	if !p.skip(value) {
+		return p.unexpected()
+	}
+	return nil
+}
+
+func (p *gqlParser) name() (string, error) {
+	if t := p.peek(); t.kind == gqlName {
+		p.pos++
+		return t.value, nil
+	}
+	return "", p.unexpected()
+}
+
+// enter is called before parsing a nested selection set, value or type, and
+// returns an error if the document is nested too deeply. Every successful
+// call must be followed by a call to leave.
+func (p *gqlParser) enter() error {
+	if p.depth >= gqlMaxDepth {
+		return fmt.Errorf("Document is nested more than %d levels deep", gqlMaxDepth)
+	}
+	p.depth++
+	return nil
+}
+
+func (p *gqlParser) leave() {
+	p.depth--
+}
+
+func (p *gqlParser) unexpected() error {
+	t := p.peek()
+	if t.kind == gqlEOF {
+		return fmt.Errorf("Unexpected end of document")
+	}
+	return fmt.Errorf("Unexpected %q at %d", t.value, t.pos)
+}
+
+func (p *gqlParser) operation() (*gqlOperation, error) {
+	operation := &gqlOperation{kind: p.next().value}
+	if p.peek().kind == gqlName {
+		operation.name = p.next().value
+	}
+	if p.skip("(") {
+		for !p.skip(")") {
+			variable, err := p.variable()
+			if err != nil {
+				return nil, err
+			}
+			operation.variables = append(operation.variables, variable)
+		}
+	}
+	if _, err := p.directives(); err != nil {
+		return nil, err
+	}
+	selections, err := p.selectionSet()
+	if err != nil {
+		return nil, err
+	}
+	operation.selections = selections
+	return operation, nil
+}
+
+func (p *gqlParser) variable() (v gqlVariable, err error) {
+	if err = p.expect("$"); err != nil {
+		return
+	}
+	if v.name, err = p.name(); err != nil {
+		return
+	}
+	if err = p.expect(":"); err != nil {
+		return
+	}
+	if v.nonNull, err = p.typeRef(); err != nil {
+		return
+	}
+	if p.skip("=") {
+		v.hasDefault = true
+		if v.def, err = p.value(true); err != nil {
+			return
+		}
+	}
+	_, err = p.directives()
+	return
+}
+
+// typeRef skips a type such as [String!]! and returns whether it is non-null.
+func (p *gqlParser) typeRef() (bool, error) {
+	if err := p.enter(); err != nil {
+		return false, err
+	}
+	defer p.leave()
+	if p.skip("[") {
+		if _, err := p.typeRef(); err != nil {
+			return false, err
+		}
+		if err := p.expect("]"); err != nil {
+			return false, err
+		}
+	} else if _, err := p.name(); err != nil {
+		return false, err
+	}
+	return p.skip("!"), nil
+}
+
+func (p *gqlParser) fragment() (*gqlFragment, error) {
+	p.next()
+	name, err := p.name()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != gqlName || t.value != "on" {
+		return nil, p.unexpected()
+	}
+	p.next()
+	if _, err := p.name(); err != nil {
+		return nil, err
+	}
+	if _, err := p.directives(); err != nil {
+		return nil, err
+	}
+	selections, err := p.selectionSet()
+	if err != nil {
+		return nil, err
+	}
+	return &gqlFragment{name: name, selections: selections}, nil
+}
+
+func (p *gqlParser) selectionSet() ([]*gqlSelection, error) {
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+	if err := p.enter(); err != nil {
+		return nil, err
+	}
+	defer p.leave()
+	selections := []*gqlSelection{}
+	for !p.skip("}") {
+		selection, err := p.selection()
+		if err != nil {
+			return nil, err
+		}
+		selections = append(selections, selection)
+	}
+	if len(selections) == 0 {
+		return nil, fmt.Errorf("Empty selection set")
+	}
+	return selections, nil
+}
+
+func (p *gqlParser) selection() (s *gqlSelection, err error) {
+	s = &gqlSelection{}
+	if p.skip("...") {
+		if t := p.peek(); t.kind == gqlName && t.value != "on" {
+			s.fragment = p.next().value
+			s.directives, err = p.directives()
+			return
+		}
+		s.inline = true
+		if t := p.peek(); t.kind == gqlName && t.value == "on" {
+			p.next()
+			if _, err = p.name(); err != nil {
+				return
+			}
+		}
+		if s.directives, err = p.directives(); err != nil {
+			return
+		}
+		s.selections, err = p.selectionSet()
+		return
+	}
+
+	if s.name, err = p.name(); err != nil {
+		return
+	}
+	if p.skip(":") {
+		s.alias = s.name
+		if s.name, err = p.name(); err != nil {
+			return
+		}
+	}
+	if s.args, err = p.arguments(); err != nil {
+		return
+	}
+	if s.directives, err = p.directives(); err != nil {
+		return
+	}
+	if t := p.peek(); t.kind == gqlPunct && t.value == "{" {
+		s.selections, err = p.selectionSet()
+	}
+	return
+}
+
+func (p *gqlParser) arguments() (map[string]interface{}, error) {
+	args := map[string]interface{}{}
+	if !p.skip("(") {
+		return args, nil
+	}
+	for !p.skip(")") {
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(":"); err != nil {
+			return nil, err
+		}
+		if args[name], err = p.value(false); err != nil {
+			return nil, err
+		}
+	}
+	return args, nil
+}
+
+func (p *gqlParser) directives() ([]gqlDirective, error) {
+	directives := []gqlDirective{}
+	for p.skip("@") {
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		args, err := p.arguments()
+		if err != nil {
+			return nil, err
+		}
+		directives = append(directives, gqlDirective{name: name, args: args})
+	}
+	return directives, nil
+}
+
+// value parses a value. Variables are not allowed in constant values.
+func (p *gqlParser) value(constant bool) (interface{}, error) {
+	if err := p.enter(); err != nil {
+		return nil, err
+	}
+	defer p.leave()
+	start := p.pos
+	t := p.next()
+	switch t.kind {
+	case gqlInt:
+		i, err := strconv.Atoi(t.value)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid number %q at %d", t.value, t.pos)
+		}
+		return i, nil
+	case gqlFloat:
+		f, err := strconv.ParseFloat(t.value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid number %q at %d", t.value, t.pos)
+		}
+		return f, nil
+	case gqlString:
+		return t.value, nil
+	case gqlName:
+		switch t.value {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		case "null":
+			return nil, nil
+		}
+		// enum values are passed to resolvers as strings
+		return t.value, nil
+	case gqlPunct:
+		switch t.value {
+		case "$":
+			if constant {
+				break
+			}
+			name, err := p.name()
+			return gqlVar(name), err
+		case "[":
+			list := []interface{}{}
+			for !p.skip("]") {
+				v, err := p.value(constant)
+				if err != nil {
+					return nil, err
+				}
+				list = append(list, v)
+			}
+			return list, nil
+		case "{":
+			object := map[string]interface{}{}
+			for !p.skip("}") {
+				name, err := p.name()
+				if err != nil {
+					return nil, err
+				}
+				if err := p.expect(":"); err != nil {
+					return nil, err
+				}
+				if object[name], err = p.value(constant); err != nil {
+					return nil, err
+				}
+			}
+			return object, nil
+		}
+	}
+	p.pos = start
+	return nil, p.unexpected()
+}
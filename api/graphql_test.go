@@ -0,0 +1,272 @@
+package api
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hybridgroup/gobot"
+	"github.com/hybridgroup/gobot/gobottest"
+)
+
+func postTestGraphQL(a *API, query string, variables map[string]interface{}) (*httptest.ResponseRecorder, map[string]interface{}) {
+	body, _ := json.Marshal(map[string]interface{}{"query": query, "variables": variables})
+	request, _ := http.NewRequest("POST", "/api/graphql", bytes.NewBuffer(body))
+	response := httptest.NewRecorder()
+	a.ServeHTTP(response, request)
+
+	var result map[string]interface{}
+	json.NewDecoder(response.Body).Decode(&result)
+	return response, result
+}
+
+func TestGraphQLQuery(t *testing.T) {
+	a := initTestAPI()
+
+	response, result := postTestGraphQL(a, `
+		query Robot($name: String!) {
+			robot(name: $name) {
+				name
+				... on Robot { state }
+				first: device(name: "Device1") { ...device }
+				connection(name: "Connection2") { name port }
+			}
+			commands { name }
+		}
+		fragment device on Device { name pin connection { name } commands { name } events }
+	`, map[string]interface{}{"name": "Robot1"})
+	gobottest.Assert(t, response.Code, 200)
+	gobottest.Assert(t, result["errors"], nil)
+
+	data := result["data"].(map[string]interface{})
+	robot := data["robot"].(map[string]interface{})
+	gobottest.Assert(t, robot["name"], "Robot1")
+	gobottest.Assert(t, robot["state"], "halted")
+	gobottest.Assert(t, robot["first"], map[string]interface{}{
+		"name":       "Device1",
+		"pin":        "0",
+		"connection": map[string]interface{}{"name": "Connection1"},
+		"commands": []interface{}{
+			map[string]interface{}{"name": "DriverCommand"},
+			map[string]interface{}{"name": "TestDriverCommand"},
+		},
+		"events": []interface{}{"TestEvent"},
+	})
+	gobottest.Assert(t, robot["connection"], map[string]interface{}{"name": "Connection2", "port": "/dev/null"})
+	gobottest.Assert(t, data["commands"], []interface{}{map[string]interface{}{"name": "TestFunction"}})
+
+	// fields keep the order of the query
+	body, _ := json.Marshal(map[string]interface{}{"query": `{ robots { name __typename } }`})
+	request, _ := http.NewRequest("POST", "/api/graphql", bytes.NewBuffer(body))
+	recorder := httptest.NewRecorder()
+	a.ServeHTTP(recorder, request)
+	gobottest.Assert(t, strings.Contains(recorder.Body.String(),
+		`{"name":"Robot1","__typename":"Robot"},{"name":"Robot2","__typename":"Robot"}`), true)
+
+	// unknown robots are null
+	_, result = postTestGraphQL(a, `{ robot(name: "UnknownRobot1") { name } }`, nil)
+	gobottest.Assert(t, result["data"], map[string]interface{}{"robot": nil})
+
+	// directives
+	_, result = postTestGraphQL(a, `query ($all: Boolean = false) {
+		robot(name: "Robot2") { name devices @include(if: $all) { name } }
+	}`, nil)
+	gobottest.Assert(t, result["data"], map[string]interface{}{
+		"robot": map[string]interface{}{"name": "Robot2"},
+	})
+}
+
+func TestGraphQLSnapshot(t *testing.T) {
+	a := initTestAPI()
+	a.gobot.Robot("Robot1").AddDevice(&testStaterDriver{
+		testDriver: newTestDriver(newTestAdaptor("Connection1", "/dev/null"), "Servo1", "3"),
+		angle:      45,
+	})
+
+	_, result := postTestGraphQL(a, `{ robot(name: "Robot1") {
+		servo: device(name: "Servo1") { snapshot }
+		led: device(name: "Device1") { snapshot }
+	} }`, nil)
+	gobottest.Assert(t, result["data"], map[string]interface{}{"robot": map[string]interface{}{
+		"servo": map[string]interface{}{"snapshot": map[string]interface{}{"angle": 45.0}},
+		"led":   map[string]interface{}{"snapshot": nil},
+	}})
+}
+
+func TestGraphQLQueryGet(t *testing.T) {
+	a := initTestAPI()
+
+	query := url.Values{}
+	query.Set("query", `query ($robot: String!) { robot(name: $robot) { devices { name } } }`)
+	query.Set("variables", `{"robot": "Robot3"}`)
+	request, _ := http.NewRequest("GET", "/api/graphql?"+query.Encode(), nil)
+	response := httptest.NewRecorder()
+	a.ServeHTTP(response, request)
+
+	var result map[string]interface{}
+	json.NewDecoder(response.Body).Decode(&result)
+	devices := result["data"].(map[string]interface{})["robot"].(map[string]interface{})["devices"].([]interface{})
+	gobottest.Assert(t, len(devices), 3)
+
+	// mutations require POST
+	query = url.Values{}
+	query.Set("query", `mutation { execute(command: "TestFunction", params: {message: "hi"}) }`)
+	request, _ = http.NewRequest("GET", "/api/graphql?"+query.Encode(), nil)
+	response = httptest.NewRecorder()
+	a.ServeHTTP(response, request)
+	gobottest.Assert(t, response.Code, http.StatusMethodNotAllowed)
+}
+
+func TestGraphQLErrors(t *testing.T) {
+	a := initTestAPI()
+
+	response, result := postTestGraphQL(a, `{ robot(name: "Robot1") { name `, nil)
+	gobottest.Assert(t, response.Code, http.StatusBadRequest)
+	gobottest.Refute(t, result["errors"], nil)
+
+	response, result = postTestGraphQL(a, `{ robot(name: "Robot1") { speed } }`, nil)
+	gobottest.Assert(t, response.Code, http.StatusOK)
+	gobottest.Assert(t, result["data"], map[string]interface{}{"robot": nil})
+	gobottest.Assert(t, result["errors"], []interface{}{map[string]interface{}{
+		"message": `Cannot query field "speed" on type "Robot"`,
+		"path":    []interface{}{"robot"},
+	}})
+
+	response, _ = postTestGraphQL(a, `query ($name: String!) { robot(name: $name) { name } }`, nil)
+	gobottest.Assert(t, response.Code, http.StatusBadRequest)
+
+	response, _ = postTestGraphQL(a, `query A { commands { name } } query B { robots { name } }`, nil)
+	gobottest.Assert(t, response.Code, http.StatusBadRequest)
+}
+
+func TestGraphQLMutation(t *testing.T) {
+	a := initTestAPI()
+	records := []AuditRecord{}
+	a.Audit = AuditFunc(func(record AuditRecord) {
+		records = append(records, record)
+	})
+
+	_, result := postTestGraphQL(a, `mutation ($params: JSON) {
+		mcp: execute(command: "TestFunction", params: {message: "Beep Boop"})
+		device: execute(robot: "Robot1", device: "Device1", command: "TestDriverCommand", params: $params)
+		unknown: execute(robot: "Robot1", command: "UnknownCommand")
+	}`, map[string]interface{}{"params": map[string]interface{}{"name": "human"}})
+
+	gobottest.Assert(t, result["data"], map[string]interface{}{
+		"mcp":     "hey Beep Boop",
+		"device":  "hello human",
+		"unknown": nil,
+	})
+	gobottest.Assert(t, result["errors"], []interface{}{map[string]interface{}{
+		"message": gobot.ErrUnknownCommand.Error(),
+		"path":    []interface{}{"unknown"},
+	}})
+	gobottest.Assert(t, len(records), 3)
+	gobottest.Assert(t, records[1].Device, "Device1")
+}
+
+func TestGraphQLAuth(t *testing.T) {
+	a := initTestAPI()
+	a.Auth = NewTokenAuth()
+	a.Auth.AddAPIKey("observer-key", "observer")
+
+	post := func(query string) map[string]interface{} {
+		body, _ := json.Marshal(map[string]interface{}{"query": query})
+		request, _ := http.NewRequest("POST", "/api/graphql", bytes.NewBuffer(body))
+		request.Header.Set("X-API-Key", "observer-key")
+		response := httptest.NewRecorder()
+		a.ServeHTTP(response, request)
+		var result map[string]interface{}
+		json.NewDecoder(response.Body).Decode(&result)
+		return result
+	}
+
+	result := post(`{ robot(name: "Robot1") { name } }`)
+	gobottest.Assert(t, result["data"], map[string]interface{}{"robot": map[string]interface{}{"name": "Robot1"}})
+
+	result = post(`mutation { execute(command: "TestFunction", params: {message: "hi"}) }`)
+	gobottest.Assert(t, result["data"], map[string]interface{}{"execute": nil})
+	gobottest.Assert(t, result["errors"].([]interface{})[0].(map[string]interface{})["message"], "Forbidden")
+}
+
+func TestGraphQLSubscription(t *testing.T) {
+	a := initTestAPI()
+	server := httptest.NewServer(a)
+	defer server.Close()
+
+	body, _ := json.Marshal(map[string]interface{}{
+		"query": `subscription { event(robot: "Robot1", device: "Device1", name: "Test*") { name data device } }`,
+	})
+	response, err := http.Post(server.URL+"/api/graphql", "application/json", bytes.NewBuffer(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer response.Body.Close()
+	gobottest.Assert(t, response.Header.Get("Content-Type"), "text/event-stream")
+
+	device := a.gobot.Robot("Robot1").Device("Device1").(gobot.Eventer)
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		device.Publish("Other", "ignored")
+		device.Publish("TestEvent", "event-data")
+	}()
+
+	lines := make(chan string)
+	go func() {
+		scanner := bufio.NewScanner(response.Body)
+		for scanner.Scan() {
+			if scanner.Text() != "" {
+				lines <- scanner.Text()
+			}
+		}
+	}()
+
+	select {
+	case line := <-lines:
+		gobottest.Assert(t, line,
+			`data: {"data":{"event":{"name":"TestEvent","data":"event-data","device":"Device1"}}}`)
+	case <-time.After(time.Second):
+		t.Fatal("Timed out waiting for the subscription event")
+	}
+}
+
+func TestGraphQLLimits(t *testing.T) {
+	a := initTestAPI()
+
+	// selection sets nested too deeply are rejected while parsing
+	deep := strings.Repeat("{ robots ", gqlMaxDepth+1) + strings.Repeat("}", gqlMaxDepth+1)
+	response, result := postTestGraphQL(a, deep, nil)
+	gobottest.Assert(t, response.Code, http.StatusBadRequest)
+	gobottest.Assert(t, result["errors"].([]interface{})[0].(map[string]interface{})["message"],
+		"Document is nested more than 16 levels deep")
+
+	// fragments are counted each time they are spread
+	query := `{ ...F0 }`
+	for i := 0; i < 10; i++ {
+		query += fmt.Sprintf(" fragment F%d on Query { a: robots { ...F%d } b: robots { ...F%d } }", i, i+1, i+1)
+	}
+	query += " fragment F10 on Query { robots { name } }"
+	response, result = postTestGraphQL(a, query, nil)
+	gobottest.Assert(t, response.Code, http.StatusBadRequest)
+	gobottest.Assert(t, result["errors"].([]interface{})[0].(map[string]interface{})["message"],
+		"Operation has more than 1000 selections")
+
+	fields := strings.Repeat("name ", gqlMaxSelections)
+	response, _ = postTestGraphQL(a, `{ robots { `+fields+`} }`, nil)
+	gobottest.Assert(t, response.Code, http.StatusBadRequest)
+
+	// large bodies are not read
+	body := `{"query": "` + strings.Repeat(" ", gqlMaxBodySize) + `{ robots { name } }"}`
+	request, _ := http.NewRequest("POST", "/api/graphql", strings.NewReader(body))
+	response = httptest.NewRecorder()
+	a.ServeHTTP(response, request)
+	gobottest.Assert(t, response.Code, http.StatusBadRequest)
+	gobottest.Assert(t, strings.Contains(response.Body.String(), "too large"), true)
+}
@@ -24,7 +24,7 @@ type testDriver struct {
 	name       string
 	pin        string
 	connection gobot.Connection
-	gobot.Commander
+	gobot.ExtendedCommander
 	gobot.Eventer
 }
 
@@ -36,11 +36,11 @@ func (t *testDriver) Connection() gobot.Connection { return t.connection }
 
 func newTestDriver(adaptor *testAdaptor, name string, pin string) *testDriver {
 	t := &testDriver{
-		name:       name,
-		connection: adaptor,
-		pin:        pin,
-		Eventer:    gobot.NewEventer(),
-		Commander:  gobot.NewCommander(),
+		name:              name,
+		connection:        adaptor,
+		pin:               pin,
+		Eventer:           gobot.NewEventer(),
+		ExtendedCommander: gobot.NewCommander(),
 	}
 
 	t.AddEvent("TestEvent")
@@ -98,3 +98,12 @@ func newTestRobot(name string) *gobot.Robot {
 	})
 	return r
 }
+
+type testStaterDriver struct {
+	*testDriver
+	angle int
+}
+
+func (t *testStaterDriver) Snapshot() map[string]interface{} {
+	return map[string]interface{}{"angle": t.angle}
+}
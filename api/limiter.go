@@ -0,0 +1,192 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// Limit restricts how often, and how many at once, the commands it matches
+// may be executed through the API. Robot, Device and Command are path.Match
+// patterns, and an empty pattern matches any name; MCP commands have no
+// robot or device, and robot commands have no device.
+//
+// A limit without a Command pattern applies to all the commands of each
+// matching device together, and one with a Command pattern applies to each
+// matching command separately.
+type Limit struct {
+	Robot   string
+	Device  string
+	Command string
+	// Rate is the number of commands allowed per second, or 0 for no limit.
+	Rate float64
+	// Burst is the number of commands allowed at once before Rate applies.
+	// It defaults to 1.
+	Burst int
+	// MaxInFlight is the number of commands allowed to run at the same time,
+	// or 0 for no limit.
+	MaxInFlight int
+	// Serialize runs the commands of each matching device one at a time,
+	// making later calls wait for earlier ones to return, or for their
+	// request to be cancelled.
+	Serialize bool
+}
+
+// RateLimitError is returned when a command is over one of its limits.
+type RateLimitError struct {
+	// RetryAfter is how long to wait before the command may be allowed.
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("Too many requests, retry after %v", e.RetryAfter)
+}
+
+// bucket is a token bucket.
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// Limiter enforces a set of Limits on the commands executed through the API.
+type Limiter struct {
+	mutex    sync.Mutex
+	limits   []Limit
+	buckets  map[string]*bucket
+	inFlight map[string]int
+	serial   map[string]chan struct{}
+	now      func() time.Time
+}
+
+// NewLimiter returns a new Limiter enforcing limits.
+func NewLimiter(limits ...Limit) *Limiter {
+	return &Limiter{
+		limits:   limits,
+		buckets:  make(map[string]*bucket),
+		inFlight: make(map[string]int),
+		serial:   make(map[string]chan struct{}),
+		now:      time.Now,
+	}
+}
+
+// AddLimit adds a limit to the Limiter.
+func (l *Limiter) AddLimit(limit Limit) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.limits = append(l.limits, limit)
+}
+
+// Acquire reserves the right to execute a command. It returns a function to
+// call once the command has returned, or a *RateLimitError if the command is
+// over one of its limits. If a matching limit serializes the device, Acquire
+// waits for earlier commands of the device to return.
+func (l *Limiter) Acquire(robot string, device string, command string) (release func(), err error) {
+	return l.AcquireContext(context.Background(), robot, device, command)
+}
+
+// AcquireContext is like Acquire, but stops waiting for earlier commands of
+// a serialized device when ctx is done, and returns ctx.Err().
+func (l *Limiter) AcquireContext(ctx context.Context, robot string, device string, command string) (release func(), err error) {
+	l.mutex.Lock()
+	now := l.now()
+	retryAfter := time.Duration(0)
+	taken := []*bucket{}
+	flights := []string{}
+	var serial chan struct{}
+
+	for i, limit := range l.limits {
+		if !matchPattern(limit.Robot, robot) ||
+			!matchPattern(limit.Device, device) ||
+			!matchPattern(limit.Command, command) {
+			continue
+		}
+		key := fmt.Sprintf("%d/%s/%s", i, robot, device)
+		if limit.Command != "" {
+			key += "/" + command
+		}
+
+		if limit.Rate > 0 {
+			b := l.refill(key, limit, now)
+			if b.tokens < 1 {
+				wait := time.Duration((1 - b.tokens) / limit.Rate * float64(time.Second))
+				retryAfter = maxDuration(retryAfter, wait)
+			} else {
+				taken = append(taken, b)
+			}
+		}
+		if limit.MaxInFlight > 0 {
+			if l.inFlight[key] >= limit.MaxInFlight {
+				retryAfter = maxDuration(retryAfter, time.Second)
+			} else {
+				flights = append(flights, key)
+			}
+		}
+		if limit.Serialize {
+			device := robot + "/" + device
+			if _, ok := l.serial[device]; !ok {
+				l.serial[device] = make(chan struct{}, 1)
+			}
+			serial = l.serial[device]
+		}
+	}
+
+	if retryAfter > 0 {
+		l.mutex.Unlock()
+		return nil, &RateLimitError{RetryAfter: retryAfter}
+	}
+	for _, b := range taken {
+		b.tokens--
+	}
+	for _, key := range flights {
+		l.inFlight[key]++
+	}
+	l.mutex.Unlock()
+
+	land := func() {
+		l.mutex.Lock()
+		defer l.mutex.Unlock()
+		for _, key := range flights {
+			l.inFlight[key]--
+		}
+	}
+
+	if serial == nil {
+		return land, nil
+	}
+	select {
+	case serial <- struct{}{}:
+	case <-ctx.Done():
+		land()
+		return nil, ctx.Err()
+	}
+	return func() {
+		<-serial
+		land()
+	}, nil
+}
+
+// refill returns the bucket of key with the tokens accumulated since it was
+// last used.
+func (l *Limiter) refill(key string, limit Limit, now time.Time) *bucket {
+	burst := float64(limit.Burst)
+	if burst < 1 {
+		burst = 1
+	}
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: burst, last: now}
+		l.buckets[key] = b
+	}
+	b.tokens = math.Min(burst, b.tokens+now.Sub(b.last).Seconds()*limit.Rate)
+	b.last = now
+	return b
+}
+
+func maxDuration(a time.Duration, b time.Duration) time.Duration {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,155 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hybridgroup/gobot"
+	"github.com/hybridgroup/gobot/gobottest"
+)
+
+func TestLimiterRate(t *testing.T) {
+	now := time.Unix(0, 0)
+	l := NewLimiter(Limit{Robot: "Robot1", Device: "Device1", Rate: 2, Burst: 2})
+	l.now = func() time.Time { return now }
+
+	for i := 0; i < 2; i++ {
+		release, err := l.Acquire("Robot1", "Device1", "Roll")
+		gobottest.Assert(t, err, nil)
+		release()
+	}
+	_, err := l.Acquire("Robot1", "Device1", "Stop")
+	gobottest.Assert(t, err.(*RateLimitError).RetryAfter, 500*time.Millisecond)
+
+	// other devices are not limited
+	_, err = l.Acquire("Robot1", "Device2", "Roll")
+	gobottest.Assert(t, err, nil)
+
+	now = now.Add(500 * time.Millisecond)
+	_, err = l.Acquire("Robot1", "Device1", "Roll")
+	gobottest.Assert(t, err, nil)
+}
+
+func TestLimiterPerCommand(t *testing.T) {
+	now := time.Unix(0, 0)
+	l := NewLimiter(Limit{Command: "Roll", Rate: 1})
+	l.now = func() time.Time { return now }
+
+	_, err := l.Acquire("Robot1", "Device1", "Roll")
+	gobottest.Assert(t, err, nil)
+	_, err = l.Acquire("Robot1", "Device1", "Roll")
+	gobottest.Refute(t, err, nil)
+	_, err = l.Acquire("Robot1", "Device1", "Stop")
+	gobottest.Assert(t, err, nil)
+	_, err = l.Acquire("Robot2", "Device1", "Roll")
+	gobottest.Assert(t, err, nil)
+}
+
+func TestLimiterMaxInFlight(t *testing.T) {
+	l := NewLimiter(Limit{MaxInFlight: 1})
+
+	release, err := l.Acquire("Robot1", "Device1", "Roll")
+	gobottest.Assert(t, err, nil)
+	_, err = l.Acquire("Robot1", "Device1", "Roll")
+	gobottest.Assert(t, err.(*RateLimitError).RetryAfter, time.Second)
+
+	release()
+	_, err = l.Acquire("Robot1", "Device1", "Roll")
+	gobottest.Assert(t, err, nil)
+}
+
+func TestLimiterSerialize(t *testing.T) {
+	l := NewLimiter(Limit{Device: "Device1", Serialize: true})
+
+	var mutex sync.Mutex
+	running, overlapped := 0, false
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			release, _ := l.Acquire("Robot1", "Device1", "Roll")
+			defer release()
+
+			mutex.Lock()
+			running++
+			overlapped = overlapped || running > 1
+			mutex.Unlock()
+			time.Sleep(time.Millisecond)
+			mutex.Lock()
+			running--
+			mutex.Unlock()
+		}()
+	}
+	wg.Wait()
+	gobottest.Assert(t, overlapped, false)
+}
+
+func TestLimiterSerializeCancel(t *testing.T) {
+	l := NewLimiter(Limit{Device: "Device1", Serialize: true, MaxInFlight: 1})
+	release, err := l.Acquire("Robot1", "Device1", "Roll")
+	gobottest.Assert(t, err, nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	l.limits[0].MaxInFlight = 2
+	_, err = l.AcquireContext(ctx, "Robot1", "Device1", "Roll")
+	gobottest.Assert(t, err, context.DeadlineExceeded)
+	gobottest.Assert(t, l.inFlight["0/Robot1/Device1"], 1)
+
+	release()
+	release, err = l.Acquire("Robot1", "Device1", "Roll")
+	gobottest.Assert(t, err, nil)
+	release()
+	gobottest.Assert(t, l.inFlight["0/Robot1/Device1"], 0)
+}
+
+func TestLimitsAPI(t *testing.T) {
+	a := initTestAPI()
+	a.Limits = NewLimiter(Limit{Robot: "Robot1", Device: "Device1", Rate: 0.5})
+	records := []AuditRecord{}
+	a.Audit = AuditFunc(func(record AuditRecord) {
+		records = append(records, record)
+	})
+
+	execute := func() *httptest.ResponseRecorder {
+		request, _ := http.NewRequest("POST",
+			"/api/robots/Robot1/devices/Device1/commands/TestDriverCommand",
+			bytes.NewBufferString(`{"name":"human"}`),
+		)
+		response := httptest.NewRecorder()
+		a.ServeHTTP(response, request)
+		return response
+	}
+
+	// unknown commands are not counted against the limits
+	request, _ := http.NewRequest("POST",
+		"/api/robots/Robot1/devices/Device1/commands/UnknownCommand",
+		bytes.NewBufferString(`{}`),
+	)
+	unknown := httptest.NewRecorder()
+	a.ServeHTTP(unknown, request)
+	gobottest.Assert(t, unknown.Code, 200)
+	gobottest.Assert(t, len(a.Limits.buckets), 0)
+
+	gobottest.Assert(t, execute().Code, 200)
+	response := execute()
+	gobottest.Assert(t, response.Code, http.StatusTooManyRequests)
+	gobottest.Assert(t, response.Header().Get("Retry-After"), "2")
+
+	var body map[string]interface{}
+	json.NewDecoder(response.Body).Decode(&body)
+	gobottest.Refute(t, body["error"], nil)
+	gobottest.Assert(t, body["retry_after"].(float64) > 1.9, true)
+
+	// rejected commands are audited
+	gobottest.Assert(t, len(records), 3)
+	gobottest.Assert(t, records[0].Error, gobot.ErrUnknownCommand.Error())
+	gobottest.Refute(t, records[2].Error, "")
+}
@@ -0,0 +1,416 @@
+package api
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/hybridgroup/gobot"
+)
+
+// openAPIVersion is the version of the OpenAPI specification followed by the
+// document served on /api/openapi.json
+const openAPIVersion = "3.0.3"
+
+// openAPIRoute describes a static route of the API.
+type openAPIRoute struct {
+	method   string
+	path     string
+	id       string
+	summary  string
+	response string
+	schema   map[string]interface{}
+	body     map[string]interface{}
+}
+
+var (
+	robotSchema          = ref("Robot")
+	deviceSchema         = ref("Device")
+	connectionSchema     = ref("Connection")
+	commandsSchema       = map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}}
+	stateSchema          = map[string]interface{}{"type": "string"}
+	stateMapSchema       = map[string]interface{}{"type": "object", "additionalProperties": true}
+	resultSchema         = map[string]interface{}{}
+	paramsSchema         = map[string]interface{}{"type": "object", "additionalProperties": true}
+	deviceConfigBody     = ref("DeviceConfig")
+	connectionConfigBody = ref("ConnectionConfig")
+	graphqlBody          = map[string]interface{}{
+		"type":     "object",
+		"required": []string{"query"},
+		"properties": map[string]interface{}{
+			"query":         map[string]interface{}{"type": "string"},
+			"operationName": map[string]interface{}{"type": "string"},
+			"variables":     map[string]interface{}{"type": "object", "additionalProperties": true},
+		},
+	}
+)
+
+// openAPIRoutes are the static routes registered by Start, with their path
+// parameters in OpenAPI form.
+var openAPIRoutes = []openAPIRoute{
+	{"get", "/api/", "getMCP", "Returns the MCP with its robots and commands", "MCP", ref("MCP"), nil},
+	{"get", "/api/commands", "listMCPCommands", "Returns the names of the MCP commands", "commands", commandsSchema, nil},
+	{"post", "/api/commands/{command}", "executeMCPCommand", "Executes an MCP command", "result", resultSchema, paramsSchema},
+	{"get", "/api/robots", "listRobots", "Returns every robot", "robots", arrayOf(robotSchema), nil},
+	{"get", "/api/robots/{robot}", "getRobot", "Returns a robot", "robot", robotSchema, nil},
+	{"get", "/api/robots/{robot}/commands", "listRobotCommands", "Returns the names of the robot commands", "commands", commandsSchema, nil},
+	{"post", "/api/robots/{robot}/commands/{command}", "executeRobotCommand", "Executes a robot command", "result", resultSchema, paramsSchema},
+	{"get", "/api/robots/{robot}/devices", "listRobotDevices", "Returns the devices of a robot", "devices", arrayOf(deviceSchema), nil},
+	{"post", "/api/robots/{robot}/devices", "attachRobotDevice", "Attaches a new device to a robot", "device", deviceSchema, deviceConfigBody},
+	{"get", "/api/robots/{robot}/devices/{device}", "getRobotDevice", "Returns a device", "device", deviceSchema, nil},
+	{"delete", "/api/robots/{robot}/devices/{device}", "detachRobotDevice", "Halts and removes a device from a robot", "state", stateSchema, nil},
+	{"post", "/api/robots/{robot}/devices/{device}/start", "startRobotDevice", "Starts a device of a running robot", "state", stateSchema, nil},
+	{"post", "/api/robots/{robot}/devices/{device}/halt", "haltRobotDevice", "Halts a device of a running robot", "state", stateSchema, nil},
+	{"get", "/api/robots/{robot}/devices/{device}/events/{event}", "streamRobotDeviceEvent", "Streams the data of a device event as server-sent events", "", nil, nil},
+	{"get", "/api/robots/{robot}/devices/{device}/state", "getRobotDeviceState", "Returns the current state of a device", "state", stateMapSchema, nil},
+	{"get", "/api/robots/{robot}/devices/{device}/commands", "listRobotDeviceCommands", "Returns the names of the device commands", "commands", commandsSchema, nil},
+	{"post", "/api/robots/{robot}/devices/{device}/commands/{command}", "executeRobotDeviceCommand", "Executes a device command", "result", resultSchema, paramsSchema},
+	{"get", "/api/robots/{robot}/connections", "listRobotConnections", "Returns the connections of a robot", "connections", arrayOf(connectionSchema), nil},
+	{"post", "/api/robots/{robot}/connections", "attachRobotConnection", "Attaches a new connection to a robot", "connection", connectionSchema, connectionConfigBody},
+	{"get", "/api/robots/{robot}/connections/{connection}", "getRobotConnection", "Returns a connection", "connection", connectionSchema, nil},
+	{"delete", "/api/robots/{robot}/connections/{connection}", "detachRobotConnection", "Finalizes and removes a connection from a robot", "state", stateSchema, nil},
+	{"post", "/api/robots/{robot}/connections/{connection}/start", "startRobotConnection", "Connects a connection of a running robot", "state", stateSchema, nil},
+	{"post", "/api/robots/{robot}/connections/{connection}/halt", "haltRobotConnection", "Finalizes a connection of a running robot", "state", stateSchema, nil},
+	{"get", "/api/socket", "connectSocket", "Opens a WebSocket to execute commands and subscribe to events", "", nil, nil},
+	{"get", "/api/openapi.json", "getOpenAPI", "Returns this OpenAPI document", "", nil, nil},
+	{"get", "/api/graphql", "queryGraphQL", "Executes a GraphQL query, or streams a subscription as server-sent events", "", nil, nil},
+	{"post", "/api/graphql", "executeGraphQL", "Executes a GraphQL query or mutation, or streams a subscription as server-sent events", "", nil, graphqlBody},
+	{"get", "/metrics", "getMetrics", "Returns the metrics in the Prometheus text format", "", nil, nil},
+}
+
+// openAPIRawResponses are the responses of the static routes which do not
+// write a JSON object with a single key, by operation id.
+var openAPIRawResponses = map[string]map[string]interface{}{
+	"streamRobotDeviceEvent": {
+		"200": map[string]interface{}{
+			"description": "Event stream",
+			"content":     map[string]interface{}{"text/event-stream": map[string]interface{}{}},
+		},
+	},
+	"connectSocket": {
+		"101": map[string]interface{}{"description": "Switching to the WebSocket protocol"},
+		"403": map[string]interface{}{"description": "The Origin of the handshake is not allowed"},
+	},
+	"getOpenAPI": {
+		"200": map[string]interface{}{
+			"description": "The OpenAPI document",
+			"content":     map[string]interface{}{"application/json": map[string]interface{}{"schema": map[string]interface{}{"type": "object"}}},
+		},
+	},
+	"queryGraphQL":   graphqlResponses,
+	"executeGraphQL": graphqlResponses,
+	"getMetrics": {
+		"200": map[string]interface{}{
+			"description": "Metrics",
+			"content":     map[string]interface{}{"text/plain": map[string]interface{}{"schema": map[string]interface{}{"type": "string"}}},
+		},
+	},
+}
+
+var graphqlResponses = map[string]interface{}{
+	"200": map[string]interface{}{
+		"description": "The data of the operation and its errors, or the events of a subscription",
+		"content": map[string]interface{}{
+			"application/json": map[string]interface{}{
+				"schema": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"data":   map[string]interface{}{"type": "object", "additionalProperties": true},
+						"errors": arrayOf(map[string]interface{}{"type": "object", "additionalProperties": true}),
+					},
+				},
+			},
+			"text/event-stream": map[string]interface{}{},
+		},
+	},
+}
+
+// openAPIQueryParams are the query parameters of the static routes, by
+// operation id.
+var openAPIQueryParams = map[string][]string{
+	"queryGraphQL": {"query", "operationName", "variables"},
+}
+
+// openAPI returns the OpenAPI document route handler.
+// Writes JSON with the OpenAPI document of the API
+func (a *API) openAPI(res http.ResponseWriter, req *http.Request) {
+	a.writeJSON(a.OpenAPI(), res)
+}
+
+// OpenAPI returns an OpenAPI 3 document describing the routes of the API, as
+// well as a route for each command of the MCP and of every robot and device,
+// with its parameters if the command has a schema.
+func (a *API) OpenAPI() map[string]interface{} {
+	paths := map[string]interface{}{}
+	addOperation := func(path string, method string, operation map[string]interface{}) {
+		item, ok := paths[path].(map[string]interface{})
+		if !ok {
+			item = map[string]interface{}{}
+			paths[path] = item
+		}
+		item[method] = operation
+	}
+
+	for _, route := range openAPIRoutes {
+		operation := map[string]interface{}{
+			"operationId": route.id,
+			"summary":     route.summary,
+			"responses":   jsonResponse(route.response, route.schema),
+		}
+		if route.response == "" {
+			operation["responses"] = openAPIRawResponses[route.id]
+		}
+		params := pathParams(route.path)
+		for _, name := range openAPIQueryParams[route.id] {
+			params = append(params, map[string]interface{}{
+				"name":   name,
+				"in":     "query",
+				"schema": map[string]interface{}{"type": "string"},
+			})
+		}
+		if len(params) > 0 {
+			operation["parameters"] = params
+		}
+		if route.body != nil {
+			operation["requestBody"] = jsonBody(route.body)
+		}
+		addOperation(route.path, route.method, operation)
+	}
+
+	for name, operation := range commandOperations(a.gobot, "mcp") {
+		addOperation("/api/commands/"+name, "post", operation)
+	}
+	a.gobot.Robots().Each(func(r *gobot.Robot) {
+		for name, operation := range commandOperations(r, r.Name) {
+			addOperation("/api/robots/"+r.Name+"/commands/"+name, "post", operation)
+		}
+		r.Devices().Each(func(d gobot.Device) {
+			if commander, ok := d.(gobot.Commander); ok {
+				for name, operation := range commandOperations(commander, r.Name, d.Name()) {
+					addOperation("/api/robots/"+r.Name+"/devices/"+d.Name()+"/commands/"+name, "post", operation)
+				}
+			}
+		})
+	})
+
+	doc := map[string]interface{}{
+		"openapi": openAPIVersion,
+		"info": map[string]interface{}{
+			"title":   "Gobot",
+			"version": gobot.Version(),
+		},
+		"paths": paths,
+		"components": map[string]interface{}{
+			"schemas": openAPISchemas(),
+		},
+	}
+
+	if a.Auth != nil {
+		doc["components"].(map[string]interface{})["securitySchemes"] = map[string]interface{}{
+			"bearerAuth": map[string]interface{}{"type": "http", "scheme": "bearer"},
+			"apiKeyAuth": map[string]interface{}{"type": "apiKey", "in": "header", "name": "X-API-Key"},
+		}
+		doc["security"] = []interface{}{
+			map[string]interface{}{"bearerAuth": []string{}},
+			map[string]interface{}{"apiKeyAuth": []string{}},
+		}
+	}
+	return doc
+}
+
+// commandOperations returns an operation executing each command of c, keyed
+// by command name. The operation ids are prefixed with owners.
+func commandOperations(c gobot.Commander, owners ...string) map[string]map[string]interface{} {
+	operations := map[string]map[string]interface{}{}
+	for name := range c.Commands() {
+		operation := map[string]interface{}{
+			"operationId": operationID(append(append([]string{"execute"}, owners...), name)),
+			"summary":     "Executes " + name,
+			"responses":   jsonResponse("result", resultSchema),
+			"requestBody": jsonBody(paramsSchema),
+		}
+		if schema := gobot.CommandSchemaOf(c, name); schema != nil {
+			if schema.Description != "" {
+				operation["description"] = schema.Description
+			}
+			operation["requestBody"] = jsonBody(commandSchema(schema))
+		}
+		operations[name] = operation
+	}
+	return operations
+}
+
+// commandSchema returns the JSON schema of the parameters of a command.
+func commandSchema(schema *gobot.CommandSchema) map[string]interface{} {
+	properties := map[string]interface{}{}
+	required := []string{}
+	for _, p := range schema.Params {
+		property := map[string]interface{}{}
+		if p.Type != "" {
+			property["type"] = string(p.Type)
+		}
+		if p.Description != "" {
+			property["description"] = p.Description
+		}
+		if p.Default != nil {
+			property["default"] = p.Default
+		}
+		if p.Range != nil {
+			property["minimum"] = p.Range.Min
+			property["maximum"] = p.Range.Max
+		}
+		if p.Required && p.Default == nil {
+			required = append(required, p.Name)
+		}
+		properties[p.Name] = property
+	}
+
+	s := map[string]interface{}{
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": true,
+	}
+	if len(required) > 0 {
+		s["required"] = required
+	}
+	return s
+}
+
+// operationID joins parts into an identifier usable by client generators.
+func operationID(parts []string) string {
+	id := ""
+	for i, part := range parts {
+		word := strings.Map(func(r rune) rune {
+			if r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+				return r
+			}
+			return '_'
+		}, part)
+		if i > 0 && len(word) > 0 {
+			word = strings.ToUpper(word[:1]) + word[1:]
+		}
+		id += word
+	}
+	return id
+}
+
+// pathParams returns the parameters of an OpenAPI path such as
+// /api/robots/{robot}.
+func pathParams(path string) []interface{} {
+	params := []interface{}{}
+	for _, segment := range strings.Split(path, "/") {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			params = append(params, map[string]interface{}{
+				"name":     strings.Trim(segment, "{}"),
+				"in":       "path",
+				"required": true,
+				"schema":   map[string]interface{}{"type": "string"},
+			})
+		}
+	}
+	return params
+}
+
+// jsonResponse returns the responses of a route which writes schema under
+// key, or an error.
+func jsonResponse(key string, schema map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"200": map[string]interface{}{
+			"description": "The " + key + ", or an error",
+			"content": map[string]interface{}{
+				"application/json": map[string]interface{}{
+					"schema": map[string]interface{}{
+						"type": "object",
+						"properties": map[string]interface{}{
+							key:     schema,
+							"error": map[string]interface{}{"type": "string"},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func jsonBody(schema map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"content": map[string]interface{}{
+			"application/json": map[string]interface{}{"schema": schema},
+		},
+	}
+}
+
+func ref(name string) map[string]interface{} {
+	return map[string]interface{}{"$ref": "#/components/schemas/" + name}
+}
+
+func arrayOf(schema map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{"type": "array", "items": schema}
+}
+
+// openAPISchemas returns the schemas of the JSON representations of Gobot.
+func openAPISchemas() map[string]interface{} {
+	str := map[string]interface{}{"type": "string"}
+	object := func(required []string, properties map[string]interface{}) map[string]interface{} {
+		sort.Strings(required)
+		return map[string]interface{}{"type": "object", "required": required, "properties": properties}
+	}
+	commandSchemas := map[string]interface{}{
+		"type":                 "object",
+		"additionalProperties": ref("CommandSchema"),
+	}
+
+	return map[string]interface{}{
+		"MCP": object([]string{"robots", "commands"}, map[string]interface{}{
+			"robots":   arrayOf(robotSchema),
+			"commands": commandsSchema,
+		}),
+		"Robot": object([]string{"name", "commands", "connections", "devices"}, map[string]interface{}{
+			"name":            str,
+			"commands":        commandsSchema,
+			"command_schemas": commandSchemas,
+			"connections":     arrayOf(connectionSchema),
+			"devices":         arrayOf(deviceSchema),
+		}),
+		"Device": object([]string{"name", "driver", "connection", "commands"}, map[string]interface{}{
+			"name":            str,
+			"driver":          str,
+			"connection":      str,
+			"commands":        commandsSchema,
+			"command_schemas": commandSchemas,
+			"state":           stateMapSchema,
+		}),
+		"Connection": object([]string{"name", "adaptor"}, map[string]interface{}{
+			"name":    str,
+			"adaptor": str,
+		}),
+		"CommandSchema": object([]string{"params"}, map[string]interface{}{
+			"description": str,
+			"params": arrayOf(object([]string{"name", "type", "required"}, map[string]interface{}{
+				"name":        str,
+				"type":        map[string]interface{}{"type": "string", "enum": []string{"number", "integer", "string", "boolean"}},
+				"description": str,
+				"required":    map[string]interface{}{"type": "boolean"},
+				"default":     map[string]interface{}{},
+				"range": object([]string{"min", "max"}, map[string]interface{}{
+					"min": map[string]interface{}{"type": "number"},
+					"max": map[string]interface{}{"type": "number"},
+				}),
+			})),
+		}),
+		"DeviceConfig": object([]string{"name", "driver", "connection"}, map[string]interface{}{
+			"name":       str,
+			"driver":     str,
+			"connection": str,
+			"pin":        str,
+			"interval":   str,
+			"params":     paramsSchema,
+		}),
+		"ConnectionConfig": object([]string{"name", "adaptor"}, map[string]interface{}{
+			"name":    str,
+			"adaptor": str,
+			"port":    str,
+			"params":  paramsSchema,
+		}),
+	}
+}
@@ -0,0 +1,75 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hybridgroup/gobot"
+	"github.com/hybridgroup/gobot/gobottest"
+)
+
+func TestOpenAPI(t *testing.T) {
+	a := initTestAPI()
+	a.gobot.Robot("Robot1").Device("Device1").(gobot.SchemaCommander).
+		AddCommandWithSchema("Move", gobot.CommandSchema{
+			Description: "Moves the servo",
+			Params: []gobot.Param{
+				{Name: "angle", Type: gobot.ParamInteger, Required: true, Range: &gobot.Range{Min: 0, Max: 180}},
+				{Name: "speed", Type: gobot.ParamNumber, Default: 1.0},
+			},
+		}, func(params map[string]interface{}) interface{} { return nil })
+
+	request, _ := http.NewRequest("GET", "/api/openapi.json", nil)
+	response := httptest.NewRecorder()
+	a.ServeHTTP(response, request)
+
+	var doc map[string]interface{}
+	gobottest.Assert(t, json.NewDecoder(response.Body).Decode(&doc), nil)
+	gobottest.Assert(t, doc["openapi"], "3.0.3")
+	gobottest.Assert(t, doc["info"].(map[string]interface{})["version"], gobot.Version())
+	gobottest.Assert(t, doc["security"], nil)
+
+	paths := doc["paths"].(map[string]interface{})
+	operation := func(path string, method string) map[string]interface{} {
+		item, ok := paths[path].(map[string]interface{})
+		if !ok {
+			t.Fatalf("path %v is not described", path)
+		}
+		return item[method].(map[string]interface{})
+	}
+
+	// static routes
+	gobottest.Assert(t, operation("/api/robots", "get")["operationId"], "listRobots")
+	params := operation("/api/robots/{robot}/devices/{device}/commands/{command}", "post")["parameters"].([]interface{})
+	gobottest.Assert(t, len(params), 3)
+	gobottest.Assert(t, params[1].(map[string]interface{})["name"], "device")
+	gobottest.Assert(t, operation("/api/socket", "get")["operationId"], "connectSocket")
+	_, ok := operation("/api/socket", "get")["responses"].(map[string]interface{})["101"]
+	gobottest.Assert(t, ok, true)
+	gobottest.Assert(t, operation("/api/openapi.json", "get")["operationId"], "getOpenAPI")
+	params = operation("/api/graphql", "get")["parameters"].([]interface{})
+	gobottest.Assert(t, len(params), 3)
+	gobottest.Assert(t, params[0].(map[string]interface{})["in"], "query")
+	gobottest.Refute(t, operation("/api/graphql", "post")["requestBody"], nil)
+	gobottest.Assert(t, operation("/metrics", "get")["operationId"], "getMetrics")
+
+	// commands of the MCP, robots and devices
+	gobottest.Assert(t, operation("/api/commands/TestFunction", "post")["operationId"], "executeMcpTestFunction")
+	gobottest.Assert(t, operation("/api/robots/Robot1/commands/robotTestFunction", "post")["operationId"], "executeRobot1RobotTestFunction")
+	move := operation("/api/robots/Robot1/devices/Device1/commands/Move", "post")
+	gobottest.Assert(t, move["operationId"], "executeRobot1Device1Move")
+	gobottest.Assert(t, move["description"], "Moves the servo")
+
+	schema := move["requestBody"].(map[string]interface{})["content"].(map[string]interface{})["application/json"].(map[string]interface{})["schema"].(map[string]interface{})
+	gobottest.Assert(t, schema["required"], []interface{}{"angle"})
+	properties := schema["properties"].(map[string]interface{})
+	gobottest.Assert(t, properties["angle"], map[string]interface{}{"type": "integer", "minimum": 0.0, "maximum": 180.0})
+	gobottest.Assert(t, properties["speed"], map[string]interface{}{"type": "number", "default": 1.0})
+	_, ok = paths["/api/robots/Robot2/devices/Device1/commands/Move"]
+	gobottest.Assert(t, ok, false)
+
+	a.Auth = NewTokenAuth()
+	gobottest.Refute(t, a.OpenAPI()["security"], nil)
+}
@@ -0,0 +1,450 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hybridgroup/gobot"
+	"golang.org/x/net/websocket"
+)
+
+// ErrRemoteNotConnected is returned when a remote device is started before
+// its connection.
+var ErrRemoteNotConnected = errors.New("Remote Gobot is not connected")
+
+// RemoteRobot connects to the API of another Gobot and mirrors its robots
+// as local proxies. Each proxy robot has a connection for every connection
+// of the remote robot, and a device for every remote device, whose commands
+// are executed on the remote host and whose events are published locally.
+//
+// Header is sent with every request, and may carry an X-API-Key or an
+// Authorization header for a remote API which requires one. TLSConfig is
+// used to connect to https URLs.
+type RemoteRobot struct {
+	URL       string
+	Prefix    string
+	Header    http.Header
+	TLSConfig *tls.Config
+	Timeout   time.Duration
+
+	mutex   sync.Mutex
+	client  *http.Client
+	ws      *websocket.Conn
+	users   int
+	nextID  int
+	pending map[string]chan SocketReply
+	devices map[string]*remoteDevice
+}
+
+// NewRemoteRobot returns a RemoteRobot for the Gobot API at url, such as
+// "http://192.168.1.10:3000".
+func NewRemoteRobot(url string) *RemoteRobot {
+	return &RemoteRobot{
+		URL:     strings.TrimSuffix(url, "/"),
+		Header:  make(http.Header),
+		Timeout: 10 * time.Second,
+		pending: make(map[string]chan SocketReply),
+		devices: make(map[string]*remoteDevice),
+	}
+}
+
+// Robots returns a proxy for each robot of the remote Gobot, named as on the
+// remote host with Prefix prepended. The robots may be added to a local
+// Gobot like any other robot.
+func (r *RemoteRobot) Robots() (robots []*gobot.Robot, err error) {
+	var body struct {
+		Robots []*gobot.JSONRobot `json:"robots"`
+	}
+	if err = r.get("/api/robots", &body); err != nil {
+		return
+	}
+
+	for _, jrobot := range body.Robots {
+		robots = append(robots, r.robot(jrobot))
+	}
+	return
+}
+
+// robot returns the proxy of a remote robot.
+func (r *RemoteRobot) robot(jrobot *gobot.JSONRobot) *gobot.Robot {
+	connections := []gobot.Connection{}
+	for _, jconnection := range jrobot.Connections {
+		connections = append(connections, &remoteConnection{
+			name:    jconnection.Name,
+			adaptor: jconnection.Adaptor,
+			remote:  r,
+		})
+	}
+	connectionFor := func(name string) gobot.Connection {
+		for _, c := range connections {
+			if c.Name() == name {
+				return c
+			}
+		}
+		c := &remoteConnection{name: name, remote: r}
+		connections = append(connections, c)
+		return c
+	}
+
+	devices := []gobot.Device{}
+	for _, jdevice := range jrobot.Devices {
+		d := &remoteDevice{
+			name:              jdevice.Name,
+			driver:            jdevice.Driver,
+			robot:             jrobot.Name,
+			connection:        connectionFor(jdevice.Connection),
+			remote:            r,
+			Eventer:           gobot.NewEventer(),
+			ExtendedCommander: gobot.NewCommander(),
+		}
+		r.addCommands(d.ExtendedCommander, jrobot.Name, jdevice.Name, jdevice.Commands, jdevice.CommandSchemas)
+		devices = append(devices, d)
+	}
+
+	robot := gobot.NewRobot(r.Prefix+jrobot.Name, connections, devices)
+	r.addCommands(robot.ExtendedCommander, jrobot.Name, "", jrobot.Commands, jrobot.CommandSchemas)
+	return robot
+}
+
+// addCommands adds a proxy to c for each of the remote commands.
+func (r *RemoteRobot) addCommands(c gobot.ContextCommander, robot string, device string,
+	commands []string, schemas map[string]*gobot.CommandSchema,
+) {
+	route := "/api/robots/" + url.PathEscape(robot)
+	if device != "" {
+		route += "/devices/" + url.PathEscape(device)
+	}
+
+	for _, name := range commands {
+		path := route + "/commands/" + url.PathEscape(name)
+		f := func(ctx context.Context, params map[string]interface{}) interface{} {
+			result, err := r.execute(ctx, path, params)
+			if err != nil {
+				return err
+			}
+			return result
+		}
+		if schema, ok := schemas[name]; ok && schema != nil {
+			c.AddCommandContextWithSchema(name, *schema, f)
+		} else {
+			c.AddCommandContext(name, f)
+		}
+	}
+}
+
+// execute executes a remote command, passing on the request ID of ctx.
+func (r *RemoteRobot) execute(ctx context.Context, path string, params map[string]interface{}) (interface{}, error) {
+	data, err := json.Marshal(params)
+	if err != nil {
+		return nil, err
+	}
+	req, err := r.newRequest(ctx, "POST", path, bytes.NewBuffer(data))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if id := gobot.RequestID(ctx); id != "" {
+		req.Header.Set(RequestIDHeader, id)
+	}
+
+	var body struct {
+		Result interface{} `json:"result"`
+		Error  string      `json:"error"`
+	}
+	res, err := r.httpClient().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("Remote Gobot returned %v", res.Status)
+	}
+	if body.Error != "" {
+		return nil, errors.New(body.Error)
+	}
+	return body.Result, nil
+}
+
+// get decodes the JSON of a remote route into v.
+func (r *RemoteRobot) get(path string, v interface{}) error {
+	req, err := r.newRequest(context.Background(), "GET", path, nil)
+	if err != nil {
+		return err
+	}
+	res, err := r.httpClient().Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("Remote Gobot returned %v", res.Status)
+	}
+	return json.NewDecoder(res.Body).Decode(v)
+}
+
+func (r *RemoteRobot) newRequest(ctx context.Context, method string, path string, body *bytes.Buffer) (*http.Request, error) {
+	var req *http.Request
+	var err error
+	if body != nil {
+		req, err = http.NewRequest(method, r.URL+path, body)
+	} else {
+		req, err = http.NewRequest(method, r.URL+path, nil)
+	}
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range r.Header {
+		req.Header[k] = v
+	}
+	return req.WithContext(ctx), nil
+}
+
+func (r *RemoteRobot) httpClient() *http.Client {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.client == nil {
+		r.client = &http.Client{
+			Timeout:   r.Timeout,
+			Transport: &http.Transport{TLSClientConfig: r.TLSConfig, Proxy: http.ProxyFromEnvironment},
+		}
+	}
+	return r.client
+}
+
+// connect opens the WebSocket to the remote Gobot for c, unless another
+// connection already has.
+func (r *RemoteRobot) connect(c *remoteConnection) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if c.ws != nil && c.ws == r.ws {
+		return nil
+	}
+	if r.ws != nil {
+		r.users++
+		c.ws = r.ws
+		return nil
+	}
+
+	location := "ws" + strings.TrimPrefix(r.URL, "http") + "/api/socket"
+	config, err := websocket.NewConfig(location, r.URL)
+	if err != nil {
+		return err
+	}
+	config.TlsConfig = r.TLSConfig
+	for k, v := range r.Header {
+		config.Header[k] = v
+	}
+	ws, err := websocket.DialConfig(config)
+	if err != nil {
+		return err
+	}
+	r.ws = ws
+	r.users = 1
+	c.ws = ws
+	go r.receive(ws)
+	return nil
+}
+
+// disconnect closes the WebSocket once every connection which opened it has
+// finalized.
+func (r *RemoteRobot) disconnect(c *remoteConnection) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	ws := c.ws
+	c.ws = nil
+	if ws == nil || ws != r.ws {
+		return nil
+	}
+	if r.users--; r.users > 0 {
+		return nil
+	}
+	err := r.ws.Close()
+	r.ws = nil
+	return err
+}
+
+// receive publishes the events received on ws on their devices, and hands
+// replies to the requests waiting for them.
+func (r *RemoteRobot) receive(ws *websocket.Conn) {
+	for {
+		var reply SocketReply
+		if err := websocket.JSON.Receive(ws, &reply); err != nil {
+			r.mutex.Lock()
+			if r.ws == ws {
+				r.ws = nil
+				r.users = 0
+			}
+			for id, c := range r.pending {
+				close(c)
+				delete(r.pending, id)
+			}
+			r.mutex.Unlock()
+			return
+		}
+
+		r.mutex.Lock()
+		if reply.Type == "event" {
+			d := r.devices[reply.Robot+"/"+reply.Device]
+			r.mutex.Unlock()
+			if d != nil {
+				if d.Event(reply.Event) == "" {
+					d.AddEvent(reply.Event)
+				}
+				d.Publish(reply.Event, reply.Data)
+			}
+			continue
+		}
+		c, ok := r.pending[reply.ID]
+		delete(r.pending, reply.ID)
+		r.mutex.Unlock()
+		if ok {
+			c <- reply
+		}
+	}
+}
+
+// request sends msg over the WebSocket and waits for its reply.
+func (r *RemoteRobot) request(msg SocketMessage) error {
+	r.mutex.Lock()
+	if r.ws == nil {
+		r.mutex.Unlock()
+		return ErrRemoteNotConnected
+	}
+	r.nextID++
+	msg.ID = strconv.Itoa(r.nextID)
+	c := make(chan SocketReply, 1)
+	r.pending[msg.ID] = c
+	ws := r.ws
+	r.mutex.Unlock()
+
+	if err := websocket.JSON.Send(ws, msg); err != nil {
+		r.mutex.Lock()
+		delete(r.pending, msg.ID)
+		r.mutex.Unlock()
+		return err
+	}
+
+	select {
+	case reply, ok := <-c:
+		if !ok {
+			return ErrRemoteNotConnected
+		}
+		if reply.Type == "error" {
+			return errors.New(reply.Error)
+		}
+		return nil
+	case <-time.After(r.Timeout):
+		r.mutex.Lock()
+		delete(r.pending, msg.ID)
+		r.mutex.Unlock()
+		return errors.New("Remote Gobot did not reply")
+	}
+}
+
+// remoteConnection is the proxy of a connection of a remote robot.
+type remoteConnection struct {
+	name    string
+	adaptor string
+	remote  *RemoteRobot
+	// ws is the WebSocket the connection last connected with, guarded by
+	// the mutex of remote.
+	ws *websocket.Conn
+}
+
+// Name returns the name of the remote connection
+func (c *remoteConnection) Name() string { return c.name }
+
+// Port returns the URL of the remote Gobot
+func (c *remoteConnection) Port() string { return c.remote.URL }
+
+// Adaptor returns the type of the adaptor of the remote connection
+func (c *remoteConnection) Adaptor() string { return c.adaptor }
+
+// Connect connects to the remote Gobot to receive events
+func (c *remoteConnection) Connect() (errs []error) {
+	if err := c.remote.connect(c); err != nil {
+		return []error{err}
+	}
+	return
+}
+
+// Finalize disconnects from the remote Gobot
+func (c *remoteConnection) Finalize() (errs []error) {
+	if err := c.remote.disconnect(c); err != nil {
+		return []error{err}
+	}
+	return
+}
+
+// Ping checks that the WebSocket the connection connected with is still open,
+// so that the Supervisor reconnects it and restarts its devices to subscribe
+// to their events again, and that the remote Gobot answers.
+func (c *remoteConnection) Ping() error {
+	c.remote.mutex.Lock()
+	connected := c.ws != nil && c.ws == c.remote.ws
+	c.remote.mutex.Unlock()
+	if !connected {
+		return ErrRemoteNotConnected
+	}
+	var body map[string]interface{}
+	return c.remote.get("/api/", &body)
+}
+
+// remoteDevice is the proxy of a device of a remote robot.
+type remoteDevice struct {
+	name       string
+	driver     string
+	robot      string
+	connection gobot.Connection
+	remote     *RemoteRobot
+	gobot.Eventer
+	gobot.ExtendedCommander
+}
+
+// Name returns the name of the remote device
+func (d *remoteDevice) Name() string { return d.name }
+
+// Driver returns the type of the driver of the remote device
+func (d *remoteDevice) Driver() string { return d.driver }
+
+// Connection returns the proxy of the connection of the remote device
+func (d *remoteDevice) Connection() gobot.Connection { return d.connection }
+
+// Start subscribes to the events of the remote device
+func (d *remoteDevice) Start() (errs []error) {
+	key := d.robot + "/" + d.name
+	d.remote.mutex.Lock()
+	d.remote.devices[key] = d
+	d.remote.mutex.Unlock()
+
+	if err := d.remote.request(SocketMessage{Action: "subscribe", Robot: d.robot, Device: d.name, Event: "*"}); err != nil {
+		d.remote.mutex.Lock()
+		delete(d.remote.devices, key)
+		d.remote.mutex.Unlock()
+		return []error{err}
+	}
+	return
+}
+
+// Halt unsubscribes from the events of the remote device
+func (d *remoteDevice) Halt() (errs []error) {
+	d.remote.mutex.Lock()
+	delete(d.remote.devices, d.robot+"/"+d.name)
+	d.remote.mutex.Unlock()
+
+	if err := d.remote.request(SocketMessage{Action: "unsubscribe", Robot: d.robot, Device: d.name, Event: "*"}); err != nil &&
+		err != ErrRemoteNotConnected {
+		return []error{err}
+	}
+	return
+}
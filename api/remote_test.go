@@ -0,0 +1,160 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/hybridgroup/gobot"
+	"github.com/hybridgroup/gobot/gobottest"
+)
+
+func initTestRemoteRobot(t *testing.T) (*API, *httptest.Server, *gobot.Robot) {
+	a := initTestAPI()
+	server := httptest.NewServer(a)
+
+	remote := NewRemoteRobot(server.URL + "/")
+	remote.Prefix = "pi1/"
+	robots, err := remote.Robots()
+	if err != nil {
+		server.Close()
+		t.Fatal(err)
+	}
+	gobottest.Assert(t, len(robots), 3)
+	return a, server, robots[0]
+}
+
+func TestRemoteRobot(t *testing.T) {
+	_, server, robot := initTestRemoteRobot(t)
+	defer server.Close()
+
+	gobottest.Assert(t, robot.Name, "pi1/Robot1")
+	gobottest.Assert(t, robot.Devices().Len(), 3)
+	gobottest.Assert(t, robot.Device("Device1").Connection().Name(), "Connection1")
+	gobottest.Assert(t, robot.Connection("Connection2").(gobot.Porter).Port(), server.URL)
+	gobottest.Assert(t, robot.Connection("Connection1").(gobot.Pinger).Ping(), ErrRemoteNotConnected)
+	gobottest.Assert(t, len(robot.Connection("Connection1").Connect()), 0)
+	defer robot.Connection("Connection1").Finalize()
+	gobottest.Assert(t, robot.Connection("Connection1").(gobot.Pinger).Ping(), nil)
+
+	device := robot.Device("Device1").(gobot.ExtendedCommander)
+	result, err := device.Execute("TestDriverCommand", map[string]interface{}{"name": "human"})
+	gobottest.Assert(t, err, nil)
+	gobottest.Assert(t, result, "hello human")
+
+	result, err = robot.Execute("robotTestFunction", map[string]interface{}{"message": "Beep Boop", "robot": "Robot1"})
+	gobottest.Assert(t, err, nil)
+	gobottest.Assert(t, result, "hey Robot1, Beep Boop")
+
+	result, _ = device.Execute("UnknownCommand", nil)
+	gobottest.Assert(t, result, nil)
+	result = device.Command("DriverCommand")(map[string]interface{}{"name": "you"})
+	gobottest.Assert(t, result, "hello you")
+}
+
+func TestRemoteRobotCommandContext(t *testing.T) {
+	a, server, robot := initTestRemoteRobot(t)
+	defer server.Close()
+
+	records := make(chan AuditRecord, 1)
+	a.Audit = AuditFunc(func(record AuditRecord) { records <- record })
+	a.gobot.Robot("Robot1").Device("Device1").(gobot.SchemaCommander).AddCommandWithSchema("Move",
+		gobot.CommandSchema{Params: []gobot.Param{{Name: "angle", Type: gobot.ParamInteger, Required: true}}},
+		func(params map[string]interface{}) interface{} { return params["angle"] },
+	)
+
+	// commands are mirrored when the robots are fetched
+	remote := NewRemoteRobot(server.URL)
+	robots, _ := remote.Robots()
+	device := robots[0].Device("Device1").(gobot.ExtendedCommander)
+	gobottest.Assert(t, device.CommandSchema("Move").Params[0].Name, "angle")
+
+	result, err := device.ExecuteContext(gobot.WithRequestID(context.Background(), "master-7"), "Move",
+		map[string]interface{}{"angle": 90})
+	gobottest.Assert(t, err, nil)
+	gobottest.Assert(t, result, 90.0)
+	gobottest.Assert(t, (<-records).RequestID, "master-7")
+
+	// params are validated locally
+	_, err = device.Execute("Move", map[string]interface{}{})
+	gobottest.Refute(t, err, nil)
+
+	// errors of the remote API are returned by the command
+	a.Audit = nil
+	a.Limits = NewLimiter(Limit{Command: "DriverCommand", Rate: 0.001})
+	device = robot.Device("Device1").(gobot.ExtendedCommander)
+	result, _ = device.Execute("DriverCommand", map[string]interface{}{"name": "human"})
+	gobottest.Assert(t, result, "hello human")
+	result, _ = device.Execute("DriverCommand", map[string]interface{}{"name": "human"})
+	gobottest.Assert(t, result.(error).Error()[:17], "Too many requests")
+}
+
+func TestRemoteRobotEvents(t *testing.T) {
+	a, server, robot := initTestRemoteRobot(t)
+	defer server.Close()
+
+	gobottest.Assert(t, len(robot.Start()), 0)
+	defer robot.Stop()
+
+	received := make(chan interface{}, 1)
+	robot.Device("Device1").(gobot.Eventer).On("TestEvent", func(data interface{}) {
+		received <- data
+	})
+	time.Sleep(10 * time.Millisecond)
+
+	a.gobot.Robot("Robot1").Device("Device1").(gobot.Eventer).Publish("TestEvent", "remote-data")
+	select {
+	case data := <-received:
+		gobottest.Assert(t, data, "remote-data")
+	case <-time.After(time.Second):
+		t.Fatal("Timed out waiting for the remote event")
+	}
+	gobottest.Assert(t, robot.Device("Device1").(gobot.Eventer).Event("TestEvent"), "TestEvent")
+
+	// unknown remote devices fail to start
+	remote := NewRemoteRobot(server.URL)
+	missing := &remoteDevice{name: "UnknownDevice1", robot: "Robot1", remote: remote,
+		Eventer: gobot.NewEventer(), ExtendedCommander: gobot.NewCommander()}
+	gobottest.Assert(t, missing.Start(), []error{ErrRemoteNotConnected})
+	connection := &remoteConnection{remote: remote}
+	gobottest.Assert(t, len(connection.Connect()), 0)
+	gobottest.Assert(t, missing.Start(), []error{errors.New("No Device found with the name UnknownDevice1")})
+	gobottest.Assert(t, len(connection.Finalize()), 0)
+}
+
+func TestRemoteConnectionPing(t *testing.T) {
+	_, server, _ := initTestRemoteRobot(t)
+	defer server.Close()
+
+	remote := NewRemoteRobot(server.URL)
+	first := &remoteConnection{remote: remote}
+	second := &remoteConnection{remote: remote}
+	gobottest.Assert(t, first.Ping(), ErrRemoteNotConnected)
+	gobottest.Assert(t, len(first.Connect()), 0)
+	gobottest.Assert(t, len(second.Connect()), 0)
+	gobottest.Assert(t, first.Ping(), nil)
+	gobottest.Assert(t, second.Ping(), nil)
+
+	// a dropped WebSocket fails the pings of every connection, even once
+	// one of them has reconnected
+	remote.mutex.Lock()
+	ws := remote.ws
+	remote.mutex.Unlock()
+	ws.Close()
+	for i := 0; i < 100 && first.Ping() == nil; i++ {
+		time.Sleep(time.Millisecond)
+	}
+	gobottest.Assert(t, first.Ping(), ErrRemoteNotConnected)
+	gobottest.Assert(t, len(first.Finalize()), 0)
+	gobottest.Assert(t, len(first.Connect()), 0)
+	gobottest.Assert(t, first.Ping(), nil)
+	gobottest.Assert(t, second.Ping(), ErrRemoteNotConnected)
+
+	// finalizing a connection of the dropped WebSocket keeps the new one open
+	gobottest.Assert(t, len(second.Finalize()), 0)
+	gobottest.Assert(t, first.Ping(), nil)
+	gobottest.Assert(t, len(first.Finalize()), 0)
+	gobottest.Assert(t, first.Ping(), ErrRemoteNotConnected)
+}
@@ -0,0 +1,158 @@
+package api
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"time"
+)
+
+// listen binds the API address and serves requests in a goroutine. It
+// returns an error if the address can not be bound or the TLS files can not
+// be loaded.
+func (a *API) listen() error {
+	server := &http.Server{
+		Addr:         net.JoinHostPort(a.Host, a.Port),
+		Handler:      a,
+		ReadTimeout:  a.ReadTimeout,
+		WriteTimeout: a.WriteTimeout,
+		IdleTimeout:  a.IdleTimeout,
+		ConnState:    a.trackConn,
+	}
+
+	secure := a.Cert != "" && a.Key != ""
+	if secure {
+		config, err := a.tlsConfig()
+		if err != nil {
+			return err
+		}
+		server.TLSConfig = config
+	} else if a.ClientCA != "" {
+		return errors.New("ClientCA requires Cert and Key")
+	}
+
+	listener, err := net.Listen("tcp", server.Addr)
+	if err != nil {
+		return err
+	}
+	if secure {
+		listener = tls.NewListener(listener, server.TLSConfig)
+	} else {
+		a.gobot.Logger.Warn("API using insecure connection. " +
+			"We recommend using an SSL certificate with Gobot.")
+	}
+
+	a.mutex.Lock()
+	a.server = server
+	a.listener = listener
+	a.closing = make(chan struct{})
+	a.conns = make(map[string]net.Conn)
+	a.mutex.Unlock()
+
+	a.gobot.Logger.Info("Initializing API", "host", a.Host, "port", a.Port, "addr", listener.Addr())
+	go func() {
+		if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			a.gobot.Logger.Error("API server stopped", "error", err)
+		}
+	}()
+	return nil
+}
+
+// tlsConfig returns the TLS configuration of the server, which requires and
+// verifies client certificates if ClientCA is set.
+func (a *API) tlsConfig() (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(a.Cert, a.Key)
+	if err != nil {
+		return nil, err
+	}
+	config := &tls.Config{Certificates: []tls.Certificate{cert}}
+
+	if a.ClientCA != "" {
+		pem, err := ioutil.ReadFile(a.ClientCA)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, errors.New("No certificates found in " + a.ClientCA)
+		}
+		config.ClientCAs = pool
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return config, nil
+}
+
+// Addr returns the address the API is listening on, or an empty string if it
+// has not been started. It is useful when Port is "0".
+func (a *API) Addr() string {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	if a.listener == nil {
+		return ""
+	}
+	return a.listener.Addr().String()
+}
+
+// Shutdown gracefully stops the API server. It stops accepting requests,
+// closes event streams and WebSockets, and waits for requests in progress
+// to complete until ctx is done.
+func (a *API) Shutdown(ctx context.Context) error {
+	a.mutex.Lock()
+	server := a.server
+	closing := a.closing
+	a.server = nil
+	a.listener = nil
+	a.mutex.Unlock()
+
+	if server == nil {
+		return nil
+	}
+	close(closing)
+	return server.Shutdown(ctx)
+}
+
+// trackConn records the open connections of the server by remote address,
+// which is the RemoteAddr of their requests, so that streams can change
+// their deadlines.
+func (a *API) trackConn(conn net.Conn, state http.ConnState) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	switch state {
+	case http.StateNew:
+		a.conns[conn.RemoteAddr().String()] = conn
+	case http.StateHijacked, http.StateClosed:
+		delete(a.conns, conn.RemoteAddr().String())
+	}
+}
+
+// streamConn returns the connection of req, after clearing the read deadline
+// set by ReadTimeout so that it can carry a stream. Returns nil if the
+// connection is not known, as when the API is not serving it.
+func (a *API) streamConn(req *http.Request) net.Conn {
+	a.mutex.Lock()
+	conn := a.conns[req.RemoteAddr]
+	a.mutex.Unlock()
+	if conn != nil {
+		conn.SetReadDeadline(time.Time{})
+	}
+	return conn
+}
+
+// extendWriteDeadline gives conn another WriteTimeout to write, and is called
+// before each write to a stream.
+func (a *API) extendWriteDeadline(conn net.Conn) {
+	if conn != nil && a.WriteTimeout > 0 {
+		conn.SetWriteDeadline(time.Now().Add(a.WriteTimeout))
+	}
+}
+
+// closingChan returns a channel which is closed when the API is shut down.
+func (a *API) closingChan() <-chan struct{} {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	return a.closing
+}
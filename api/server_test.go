@@ -0,0 +1,193 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hybridgroup/gobot"
+	"github.com/hybridgroup/gobot/gobottest"
+	"golang.org/x/net/websocket"
+)
+
+func newTestServer() *API {
+	g := gobot.NewGobot()
+	g.AddRobot(newTestRobot("Robot1"))
+	a := NewAPI(g)
+	a.Host = "127.0.0.1"
+	a.Port = "0"
+	return a
+}
+
+func TestAPIStartShutdown(t *testing.T) {
+	a := newTestServer()
+	a.ReadTimeout = time.Second
+	gobottest.Assert(t, a.Addr(), "")
+	gobottest.Assert(t, a.Start(), nil)
+
+	response, err := http.Get("http://" + a.Addr() + "/api/robots")
+	gobottest.Assert(t, err, nil)
+	gobottest.Assert(t, response.StatusCode, 200)
+	response.Body.Close()
+
+	// a second API can not bind the same address
+	b := newTestServer()
+	_, b.Port, _ = net.SplitHostPort(a.Addr())
+	gobottest.Refute(t, b.Start(), nil)
+
+	addr := a.Addr()
+	gobottest.Assert(t, a.Shutdown(context.Background()), nil)
+	gobottest.Assert(t, a.Addr(), "")
+	_, err = http.Get("http://" + addr + "/api/robots")
+	gobottest.Refute(t, err, nil)
+
+	// shutting down a stopped API has no effect
+	gobottest.Assert(t, a.Shutdown(context.Background()), nil)
+}
+
+func TestAPIShutdownClosesEventStreams(t *testing.T) {
+	a := newTestServer()
+	gobottest.Assert(t, a.Start(), nil)
+
+	go http.Get("http://" + a.Addr() + "/api/robots/Robot1/devices/Device1/events/TestEvent")
+	time.Sleep(50 * time.Millisecond)
+
+	// the stream never ends on its own, so Shutdown only returns before the
+	// timeout if the stream is closed
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	gobottest.Assert(t, a.Shutdown(ctx), nil)
+}
+
+func TestAPIStreamsOutliveTimeouts(t *testing.T) {
+	a := newTestServer()
+	a.ReadTimeout = 50 * time.Millisecond
+	a.WriteTimeout = 50 * time.Millisecond
+	gobottest.Assert(t, a.Start(), nil)
+	defer a.Shutdown(context.Background())
+	device := a.gobot.Robot("Robot1").Device("Device1").(gobot.Eventer)
+
+	// the event stream responds with its first event
+	lines := make(chan string, 1)
+	go func() {
+		response, err := http.Get("http://" + a.Addr() + "/api/robots/Robot1/devices/Device1/events/TestEvent")
+		if err != nil {
+			lines <- err.Error()
+			return
+		}
+		defer response.Body.Close()
+		line, _ := bufio.NewReader(response.Body).ReadString('\n')
+		lines <- line
+	}()
+
+	ws, err := websocket.Dial("ws://"+a.Addr()+"/api/socket", "", "http://"+a.Addr())
+	gobottest.Assert(t, err, nil)
+	defer ws.Close()
+	websocket.JSON.Send(ws, SocketMessage{ID: "1", Action: "subscribe", Robot: "Robot1", Device: "Device1", Event: "TestEvent"})
+	var reply SocketReply
+	gobottest.Assert(t, websocket.JSON.Receive(ws, &reply), nil)
+
+	time.Sleep(150 * time.Millisecond)
+	device.Publish("TestEvent", "late")
+
+	select {
+	case line := <-lines:
+		gobottest.Assert(t, line, "data: \"late\"\n")
+	case <-time.After(time.Second):
+		t.Fatal("the event stream was not written")
+	}
+
+	gobottest.Assert(t, websocket.JSON.Receive(ws, &reply), nil)
+	gobottest.Assert(t, reply.Data, "late")
+}
+
+func TestAPIClientCAWithoutTLS(t *testing.T) {
+	a := newTestServer()
+	a.ClientCA = "ca.pem"
+	gobottest.Assert(t, a.Start().Error(), "ClientCA requires Cert and Key")
+}
+
+// writeTestCert writes a certificate signed by parent, or self-signed if
+// parent is nil, and its key to dir. It returns the certificate and key.
+func writeTestCert(t *testing.T, dir string, name string, parent *x509.Certificate, parentKey *rsa.PrivateKey) (*x509.Certificate, *rsa.PrivateKey) {
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		parent, parentKey = template, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+
+	ioutil.WriteFile(filepath.Join(dir, name+".pem"),
+		pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600)
+	ioutil.WriteFile(filepath.Join(dir, name+".key"),
+		pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}), 0600)
+	return cert, key
+}
+
+func TestAPIMutualTLS(t *testing.T) {
+	dir, err := ioutil.TempDir("", "api")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	ca, caKey := writeTestCert(t, dir, "ca", nil, nil)
+	writeTestCert(t, dir, "server", ca, caKey)
+	writeTestCert(t, dir, "client", ca, caKey)
+
+	a := newTestServer()
+	a.Cert = filepath.Join(dir, "server.pem")
+	a.Key = filepath.Join(dir, "server.key")
+	a.ClientCA = filepath.Join(dir, "ca.pem")
+	gobottest.Assert(t, a.Start(), nil)
+	defer a.Shutdown(context.Background())
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca)
+	clientCert, _ := tls.LoadX509KeyPair(filepath.Join(dir, "client.pem"), filepath.Join(dir, "client.key"))
+
+	client := &http.Client{Transport: &http.Transport{
+		TLSClientConfig: &tls.Config{RootCAs: roots, Certificates: []tls.Certificate{clientCert}},
+	}}
+	response, err := client.Get("https://" + a.Addr() + "/api/robots")
+	gobottest.Assert(t, err, nil)
+	gobottest.Assert(t, response.StatusCode, 200)
+	response.Body.Close()
+
+	// clients without a certificate are rejected
+	client = &http.Client{Transport: &http.Transport{
+		TLSClientConfig: &tls.Config{RootCAs: roots},
+	}}
+	_, err = client.Get("https://" + a.Addr() + "/api/robots")
+	gobottest.Refute(t, err, nil)
+}
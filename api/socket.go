@@ -0,0 +1,291 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/hybridgroup/gobot"
+	"golang.org/x/net/websocket"
+)
+
+// SocketMessage is a message sent by a client over the WebSocket endpoint.
+//
+// The action is one of "subscribe", "unsubscribe" or "command". Subscriptions
+// are to the events of a robot, or of one of its devices if device is set,
+// whose names match event, which may be a pattern such as "*". Commands are
+// those of the MCP if robot is empty, of a robot, or of one of its devices.
+type SocketMessage struct {
+	ID      string                 `json:"id,omitempty"`
+	Action  string                 `json:"action"`
+	Robot   string                 `json:"robot,omitempty"`
+	Device  string                 `json:"device,omitempty"`
+	Event   string                 `json:"event,omitempty"`
+	Command string                 `json:"command,omitempty"`
+	Params  map[string]interface{} `json:"params,omitempty"`
+}
+
+// SocketReply is a message sent to a client over the WebSocket endpoint. Its
+// type is "subscribed", "unsubscribed", "result" or "error" in reply to a
+// SocketMessage with the same id, or "event" for a published event.
+type SocketReply struct {
+	ID     string      `json:"id,omitempty"`
+	Type   string      `json:"type"`
+	Robot  string      `json:"robot,omitempty"`
+	Device string      `json:"device,omitempty"`
+	Event  string      `json:"event,omitempty"`
+	Data   interface{} `json:"data,omitempty"`
+	Result interface{} `json:"result,omitempty"`
+	Error  string      `json:"error,omitempty"`
+}
+
+// socket is one client connection to the WebSocket endpoint.
+type socket struct {
+	api   *API
+	ws    *websocket.Conn
+	mutex sync.Mutex
+	subs  map[string]func()
+	roles []*Role
+}
+
+// socketHandler returns the WebSocket endpoint route handler, which
+// multiplexes the events and commands of every robot and device on one
+// connection.
+func (a *API) socketHandler() func(http.ResponseWriter, *http.Request) {
+	server := websocket.Server{Handshake: a.checkSocketOrigin, Handler: func(ws *websocket.Conn) {
+		// the WebSocket outlives ReadTimeout, and extends its write
+		// deadline for each message it sends
+		ws.SetReadDeadline(time.Time{})
+		s := &socket{api: a, ws: ws, subs: make(map[string]func())}
+		if a.Auth != nil {
+			s.roles, _ = a.Auth.Authenticate(ws.Request())
+		}
+		defer s.close()
+
+		done := make(chan struct{})
+		defer close(done)
+		go func() {
+			select {
+			case <-a.closingChan():
+				ws.Close()
+			case <-done:
+			}
+		}()
+
+		s.serve()
+	}}
+	return server.ServeHTTP
+}
+
+// checkSocketOrigin rejects WebSocket handshakes from pages of other origins
+// than the API's own or those allowed by SocketOrigins. Browsers send cached
+// credentials with cross-origin WebSocket handshakes, which CORS does not
+// apply to.
+func (a *API) checkSocketOrigin(config *websocket.Config, req *http.Request) error {
+	origin := req.Header.Get("Origin")
+	if origin == "" {
+		return nil
+	}
+	if u, err := url.Parse(origin); err == nil && u.Host == req.Host {
+		return nil
+	}
+	c := &CORS{AllowOrigins: a.SocketOrigins}
+	c.generatePatterns()
+	if c.isOriginAllowed(origin) {
+		return nil
+	}
+	return fmt.Errorf("Origin %v is not allowed", origin)
+}
+
+// serve handles messages from the client until the connection is closed.
+func (s *socket) serve() {
+	for {
+		var msg SocketMessage
+		if err := websocket.JSON.Receive(s.ws, &msg); err != nil {
+			switch err.(type) {
+			case *json.SyntaxError, *json.UnmarshalTypeError:
+				s.send(SocketReply{Type: "error", Error: err.Error()})
+				continue
+			}
+			return
+		}
+
+		switch msg.Action {
+		case "subscribe":
+			s.subscribe(msg)
+		case "unsubscribe":
+			s.unsubscribe(msg)
+		case "command":
+			s.command(msg)
+		default:
+			s.send(SocketReply{ID: msg.ID, Type: "error", Error: "Unknown action " + msg.Action})
+		}
+	}
+}
+
+// send writes reply to the client. It is safe to call from event handlers.
+func (s *socket) send(reply SocketReply) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.api.extendWriteDeadline(s.ws)
+	websocket.JSON.Send(s.ws, reply)
+}
+
+// allowed returns true if the client may access res.
+func (s *socket) allowed(res resource) bool {
+	return s.api.Auth == nil || allowed(s.roles, res)
+}
+
+// close cancels every subscription of the connection.
+func (s *socket) close() {
+	s.mutex.Lock()
+	subs := s.subs
+	s.subs = make(map[string]func())
+	s.mutex.Unlock()
+
+	for _, cancel := range subs {
+		cancel()
+	}
+}
+
+// subscribe forwards the events matching msg to the client. Subscribing
+// twice to the same events has no further effect.
+func (s *socket) subscribe(msg SocketMessage) {
+	eventer, err := s.api.eventerFor(msg.Robot, msg.Device)
+	if err == nil && !s.allowed(resource{access: ReadAccess, robot: msg.Robot, device: msg.Device}) {
+		err = errors.New("Forbidden")
+	}
+	if err == nil && msg.Event == "" {
+		err = errors.New("Subscribe requires an event")
+	}
+	if err == nil {
+		_, err = path.Match(msg.Event, "")
+	}
+	if err != nil {
+		s.send(SocketReply{ID: msg.ID, Type: "error", Error: err.Error()})
+		return
+	}
+
+	key := msg.Robot + "/" + msg.Device + "/" + msg.Event
+	s.mutex.Lock()
+	_, exists := s.subs[key]
+	var events <-chan *gobot.Event
+	if !exists {
+		ch := eventer.Subscribe()
+		s.subs[key] = func() { eventer.Unsubscribe(ch) }
+		events = ch
+	}
+	s.mutex.Unlock()
+
+	s.send(SocketReply{ID: msg.ID, Type: "subscribed", Robot: msg.Robot, Device: msg.Device, Event: msg.Event})
+	if events != nil {
+		go s.forward(msg, events)
+	}
+}
+
+// forward sends the events which match the subscription until events is
+// closed by unsubscribing.
+func (s *socket) forward(msg SocketMessage, events <-chan *gobot.Event) {
+	for evt := range events {
+		if matched, _ := path.Match(msg.Event, evt.Name); !matched {
+			continue
+		}
+		data := evt.Data
+		if err, ok := data.(error); ok {
+			data = err.Error()
+		}
+		s.send(SocketReply{Type: "event", Robot: msg.Robot, Device: msg.Device, Event: evt.Name, Data: data})
+	}
+}
+
+// unsubscribe stops forwarding the events of an earlier subscription.
+func (s *socket) unsubscribe(msg SocketMessage) {
+	key := msg.Robot + "/" + msg.Device + "/" + msg.Event
+	s.mutex.Lock()
+	cancel, ok := s.subs[key]
+	delete(s.subs, key)
+	s.mutex.Unlock()
+
+	if !ok {
+		s.send(SocketReply{ID: msg.ID, Type: "error", Error: "No subscription to " + key})
+		return
+	}
+	cancel()
+	s.send(SocketReply{ID: msg.ID, Type: "unsubscribed", Robot: msg.Robot, Device: msg.Device, Event: msg.Event})
+}
+
+// command executes the command named in msg and replies with its result.
+func (s *socket) command(msg SocketMessage) {
+	commander, err := s.api.commanderFor(msg.Robot, msg.Device)
+	if err == nil && !s.allowed(resource{access: ExecuteAccess, robot: msg.Robot, device: msg.Device, command: msg.Command}) {
+		err = errors.New("Forbidden")
+	}
+	if err != nil {
+		s.send(SocketReply{ID: msg.ID, Type: "error", Error: err.Error()})
+		return
+	}
+	if msg.Params == nil {
+		msg.Params = make(map[string]interface{})
+	}
+
+	id := newRequestID()
+	if msg.ID != "" {
+		id = requestID(s.ws.Request()) + "/" + msg.ID
+	}
+	if result, err := s.api.execute(s.ws.Request(), id, commander, msg.Robot, msg.Device, msg.Command, msg.Params); err != nil {
+		s.send(SocketReply{ID: msg.ID, Type: "error", Error: err.Error()})
+	} else {
+		if err, ok := result.(error); ok {
+			result = err.Error()
+		}
+		s.send(SocketReply{ID: msg.ID, Type: "result", Result: result})
+	}
+}
+
+// eventerFor returns the Eventer of a robot, or of one of its devices if
+// device is not empty.
+func (a *API) eventerFor(robot string, device string) (gobot.Eventer, error) {
+	r := a.gobot.Robot(robot)
+	if r == nil {
+		return nil, errors.New("No Robot found with the name " + robot)
+	}
+	if device == "" {
+		return r, nil
+	}
+	d := r.Device(device)
+	if d == nil {
+		return nil, errors.New("No Device found with the name " + device)
+	}
+	if eventer, ok := d.(gobot.Eventer); ok {
+		return eventer, nil
+	}
+	return nil, errors.New("Device " + device + " does not publish events")
+}
+
+// commanderFor returns the Commander of the MCP if robot is empty, of a
+// robot, or of one of its devices if device is not empty.
+func (a *API) commanderFor(robot string, device string) (gobot.Commander, error) {
+	if robot == "" {
+		return a.gobot, nil
+	}
+	r := a.gobot.Robot(robot)
+	if r == nil {
+		return nil, errors.New("No Robot found with the name " + robot)
+	}
+	if device == "" {
+		return r, nil
+	}
+	d := r.Device(device)
+	if d == nil {
+		return nil, errors.New("No Device found with the name " + device)
+	}
+	if commander, ok := d.(gobot.Commander); ok {
+		return commander, nil
+	}
+	return nil, errors.New("Device " + device + " does not have commands")
+}
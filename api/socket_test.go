@@ -0,0 +1,127 @@
+package api
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hybridgroup/gobot"
+	"github.com/hybridgroup/gobot/gobottest"
+	"golang.org/x/net/websocket"
+)
+
+func dialTestSocket(t *testing.T, server *httptest.Server) *websocket.Conn {
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/api/socket"
+	ws, err := websocket.Dial(url, "", server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ws
+}
+
+func receiveTestReply(t *testing.T, ws *websocket.Conn) SocketReply {
+	var reply SocketReply
+	ws.SetReadDeadline(time.Now().Add(time.Second))
+	if err := websocket.JSON.Receive(ws, &reply); err != nil {
+		t.Fatal(err)
+	}
+	return reply
+}
+
+func TestSocketOrigin(t *testing.T) {
+	a := initTestAPI()
+	server := httptest.NewServer(a)
+	defer server.Close()
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/api/socket"
+
+	// same origin
+	ws, err := websocket.Dial(url, "", server.URL)
+	gobottest.Assert(t, err, nil)
+	ws.Close()
+
+	_, err = websocket.Dial(url, "", "http://evil.example.com")
+	gobottest.Refute(t, err, nil)
+
+	a.SocketOrigins = []string{"http://*.example.com"}
+	ws, err = websocket.Dial(url, "", "http://robots.example.com")
+	gobottest.Assert(t, err, nil)
+	ws.Close()
+	_, err = websocket.Dial(url, "", "http://example.org")
+	gobottest.Refute(t, err, nil)
+}
+
+func TestSocketEvents(t *testing.T) {
+	a := initTestAPI()
+	server := httptest.NewServer(a)
+	defer server.Close()
+
+	ws := dialTestSocket(t, server)
+	defer ws.Close()
+
+	websocket.JSON.Send(ws, SocketMessage{ID: "1", Action: "subscribe", Robot: "Robot1", Device: "Device1", Event: "Test*"})
+	reply := receiveTestReply(t, ws)
+	gobottest.Assert(t, reply.ID, "1")
+	gobottest.Assert(t, reply.Type, "subscribed")
+
+	websocket.JSON.Send(ws, SocketMessage{ID: "2", Action: "subscribe", Robot: "Robot2", Device: "Device2", Event: "TestEvent"})
+	gobottest.Assert(t, receiveTestReply(t, ws).Type, "subscribed")
+
+	device := a.gobot.Robot("Robot1").Device("Device1").(gobot.Eventer)
+	device.Publish("Other", "ignored")
+	device.Publish("TestEvent", "event-data")
+	reply = receiveTestReply(t, ws)
+	gobottest.Assert(t, reply.Type, "event")
+	gobottest.Assert(t, reply.Robot, "Robot1")
+	gobottest.Assert(t, reply.Device, "Device1")
+	gobottest.Assert(t, reply.Event, "TestEvent")
+	gobottest.Assert(t, reply.Data, "event-data")
+
+	a.gobot.Robot("Robot2").Device("Device2").(gobot.Eventer).Publish("TestEvent", 2)
+	reply = receiveTestReply(t, ws)
+	gobottest.Assert(t, reply.Robot, "Robot2")
+	gobottest.Assert(t, reply.Data, 2.0)
+
+	websocket.JSON.Send(ws, SocketMessage{ID: "3", Action: "unsubscribe", Robot: "Robot1", Device: "Device1", Event: "Test*"})
+	gobottest.Assert(t, receiveTestReply(t, ws).Type, "unsubscribed")
+
+	device.Publish("TestEvent", "event-data")
+	websocket.JSON.Send(ws, SocketMessage{ID: "4", Action: "unsubscribe", Robot: "Robot1", Device: "Device1", Event: "Test*"})
+	reply = receiveTestReply(t, ws)
+	gobottest.Assert(t, reply.ID, "4")
+	gobottest.Assert(t, reply.Type, "error")
+
+	websocket.JSON.Send(ws, SocketMessage{ID: "5", Action: "subscribe", Robot: "Robot1", Device: "UnknownDevice1", Event: "TestEvent"})
+	reply = receiveTestReply(t, ws)
+	gobottest.Assert(t, reply.Type, "error")
+	gobottest.Assert(t, reply.Error, "No Device found with the name UnknownDevice1")
+}
+
+func TestSocketCommands(t *testing.T) {
+	a := initTestAPI()
+	server := httptest.NewServer(a)
+	defer server.Close()
+
+	ws := dialTestSocket(t, server)
+	defer ws.Close()
+
+	websocket.JSON.Send(ws, SocketMessage{ID: "1", Action: "command", Command: "TestFunction", Params: map[string]interface{}{"message": "Beep Boop"}})
+	reply := receiveTestReply(t, ws)
+	gobottest.Assert(t, reply.ID, "1")
+	gobottest.Assert(t, reply.Type, "result")
+	gobottest.Assert(t, reply.Result, "hey Beep Boop")
+
+	websocket.JSON.Send(ws, SocketMessage{ID: "2", Action: "command", Robot: "Robot1", Device: "Device1", Command: "TestDriverCommand", Params: map[string]interface{}{"name": "human"}})
+	gobottest.Assert(t, receiveTestReply(t, ws).Result, "hello human")
+
+	websocket.JSON.Send(ws, SocketMessage{ID: "3", Action: "command", Robot: "UnknownRobot1", Command: "robotTestFunction"})
+	reply = receiveTestReply(t, ws)
+	gobottest.Assert(t, reply.Type, "error")
+	gobottest.Assert(t, reply.Error, "No Robot found with the name UnknownRobot1")
+
+	websocket.JSON.Send(ws, SocketMessage{ID: "4", Action: "dance"})
+	gobottest.Assert(t, receiveTestReply(t, ws).Error, "Unknown action dance")
+
+	websocket.Message.Send(ws, "{not json")
+	gobottest.Assert(t, receiveTestReply(t, ws).Type, "error")
+}
@@ -0,0 +1,413 @@
+package api
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Access is a kind of access to the resources of the API.
+type Access int
+
+const (
+	// ReadAccess allows reading robots, devices, connections, events and metrics
+	ReadAccess Access = iota
+	// ExecuteAccess allows executing MCP, robot and device commands
+	ExecuteAccess
+	// ManageAccess allows attaching, detaching, starting and halting devices
+	// and connections
+	ManageAccess
+)
+
+// String returns the name of the access
+func (a Access) String() string {
+	switch a {
+	case ReadAccess:
+		return "read"
+	case ExecuteAccess:
+		return "execute"
+	case ManageAccess:
+		return "manage"
+	}
+	return "unknown"
+}
+
+// Rule grants one kind of access to the resources it matches. Robot, Device,
+// Connection and Command are path.Match patterns, and an empty pattern
+// matches any resource. A rule naming a robot does not match requests for
+// every robot, such as listing robots or executing MCP commands.
+type Rule struct {
+	Access     Access
+	Robot      string
+	Device     string
+	Connection string
+	Command    string
+}
+
+// Role is a named set of rules.
+type Role struct {
+	Name  string
+	Rules []Rule
+}
+
+var (
+	// ObserverRole may only read.
+	ObserverRole = &Role{Name: "observer", Rules: []Rule{{Access: ReadAccess}}}
+	// OperatorRole may read and execute any command.
+	OperatorRole = &Role{Name: "operator", Rules: []Rule{{Access: ReadAccess}, {Access: ExecuteAccess}}}
+	// AdminRole has all access.
+	AdminRole = &Role{Name: "admin", Rules: []Rule{{Access: ReadAccess}, {Access: ExecuteAccess}, {Access: ManageAccess}}}
+)
+
+var (
+	// ErrNoToken is returned when a request has no API key or bearer token
+	ErrNoToken = errors.New("No token")
+	// ErrInvalidToken is returned when a token is unknown, malformed, has a bad
+	// signature or has expired
+	ErrInvalidToken = errors.New("Invalid token")
+)
+
+// resource is the target of a request.
+type resource struct {
+	access     Access
+	robot      string
+	device     string
+	connection string
+	command    string
+}
+
+func (r Rule) allows(res resource) bool {
+	return r.Access == res.access &&
+		matchPattern(r.Robot, res.robot) &&
+		matchPattern(r.Device, res.device) &&
+		matchPattern(r.Connection, res.connection) &&
+		matchPattern(r.Command, res.command)
+}
+
+func matchPattern(pattern string, name string) bool {
+	if pattern == "" {
+		return true
+	}
+	matched, _ := path.Match(pattern, name)
+	return matched
+}
+
+// Allows returns true if one of the rules of the role grants access to the
+// resource.
+func (r *Role) Allows(access Access, robot, device, connection, command string) bool {
+	return r.allows(resource{access, robot, device, connection, command})
+}
+
+func (r *Role) allows(res resource) bool {
+	for _, rule := range r.Rules {
+		if rule.allows(res) {
+			return true
+		}
+	}
+	return false
+}
+
+// TokenAuth authenticates API requests with an API key or a bearer token,
+// and authorizes them according to the roles of the client.
+//
+// API keys are sent in the X-API-Key header or as a bearer token. JSON Web
+// Tokens are sent as a bearer token, signed with HS256 or RS256 by a key
+// given to the TokenAuth, and carry the role names of the client in a
+// "role" or "roles" claim.
+type TokenAuth struct {
+	mutex   sync.RWMutex
+	roles   map[string]*Role
+	keys    map[string][]string
+	hmacKey []byte
+	rsaKey  *rsa.PublicKey
+}
+
+// NewTokenAuth returns a new TokenAuth with the observer, operator and admin
+// roles.
+func NewTokenAuth() *TokenAuth {
+	t := &TokenAuth{
+		roles: make(map[string]*Role),
+		keys:  make(map[string][]string),
+	}
+	t.AddRole(ObserverRole)
+	t.AddRole(OperatorRole)
+	t.AddRole(AdminRole)
+	return t
+}
+
+// AddRole adds a role, replacing any role with the same name.
+func (t *TokenAuth) AddRole(role *Role) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.roles[role.Name] = role
+}
+
+// AddAPIKey adds an API key granting the named roles.
+func (t *TokenAuth) AddAPIKey(key string, roles ...string) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.keys[key] = roles
+}
+
+// RemoveAPIKey revokes an API key.
+func (t *TokenAuth) RemoveAPIKey(key string) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	delete(t.keys, key)
+}
+
+// UseHMACKey verifies JSON Web Tokens signed with HS256 and key.
+func (t *TokenAuth) UseHMACKey(key []byte) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.hmacKey = key
+}
+
+// UseRSAKey verifies JSON Web Tokens signed with RS256 and the private key
+// of key.
+func (t *TokenAuth) UseRSAKey(key *rsa.PublicKey) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.rsaKey = key
+}
+
+// IssueToken returns a JSON Web Token signed with the HMAC key, for subject
+// with roles, which expires after ttl or never if ttl is 0.
+func (t *TokenAuth) IssueToken(subject string, roles []string, ttl time.Duration) (string, error) {
+	t.mutex.RLock()
+	key := t.hmacKey
+	t.mutex.RUnlock()
+	if key == nil {
+		return "", errors.New("No HMAC key to sign tokens with")
+	}
+
+	claims := map[string]interface{}{"sub": subject, "roles": roles}
+	if ttl > 0 {
+		claims["exp"] = time.Now().Add(ttl).Unix()
+	}
+	header, _ := json.Marshal(map[string]string{"alg": "HS256", "typ": "JWT"})
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." +
+		base64.RawURLEncoding.EncodeToString(payload)
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(signed))
+	return signed + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil)), nil
+}
+
+// Authenticate returns the roles of the client of req.
+func (t *TokenAuth) Authenticate(req *http.Request) ([]*Role, error) {
+	_, roles, err := t.authenticate(req)
+	return roles, err
+}
+
+// Subject returns the identity of the client of req: the subject of its JSON
+// Web Token, or a fingerprint of its API key.
+func (t *TokenAuth) Subject(req *http.Request) (string, error) {
+	subject, _, err := t.authenticate(req)
+	return subject, err
+}
+
+func (t *TokenAuth) authenticate(req *http.Request) (string, []*Role, error) {
+	token := req.Header.Get("X-API-Key")
+	if auth := req.Header.Get("Authorization"); token == "" && strings.HasPrefix(auth, "Bearer ") {
+		token = strings.TrimSpace(strings.TrimPrefix(auth, "Bearer "))
+	}
+	if token == "" {
+		return "", nil, ErrNoToken
+	}
+
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+
+	subject := ""
+	names, ok := t.keys[token]
+	if ok {
+		sum := sha256.Sum256([]byte(token))
+		subject = "key:" + hex.EncodeToString(sum[:4])
+	} else {
+		var err error
+		if subject, names, err = t.verifyJWT(token); err != nil {
+			return "", nil, err
+		}
+	}
+
+	roles := []*Role{}
+	for _, name := range names {
+		if role, ok := t.roles[name]; ok {
+			roles = append(roles, role)
+		}
+	}
+	return subject, roles, nil
+}
+
+// verifyJWT returns the subject and role names of a JSON Web Token with a
+// valid signature which has not expired.
+func (t *TokenAuth) verifyJWT(token string) (string, []string, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return "", nil, ErrInvalidToken
+	}
+	header, err := decodeSegment(parts[0])
+	if err != nil {
+		return "", nil, ErrInvalidToken
+	}
+	payload, err := decodeSegment(parts[1])
+	if err != nil {
+		return "", nil, ErrInvalidToken
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return "", nil, ErrInvalidToken
+	}
+
+	var h struct {
+		Alg string `json:"alg"`
+	}
+	if err := json.Unmarshal(header, &h); err != nil {
+		return "", nil, ErrInvalidToken
+	}
+	signed := []byte(parts[0] + "." + parts[1])
+	switch {
+	case h.Alg == "HS256" && t.hmacKey != nil:
+		mac := hmac.New(sha256.New, t.hmacKey)
+		mac.Write(signed)
+		if !hmac.Equal(signature, mac.Sum(nil)) {
+			return "", nil, ErrInvalidToken
+		}
+	case h.Alg == "RS256" && t.rsaKey != nil:
+		sum := sha256.Sum256(signed)
+		if rsa.VerifyPKCS1v15(t.rsaKey, crypto.SHA256, sum[:], signature) != nil {
+			return "", nil, ErrInvalidToken
+		}
+	default:
+		return "", nil, ErrInvalidToken
+	}
+
+	var claims struct {
+		Exp   *float64    `json:"exp"`
+		Nbf   *float64    `json:"nbf"`
+		Sub   string      `json:"sub"`
+		Role  string      `json:"role"`
+		Roles interface{} `json:"roles"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return "", nil, ErrInvalidToken
+	}
+	now := float64(time.Now().Unix())
+	if (claims.Exp != nil && now >= *claims.Exp) || (claims.Nbf != nil && now < *claims.Nbf) {
+		return "", nil, ErrInvalidToken
+	}
+
+	names := []string{}
+	if claims.Role != "" {
+		names = append(names, claims.Role)
+	}
+	switch roles := claims.Roles.(type) {
+	case string:
+		names = append(names, roles)
+	case []interface{}:
+		for _, role := range roles {
+			if name, ok := role.(string); ok {
+				names = append(names, name)
+			}
+		}
+	}
+	return claims.Sub, names, nil
+}
+
+func decodeSegment(s string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+}
+
+// authorize returns the HTTP status for req: http.StatusOK if the client
+// may access the resource it targets, http.StatusUnauthorized if it could
+// not be authenticated and http.StatusForbidden otherwise.
+func (t *TokenAuth) authorize(req *http.Request) int {
+	res, ok := requestResource(req)
+	if !ok {
+		return http.StatusOK
+	}
+	roles, err := t.Authenticate(req)
+	if err != nil {
+		return http.StatusUnauthorized
+	}
+	if !allowed(roles, res) {
+		return http.StatusForbidden
+	}
+	return http.StatusOK
+}
+
+func allowed(roles []*Role, res resource) bool {
+	for _, role := range roles {
+		if role.allows(res) {
+			return true
+		}
+	}
+	return false
+}
+
+// requestResource returns the resource targeted by req, or false if req
+// needs no authorization, such as a robeaux asset or a CORS preflight.
+func requestResource(req *http.Request) (resource, bool) {
+	if req.Method == "OPTIONS" {
+		return resource{}, false
+	}
+	if req.URL.Path == "/metrics" {
+		return resource{access: ReadAccess}, true
+	}
+	if !strings.HasPrefix(req.URL.Path, "/api/") {
+		return resource{}, false
+	}
+
+	segments := strings.Split(strings.Trim(strings.TrimPrefix(req.URL.Path, "/api/"), "/"), "/")
+	res := resource{access: ReadAccess}
+	if len(segments) == 2 && segments[0] == "commands" {
+		res.access = ExecuteAccess
+		res.command = segments[1]
+		return res, true
+	}
+	if segments[0] != "robots" || len(segments) < 2 {
+		return res, true
+	}
+
+	res.robot = segments[1]
+	rest := segments[2:]
+	if len(rest) == 2 && rest[0] == "commands" {
+		res.access = ExecuteAccess
+		res.command = rest[1]
+		return res, true
+	}
+	if len(rest) == 0 || (rest[0] != "devices" && rest[0] != "connections") {
+		return res, true
+	}
+
+	if req.Method == "POST" || req.Method == "DELETE" {
+		res.access = ManageAccess
+	}
+	if len(rest) < 2 {
+		return res, true
+	}
+	if rest[0] == "devices" {
+		res.device = rest[1]
+		if len(rest) == 4 && rest[2] == "commands" {
+			res.access = ExecuteAccess
+			res.command = rest[3]
+		}
+	} else {
+		res.connection = rest[1]
+	}
+	return res, true
+}
@@ -0,0 +1,172 @@
+package api
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hybridgroup/gobot/gobottest"
+	"golang.org/x/net/websocket"
+)
+
+func testTokenRequest(a *API, method string, url string, token string) int {
+	request, _ := http.NewRequest(method, url, strings.NewReader(`{"name": "human", "message": "Beep Boop"}`))
+	if token != "" {
+		request.Header.Set("Authorization", "Bearer "+token)
+	}
+	response := httptest.NewRecorder()
+	a.ServeHTTP(response, request)
+	return response.Code
+}
+
+func TestTokenAuthAPIKeys(t *testing.T) {
+	a := initTestAPI()
+	a.Auth = NewTokenAuth()
+	a.Auth.AddAPIKey("watch", "observer")
+	a.Auth.AddAPIKey("fly", "operator")
+
+	gobottest.Assert(t, testTokenRequest(a, "GET", "/api/robots", ""), 401)
+	gobottest.Assert(t, testTokenRequest(a, "GET", "/api/robots", "unknown"), 401)
+	gobottest.Assert(t, testTokenRequest(a, "GET", "/api/robots", "watch"), 200)
+	gobottest.Assert(t, testTokenRequest(a, "GET", "/metrics", "watch"), 200)
+	gobottest.Assert(t, testTokenRequest(a, "GET", "/api/robots/Robot1/devices/Device1", "watch"), 200)
+	gobottest.Assert(t, testTokenRequest(a, "POST", "/api/commands/TestFunction", "watch"), 403)
+	gobottest.Assert(t, testTokenRequest(a, "POST", "/api/robots/Robot1/devices/Device1/commands/TestDriverCommand", "watch"), 403)
+	gobottest.Assert(t, testTokenRequest(a, "POST", "/api/robots/Robot1/devices/Device1/commands/TestDriverCommand", "fly"), 200)
+	gobottest.Assert(t, testTokenRequest(a, "POST", "/api/robots/Robot1/devices/Device1/halt", "fly"), 403)
+
+	// robeaux assets and CORS preflight requests need no token
+	gobottest.Assert(t, testTokenRequest(a, "GET", "/index.html", ""), 200)
+	gobottest.Assert(t, testTokenRequest(a, "OPTIONS", "/api/robots", ""), 405)
+
+	request, _ := http.NewRequest("GET", "/api/robots", nil)
+	request.Header.Set("X-API-Key", "watch")
+	response := httptest.NewRecorder()
+	a.ServeHTTP(response, request)
+	gobottest.Assert(t, response.Code, 200)
+
+	a.Auth.RemoveAPIKey("watch")
+	gobottest.Assert(t, testTokenRequest(a, "GET", "/api/robots", "watch"), 401)
+}
+
+func TestTokenAuthRules(t *testing.T) {
+	a := initTestAPI()
+	a.Auth = NewTokenAuth()
+	a.Auth.AddRole(&Role{Name: "pilot", Rules: []Rule{
+		{Access: ReadAccess, Robot: "Robot1"},
+		{Access: ExecuteAccess, Robot: "Robot1", Device: "Device1", Command: "TestDriver*"},
+	}})
+	a.Auth.AddAPIKey("pilot", "pilot")
+
+	gobottest.Assert(t, testTokenRequest(a, "GET", "/api/robots/Robot1", "pilot"), 200)
+	gobottest.Assert(t, testTokenRequest(a, "GET", "/api/robots/Robot2", "pilot"), 403)
+	gobottest.Assert(t, testTokenRequest(a, "GET", "/api/robots", "pilot"), 403)
+	gobottest.Assert(t, testTokenRequest(a, "GET", "/api/robots/Robot1/devices/Device1/commands/TestDriverCommand", "pilot"), 200)
+	gobottest.Assert(t, testTokenRequest(a, "GET", "/api/robots/Robot1/devices/Device1/commands/DriverCommand", "pilot"), 403)
+	gobottest.Assert(t, testTokenRequest(a, "GET", "/api/robots/Robot1/commands/robotTestFunction", "pilot"), 403)
+	gobottest.Assert(t, testTokenRequest(a, "GET", "/api/commands/TestFunction", "pilot"), 403)
+
+	gobottest.Assert(t, AdminRole.Allows(ManageAccess, "Robot1", "", "Connection1", ""), true)
+	gobottest.Assert(t, OperatorRole.Allows(ManageAccess, "Robot1", "", "Connection1", ""), false)
+}
+
+func TestTokenAuthHMAC(t *testing.T) {
+	a := initTestAPI()
+	a.Auth = NewTokenAuth()
+
+	_, err := a.Auth.IssueToken("eve", []string{"observer"}, 0)
+	gobottest.Refute(t, err, nil)
+
+	a.Auth.UseHMACKey([]byte("secret"))
+	token, err := a.Auth.IssueToken("eve", []string{"observer"}, time.Minute)
+	gobottest.Assert(t, err, nil)
+	gobottest.Assert(t, testTokenRequest(a, "GET", "/api/robots", token), 200)
+	gobottest.Assert(t, testTokenRequest(a, "GET", "/api/commands/TestFunction", token), 403)
+
+	parts := strings.Split(token, ".")
+	tampered := parts[0] + "." + base64.RawURLEncoding.EncodeToString([]byte(`{"roles":["admin"]}`)) + "." + parts[2]
+	gobottest.Assert(t, testTokenRequest(a, "GET", "/api/robots", tampered), 401)
+
+	unsigned := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none"}`)) + "." + parts[1] + "."
+	gobottest.Assert(t, testTokenRequest(a, "GET", "/api/robots", unsigned), 401)
+
+	a.Auth.UseHMACKey([]byte("other"))
+	gobottest.Assert(t, testTokenRequest(a, "GET", "/api/robots", token), 401)
+}
+
+func TestTokenAuthExpiry(t *testing.T) {
+	auth := NewTokenAuth()
+	auth.UseHMACKey([]byte("secret"))
+
+	token, _ := auth.IssueToken("eve", []string{"observer"}, time.Minute)
+	request, _ := http.NewRequest("GET", "/api/robots", nil)
+	request.Header.Set("Authorization", "Bearer "+token)
+	roles, err := auth.Authenticate(request)
+	gobottest.Assert(t, err, nil)
+	gobottest.Assert(t, roles, []*Role{ObserverRole})
+
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+	payload := base64.RawURLEncoding.EncodeToString([]byte(`{"role":"observer","exp":1}`))
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write([]byte(header + "." + payload))
+	request.Header.Set("Authorization", "Bearer "+header+"."+payload+"."+base64.RawURLEncoding.EncodeToString(mac.Sum(nil)))
+	_, err = auth.Authenticate(request)
+	gobottest.Assert(t, err, ErrInvalidToken)
+
+	request.Header.Del("Authorization")
+	_, err = auth.Authenticate(request)
+	gobottest.Assert(t, err, ErrNoToken)
+}
+
+func TestTokenAuthRSA(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	gobottest.Assert(t, err, nil)
+
+	auth := NewTokenAuth()
+	auth.UseRSAKey(&key.PublicKey)
+
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"RS256","typ":"JWT"}`))
+	payload := base64.RawURLEncoding.EncodeToString([]byte(`{"roles":"admin"}`))
+	sum := sha256.Sum256([]byte(header + "." + payload))
+	signature, _ := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, sum[:])
+
+	request, _ := http.NewRequest("GET", "/api/robots", nil)
+	request.Header.Set("Authorization", "Bearer "+header+"."+payload+"."+base64.RawURLEncoding.EncodeToString(signature))
+	roles, err := auth.Authenticate(request)
+	gobottest.Assert(t, err, nil)
+	gobottest.Assert(t, roles, []*Role{AdminRole})
+}
+
+func TestTokenAuthSocket(t *testing.T) {
+	a := initTestAPI()
+	a.Auth = NewTokenAuth()
+	a.Auth.AddAPIKey("watch", "observer")
+	server := httptest.NewServer(a)
+	defer server.Close()
+
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/api/socket"
+	_, err := websocket.Dial(url, "", server.URL)
+	gobottest.Refute(t, err, nil)
+
+	config, _ := websocket.NewConfig(url, server.URL)
+	config.Header.Set("X-API-Key", "watch")
+	ws, err := websocket.DialConfig(config)
+	gobottest.Assert(t, err, nil)
+	defer ws.Close()
+
+	websocket.JSON.Send(ws, SocketMessage{ID: "1", Action: "subscribe", Robot: "Robot1", Device: "Device1", Event: "TestEvent"})
+	gobottest.Assert(t, receiveTestReply(t, ws).Type, "subscribed")
+
+	websocket.JSON.Send(ws, SocketMessage{ID: "2", Action: "command", Command: "TestFunction", Params: map[string]interface{}{"message": "Beep Boop"}})
+	reply := receiveTestReply(t, ws)
+	gobottest.Assert(t, reply.Type, "error")
+	gobottest.Assert(t, reply.Error, "Forbidden")
+}
@@ -1,7 +1,187 @@
 package gobot
 
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+)
+
+var (
+	// ErrUnknownCommand is the error resulting if the specified command does not exist
+	ErrUnknownCommand = errors.New("Unknown Command")
+)
+
+const (
+	maxInt = int(^uint(0) >> 1)
+	minInt = -maxInt - 1
+)
+
+// ParamType is the type of a command parameter.
+type ParamType string
+
+const (
+	// ParamNumber parameters are coerced to float64
+	ParamNumber ParamType = "number"
+	// ParamInteger parameters are coerced to int, and must fit in one
+	ParamInteger ParamType = "integer"
+	// ParamString parameters are coerced to string
+	ParamString ParamType = "string"
+	// ParamBool parameters are coerced to bool
+	ParamBool ParamType = "boolean"
+)
+
+// Range is the inclusive range of values allowed for a numeric parameter.
+type Range struct {
+	Min float64 `json:"min"`
+	Max float64 `json:"max"`
+}
+
+// Param describes a single command parameter.
+type Param struct {
+	Name        string      `json:"name"`
+	Type        ParamType   `json:"type"`
+	Description string      `json:"description,omitempty"`
+	Required    bool        `json:"required"`
+	Default     interface{} `json:"default,omitempty"`
+	Range       *Range      `json:"range,omitempty"`
+}
+
+// CommandSchema describes a command and the parameters it expects.
+type CommandSchema struct {
+	Description string  `json:"description,omitempty"`
+	Params      []Param `json:"params"`
+}
+
+// Coerce validates params against the schema. It returns a new map with
+// defaults filled in and every declared parameter converted to its type.
+// Parameters not declared in the schema are passed through unchanged.
+func (s *CommandSchema) Coerce(params map[string]interface{}) (map[string]interface{}, error) {
+	coerced := make(map[string]interface{}, len(params))
+	for k, v := range params {
+		coerced[k] = v
+	}
+
+	for _, p := range s.Params {
+		v, ok := coerced[p.Name]
+		if !ok || v == nil {
+			if p.Default != nil {
+				v = p.Default
+			} else if p.Required {
+				return nil, fmt.Errorf("Parameter %q is required", p.Name)
+			} else {
+				continue
+			}
+		}
+
+		value, err := p.coerce(v)
+		if err != nil {
+			return nil, err
+		}
+		coerced[p.Name] = value
+	}
+	return coerced, nil
+}
+
+// coerce converts v to the parameter type and checks its range.
+func (p Param) coerce(v interface{}) (interface{}, error) {
+	switch p.Type {
+	case ParamNumber, ParamInteger:
+		f, err := toFloat(v)
+		if err != nil {
+			return nil, fmt.Errorf("Parameter %q must be a %v: %v", p.Name, p.Type, err)
+		}
+		if p.Range != nil && (f < p.Range.Min || f > p.Range.Max) {
+			return nil, fmt.Errorf("Parameter %q must be between %v and %v", p.Name, p.Range.Min, p.Range.Max)
+		}
+		if p.Type == ParamNumber {
+			return f, nil
+		}
+		if f != math.Trunc(f) {
+			return nil, fmt.Errorf("Parameter %q must be an integer", p.Name)
+		}
+		if f < float64(minInt) || f >= -float64(minInt) {
+			return nil, fmt.Errorf("Parameter %q must be between %v and %v", p.Name, minInt, maxInt)
+		}
+		return int(f), nil
+	case ParamBool:
+		switch b := v.(type) {
+		case bool:
+			return b, nil
+		case string:
+			parsed, err := strconv.ParseBool(b)
+			if err != nil {
+				return nil, fmt.Errorf("Parameter %q must be a boolean", p.Name)
+			}
+			return parsed, nil
+		}
+		return nil, fmt.Errorf("Parameter %q must be a boolean", p.Name)
+	case ParamString:
+		if s, ok := v.(string); ok {
+			return s, nil
+		}
+		return fmt.Sprint(v), nil
+	}
+	return v, nil
+}
+
+// toFloat converts the numeric types produced by encoding/json and Go code,
+// as well as numeric strings, to float64.
+func toFloat(v interface{}) (float64, error) {
+	switch n := v.(type) {
+	case float64:
+		return n, nil
+	case float32:
+		return float64(n), nil
+	case int:
+		return float64(n), nil
+	case int8:
+		return float64(n), nil
+	case int16:
+		return float64(n), nil
+	case int32:
+		return float64(n), nil
+	case int64:
+		return float64(n), nil
+	case uint:
+		return float64(n), nil
+	case uint8:
+		return float64(n), nil
+	case uint16:
+		return float64(n), nil
+	case uint32:
+		return float64(n), nil
+	case uint64:
+		return float64(n), nil
+	case string:
+		return strconv.ParseFloat(n, 64)
+	}
+	return 0, fmt.Errorf("unsupported value %v", v)
+}
+
+// requestIDKey is the context key of request IDs.
+type requestIDKey struct{}
+
+// WithRequestID returns a copy of ctx carrying a request ID, which
+// identifies the request which caused a command to be executed.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID returns the request ID carried by ctx, or an empty string.
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
 type commander struct {
 	commands map[string]func(map[string]interface{}) interface{}
+	contexts map[string]func(context.Context, map[string]interface{}) interface{}
+	schemas  map[string]*CommandSchema
+	metrics  *Registry
+	labels   Labels
 }
 
 // Commander is the interface which describes the behaviour for a Driver or Adaptor
@@ -15,22 +195,204 @@ type Commander interface {
 	AddCommand(name string, command func(map[string]interface{}) interface{})
 }
 
+// SchemaCommander is implemented by a Commander whose commands describe
+// their parameters, and which validates them before calling a command.
+type SchemaCommander interface {
+	// AddCommandWithSchema adds a command given a name and a description of its parameters.
+	AddCommandWithSchema(name string, schema CommandSchema, command func(map[string]interface{}) interface{})
+	// CommandSchema returns the schema of a command. Returns nil if the command has no schema.
+	CommandSchema(name string) (schema *CommandSchema)
+	// Execute validates params and calls the command given a name.
+	Execute(name string, params map[string]interface{}) (result interface{}, err error)
+}
+
+// ContextCommander is implemented by a Commander whose commands can receive
+// the context they are executed with.
+type ContextCommander interface {
+	// AddCommandContext adds a command given a name, which receives the context it is executed with.
+	AddCommandContext(name string, command func(context.Context, map[string]interface{}) interface{})
+	// AddCommandContextWithSchema adds a command given a name and a description of its parameters,
+	// which receives the context it is executed with.
+	AddCommandContextWithSchema(name string, schema CommandSchema, command func(context.Context, map[string]interface{}) interface{})
+	// ExecuteContext validates params and calls the command given a name with ctx.
+	ExecuteContext(ctx context.Context, name string, params map[string]interface{}) (result interface{}, err error)
+}
+
+// InstrumentedCommander is implemented by a Commander which records the
+// invocations and latency of its commands.
+type InstrumentedCommander interface {
+	// Instrument records the invocations and latency of commands in a Registry.
+	Instrument(metrics *Registry, labels Labels)
+}
+
+// ExtendedCommander is a Commander which implements every optional Commander
+// interface. Drivers embed one so that their commands are described,
+// validated and instrumented by the API and the Robot.
+type ExtendedCommander interface {
+	Commander
+	SchemaCommander
+	ContextCommander
+	InstrumentedCommander
+}
+
 // NewCommander returns a new Commander.
-func NewCommander() Commander {
+func NewCommander() ExtendedCommander {
 	return &commander{
 		commands: make(map[string]func(map[string]interface{}) interface{}),
+		contexts: make(map[string]func(context.Context, map[string]interface{}) interface{}),
+		schemas:  make(map[string]*CommandSchema),
 	}
 }
 
+// Command returns a command given a name. Commands added with a schema
+// validate and coerce their parameters first, and return the validation
+// error instead of calling the command if they are invalid.
 func (c *commander) Command(name string) (command func(map[string]interface{}) interface{}) {
 	command, _ = c.commands[name]
+	if command == nil {
+		return
+	}
+	if schema, ok := c.schemas[name]; ok {
+		command = validated(schema, command)
+	}
+	if c.metrics != nil {
+		command = c.timed(name, command)
+	}
 	return
 }
 
 func (c *commander) Commands() map[string]func(map[string]interface{}) interface{} {
-	return c.commands
+	commands := make(map[string]func(map[string]interface{}) interface{}, len(c.commands))
+	for name := range c.commands {
+		commands[name] = c.Command(name)
+	}
+	return commands
 }
 
 func (c *commander) AddCommand(name string, command func(map[string]interface{}) interface{}) {
 	c.commands[name] = command
+	delete(c.contexts, name)
+	delete(c.schemas, name)
+}
+
+func (c *commander) AddCommandWithSchema(name string, schema CommandSchema, command func(map[string]interface{}) interface{}) {
+	c.commands[name] = command
+	delete(c.contexts, name)
+	c.schemas[name] = &schema
+}
+
+// AddCommandContext adds a command which is called with the context passed
+// to ExecuteContext, or context.Background when it is called any other way.
+func (c *commander) AddCommandContext(name string, command func(context.Context, map[string]interface{}) interface{}) {
+	c.commands[name] = func(params map[string]interface{}) interface{} {
+		return command(context.Background(), params)
+	}
+	c.contexts[name] = command
+	delete(c.schemas, name)
+}
+
+func (c *commander) AddCommandContextWithSchema(name string, schema CommandSchema, command func(context.Context, map[string]interface{}) interface{}) {
+	c.AddCommandContext(name, command)
+	c.schemas[name] = &schema
+}
+
+func (c *commander) CommandSchema(name string) *CommandSchema {
+	return c.schemas[name]
+}
+
+func (c *commander) Execute(name string, params map[string]interface{}) (result interface{}, err error) {
+	return c.ExecuteContext(context.Background(), name, params)
+}
+
+func (c *commander) ExecuteContext(ctx context.Context, name string, params map[string]interface{}) (result interface{}, err error) {
+	command, ok := c.commands[name]
+	if !ok || command == nil {
+		return nil, ErrUnknownCommand
+	}
+	if schema, ok := c.schemas[name]; ok {
+		if params, err = schema.Coerce(params); err != nil {
+			return
+		}
+	}
+	defer c.observe(name, time.Now())
+	if command, ok := c.contexts[name]; ok {
+		return command(ctx, params), nil
+	}
+	return command(params), nil
+}
+
+func (c *commander) Instrument(metrics *Registry, labels Labels) {
+	c.metrics = metrics
+	c.labels = labels
+}
+
+// timed wraps command so that its invocations and latency are recorded.
+func (c *commander) timed(name string, command func(map[string]interface{}) interface{}) func(map[string]interface{}) interface{} {
+	return func(params map[string]interface{}) interface{} {
+		defer c.observe(name, time.Now())
+		return command(params)
+	}
+}
+
+// observe records an invocation of the named command which began at start.
+func (c *commander) observe(name string, start time.Time) {
+	if c.metrics == nil {
+		return
+	}
+	labels := c.labels.with("command", name)
+	c.metrics.Counter("gobot_command_invocations_total",
+		"Command invocations.", labels).Inc()
+	c.metrics.Histogram("gobot_command_duration_seconds",
+		"Command latency in seconds.", labels, nil).ObserveSince(start)
+}
+
+// validated wraps command so that its params are coerced by schema first.
+func validated(schema *CommandSchema, command func(map[string]interface{}) interface{}) func(map[string]interface{}) interface{} {
+	return func(params map[string]interface{}) interface{} {
+		params, err := schema.Coerce(params)
+		if err != nil {
+			return err
+		}
+		return command(params)
+	}
+}
+
+// CommandSchemaOf returns the schema of the named command of c. Returns nil
+// if the command has no schema or c is not a SchemaCommander.
+func CommandSchemaOf(c Commander, name string) *CommandSchema {
+	if sc, ok := c.(SchemaCommander); ok {
+		return sc.CommandSchema(name)
+	}
+	return nil
+}
+
+// ExecuteCommand calls the named command of c with params. They are
+// validated first if c is a SchemaCommander, and the command receives ctx if
+// c is a ContextCommander.
+func ExecuteCommand(ctx context.Context, c Commander, name string, params map[string]interface{}) (result interface{}, err error) {
+	if cc, ok := c.(ContextCommander); ok {
+		return cc.ExecuteContext(ctx, name, params)
+	}
+	if sc, ok := c.(SchemaCommander); ok {
+		return sc.Execute(name, params)
+	}
+	command := c.Command(name)
+	if command == nil {
+		return nil, ErrUnknownCommand
+	}
+	return command(params), nil
+}
+
+// commandSchemas returns the schemas of every command in c which has one,
+// or nil if there are none.
+func commandSchemas(c Commander) (schemas map[string]*CommandSchema) {
+	for name := range c.Commands() {
+		if schema := CommandSchemaOf(c, name); schema != nil {
+			if schemas == nil {
+				schemas = make(map[string]*CommandSchema)
+			}
+			schemas[name] = schema
+		}
+	}
+	return
 }
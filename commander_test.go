@@ -1,6 +1,8 @@
 package gobot
 
 import (
+	"context"
+	"fmt"
 	"testing"
 
 	"github.com/hybridgroup/gobot/gobottest"
@@ -22,3 +24,141 @@ func TestCommaner(t *testing.T) {
 	command = c.Command("booyeah")
 	gobottest.Assert(t, command, (func(map[string]interface{}) interface{})(nil))
 }
+
+func TestCommanderSchema(t *testing.T) {
+	c := NewCommander()
+	c.AddCommandWithSchema("move", CommandSchema{
+		Description: "move",
+		Params: []Param{
+			{Name: "angle", Type: ParamInteger, Required: true, Range: &Range{Min: 0, Max: 180}},
+			{Name: "speed", Type: ParamNumber, Default: 1.0},
+			{Name: "fast", Type: ParamBool},
+			{Name: "label", Type: ParamString},
+		},
+	}, func(params map[string]interface{}) interface{} {
+		return params
+	})
+
+	gobottest.Assert(t, c.CommandSchema("move").Description, "move")
+	gobottest.Assert(t, c.CommandSchema("booyeah"), (*CommandSchema)(nil))
+
+	result, err := c.Execute("move", map[string]interface{}{
+		"angle": 90.0,
+		"fast":  "true",
+		"label": 12,
+	})
+	gobottest.Assert(t, err, nil)
+	gobottest.Assert(t, result, map[string]interface{}{
+		"angle": 90,
+		"speed": 1.0,
+		"fast":  true,
+		"label": "12",
+	})
+
+	result, err = c.Execute("move", map[string]interface{}{"angle": "45"})
+	gobottest.Assert(t, err, nil)
+	gobottest.Assert(t, result.(map[string]interface{})["angle"], 45)
+
+	_, err = c.Execute("move", map[string]interface{}{})
+	gobottest.Assert(t, err.Error(), "Parameter \"angle\" is required")
+
+	_, err = c.Execute("move", map[string]interface{}{"angle": 181})
+	gobottest.Assert(t, err.Error(), "Parameter \"angle\" must be between 0 and 180")
+
+	_, err = c.Execute("move", map[string]interface{}{"angle": 1.5})
+	gobottest.Assert(t, err.Error(), "Parameter \"angle\" must be an integer")
+
+	c.CommandSchema("move").Params[0].Range = nil
+	_, err = c.Execute("move", map[string]interface{}{"angle": 1e19})
+	gobottest.Assert(t, err.Error(), fmt.Sprintf("Parameter \"angle\" must be between %v and %v", minInt, maxInt))
+	c.CommandSchema("move").Params[0].Range = &Range{Min: 0, Max: 180}
+
+	_, err = c.Execute("move", map[string]interface{}{"angle": 1, "fast": 2})
+	gobottest.Assert(t, err.Error(), "Parameter \"fast\" must be a boolean")
+
+	_, err = c.Execute("booyeah", nil)
+	gobottest.Assert(t, err, ErrUnknownCommand)
+
+	// Command validates params too
+	gobottest.Assert(t, c.Command("move")(map[string]interface{}{"angle": 10}).(map[string]interface{})["angle"], 10)
+	gobottest.Refute(t, c.Command("move")(map[string]interface{}{}).(error), nil)
+
+	// AddCommand replaces the schema
+	c.AddCommand("move", func(params map[string]interface{}) interface{} { return nil })
+	gobottest.Assert(t, c.CommandSchema("move"), (*CommandSchema)(nil))
+}
+
+func TestJSONDeviceCommandSchemas(t *testing.T) {
+	d := newTestDriver(newTestAdaptor("Connection1", "/dev/null"), "Device1", "0")
+	gobottest.Assert(t, NewJSONDevice(d).CommandSchemas, (map[string]*CommandSchema)(nil))
+
+	d.AddCommandWithSchema("Move", CommandSchema{
+		Params: []Param{{Name: "angle", Type: ParamInteger}},
+	}, func(params map[string]interface{}) interface{} { return nil })
+	gobottest.Assert(t, NewJSONDevice(d).CommandSchemas["Move"].Params[0].Name, "angle")
+}
+
+func TestCommanderContext(t *testing.T) {
+	c := NewCommander()
+	c.AddCommandContext("whoami", func(ctx context.Context, params map[string]interface{}) interface{} {
+		return RequestID(ctx)
+	})
+
+	result, err := c.ExecuteContext(WithRequestID(context.Background(), "abc123"), "whoami", nil)
+	gobottest.Assert(t, err, nil)
+	gobottest.Assert(t, result, "abc123")
+
+	result, _ = c.Execute("whoami", nil)
+	gobottest.Assert(t, result, "")
+	gobottest.Assert(t, c.Command("whoami")(nil), "")
+
+	_, err = c.ExecuteContext(context.Background(), "unknown", nil)
+	gobottest.Assert(t, err, ErrUnknownCommand)
+
+	c.AddCommand("whoami", func(map[string]interface{}) interface{} { return "plain" })
+	result, _ = c.ExecuteContext(WithRequestID(context.Background(), "abc123"), "whoami", nil)
+	gobottest.Assert(t, result, "plain")
+
+	c.AddCommandContextWithSchema("move", CommandSchema{
+		Params: []Param{{Name: "angle", Type: ParamInteger, Required: true}},
+	}, func(ctx context.Context, params map[string]interface{}) interface{} {
+		return RequestID(ctx)
+	})
+	gobottest.Assert(t, c.CommandSchema("move").Params[0].Name, "angle")
+	result, err = c.ExecuteContext(WithRequestID(context.Background(), "abc123"), "move", map[string]interface{}{"angle": 90})
+	gobottest.Assert(t, err, nil)
+	gobottest.Assert(t, result, "abc123")
+	_, err = c.ExecuteContext(context.Background(), "move", nil)
+	gobottest.Refute(t, err, nil)
+}
+
+// plainCommander implements only the Commander interface.
+type plainCommander struct {
+	Commander
+}
+
+func TestExecuteCommand(t *testing.T) {
+	c := NewCommander()
+	c.AddCommandContextWithSchema("move", CommandSchema{
+		Params: []Param{{Name: "angle", Type: ParamInteger, Required: true}},
+	}, func(ctx context.Context, params map[string]interface{}) interface{} {
+		return fmt.Sprint(RequestID(ctx), params["angle"])
+	})
+	ctx := WithRequestID(context.Background(), "abc123")
+
+	result, err := ExecuteCommand(ctx, c, "move", map[string]interface{}{"angle": "90"})
+	gobottest.Assert(t, err, nil)
+	gobottest.Assert(t, result, "abc12390")
+	gobottest.Refute(t, CommandSchemaOf(c, "move"), (*CommandSchema)(nil))
+
+	// a Commander without the optional interfaces calls its commands as they are
+	p := plainCommander{c}
+	_, ok := interface{}(p).(SchemaCommander)
+	gobottest.Assert(t, ok, false)
+	gobottest.Assert(t, CommandSchemaOf(p, "move"), (*CommandSchema)(nil))
+	result, err = ExecuteCommand(ctx, p, "move", map[string]interface{}{"angle": "90"})
+	gobottest.Assert(t, err, nil)
+	gobottest.Assert(t, result, "90")
+	_, err = ExecuteCommand(ctx, p, "booyeah", nil)
+	gobottest.Assert(t, err, ErrUnknownCommand)
+}
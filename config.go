@@ -0,0 +1,175 @@
+package gobot
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// RobotConfig describes a Robot, its Connections and its Devices.
+type RobotConfig struct {
+	Name        string             `json:"name" yaml:"name"`
+	Connections []ConnectionConfig `json:"connections" yaml:"connections"`
+	Devices     []DeviceConfig     `json:"devices" yaml:"devices"`
+}
+
+// ConnectionConfig describes a Connection. Adaptor is the name the adaptor
+// was registered with.
+type ConnectionConfig struct {
+	Name    string       `json:"name" yaml:"name"`
+	Adaptor string       `json:"adaptor" yaml:"adaptor"`
+	Port    string       `json:"port,omitempty" yaml:"port,omitempty"`
+	Params  ConfigParams `json:"params,omitempty" yaml:"params,omitempty"`
+}
+
+// DeviceConfig describes a Device. Driver is the name the driver was
+// registered with, and Connection is the name of the Connection it uses. The
+// Connection may be omitted when the Robot has only one.
+type DeviceConfig struct {
+	Name       string       `json:"name" yaml:"name"`
+	Driver     string       `json:"driver" yaml:"driver"`
+	Connection string       `json:"connection,omitempty" yaml:"connection,omitempty"`
+	Pin        string       `json:"pin,omitempty" yaml:"pin,omitempty"`
+	Interval   string       `json:"interval,omitempty" yaml:"interval,omitempty"`
+	Params     ConfigParams `json:"params,omitempty" yaml:"params,omitempty"`
+}
+
+// Intervals returns the polling interval of the device as a slice which
+// can be passed on to driver constructors accepting v ...time.Duration.
+// The slice is empty if no interval is configured.
+func (d DeviceConfig) Intervals() ([]time.Duration, error) {
+	if d.Interval == "" {
+		return nil, nil
+	}
+	interval, err := time.ParseDuration(d.Interval)
+	if err != nil {
+		return nil, fmt.Errorf("Device %q: %v", d.Name, err)
+	}
+	return []time.Duration{interval}, nil
+}
+
+// ConnectionFrom returns the Connection the device uses out of connections:
+// the one named by its Connection, or the only one if it is omitted.
+func (d DeviceConfig) ConnectionFrom(connections []Connection) (Connection, error) {
+	if d.Connection == "" && len(connections) == 1 {
+		return connections[0], nil
+	}
+	for _, c := range connections {
+		if c.Name() == d.Connection {
+			return c, nil
+		}
+	}
+	return nil, fmt.Errorf("No Connection found with the name %q", d.Connection)
+}
+
+// ConfigParams holds driver or adaptor specific settings.
+type ConfigParams map[string]interface{}
+
+// String returns the param given a key, or def if it is not set.
+func (p ConfigParams) String(key string, def string) string {
+	if v, ok := p[key]; ok {
+		return fmt.Sprint(v)
+	}
+	return def
+}
+
+// Int returns the param given a key, or def if it is not set or is not a number.
+func (p ConfigParams) Int(key string, def int) int {
+	if v, ok := p[key]; ok {
+		if f, err := toFloat(v); err == nil {
+			return int(f)
+		}
+	}
+	return def
+}
+
+// Float returns the param given a key, or def if it is not set or is not a number.
+func (p ConfigParams) Float(key string, def float64) float64 {
+	if v, ok := p[key]; ok {
+		if f, err := toFloat(v); err == nil {
+			return f
+		}
+	}
+	return def
+}
+
+// Bool returns the param given a key, or def if it is not set or is not a boolean.
+func (p ConfigParams) Bool(key string, def bool) bool {
+	switch v := p[key].(type) {
+	case bool:
+		return v
+	case string:
+		if b, err := strconv.ParseBool(v); err == nil {
+			return b
+		}
+	}
+	return def
+}
+
+// LoadRobotConfig reads a RobotConfig from a file. Files ending in .yml or
+// .yaml are parsed as YAML, and files ending in .json as JSON.
+func LoadRobotConfig(path string) (*RobotConfig, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &RobotConfig{}
+	switch filepath.Ext(path) {
+	case ".yml", ".yaml":
+		err = yaml.Unmarshal(data, config)
+	case ".json":
+		err = json.Unmarshal(data, config)
+	default:
+		err = fmt.Errorf("Unknown config format %q", filepath.Ext(path))
+	}
+	if err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// NewRobotFromConfig returns a new Robot built from the configuration file at
+// path, using the adaptors and drivers registered by the platform packages.
+// It optionally accepts the same work routine and Supervisor as NewRobot.
+func NewRobotFromConfig(path string, v ...interface{}) (*Robot, error) {
+	config, err := LoadRobotConfig(path)
+	if err != nil {
+		return nil, err
+	}
+	return config.NewRobot(v...)
+}
+
+// NewRobot returns a new Robot built from the configuration, using the
+// adaptors and drivers registered by the platform packages. It optionally
+// accepts the same work routine and Supervisor as NewRobot.
+func (rc *RobotConfig) NewRobot(v ...interface{}) (*Robot, error) {
+	connections := []Connection{}
+	for _, cc := range rc.Connections {
+		a, err := NewAdaptor(cc)
+		if err != nil {
+			return nil, fmt.Errorf("Connection %q: %v", cc.Name, err)
+		}
+		connections = append(connections, a)
+	}
+
+	devices := []Device{}
+	for _, dc := range rc.Devices {
+		c, err := dc.ConnectionFrom(connections)
+		if err != nil {
+			return nil, fmt.Errorf("Device %q: %v", dc.Name, err)
+		}
+		d, err := NewDriver(c, dc)
+		if err != nil {
+			return nil, fmt.Errorf("Device %q: %v", dc.Name, err)
+		}
+		devices = append(devices, d)
+	}
+
+	return NewRobot(rc.Name, append([]interface{}{connections, devices}, v...)...), nil
+}
@@ -0,0 +1,117 @@
+package gobot
+
+import (
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hybridgroup/gobot/gobottest"
+)
+
+func writeTestConfig(t *testing.T, name string, data string) string {
+	dir, err := ioutil.TempDir("", "gobot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestNewRobotFromYAMLConfig(t *testing.T) {
+	log.SetOutput(&NullReadWriteCloser{})
+	path := writeTestConfig(t, "robot.yml", `
+name: bot
+connections:
+  - name: arduino
+    adaptor: test
+    port: /dev/ttyACM0
+devices:
+  - name: led
+    driver: test
+    pin: "13"
+  - name: button
+    driver: test
+    connection: arduino
+    pin: "2"
+    interval: 20ms
+    params:
+      pin_override: "3"
+`)
+	defer os.RemoveAll(filepath.Dir(path))
+
+	r, err := NewRobotFromConfig(path)
+	gobottest.Assert(t, err, nil)
+	gobottest.Assert(t, r.Name, "bot")
+	gobottest.Assert(t, r.Connections().Len(), 1)
+	gobottest.Assert(t, r.Connection("arduino").(Porter).Port(), "/dev/ttyACM0")
+	gobottest.Assert(t, r.Devices().Len(), 2)
+	gobottest.Assert(t, r.Device("led").(Pinner).Pin(), "13")
+	gobottest.Assert(t, r.Device("button").(Pinner).Pin(), "3")
+}
+
+func TestNewRobotFromJSONConfig(t *testing.T) {
+	log.SetOutput(&NullReadWriteCloser{})
+	path := writeTestConfig(t, "robot.json", `{
+		"name": "bot",
+		"connections": [{"name": "arduino", "adaptor": "test"}],
+		"devices": [{"name": "led", "driver": "test", "pin": "13"}]
+	}`)
+	defer os.RemoveAll(filepath.Dir(path))
+
+	work := func() {}
+	r, err := NewRobotFromConfig(path, work)
+	gobottest.Assert(t, err, nil)
+	gobottest.Refute(t, r.Work, nil)
+	gobottest.Assert(t, r.Device("led").Connection().Name(), "arduino")
+}
+
+func TestNewRobotFromConfigErrors(t *testing.T) {
+	_, err := NewRobotFromConfig("/nonexistent/robot.yml")
+	gobottest.Refute(t, err, nil)
+
+	path := writeTestConfig(t, "robot.toml", "")
+	defer os.RemoveAll(filepath.Dir(path))
+	_, err = NewRobotFromConfig(path)
+	gobottest.Assert(t, err.Error(), "Unknown config format \".toml\"")
+
+	_, err = (&RobotConfig{
+		Connections: []ConnectionConfig{{Name: "a", Adaptor: "unknown"}},
+	}).NewRobot()
+	gobottest.Assert(t, err.Error(), "Connection \"a\": Unknown adaptor \"unknown\"")
+
+	_, err = (&RobotConfig{
+		Connections: []ConnectionConfig{{Name: "a", Adaptor: "test"}, {Name: "b", Adaptor: "test"}},
+		Devices:     []DeviceConfig{{Name: "led", Driver: "test"}},
+	}).NewRobot()
+	gobottest.Assert(t, err.Error(), "Device \"led\": No Connection found with the name \"\"")
+}
+
+func TestDeviceConfigIntervals(t *testing.T) {
+	v, err := DeviceConfig{}.Intervals()
+	gobottest.Assert(t, err, nil)
+	gobottest.Assert(t, len(v), 0)
+
+	v, err = DeviceConfig{Interval: "10ms"}.Intervals()
+	gobottest.Assert(t, err, nil)
+	gobottest.Assert(t, v, []time.Duration{10 * time.Millisecond})
+
+	_, err = DeviceConfig{Name: "led", Interval: "soon"}.Intervals()
+	gobottest.Refute(t, err, nil)
+}
+
+func TestConfigParams(t *testing.T) {
+	p := ConfigParams{"s": "str", "i": 3, "f": "1.5", "b": "true"}
+	gobottest.Assert(t, p.String("s", ""), "str")
+	gobottest.Assert(t, p.String("missing", "def"), "def")
+	gobottest.Assert(t, p.Int("i", 0), 3)
+	gobottest.Assert(t, p.Int("s", 7), 7)
+	gobottest.Assert(t, p.Float("f", 0), 1.5)
+	gobottest.Assert(t, p.Bool("b", false), true)
+	gobottest.Assert(t, p.Bool("missing", true), true)
+}
@@ -2,7 +2,6 @@ package gobot
 
 import (
 	"fmt"
-	"log"
 	"reflect"
 )
 
@@ -23,6 +22,15 @@ func NewJSONConnection(connection Connection) *JSONConnection {
 // A Connection is an instance of an Adaptor
 type Connection Adaptor
 
+// connectionKeyvals returns the log keys and values which identify c.
+func connectionKeyvals(c Connection) []interface{} {
+	keyvals := []interface{}{"connection", c.Name()}
+	if porter, ok := c.(Porter); ok {
+		keyvals = append(keyvals, "port", porter.Port())
+	}
+	return keyvals
+}
+
 // Connections represents a collection of Connection
 type Connections []Connection
 
@@ -40,15 +48,13 @@ func (c *Connections) Each(f func(Connection)) {
 
 // Start calls Connect on each Connection in c
 func (c *Connections) Start() (errs []error) {
-	log.Println("Starting connections...")
-	for _, connection := range *c {
-		info := "Starting connection " + connection.Name()
-
-		if porter, ok := connection.(Porter); ok {
-			info = info + " on port " + porter.Port()
-		}
+	return c.start(DefaultLogger())
+}
 
-		log.Println(info + "...")
+func (c *Connections) start(logger Logger) (errs []error) {
+	logger.Debug("Starting connections")
+	for _, connection := range *c {
+		logger.Info("Starting connection", connectionKeyvals(connection)...)
 
 		if errs = connection.Connect(); len(errs) > 0 {
 			for i, err := range errs {
@@ -2,16 +2,17 @@ package gobot
 
 import (
 	"fmt"
-	"log"
 	"reflect"
 )
 
 // JSONDevice is a JSON representation of a Device.
 type JSONDevice struct {
-	Name       string   `json:"name"`
-	Driver     string   `json:"driver"`
-	Connection string   `json:"connection"`
-	Commands   []string `json:"commands"`
+	Name           string                    `json:"name"`
+	Driver         string                    `json:"driver"`
+	Connection     string                    `json:"connection"`
+	Commands       []string                  `json:"commands"`
+	CommandSchemas map[string]*CommandSchema `json:"command_schemas,omitempty"`
+	State          map[string]interface{}    `json:"state,omitempty"`
 }
 
 // NewJSONDevice returns a JSONDevice given a Device.
@@ -29,6 +30,10 @@ func NewJSONDevice(device Device) *JSONDevice {
 		for command := range commander.Commands() {
 			jsonDevice.Commands = append(jsonDevice.Commands, command)
 		}
+		jsonDevice.CommandSchemas = commandSchemas(commander)
+	}
+	if stater, ok := device.(Stater); ok {
+		jsonDevice.State = stater.Snapshot()
 	}
 	return jsonDevice
 }
@@ -36,6 +41,15 @@ func NewJSONDevice(device Device) *JSONDevice {
 // A Device is an instnace of a Driver
 type Device Driver
 
+// deviceKeyvals returns the log keys and values which identify d.
+func deviceKeyvals(d Device) []interface{} {
+	keyvals := []interface{}{"device", d.Name()}
+	if pinner, ok := d.(Pinner); ok {
+		keyvals = append(keyvals, "pin", pinner.Pin())
+	}
+	return keyvals
+}
+
 // Devices represents a collection of Device
 type Devices []Device
 
@@ -53,15 +67,13 @@ func (d *Devices) Each(f func(Device)) {
 
 // Start calls Start on each Device in d
 func (d *Devices) Start() (errs []error) {
-	log.Println("Starting devices...")
-	for _, device := range *d {
-		info := "Starting device " + device.Name()
-
-		if pinner, ok := device.(Pinner); ok {
-			info = info + " on pin " + pinner.Pin()
-		}
+	return d.start(DefaultLogger())
+}
 
-		log.Println(info + "...")
+func (d *Devices) start(logger Logger) (errs []error) {
+	logger.Debug("Starting devices")
+	for _, device := range *d {
+		logger.Info("Starting device", deviceKeyvals(device)...)
 		if errs = device.Start(); len(errs) > 0 {
 			for i, err := range errs {
 				errs[i] = fmt.Errorf("Device %q: %v", device.Name(), err)
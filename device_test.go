@@ -0,0 +1,26 @@
+package gobot
+
+import (
+	"testing"
+
+	"github.com/hybridgroup/gobot/gobottest"
+)
+
+type testStaterDriver struct {
+	*testDriver
+	on bool
+}
+
+func (t *testStaterDriver) Snapshot() map[string]interface{} {
+	return map[string]interface{}{"on": t.on}
+}
+
+func TestJSONDeviceState(t *testing.T) {
+	d := newTestDriver(newTestAdaptor("Connection1", "/dev/null"), "Device1", "0")
+	gobottest.Assert(t, NewJSONDevice(d).State, (map[string]interface{})(nil))
+
+	s := &testStaterDriver{testDriver: d}
+	gobottest.Assert(t, NewJSONDevice(s).State, map[string]interface{}{"on": false})
+	s.on = true
+	gobottest.Assert(t, NewJSONDevice(s).State, map[string]interface{}{"on": true})
+}
@@ -101,5 +101,53 @@ web service.
     	gbot.Start()
     }
 
+Robots can also be described in a YAML or JSON file. Each platform package
+registers its adaptors and drivers by name when it is imported, so the file
+can be changed without recompiling.
+
+    name: Eve
+    connections:
+      - name: arduino
+        adaptor: firmata
+        port: /dev/ttyACM0
+    devices:
+      - name: led
+        driver: led
+        pin: "13"
+
+    package main
+
+    import (
+    	"github.com/hybridgroup/gobot"
+    	_ "github.com/hybridgroup/gobot/platforms/firmata"
+    	_ "github.com/hybridgroup/gobot/platforms/gpio"
+    )
+
+    func main() {
+    	gbot := gobot.NewGobot()
+
+    	robot, err := gobot.NewRobotFromConfig("eve.yml")
+    	if err != nil {
+    		panic(err)
+    	}
+
+    	gbot.AddRobot(robot)
+
+    	gbot.Start()
+    }
+
+Gobot logs through the Logger interface. Call SetDefaultLogger before
+creating any robots to route its output into another logging package, or to
+silence the startup messages:
+
+    gobot.SetDefaultLogger(gobot.NewLogger(gobot.LevelWarn))
+
+The events published by a robot's devices can be captured with a Recorder,
+and played back without the hardware by the replay platform:
+
+    f, _ := os.Create("session.jsonl")
+    recorder := gobot.NewRecorder(f, gobot.RecordJSON)
+    recorder.Record(robot)
+
 */
 package gobot
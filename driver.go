@@ -16,3 +16,11 @@ type Driver interface {
 type Pinner interface {
 	Pin() string
 }
+
+// Stater is the interface that describes a driver which reports its current
+// state, such as whether an LED is on or the last reading of a sensor.
+type Stater interface {
+	// Snapshot returns the current state of the driver as a map of names to
+	// JSON-encodable values
+	Snapshot() map[string]interface{}
+}
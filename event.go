@@ -1,13 +1,18 @@
 package gobot
 
+import "time"
+
 // Event represents when something asyncronous happens in a Driver
 // or Adaptor
 type Event struct {
 	Name string
 	Data interface{}
+	// Time is when the Event was published
+	Time time.Time
 }
 
-// NewEvent returns a new Event and its associated data.
+// NewEvent returns a new Event and its associated data, stamped with the
+// current time.
 func NewEvent(name string, data interface{}) *Event {
-	return &Event{Name: name, Data: data}
+	return &Event{Name: name, Data: data, Time: time.Now()}
 }
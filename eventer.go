@@ -1,16 +1,70 @@
 package gobot
 
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
 type eventChannel chan *Event
 
+// OverflowPolicy describes what happens to a published Event when a
+// subscriber's buffer is full.
+type OverflowPolicy int
+
+const (
+	// DropOldest discards the oldest buffered Event to make room for the new one.
+	DropOldest OverflowPolicy = iota
+	// DropNewest discards the Event being published.
+	DropNewest
+	// BlockWithTimeout waits up to the subscriber's Timeout for room in the
+	// buffer, and discards the Event being published if none becomes available.
+	BlockWithTimeout
+)
+
+// SubscribeOptions configures the buffer of a single subscriber.
+type SubscribeOptions struct {
+	// BufferSize is the number of Events which can be queued for the subscriber.
+	BufferSize int
+	// Policy is applied when the buffer is full.
+	Policy OverflowPolicy
+	// Timeout is the longest time Publish waits when Policy is BlockWithTimeout.
+	Timeout time.Duration
+}
+
+// DefaultSubscribeOptions are used by Subscribe, On and Once.
+var DefaultSubscribeOptions = SubscribeOptions{
+	BufferSize: 16,
+	Policy:     DropOldest,
+	Timeout:    10 * time.Millisecond,
+}
+
+// subscriber and eventer keep their counters first so that they are 64-bit
+// aligned for atomic access on 32-bit platforms.
+type subscriber struct {
+	dropped uint64
+	events  eventChannel
+	policy  OverflowPolicy
+	timeout time.Duration
+	// accept, if set, selects the Events which are queued for the subscriber
+	accept func(evt *Event) bool
+}
+
 type eventer struct {
+	// total number of Events dropped across all subscribers
+	dropped uint64
+
+	// total number of Events published
+	published uint64
+
+	mutex sync.RWMutex
+
 	// map of valid Event names
 	eventnames map[string]string
 
-	// new events get put in to the event channel
-	in eventChannel
-
-	// map of out channels used by subscribers
-	outs map[eventChannel]eventChannel
+	// map of subscribers, keyed by their out channel
+	outs map[eventChannel]*subscriber
 }
 
 // Eventer is the interface which describes how a Driver or Adaptor
@@ -35,111 +89,273 @@ type Eventer interface {
 	// Subscribe to events
 	Subscribe() (events eventChannel)
 
+	// SubscribeWithOptions subscribes to events using a custom buffer
+	SubscribeWithOptions(opts SubscribeOptions) (events eventChannel)
+
 	// Unsubscribe from an event channel
 	Unsubscribe(events eventChannel)
 
-	// Event handler
-	On(name string, f func(s interface{})) (err error)
+	// Event handler, name may be a pattern such as "button.*"
+	On(name string, f func(s interface{}), filters ...EventFilter) (sub *Subscription, err error)
+
+	// Event handler, stops executing when ctx is done
+	OnContext(ctx context.Context, name string, f func(s interface{}), filters ...EventFilter) (sub *Subscription, err error)
 
 	// Event handler, only executes one time
-	Once(name string, f func(s interface{})) (err error)
+	Once(name string, f func(s interface{}), filters ...EventFilter) (sub *Subscription, err error)
+
+	// Event handler, only executes one time unless ctx is done first
+	OnceContext(ctx context.Context, name string, f func(s interface{}), filters ...EventFilter) (sub *Subscription, err error)
+
+	// Event handler, buffering the matching events as described by opts
+	OnWithOptions(name string, opts SubscribeOptions, f func(s interface{}), filters ...EventFilter) (sub *Subscription, err error)
+
+	// Event handler, only executes one time, buffering the matching events as described by opts
+	OnceWithOptions(name string, opts SubscribeOptions, f func(s interface{}), filters ...EventFilter) (sub *Subscription, err error)
+
+	// Published returns the number of events published
+	Published() (count uint64)
+
+	// Dropped returns the number of events dropped across all subscribers
+	Dropped() (count uint64)
+
+	// DroppedFor returns the number of events dropped for one subscriber
+	DroppedFor(events eventChannel) (count uint64)
 }
 
 // NewEventer returns a new Eventer.
 func NewEventer() Eventer {
-	evtr := &eventer{
+	return &eventer{
 		eventnames: make(map[string]string),
-		in:         make(eventChannel, 1),
-		outs:       make(map[eventChannel]eventChannel),
+		outs:       make(map[eventChannel]*subscriber),
 	}
-
-	// goroutine to cascade "in" events to all "out" event channels
-	go func() {
-		for {
-			select {
-			case evt := <-evtr.in:
-				for _, out := range evtr.outs {
-					out <- evt
-				}
-			}
-		}
-	}()
-
-	return evtr
 }
 
-// Events returns the map of valid Event names.
+// Events returns a copy of the map of valid Event names.
 func (e *eventer) Events() map[string]string {
-	return e.eventnames
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+
+	eventnames := make(map[string]string, len(e.eventnames))
+	for k, v := range e.eventnames {
+		eventnames[k] = v
+	}
+	return eventnames
 }
 
 // Event returns an Event string from map of valid Event names.
 // Mostly used to validate that an Event name is valid.
 func (e *eventer) Event(name string) string {
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
 	return e.eventnames[name]
 }
 
 // AddEvent registers a new Event name.
 func (e *eventer) AddEvent(name string) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
 	e.eventnames[name] = name
 }
 
 // DeleteEvent removes a previously registered Event name.
 func (e *eventer) DeleteEvent(name string) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
 	delete(e.eventnames, name)
 }
 
-// Publish new events to anyone that is subscribed
+// Publish new events to anyone that is subscribed. Publish never waits on a
+// subscriber unless that subscriber uses the BlockWithTimeout policy.
 func (e *eventer) Publish(name string, data interface{}) {
 	evt := NewEvent(name, data)
-	e.in <- evt
+	atomic.AddUint64(&e.published, 1)
+
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+
+	for _, sub := range e.outs {
+		if sub.accept != nil && !sub.accept(evt) {
+			continue
+		}
+		if !sub.deliver(evt) {
+			atomic.AddUint64(&sub.dropped, 1)
+			atomic.AddUint64(&e.dropped, 1)
+		}
+	}
 }
 
-// Subscribe to any events from this eventer
+// deliver queues evt for the subscriber according to its overflow policy.
+// Returns false if an Event was dropped.
+func (s *subscriber) deliver(evt *Event) bool {
+	select {
+	case s.events <- evt:
+		return true
+	default:
+	}
+
+	switch s.policy {
+	case DropNewest:
+		return false
+	case BlockWithTimeout:
+		timer := time.NewTimer(s.timeout)
+		defer timer.Stop()
+		select {
+		case s.events <- evt:
+			return true
+		case <-timer.C:
+			return false
+		}
+	default:
+		dropped := false
+		for {
+			select {
+			case <-s.events:
+				dropped = true
+			default:
+			}
+			select {
+			case s.events <- evt:
+				return !dropped
+			default:
+			}
+		}
+	}
+}
+
+// Subscribe to any events from this eventer using DefaultSubscribeOptions
 func (e *eventer) Subscribe() eventChannel {
-	out := make(eventChannel)
-	e.outs[out] = out
+	return e.SubscribeWithOptions(DefaultSubscribeOptions)
+}
+
+// SubscribeWithOptions subscribes to any events from this eventer, buffering
+// them as described by opts.
+func (e *eventer) SubscribeWithOptions(opts SubscribeOptions) eventChannel {
+	return e.subscribe(opts, nil)
+}
+
+// subscribe subscribes to the events accepted by accept, or to any events if
+// accept is nil.
+func (e *eventer) subscribe(opts SubscribeOptions, accept func(evt *Event) bool) eventChannel {
+	if opts.BufferSize < 1 {
+		opts.BufferSize = 1
+	}
+
+	out := make(eventChannel, opts.BufferSize)
+
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.outs[out] = &subscriber{
+		events:  out,
+		policy:  opts.Policy,
+		timeout: opts.Timeout,
+		accept:  accept,
+	}
 	return out
 }
 
-// Unsubscribe from the event channel
+// Unsubscribe from the event channel. The channel is closed once no more
+// events can be published to it.
 func (e *eventer) Unsubscribe(events eventChannel) {
-	delete(e.outs, events)
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	if _, ok := e.outs[events]; ok {
+		delete(e.outs, events)
+		close(events)
+	}
 }
 
-// On executes the event handler f when e is Published to.
-func (e *eventer) On(n string, f func(s interface{})) (err error) {
-	out := e.Subscribe()
-	go func() {
-		for {
-			select {
-			case evt := <-out:
-				if evt.Name == n {
-					f(evt.Data)
-				}
-			}
-		}
-	}()
+// Published returns the number of events published.
+func (e *eventer) Published() uint64 {
+	return atomic.LoadUint64(&e.published)
+}
+
+// Dropped returns the number of events dropped across all subscribers
+func (e *eventer) Dropped() uint64 {
+	return atomic.LoadUint64(&e.dropped)
+}
 
-	return
+// DroppedFor returns the number of events dropped for the subscriber of
+// events. Returns 0 if events is not subscribed.
+func (e *eventer) DroppedFor(events eventChannel) uint64 {
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+	if sub, ok := e.outs[events]; ok {
+		return atomic.LoadUint64(&sub.dropped)
+	}
+	return 0
+}
+
+// On executes the event handler f when e is Published to. The name may be
+// a pattern such as "button.*", and f is only called when every filter
+// accepts the Event data. The name and filters are matched when the Event is
+// published, so other events never take the place of matching ones in the
+// buffer, which uses DefaultSubscribeOptions.
+func (e *eventer) On(n string, f func(s interface{}), filters ...EventFilter) (*Subscription, error) {
+	return e.handle(context.Background(), n, DefaultSubscribeOptions, f, false, filters)
+}
+
+// OnContext executes the event handler f when e is Published to, until ctx
+// is done or the returned Subscription is cancelled.
+func (e *eventer) OnContext(ctx context.Context, n string, f func(s interface{}), filters ...EventFilter) (*Subscription, error) {
+	return e.handle(ctx, n, DefaultSubscribeOptions, f, false, filters)
 }
 
 // Once is similar to On except that it only executes f one time.
-func (e *eventer) Once(n string, f func(s interface{})) (err error) {
-	out := e.Subscribe()
+func (e *eventer) Once(n string, f func(s interface{}), filters ...EventFilter) (*Subscription, error) {
+	return e.handle(context.Background(), n, DefaultSubscribeOptions, f, true, filters)
+}
+
+// OnceContext is similar to OnContext except that it only executes f one time.
+func (e *eventer) OnceContext(ctx context.Context, n string, f func(s interface{}), filters ...EventFilter) (*Subscription, error) {
+	return e.handle(ctx, n, DefaultSubscribeOptions, f, true, filters)
+}
+
+// OnWithOptions is similar to On except that the matching events are
+// buffered as described by opts.
+func (e *eventer) OnWithOptions(n string, opts SubscribeOptions, f func(s interface{}), filters ...EventFilter) (*Subscription, error) {
+	return e.handle(context.Background(), n, opts, f, false, filters)
+}
+
+// OnceWithOptions is similar to Once except that the matching events are
+// buffered as described by opts.
+func (e *eventer) OnceWithOptions(n string, opts SubscribeOptions, f func(s interface{}), filters ...EventFilter) (*Subscription, error) {
+	return e.handle(context.Background(), n, opts, f, true, filters)
+}
+
+// handle starts the goroutine behind On, Once and their variants. The filters
+// run in the publishing goroutine.
+func (e *eventer) handle(ctx context.Context, n string, opts SubscribeOptions, f func(s interface{}), once bool, filters []EventFilter) (*Subscription, error) {
+	match, err := eventMatcher(n)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	sub := &Subscription{cancel: cancel, done: make(chan struct{})}
+	out := e.subscribe(opts, func(evt *Event) bool {
+		return match(evt.Name) && acceptEvent(evt.Data, filters)
+	})
+
 	go func() {
-	ProcessEvents:
+		defer close(sub.done)
+		defer cancel()
+		defer e.Unsubscribe(out)
 		for {
 			select {
-			case evt := <-out:
-				if evt.Name == n {
-					f(evt.Data)
-					e.Unsubscribe(out)
-					break ProcessEvents
+			case evt, ok := <-out:
+				if !ok {
+					return
+				}
+				f(evt.Data)
+				if once {
+					return
 				}
+			case <-ctx.Done():
+				return
 			}
 		}
 	}()
 
-	return
+	return sub, nil
 }
@@ -1,8 +1,11 @@
 package gobot
 
 import (
+	"context"
 	"testing"
 	"time"
+
+	"github.com/hybridgroup/gobot/gobottest"
 )
 
 func TestEventerAddEvent(t *testing.T) {
@@ -73,3 +76,289 @@ func TestEventerOnce(t *testing.T) {
 	case <-time.After(10 * time.Millisecond):
 	}
 }
+
+func TestEventerOnContext(t *testing.T) {
+	e := NewEventer()
+	e.AddEvent("test")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	sem := make(chan bool, 1)
+	e.OnContext(ctx, "test", func(data interface{}) {
+		sem <- true
+	})
+
+	e.Publish("test", true)
+
+	select {
+	case <-sem:
+	case <-time.After(10 * time.Millisecond):
+		t.Errorf("OnContext was not called")
+	}
+
+	cancel()
+	time.Sleep(5 * time.Millisecond)
+	e.Publish("test", true)
+
+	select {
+	case <-sem:
+		t.Errorf("OnContext was called after cancel")
+	case <-time.After(10 * time.Millisecond):
+	}
+}
+
+func TestEventerOnceContext(t *testing.T) {
+	e := NewEventer()
+	e.AddEvent("test")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	sem := make(chan bool, 1)
+	e.OnceContext(ctx, "test", func(data interface{}) {
+		sem <- true
+	})
+
+	cancel()
+	time.Sleep(5 * time.Millisecond)
+	e.Publish("test", true)
+
+	select {
+	case <-sem:
+		t.Errorf("OnceContext was called after cancel")
+	case <-time.After(10 * time.Millisecond):
+	}
+}
+
+func TestEventerDropOldest(t *testing.T) {
+	e := NewEventer()
+	out := e.SubscribeWithOptions(SubscribeOptions{BufferSize: 2, Policy: DropOldest})
+
+	e.Publish("test", 1)
+	e.Publish("test", 2)
+	e.Publish("test", 3)
+
+	gobottest.Assert(t, (<-out).Data, 2)
+	gobottest.Assert(t, (<-out).Data, 3)
+	gobottest.Assert(t, e.DroppedFor(out), uint64(1))
+	gobottest.Assert(t, e.Dropped(), uint64(1))
+}
+
+func TestEventerDropNewest(t *testing.T) {
+	e := NewEventer()
+	out := e.SubscribeWithOptions(SubscribeOptions{BufferSize: 2, Policy: DropNewest})
+
+	e.Publish("test", 1)
+	e.Publish("test", 2)
+	e.Publish("test", 3)
+
+	gobottest.Assert(t, (<-out).Data, 1)
+	gobottest.Assert(t, (<-out).Data, 2)
+	gobottest.Assert(t, e.DroppedFor(out), uint64(1))
+}
+
+func TestEventerBlockWithTimeout(t *testing.T) {
+	e := NewEventer()
+	out := e.SubscribeWithOptions(SubscribeOptions{
+		BufferSize: 1,
+		Policy:     BlockWithTimeout,
+		Timeout:    20 * time.Millisecond,
+	})
+
+	e.Publish("test", 1)
+	go func() {
+		time.Sleep(5 * time.Millisecond)
+		<-out
+	}()
+	e.Publish("test", 2)
+	gobottest.Assert(t, e.DroppedFor(out), uint64(0))
+
+	e.Publish("test", 3)
+	gobottest.Assert(t, (<-out).Data, 2)
+	gobottest.Assert(t, e.DroppedFor(out), uint64(1))
+}
+
+func TestEventerSlowSubscriber(t *testing.T) {
+	e := NewEventer()
+	e.Subscribe()
+
+	done := make(chan bool)
+	go func() {
+		for i := 0; i < DefaultSubscribeOptions.BufferSize*2; i++ {
+			e.Publish("test", i)
+		}
+		done <- true
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(10 * time.Millisecond):
+		t.Errorf("Publish was blocked by a slow subscriber")
+	}
+	gobottest.Assert(t, e.Dropped(), uint64(DefaultSubscribeOptions.BufferSize))
+}
+
+func TestEventerUnsubscribe(t *testing.T) {
+	e := NewEventer()
+	out := e.Subscribe()
+	e.Unsubscribe(out)
+
+	_, ok := <-out
+	gobottest.Assert(t, ok, false)
+
+	// unsubscribing twice is harmless
+	e.Unsubscribe(out)
+	e.Publish("test", true)
+}
+
+func TestEventerOnWildcard(t *testing.T) {
+	e := NewEventer()
+
+	sem := make(chan interface{}, 2)
+	e.On("button.*", func(data interface{}) {
+		sem <- data
+	})
+
+	e.Publish("button.push", 1)
+	e.Publish("led.on", 2)
+	e.Publish("button.release", 3)
+
+	for _, expected := range []interface{}{1, 3} {
+		select {
+		case data := <-sem:
+			gobottest.Assert(t, data, expected)
+		case <-time.After(10 * time.Millisecond):
+			t.Errorf("On was not called for %v", expected)
+		}
+	}
+}
+
+func TestEventerOnBadPattern(t *testing.T) {
+	e := NewEventer()
+	sub, err := e.On("button.[", func(data interface{}) {})
+	gobottest.Refute(t, err, nil)
+	gobottest.Assert(t, sub, (*Subscription)(nil))
+}
+
+func TestEventerOnFilter(t *testing.T) {
+	e := NewEventer()
+
+	sem := make(chan interface{}, 1)
+	e.On("data", func(data interface{}) {
+		sem <- data
+	}, func(data interface{}) bool {
+		return data.(int) > 10
+	})
+
+	e.Publish("data", 5)
+	e.Publish("data", 15)
+
+	select {
+	case data := <-sem:
+		gobottest.Assert(t, data, 15)
+	case <-time.After(10 * time.Millisecond):
+		t.Errorf("On was not called")
+	}
+}
+
+func TestEventerSubscriptionCancel(t *testing.T) {
+	e := NewEventer()
+
+	sem := make(chan bool, 1)
+	sub, err := e.On("test", func(data interface{}) {
+		sem <- true
+	})
+	gobottest.Assert(t, err, nil)
+
+	sub.Cancel()
+	select {
+	case <-sub.Done():
+	case <-time.After(10 * time.Millisecond):
+		t.Errorf("Subscription was not stopped")
+	}
+
+	e.Publish("test", true)
+	select {
+	case <-sem:
+		t.Errorf("On was called after Cancel")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	// cancelling twice is harmless
+	sub.Cancel()
+}
+
+func TestEventerOnceDone(t *testing.T) {
+	e := NewEventer()
+
+	sub, _ := e.Once("test", func(data interface{}) {})
+	e.Publish("test", true)
+
+	select {
+	case <-sub.Done():
+	case <-time.After(10 * time.Millisecond):
+		t.Errorf("Once subscription was not stopped")
+	}
+}
+
+func TestEventerOnSkipsUnmatchedEvents(t *testing.T) {
+	e := NewEventer()
+
+	release := make(chan bool)
+	sem := make(chan interface{}, 3)
+	e.On("wanted", func(data interface{}) {
+		<-release
+		sem <- data
+	})
+
+	e.Publish("wanted", 1)
+	e.Publish("wanted", 2)
+	for i := 0; i < DefaultSubscribeOptions.BufferSize*4; i++ {
+		e.Publish("noise", i)
+	}
+	gobottest.Assert(t, e.Dropped(), uint64(0))
+
+	close(release)
+	for _, want := range []int{1, 2} {
+		select {
+		case data := <-sem:
+			gobottest.Assert(t, data, want)
+		case <-time.After(10 * time.Millisecond):
+			t.Errorf("matching event %v was dropped", want)
+		}
+	}
+}
+
+func TestEventerOnWithOptions(t *testing.T) {
+	e := NewEventer()
+
+	release := make(chan bool)
+	sem := make(chan interface{}, 4)
+	e.OnWithOptions("test", SubscribeOptions{BufferSize: 1, Policy: DropNewest}, func(data interface{}) {
+		<-release
+		sem <- data
+	})
+
+	e.Publish("test", 1)
+	time.Sleep(5 * time.Millisecond)
+	e.Publish("test", 2)
+	e.Publish("test", 3)
+	gobottest.Assert(t, e.Dropped(), uint64(1))
+
+	close(release)
+	gobottest.Assert(t, <-sem, 1)
+	gobottest.Assert(t, <-sem, 2)
+}
+
+func TestEventerOnceWithOptions(t *testing.T) {
+	e := NewEventer()
+
+	sem := make(chan interface{}, 2)
+	sub, err := e.OnceWithOptions("test", SubscribeOptions{BufferSize: 4, Policy: DropOldest}, func(data interface{}) {
+		sem <- data
+	})
+	gobottest.Assert(t, err, nil)
+
+	e.Publish("test", 1)
+	e.Publish("test", 2)
+	<-sub.Done()
+	gobottest.Assert(t, len(sem), 1)
+	gobottest.Assert(t, <-sem, 1)
+}
@@ -65,7 +65,7 @@ type pingDriver struct {
 	pin        string
 	connection gobot.Connection
 	gobot.Eventer
-	gobot.Commander
+	gobot.ExtendedCommander
 }
 
 func (t *pingDriver) Start() (errs []error)        { return }
@@ -76,11 +76,11 @@ func (t *pingDriver) Connection() gobot.Connection { return t.connection }
 
 func NewPingDriver(adaptor *loopbackAdaptor, name string, pin string) *pingDriver {
 	t := &pingDriver{
-		name:       name,
-		connection: adaptor,
-		pin:        pin,
-		Eventer:    gobot.NewEventer(),
-		Commander:  gobot.NewCommander(),
+		name:              name,
+		connection:        adaptor,
+		pin:               pin,
+		Eventer:           gobot.NewEventer(),
+		ExtendedCommander: gobot.NewCommander(),
 	}
 
 	t.AddEvent("ping")
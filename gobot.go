@@ -1,11 +1,18 @@
 package gobot
 
 import (
-	"log"
+	"context"
+	"fmt"
 	"os"
 	"os/signal"
+	"syscall"
+	"time"
 )
 
+// DefaultShutdownTimeout is the time Start waits for all robots to stop once it
+// has been interrupted.
+const DefaultShutdownTimeout = 30 * time.Second
+
 // JSONGobot is a JSON representation of a Gobot.
 type JSONGobot struct {
 	Robots   []*JSONRobot `json:"robots"`
@@ -31,11 +38,17 @@ func NewJSONGobot(gobot *Gobot) *JSONGobot {
 
 // Gobot is the main type of your Gobot application and contains a collection of
 // Robots, API commands and Events.
+//
+// When AutoStop is set, Start blocks until the process receives SIGINT or
+// SIGTERM and then stops every Robot, giving up on any which have not
+// stopped within ShutdownTimeout.
 type Gobot struct {
-	robots   *Robots
-	trap     func(chan os.Signal)
-	AutoStop bool
-	Commander
+	robots          *Robots
+	trap            func(chan os.Signal)
+	AutoStop        bool
+	ShutdownTimeout time.Duration
+	Logger          Logger
+	ExtendedCommander
 	Eventer
 }
 
@@ -44,11 +57,13 @@ func NewGobot() *Gobot {
 	return &Gobot{
 		robots: &Robots{},
 		trap: func(c chan os.Signal) {
-			signal.Notify(c, os.Interrupt)
+			signal.Notify(c, os.Interrupt, syscall.SIGTERM)
 		},
-		AutoStop:  true,
-		Commander: NewCommander(),
-		Eventer:   NewEventer(),
+		AutoStop:          true,
+		ShutdownTimeout:   DefaultShutdownTimeout,
+		Logger:            DefaultLogger(),
+		ExtendedCommander: NewCommander(),
+		Eventer:           NewEventer(),
 	}
 }
 
@@ -56,9 +71,17 @@ func NewGobot() *Gobot {
 // error, call Stop to ensure that all robots are returned to a sane, stopped
 // state.
 func (g *Gobot) Start() (errs []error) {
+	return g.StartContext(context.Background())
+}
+
+// StartContext calls the Start method on each robot in its collection of
+// robots. If AutoStop is set, it then waits for SIGINT, SIGTERM or for ctx to
+// be done before stopping the robots. On error, the robots are stopped
+// immediately to return them to a sane, stopped state.
+func (g *Gobot) StartContext(ctx context.Context) (errs []error) {
 	if rerrs := g.robots.Start(); len(rerrs) > 0 {
 		for _, err := range rerrs {
-			log.Println("Error:", err)
+			g.Logger.Error(err.Error())
 			errs = append(errs, err)
 		}
 	}
@@ -66,17 +89,28 @@ func (g *Gobot) Start() (errs []error) {
 	if g.AutoStop {
 		c := make(chan os.Signal, 1)
 		g.trap(c)
+		defer signal.Stop(c)
 		if len(errs) > 0 {
 			// there was an error during start, so we immediately pass the interrupt
 			// in order to disconnect the initialized robots, connections and devices
 			c <- os.Interrupt
 		}
 
-		// waiting for interrupt coming on the channel
-		<-c
+		// waiting for interrupt coming on the channel, or for ctx to be done
+		select {
+		case <-c:
+		case <-ctx.Done():
+		}
 
-		// Stop calls the Stop method on each robot in its collection of robots.
-		g.Stop()
+		stopCtx := context.Background()
+		if g.ShutdownTimeout > 0 {
+			var cancel context.CancelFunc
+			stopCtx, cancel = context.WithTimeout(stopCtx, g.ShutdownTimeout)
+			defer cancel()
+		}
+
+		// StopContext calls the Stop method on each robot in its collection of robots.
+		g.StopContext(stopCtx)
 	}
 
 	return errs
@@ -84,16 +118,23 @@ func (g *Gobot) Start() (errs []error) {
 
 // Stop calls the Stop method on each robot in its collection of robots.
 func (g *Gobot) Stop() (errs []error) {
-	if rerrs := g.robots.Stop(); len(rerrs) > 0 {
-		for _, err := range rerrs {
-			log.Println("Error:", err)
-			errs = append(errs, err)
-		}
+	for _, f := range g.StopContext(context.Background()).Failures {
+		errs = append(errs, fmt.Errorf("Robot %q: %v", f.Robot, f.Err))
 	}
-
 	return errs
 }
 
+// StopContext stops each robot in its collection of robots in parallel, and
+// returns a report of the devices and connections which failed to stop before
+// their halt timeout or before ctx was done.
+func (g *Gobot) StopContext(ctx context.Context) *ShutdownReport {
+	report := g.robots.StopContext(ctx)
+	for _, f := range report.Failures {
+		g.Logger.Error(f.Err.Error(), "robot", f.Robot, "timed_out", f.TimedOut)
+	}
+	return report
+}
+
 // Robots returns all robots associated with this Gobot.
 func (g *Gobot) Robots() *Robots {
 	return g.robots
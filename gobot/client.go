@@ -0,0 +1,358 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/codegangsta/cli"
+)
+
+// clientFlags are the flags of the commands which talk to a running Gobot
+// API.
+var clientFlags = []cli.Flag{
+	cli.StringFlag{Name: "url", Value: "http://localhost:3000", Usage: "URL of the Gobot API", EnvVar: "GOBOT_URL"},
+	cli.StringFlag{Name: "api-key", Usage: "API key sent in the X-API-Key header", EnvVar: "GOBOT_API_KEY"},
+	cli.StringFlag{Name: "token", Usage: "bearer token sent in the Authorization header", EnvVar: "GOBOT_TOKEN"},
+	cli.StringFlag{Name: "user", Usage: "username:password for basic authentication", EnvVar: "GOBOT_USER"},
+	cli.StringFlag{Name: "cacert", Usage: "PEM file of the CA which signed the API certificate"},
+	cli.StringFlag{Name: "cert", Usage: "PEM file of the client certificate, for mutual TLS"},
+	cli.StringFlag{Name: "key", Usage: "PEM file of the client key, for mutual TLS"},
+	cli.BoolFlag{Name: "insecure", Usage: "do not verify the API certificate"},
+	cli.DurationFlag{Name: "timeout", Value: 10 * time.Second, Usage: "timeout of each request"},
+	cli.BoolFlag{Name: "json", Usage: "print the JSON responses of the API"},
+}
+
+// client makes requests to a running Gobot API.
+type client struct {
+	url     string
+	header  http.Header
+	http    *http.Client
+	json    bool
+	timeout time.Duration
+}
+
+// newClient returns a client configured by the flags of c.
+func newClient(c *cli.Context) (*client, error) {
+	cl := &client{
+		url:     strings.TrimSuffix(c.String("url"), "/"),
+		header:  make(http.Header),
+		json:    c.Bool("json"),
+		timeout: c.Duration("timeout"),
+	}
+	if key := c.String("api-key"); key != "" {
+		cl.header.Set("X-API-Key", key)
+	}
+	if token := c.String("token"); token != "" {
+		cl.header.Set("Authorization", "Bearer "+token)
+	}
+	if user := c.String("user"); user != "" {
+		parts := strings.SplitN(user, ":", 2)
+		req := &http.Request{Header: make(http.Header)}
+		if len(parts) < 2 {
+			parts = append(parts, "")
+		}
+		req.SetBasicAuth(parts[0], parts[1])
+		cl.header.Set("Authorization", req.Header.Get("Authorization"))
+	}
+
+	config := &tls.Config{InsecureSkipVerify: c.Bool("insecure")}
+	if cacert := c.String("cacert"); cacert != "" {
+		pem, err := ioutil.ReadFile(cacert)
+		if err != nil {
+			return nil, err
+		}
+		config.RootCAs = x509.NewCertPool()
+		if !config.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, errors.New("No certificates found in " + cacert)
+		}
+	}
+	if c.String("cert") != "" || c.String("key") != "" {
+		cert, err := tls.LoadX509KeyPair(c.String("cert"), c.String("key"))
+		if err != nil {
+			return nil, err
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	cl.http = &http.Client{Transport: &http.Transport{TLSClientConfig: config, Proxy: http.ProxyFromEnvironment}}
+	return cl, nil
+}
+
+// do sends a request to the API and returns its response, which the caller
+// must close.
+func (cl *client) do(method string, path string, body interface{}, timeout time.Duration) (*http.Response, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewBuffer(data)
+	}
+	req, err := http.NewRequest(method, cl.url+path, reader)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range cl.header {
+		req.Header[k] = v
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	cl.http.Timeout = timeout
+	res, err := cl.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode == http.StatusUnauthorized || res.StatusCode == http.StatusForbidden {
+		res.Body.Close()
+		return nil, errors.New(res.Status)
+	}
+	return res, nil
+}
+
+// request sends a request to the API and decodes its JSON response. If the
+// response has an error, it is returned.
+func (cl *client) request(method string, path string, body interface{}) (map[string]interface{}, error) {
+	res, err := cl.do(method, path, body, cl.timeout)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	var response map[string]interface{}
+	if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("Invalid response from the API: %v", res.Status)
+	}
+	if message, ok := response["error"].(string); ok {
+		return response, errors.New(message)
+	}
+	return response, nil
+}
+
+// printJSON prints v as indented JSON.
+func printJSON(v interface{}) {
+	data, _ := json.MarshalIndent(v, "", "  ")
+	fmt.Println(string(data))
+}
+
+// fail prints err and exits.
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(1)
+}
+
+// names returns the "name" of each object in list, joined by commas.
+func names(list interface{}) string {
+	s := []string{}
+	items, _ := list.([]interface{})
+	for _, item := range items {
+		switch item := item.(type) {
+		case map[string]interface{}:
+			s = append(s, fmt.Sprint(item["name"]))
+		default:
+			s = append(s, fmt.Sprint(item))
+		}
+	}
+	sort.Strings(s)
+	return strings.Join(s, ",")
+}
+
+// parseParams parses command params given as key=value. Values which are
+// valid JSON, such as numbers, booleans and quoted strings, are decoded, and
+// any other value is a string.
+func parseParams(args []string) (map[string]interface{}, error) {
+	params := map[string]interface{}{}
+	for _, arg := range args {
+		parts := strings.SplitN(arg, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("Invalid param %q, expected key=value", arg)
+		}
+		var value interface{}
+		if err := json.Unmarshal([]byte(parts[1]), &value); err != nil {
+			value = parts[1]
+		}
+		params[parts[0]] = value
+	}
+	return params, nil
+}
+
+// Robots returns the command which lists the robots of a running API.
+func Robots() cli.Command {
+	return cli.Command{
+		Name:  "robots",
+		Usage: "List the robots of a running Gobot API",
+		Flags: clientFlags,
+		Action: func(c *cli.Context) {
+			cl, err := newClient(c)
+			if err != nil {
+				fail(err)
+			}
+			response, err := cl.request("GET", "/api/robots", nil)
+			if err != nil {
+				fail(err)
+			}
+			if cl.json {
+				printJSON(response["robots"])
+				return
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+			fmt.Fprintln(w, "NAME\tCONNECTIONS\tDEVICES\tCOMMANDS")
+			robots, _ := response["robots"].([]interface{})
+			for _, robot := range robots {
+				r, _ := robot.(map[string]interface{})
+				fmt.Fprintf(w, "%v\t%v\t%v\t%v\n", r["name"], names(r["connections"]), names(r["devices"]), names(r["commands"]))
+			}
+			w.Flush()
+		},
+	}
+}
+
+// Devices returns the command which lists the devices of a robot of a
+// running API.
+func Devices() cli.Command {
+	return cli.Command{
+		Name:  "devices",
+		Usage: "List the devices of a robot: gobot devices <robot>",
+		Flags: clientFlags,
+		Action: func(c *cli.Context) {
+			if len(c.Args()) != 1 {
+				fail(errors.New("Usage: gobot devices <robot>"))
+			}
+			cl, err := newClient(c)
+			if err != nil {
+				fail(err)
+			}
+			response, err := cl.request("GET", "/api/robots/"+url.PathEscape(c.Args().First())+"/devices", nil)
+			if err != nil {
+				fail(err)
+			}
+			if cl.json {
+				printJSON(response["devices"])
+				return
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+			fmt.Fprintln(w, "NAME\tDRIVER\tCONNECTION\tCOMMANDS")
+			devices, _ := response["devices"].([]interface{})
+			for _, device := range devices {
+				d, _ := device.(map[string]interface{})
+				fmt.Fprintf(w, "%v\t%v\t%v\t%v\n", d["name"], d["driver"], d["connection"], names(d["commands"]))
+			}
+			w.Flush()
+		},
+	}
+}
+
+// Exec returns the command which executes a command of a robot or device
+// of a running API.
+func Exec() cli.Command {
+	return cli.Command{
+		Name:  "exec",
+		Usage: "Execute a command: gobot exec <robot> <device> <command> [key=value...], with - as the device for robot commands",
+		Flags: clientFlags,
+		Action: func(c *cli.Context) {
+			if len(c.Args()) < 3 {
+				fail(errors.New("Usage: gobot exec <robot> <device> <command> [key=value...]"))
+			}
+			cl, err := newClient(c)
+			if err != nil {
+				fail(err)
+			}
+			params, err := parseParams(c.Args()[3:])
+			if err != nil {
+				fail(err)
+			}
+
+			path := "/api/robots/" + url.PathEscape(c.Args()[0])
+			if device := c.Args()[1]; device != "-" {
+				path += "/devices/" + url.PathEscape(device)
+			}
+			path += "/commands/" + url.PathEscape(c.Args()[2])
+
+			response, err := cl.request("POST", path, params)
+			if cl.json && response != nil {
+				printJSON(response)
+			}
+			if err != nil {
+				fail(err)
+			}
+			if cl.json {
+				return
+			}
+			if result, ok := response["result"].(string); ok {
+				fmt.Println(result)
+			} else {
+				printJSON(response["result"])
+			}
+		},
+	}
+}
+
+// Events returns the command which prints the events of a device of a
+// running API as they are published.
+func Events() cli.Command {
+	return cli.Command{
+		Name:  "events",
+		Usage: "Print the events of a device: gobot events <robot> <device> <event>",
+		Flags: clientFlags,
+		Action: func(c *cli.Context) {
+			if len(c.Args()) != 3 {
+				fail(errors.New("Usage: gobot events <robot> <device> <event>"))
+			}
+			cl, err := newClient(c)
+			if err != nil {
+				fail(err)
+			}
+			robot, device, event := c.Args()[0], c.Args()[1], c.Args()[2]
+			res, err := cl.do("GET", "/api/robots/"+url.PathEscape(robot)+"/devices/"+url.PathEscape(device)+
+				"/events/"+url.PathEscape(event), nil, 0)
+			if err != nil {
+				fail(err)
+			}
+			defer res.Body.Close()
+
+			scanner := bufio.NewScanner(res.Body)
+			for scanner.Scan() {
+				line := scanner.Text()
+				if !strings.HasPrefix(line, "data: ") {
+					var response map[string]interface{}
+					if json.Unmarshal([]byte(line), &response) == nil && response["error"] != nil {
+						fail(fmt.Errorf("%v", response["error"]))
+					}
+					continue
+				}
+				data := strings.TrimPrefix(line, "data: ")
+				if cl.json {
+					line, _ := json.Marshal(struct {
+						Robot  string          `json:"robot"`
+						Device string          `json:"device"`
+						Event  string          `json:"event"`
+						Data   json.RawMessage `json:"data"`
+					}{robot, device, event, json.RawMessage(data)})
+					fmt.Println(string(line))
+				} else {
+					fmt.Println(data)
+				}
+			}
+			if err := scanner.Err(); err != nil {
+				fail(err)
+			}
+		},
+	}
+}
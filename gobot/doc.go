@@ -1,5 +1,6 @@
 /*
-CLI tool for generating new Gobot projects.
+CLI tool for generating new Gobot projects, and for driving the robots of a
+running Gobot API.
 
 	NAME:
 		 gobot - Command Line Utility for Gobot
@@ -12,11 +13,24 @@ CLI tool for generating new Gobot projects.
 
 	COMMANDS:
 		 generate     Generate new Gobot skeleton project
+		 robots       List the robots of a running Gobot API
+		 devices      List the devices of a robot: gobot devices <robot>
+		 exec         Execute a command: gobot exec <robot> <device> <command> [key=value...]
+		 events       Print the events of a device: gobot events <robot> <device> <event>
 		 help, h      Shows a list of commands or help for one command
 
 	GLOBAL OPTIONS:
 		 --help, -h           show help
 		 --version, -v        print the version
 
+The robots, devices, exec and events commands talk to the API at --url, or
+$GOBOT_URL, which defaults to http://localhost:3000. They authenticate with
+--api-key, --token or --user, verify TLS with --cacert, present a client
+certificate with --cert and --key, and print the JSON responses of the API
+with --json:
+
+	gobot exec --url https://pi1.local:3000 --api-key secret sphero sphero Roll speed=100 heading=90
+	gobot events --json sphero sphero collision
+
 */
 package main
@@ -206,7 +206,7 @@ type {{.UpperName}}Driver struct {
 	interval time.Duration
 	halt chan bool
 	gobot.Eventer
-	gobot.Commander
+	gobot.ExtendedCommander
 }
 
 func New{{.UpperName}}Driver(a *{{.UpperName}}Adaptor, name string) *{{.UpperName}}Driver {
@@ -216,7 +216,7 @@ func New{{.UpperName}}Driver(a *{{.UpperName}}Adaptor, name string) *{{.UpperNam
 		interval: 500*time.Millisecond,
 		halt: make(chan bool, 0),
     Eventer:    gobot.NewEventer(),
-    Commander:  gobot.NewCommander(),
+    ExtendedCommander: gobot.NewCommander(),
 	}
 
 	{{.FirstLetter}}.AddEvent(Hello)
@@ -16,6 +16,10 @@ func main() {
 	app.Usage = "Command Line Utility for Gobot"
 	app.Commands = []cli.Command{
 		Generate(),
+		Robots(),
+		Devices(),
+		Exec(),
+		Events(),
 	}
 	app.Run(os.Args)
 }
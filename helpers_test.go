@@ -18,7 +18,7 @@ type testDriver struct {
 	name       string
 	pin        string
 	connection Connection
-	Commander
+	ExtendedCommander
 }
 
 var testDriverStart = func() (errs []error) { return }
@@ -32,10 +32,10 @@ func (t *testDriver) Connection() Connection { return t.connection }
 
 func newTestDriver(adaptor *testAdaptor, name string, pin string) *testDriver {
 	t := &testDriver{
-		name:       name,
-		connection: adaptor,
-		pin:        pin,
-		Commander:  NewCommander(),
+		name:              name,
+		connection:        adaptor,
+		pin:               pin,
+		ExtendedCommander: NewCommander(),
 	}
 
 	t.AddCommand("DriverCommand", func(params map[string]interface{}) interface{} { return nil })
@@ -0,0 +1,174 @@
+package gobot
+
+import "fmt"
+
+// running returns true if the Robot has been started and not yet stopped.
+func (r *Robot) running() bool {
+	return r.State() != StateHalted
+}
+
+// AttachDevice adds a Device to a Robot. If the Robot is running, the Device
+// is started as well, and is not added if it fails to start.
+func (r *Robot) AttachDevice(d Device) (errs []error) {
+	if d.Connection() != nil && r.Connection(d.Connection().Name()) == nil {
+		return []error{fmt.Errorf("Device %q: No Connection found with the name %q", d.Name(), d.Connection().Name())}
+	}
+	if !r.addDevice(d, true) {
+		return []error{fmt.Errorf("Device %q already exists", d.Name())}
+	}
+
+	r.Logger.Info("Attaching device", deviceKeyvals(d)...)
+	if r.running() {
+		if errs = r.StartDevice(d.Name()); len(errs) > 0 {
+			r.removeDevice(d.Name())
+			r.forgetDevice(d)
+			r.refreshRunningState()
+		}
+	}
+	return
+}
+
+// DetachDevice halts a Device, if the Robot is running, and removes it from
+// the Robot given a name.
+func (r *Robot) DetachDevice(name string) (errs []error) {
+	d := r.Device(name)
+	if d == nil {
+		return []error{fmt.Errorf("No Device found with the name %v", name)}
+	}
+
+	r.Logger.Info("Detaching device", deviceKeyvals(d)...)
+	if r.running() {
+		errs = r.HaltDevice(name)
+	}
+	r.removeDevice(name)
+	r.forgetDevice(d)
+	r.refreshRunningState()
+	return
+}
+
+// StartDevice starts a Device of a running Robot given a name.
+func (r *Robot) StartDevice(name string) (errs []error) {
+	d := r.Device(name)
+	if d == nil {
+		return []error{fmt.Errorf("No Device found with the name %v", name)}
+	}
+
+	r.Logger.Info("Starting device", deviceKeyvals(d)...)
+	r.setDeviceState(d, StateStarting, nil)
+	if errs = d.Start(); len(errs) > 0 {
+		for i, err := range errs {
+			errs[i] = fmt.Errorf("Device %q: %v", name, err)
+		}
+		r.setDeviceState(d, StateHalted, errs[0])
+	} else {
+		r.setDeviceState(d, StateRunning, nil)
+	}
+	r.refreshRunningState()
+	return
+}
+
+// HaltDevice halts a Device of a running Robot given a name.
+func (r *Robot) HaltDevice(name string) (errs []error) {
+	d := r.Device(name)
+	if d == nil {
+		return []error{fmt.Errorf("No Device found with the name %v", name)}
+	}
+
+	r.Logger.Info("Halting device", deviceKeyvals(d)...)
+	if errs = d.Halt(); len(errs) > 0 {
+		for i, err := range errs {
+			errs[i] = fmt.Errorf("Device %q: %v", name, err)
+		}
+	}
+	r.setDeviceState(d, StateHalted, nil)
+	r.refreshRunningState()
+	return
+}
+
+// AttachConnection adds a Connection to a Robot. If the Robot is running, the
+// Connection is connected as well, and is not added if it fails to connect.
+func (r *Robot) AttachConnection(c Connection) (errs []error) {
+	if !r.addConnection(c, true) {
+		return []error{fmt.Errorf("Connection %q already exists", c.Name())}
+	}
+
+	r.Logger.Info("Attaching connection", connectionKeyvals(c)...)
+	if r.running() {
+		if errs = r.StartConnection(c.Name()); len(errs) > 0 {
+			r.removeConnection(c.Name())
+			r.forgetConnection(c)
+			r.refreshRunningState()
+		}
+	}
+	return
+}
+
+// DetachConnection finalizes a Connection, if the Robot is running, and
+// removes it from the Robot given a name. A Connection which is still used by
+// a Device can not be detached.
+func (r *Robot) DetachConnection(name string) (errs []error) {
+	c := r.Connection(name)
+	if c == nil {
+		return []error{fmt.Errorf("No Connection found with the name %v", name)}
+	}
+	for _, d := range *r.Devices() {
+		if d.Connection() == c {
+			return []error{fmt.Errorf("Connection %q is used by device %q", name, d.Name())}
+		}
+	}
+
+	r.Logger.Info("Detaching connection", connectionKeyvals(c)...)
+	if r.running() {
+		errs = r.HaltConnection(name)
+	}
+	r.removeConnection(name)
+	r.forgetConnection(c)
+	r.refreshRunningState()
+	return
+}
+
+// StartConnection connects a Connection of a running Robot given a name.
+func (r *Robot) StartConnection(name string) (errs []error) {
+	c := r.Connection(name)
+	if c == nil {
+		return []error{fmt.Errorf("No Connection found with the name %v", name)}
+	}
+
+	r.Logger.Info("Starting connection", connectionKeyvals(c)...)
+	r.setConnectionState(c, StateStarting, nil)
+	if errs = c.Connect(); len(errs) > 0 {
+		for i, err := range errs {
+			errs[i] = fmt.Errorf("Connection %q: %v", name, err)
+		}
+		r.setConnectionState(c, StateHalted, errs[0])
+	} else {
+		r.setConnectionState(c, StateRunning, nil)
+	}
+	r.refreshRunningState()
+	return
+}
+
+// HaltConnection finalizes a Connection of a running Robot given a name.
+func (r *Robot) HaltConnection(name string) (errs []error) {
+	c := r.Connection(name)
+	if c == nil {
+		return []error{fmt.Errorf("No Connection found with the name %v", name)}
+	}
+
+	r.Logger.Info("Halting connection", connectionKeyvals(c)...)
+	if errs = c.Finalize(); len(errs) > 0 {
+		for i, err := range errs {
+			errs[i] = fmt.Errorf("Connection %q: %v", name, err)
+		}
+	}
+	r.setConnectionState(c, StateHalted, nil)
+	r.refreshRunningState()
+	return
+}
+
+// refreshRunningState refreshes the Robot state, unless it is halted.
+func (r *Robot) refreshRunningState() {
+	if r.running() {
+		r.refreshState()
+	}
+}
@@ -0,0 +1,146 @@
+package gobot
+
+import (
+	"errors"
+	"log"
+	"sync"
+	"testing"
+
+	"github.com/hybridgroup/gobot/gobottest"
+)
+
+func TestRobotAttachDevice(t *testing.T) {
+	log.SetOutput(&NullReadWriteCloser{})
+	r := newTestRobot("Robot1")
+
+	// before Start the device is only added
+	d := newTestDriver(r.Connection("Connection1").(*testAdaptor), "Device4", "4")
+	gobottest.Assert(t, len(r.AttachDevice(d)), 0)
+	gobottest.Assert(t, r.Devices().Len(), 4)
+	gobottest.Assert(t, r.DeviceState("Device4"), StateHalted)
+
+	gobottest.Assert(t, r.AttachDevice(d)[0].Error(), "Device \"Device4\" already exists")
+
+	r.Start()
+	defer r.Stop()
+
+	d = newTestDriver(r.Connection("Connection1").(*testAdaptor), "Device5", "5")
+	gobottest.Assert(t, len(r.AttachDevice(d)), 0)
+	gobottest.Assert(t, r.DeviceState("Device5"), StateRunning)
+	gobottest.Assert(t, r.State(), StateRunning)
+
+	d = newTestDriver(newTestAdaptor("Unknown", "/dev/null"), "Device6", "6")
+	gobottest.Assert(t, r.AttachDevice(d)[0].Error(), "Device \"Device6\": No Connection found with the name \"Unknown\"")
+}
+
+func TestRobotAttachConcurrently(t *testing.T) {
+	log.SetOutput(&NullReadWriteCloser{})
+	r := newTestRobot("Robot1")
+	a := r.Connection("Connection1").(*testAdaptor)
+
+	var wg sync.WaitGroup
+	var mutex sync.Mutex
+	attached := 0
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			derrs := r.AttachDevice(newTestDriver(a, "Device4", "4"))
+			cerrs := r.AttachConnection(newTestAdaptor("Connection4", "/dev/null"))
+			mutex.Lock()
+			defer mutex.Unlock()
+			attached += 2 - len(derrs) - len(cerrs)
+		}()
+	}
+	wg.Wait()
+	gobottest.Assert(t, attached, 2)
+	gobottest.Assert(t, r.Devices().Len(), 4)
+	gobottest.Assert(t, r.Connections().Len(), 4)
+}
+
+func TestRobotAttachDeviceStartError(t *testing.T) {
+	log.SetOutput(&NullReadWriteCloser{})
+	r := newTestRobot("Robot1")
+	r.Start()
+	defer r.Stop()
+
+	testDriverStart = func() (errs []error) {
+		return []error{errors.New("start error")}
+	}
+	defer func() { testDriverStart = func() (errs []error) { return } }()
+
+	d := newTestDriver(r.Connection("Connection1").(*testAdaptor), "Device4", "4")
+	gobottest.Assert(t, r.AttachDevice(d)[0].Error(), "Device \"Device4\": start error")
+	gobottest.Assert(t, r.Device("Device4"), (Device)(nil))
+	gobottest.Assert(t, r.State(), StateRunning)
+}
+
+func TestRobotDetachDevice(t *testing.T) {
+	log.SetOutput(&NullReadWriteCloser{})
+	r := newTestRobot("Robot1")
+	r.Start()
+	defer r.Stop()
+
+	testDriverHalt = func() (errs []error) { return }
+	gobottest.Assert(t, len(r.DetachDevice("Device1")), 0)
+	gobottest.Assert(t, r.Device("Device1"), (Device)(nil))
+	gobottest.Assert(t, r.Devices().Len(), 2)
+
+	gobottest.Assert(t, r.DetachDevice("Device1")[0].Error(), "No Device found with the name Device1")
+}
+
+func TestRobotStartHaltDevice(t *testing.T) {
+	log.SetOutput(&NullReadWriteCloser{})
+	r := newTestRobot("Robot1")
+	r.Start()
+	defer r.Stop()
+
+	testDriverHalt = func() (errs []error) { return }
+	gobottest.Assert(t, len(r.HaltDevice("Device1")), 0)
+	gobottest.Assert(t, r.DeviceState("Device1"), StateHalted)
+	gobottest.Assert(t, r.State(), StateDegraded)
+
+	gobottest.Assert(t, len(r.StartDevice("Device1")), 0)
+	gobottest.Assert(t, r.DeviceState("Device1"), StateRunning)
+	gobottest.Assert(t, r.State(), StateRunning)
+}
+
+func TestRobotAttachDetachConnection(t *testing.T) {
+	log.SetOutput(&NullReadWriteCloser{})
+	r := newTestRobot("Robot1")
+	r.Start()
+	defer r.Stop()
+
+	c := newTestAdaptor("Connection4", "/dev/null")
+	gobottest.Assert(t, len(r.AttachConnection(c)), 0)
+	gobottest.Assert(t, r.ConnectionState("Connection4"), StateRunning)
+	gobottest.Assert(t, r.AttachConnection(c)[0].Error(), "Connection \"Connection4\" already exists")
+
+	d := newTestDriver(c, "Device4", "4")
+	gobottest.Assert(t, len(r.AttachDevice(d)), 0)
+	gobottest.Assert(t, r.DetachConnection("Connection4")[0].Error(), "Connection \"Connection4\" is used by device \"Device4\"")
+
+	testDriverHalt = func() (errs []error) { return }
+	testAdaptorFinalize = func() (errs []error) { return }
+	gobottest.Assert(t, len(r.DetachDevice("Device4")), 0)
+	gobottest.Assert(t, len(r.DetachConnection("Connection4")), 0)
+	gobottest.Assert(t, r.Connection("Connection4"), (Connection)(nil))
+	gobottest.Assert(t, r.State(), StateRunning)
+}
+
+func TestRobotStartHaltConnection(t *testing.T) {
+	log.SetOutput(&NullReadWriteCloser{})
+	r := newTestRobot("Robot1")
+	r.Start()
+	defer r.Stop()
+
+	testAdaptorFinalize = func() (errs []error) { return }
+	gobottest.Assert(t, len(r.HaltConnection("Connection1")), 0)
+	gobottest.Assert(t, r.ConnectionState("Connection1"), StateHalted)
+	gobottest.Assert(t, r.State(), StateDegraded)
+
+	gobottest.Assert(t, len(r.StartConnection("Connection1")), 0)
+	gobottest.Assert(t, r.State(), StateRunning)
+
+	gobottest.Assert(t, r.StartConnection("Connection9")[0].Error(), "No Connection found with the name Connection9")
+}
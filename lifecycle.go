@@ -0,0 +1,164 @@
+package gobot
+
+import "sync"
+
+// State is the lifecycle state of a Robot, Connection or Device.
+type State string
+
+const (
+	// StateHalted is the state before Start and after Stop, or after a
+	// Connection could not be recovered.
+	StateHalted State = "halted"
+	// StateStarting is the state while connections and devices are started.
+	StateStarting State = "starting"
+	// StateRunning is the state once everything has started successfully.
+	StateRunning State = "running"
+	// StateDegraded is the state when a Connection has failed a health check,
+	// or a Robot has a Connection or Device which is not running.
+	StateDegraded State = "degraded"
+	// StateReconnecting is the state while a Connection is being re-established.
+	StateReconnecting State = "reconnecting"
+)
+
+const (
+	// RobotStateEvent is published on a Robot's Eventer when the Robot changes state.
+	RobotStateEvent = "robot.state"
+	// ConnectionStateEvent is published on a Robot's Eventer when one of its
+	// Connections changes state.
+	ConnectionStateEvent = "connection.state"
+	// DeviceStateEvent is published on a Robot's Eventer when one of its
+	// Devices changes state.
+	DeviceStateEvent = "device.state"
+)
+
+// Pinger is the interface that describes an adaptor which can check that
+// its connection is still alive.
+type Pinger interface {
+	Ping() error
+}
+
+// StateChange is the data of a state Event.
+type StateChange struct {
+	Name  string `json:"name"`
+	From  State  `json:"from"`
+	To    State  `json:"to"`
+	Error error  `json:"-"`
+}
+
+// lifecycle tracks the state of a Robot and of its Connections and Devices,
+// and publishes every change to the Robot's Eventer.
+type lifecycle struct {
+	mutex       sync.RWMutex
+	state       State
+	connections map[string]State
+	devices     map[string]State
+}
+
+func newLifecycle() *lifecycle {
+	return &lifecycle{
+		state:       StateHalted,
+		connections: make(map[string]State),
+		devices:     make(map[string]State),
+	}
+}
+
+// State returns the current state of the Robot.
+func (r *Robot) State() State {
+	r.lifecycle.mutex.RLock()
+	defer r.lifecycle.mutex.RUnlock()
+	return r.lifecycle.state
+}
+
+// ConnectionState returns the current state of a Connection given its name.
+func (r *Robot) ConnectionState(name string) State {
+	r.lifecycle.mutex.RLock()
+	defer r.lifecycle.mutex.RUnlock()
+	if state, ok := r.lifecycle.connections[name]; ok {
+		return state
+	}
+	return StateHalted
+}
+
+// DeviceState returns the current state of a Device given its name.
+func (r *Robot) DeviceState(name string) State {
+	r.lifecycle.mutex.RLock()
+	defer r.lifecycle.mutex.RUnlock()
+	if state, ok := r.lifecycle.devices[name]; ok {
+		return state
+	}
+	return StateHalted
+}
+
+func (r *Robot) setState(to State, err error) {
+	r.lifecycle.mutex.Lock()
+	from := r.lifecycle.state
+	r.lifecycle.state = to
+	r.lifecycle.mutex.Unlock()
+
+	if from != to {
+		r.Publish(RobotStateEvent, StateChange{Name: r.Name, From: from, To: to, Error: err})
+	}
+}
+
+func (r *Robot) setConnectionState(c Connection, to State, err error) {
+	r.lifecycle.mutex.Lock()
+	from, ok := r.lifecycle.connections[c.Name()]
+	if !ok {
+		from = StateHalted
+	}
+	r.lifecycle.connections[c.Name()] = to
+	r.lifecycle.mutex.Unlock()
+
+	if from != to {
+		r.Publish(ConnectionStateEvent, StateChange{Name: c.Name(), From: from, To: to, Error: err})
+	}
+}
+
+func (r *Robot) setDeviceState(d Device, to State, err error) {
+	r.lifecycle.mutex.Lock()
+	from, ok := r.lifecycle.devices[d.Name()]
+	if !ok {
+		from = StateHalted
+	}
+	r.lifecycle.devices[d.Name()] = to
+	r.lifecycle.mutex.Unlock()
+
+	if from != to {
+		r.Publish(DeviceStateEvent, StateChange{Name: d.Name(), From: from, To: to, Error: err})
+	}
+}
+
+// refreshState sets the Robot state to running if all of its Connections and
+// Devices are running, or degraded otherwise.
+func (r *Robot) refreshState() {
+	r.lifecycle.mutex.RLock()
+	state := StateRunning
+	for _, s := range r.lifecycle.connections {
+		if s != StateRunning {
+			state = StateDegraded
+		}
+	}
+	for _, s := range r.lifecycle.devices {
+		if s != StateRunning {
+			state = StateDegraded
+		}
+	}
+	r.lifecycle.mutex.RUnlock()
+
+	r.setState(state, nil)
+}
+
+// forgetDevice stops tracking the state of a Device which has been removed.
+func (r *Robot) forgetDevice(d Device) {
+	r.lifecycle.mutex.Lock()
+	defer r.lifecycle.mutex.Unlock()
+	delete(r.lifecycle.devices, d.Name())
+}
+
+// forgetConnection stops tracking the state of a Connection which has been
+// removed.
+func (r *Robot) forgetConnection(c Connection) {
+	r.lifecycle.mutex.Lock()
+	defer r.lifecycle.mutex.Unlock()
+	delete(r.lifecycle.connections, c.Name())
+}
@@ -0,0 +1,92 @@
+package gobot
+
+import (
+	"errors"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/hybridgroup/gobot/gobottest"
+)
+
+func TestRobotLifecycle(t *testing.T) {
+	log.SetOutput(&NullReadWriteCloser{})
+	r := newTestRobot("Robot1")
+
+	gobottest.Assert(t, r.State(), StateHalted)
+	gobottest.Assert(t, r.ConnectionState("Connection1"), StateHalted)
+	gobottest.Assert(t, r.DeviceState("Device1"), StateHalted)
+
+	states := r.Subscribe()
+
+	gobottest.Assert(t, len(r.Start()), 0)
+	gobottest.Assert(t, r.State(), StateRunning)
+	gobottest.Assert(t, r.ConnectionState("Connection1"), StateRunning)
+	gobottest.Assert(t, r.DeviceState("Device1"), StateRunning)
+
+	evt := <-states
+	gobottest.Assert(t, evt.Name, RobotStateEvent)
+	gobottest.Assert(t, evt.Data.(StateChange).To, StateStarting)
+
+	r.Stop()
+	gobottest.Assert(t, r.State(), StateHalted)
+	gobottest.Assert(t, r.ConnectionState("Connection1"), StateHalted)
+	gobottest.Assert(t, r.DeviceState("Device1"), StateHalted)
+}
+
+func TestRobotLifecycleStartError(t *testing.T) {
+	log.SetOutput(&NullReadWriteCloser{})
+	r := newTestRobot("Robot1")
+
+	testAdaptorConnect = func() (errs []error) {
+		return []error{errors.New("connect error")}
+	}
+	defer func() { testAdaptorConnect = func() (errs []error) { return } }()
+
+	gobottest.Assert(t, len(r.Start()), 1)
+	gobottest.Assert(t, r.State(), StateHalted)
+	gobottest.Assert(t, r.ConnectionState("Connection1"), StateHalted)
+}
+
+func TestRobotRefreshState(t *testing.T) {
+	log.SetOutput(&NullReadWriteCloser{})
+	r := newTestRobot("Robot1")
+	r.Start()
+
+	r.setDeviceState(r.Device("Device1"), StateDegraded, nil)
+	r.refreshState()
+	gobottest.Assert(t, r.State(), StateDegraded)
+
+	r.setDeviceState(r.Device("Device1"), StateRunning, nil)
+	r.refreshState()
+	gobottest.Assert(t, r.State(), StateRunning)
+}
+
+func TestStateEvents(t *testing.T) {
+	log.SetOutput(&NullReadWriteCloser{})
+	r := newTestRobot("Robot1")
+
+	sem := make(chan StateChange, 10)
+	r.On("*.state", func(data interface{}) {
+		sem <- data.(StateChange)
+	})
+
+	r.setConnectionState(r.Connection("Connection1"), StateRunning, nil)
+
+	select {
+	case change := <-sem:
+		gobottest.Assert(t, change.Name, "Connection1")
+		gobottest.Assert(t, change.From, StateHalted)
+		gobottest.Assert(t, change.To, StateRunning)
+	case <-time.After(10 * time.Millisecond):
+		t.Errorf("state event was not published")
+	}
+
+	// no event when the state does not change
+	r.setConnectionState(r.Connection("Connection1"), StateRunning, nil)
+	select {
+	case <-sem:
+		t.Errorf("state event was published without a change")
+	case <-time.After(10 * time.Millisecond):
+	}
+}
@@ -0,0 +1,131 @@
+package gobot
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+)
+
+// Level is the severity of a log message.
+type Level int
+
+const (
+	// LevelDebug is for detailed output which is only useful while debugging
+	LevelDebug Level = iota
+	// LevelInfo is for the startup and shutdown progress of robots
+	LevelInfo
+	// LevelWarn is for problems which do not stop a robot from working
+	LevelWarn
+	// LevelError is for problems which do
+	LevelError
+	// LevelOff disables logging
+	LevelOff
+)
+
+var levelNames = map[Level]string{
+	LevelDebug: "DEBUG",
+	LevelInfo:  "INFO",
+	LevelWarn:  "WARN",
+	LevelError: "ERROR",
+	LevelOff:   "OFF",
+}
+
+// String returns the name of the level.
+func (l Level) String() string {
+	if name, ok := levelNames[l]; ok {
+		return name
+	}
+	return fmt.Sprintf("Level(%d)", int(l))
+}
+
+// Logger is the interface which describes the behaviour of a structured,
+// levelled logger. Each method accepts a message followed by alternating
+// keys and values, for example:
+//
+//	logger.Info("Starting device", "device", "led", "pin", "13")
+//
+// Gobot uses the keys "robot", "connection", "device" and "pin" for the
+// names of the entities a message is about.
+type Logger interface {
+	Debug(msg string, keyvals ...interface{})
+	Info(msg string, keyvals ...interface{})
+	Warn(msg string, keyvals ...interface{})
+	Error(msg string, keyvals ...interface{})
+	// With returns a Logger which adds keyvals to every message.
+	With(keyvals ...interface{}) Logger
+}
+
+// LoggerSetter is the interface which describes a Driver or Adaptor which
+// accepts a Logger from the Robot it is added to.
+type LoggerSetter interface {
+	SetLogger(Logger)
+}
+
+// NewLogger returns a Logger which writes messages of at least level to the
+// standard log package, with their keys and values appended as key=value.
+func NewLogger(level Level) Logger {
+	return &stdLogger{level: level}
+}
+
+type stdLogger struct {
+	level   Level
+	keyvals []interface{}
+}
+
+func (s *stdLogger) Debug(msg string, keyvals ...interface{}) { s.log(LevelDebug, msg, keyvals) }
+func (s *stdLogger) Info(msg string, keyvals ...interface{})  { s.log(LevelInfo, msg, keyvals) }
+func (s *stdLogger) Warn(msg string, keyvals ...interface{})  { s.log(LevelWarn, msg, keyvals) }
+func (s *stdLogger) Error(msg string, keyvals ...interface{}) { s.log(LevelError, msg, keyvals) }
+
+func (s *stdLogger) With(keyvals ...interface{}) Logger {
+	return &stdLogger{
+		level:   s.level,
+		keyvals: append(append([]interface{}{}, s.keyvals...), keyvals...),
+	}
+}
+
+func (s *stdLogger) log(level Level, msg string, keyvals []interface{}) {
+	if level < s.level || s.level == LevelOff {
+		return
+	}
+	log.Println(formatLog(level, msg, append(append([]interface{}{}, s.keyvals...), keyvals...)))
+}
+
+// formatLog formats a message as "[LEVEL] msg key=value ...". A key without
+// a value is paired with "MISSING".
+func formatLog(level Level, msg string, keyvals []interface{}) string {
+	parts := []string{"[" + level.String() + "]", msg}
+	for i := 0; i < len(keyvals); i += 2 {
+		var v interface{} = "MISSING"
+		if i+1 < len(keyvals) {
+			v = keyvals[i+1]
+		}
+		parts = append(parts, fmt.Sprintf("%v=%v", keyvals[i], v))
+	}
+	return strings.Join(parts, " ")
+}
+
+var (
+	defaultLoggerMutex sync.RWMutex
+	defaultLogger      = NewLogger(LevelInfo)
+)
+
+// DefaultLogger returns the Logger used by Gobots and Robots which are not
+// given one, and by drivers which are not added to a Robot.
+func DefaultLogger() Logger {
+	defaultLoggerMutex.RLock()
+	defer defaultLoggerMutex.RUnlock()
+	return defaultLogger
+}
+
+// SetDefaultLogger sets the Logger returned by DefaultLogger. It affects
+// Gobots, Robots and drivers created after it is called, so it should be
+// called before any of them are created, for example:
+//
+//	gobot.SetDefaultLogger(gobot.NewLogger(gobot.LevelWarn))
+func SetDefaultLogger(l Logger) {
+	defaultLoggerMutex.Lock()
+	defer defaultLoggerMutex.Unlock()
+	defaultLogger = l
+}
@@ -0,0 +1,120 @@
+package gobot
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/hybridgroup/gobot/gobottest"
+)
+
+// recordingLogger records every message logged through it or through a
+// Logger returned by With.
+type recordingLogger struct {
+	mutex    *sync.Mutex
+	keyvals  []interface{}
+	messages *[]string
+}
+
+func newRecordingLogger() *recordingLogger {
+	return &recordingLogger{mutex: &sync.Mutex{}, messages: &[]string{}}
+}
+
+func (l *recordingLogger) log(level Level, msg string, keyvals []interface{}) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	*l.messages = append(*l.messages, formatLog(level, msg, append(append([]interface{}{}, l.keyvals...), keyvals...)))
+}
+
+func (l *recordingLogger) Debug(msg string, keyvals ...interface{}) { l.log(LevelDebug, msg, keyvals) }
+func (l *recordingLogger) Info(msg string, keyvals ...interface{})  { l.log(LevelInfo, msg, keyvals) }
+func (l *recordingLogger) Warn(msg string, keyvals ...interface{})  { l.log(LevelWarn, msg, keyvals) }
+func (l *recordingLogger) Error(msg string, keyvals ...interface{}) { l.log(LevelError, msg, keyvals) }
+func (l *recordingLogger) With(keyvals ...interface{}) Logger {
+	return &recordingLogger{
+		mutex:    l.mutex,
+		keyvals:  append(append([]interface{}{}, l.keyvals...), keyvals...),
+		messages: l.messages,
+	}
+}
+
+func (l *recordingLogger) Messages() []string {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return append([]string{}, *l.messages...)
+}
+
+type loggingDriver struct {
+	*testDriver
+	logger Logger
+}
+
+func (l *loggingDriver) SetLogger(logger Logger) { l.logger = logger }
+
+func TestLevelString(t *testing.T) {
+	gobottest.Assert(t, LevelDebug.String(), "DEBUG")
+	gobottest.Assert(t, LevelError.String(), "ERROR")
+	gobottest.Assert(t, Level(42).String(), "Level(42)")
+}
+
+func TestFormatLog(t *testing.T) {
+	gobottest.Assert(t, formatLog(LevelInfo, "Starting device", []interface{}{"device", "led", "pin", "13"}),
+		"[INFO] Starting device device=led pin=13")
+	gobottest.Assert(t, formatLog(LevelWarn, "Odd", []interface{}{"key"}), "[WARN] Odd key=MISSING")
+}
+
+func TestNewLogger(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	l := NewLogger(LevelInfo).With("robot", "Robot1")
+	l.Debug("hidden")
+	l.Info("Starting Robot")
+	l.Error("Failed", "device", "led")
+
+	out := buf.String()
+	gobottest.Assert(t, strings.Contains(out, "hidden"), false)
+	gobottest.Assert(t, strings.Contains(out, "[INFO] Starting Robot robot=Robot1"), true)
+	gobottest.Assert(t, strings.Contains(out, "[ERROR] Failed robot=Robot1 device=led"), true)
+
+	buf.Reset()
+	NewLogger(LevelOff).Error("silenced")
+	gobottest.Assert(t, buf.Len(), 0)
+}
+
+func TestSetDefaultLogger(t *testing.T) {
+	l := newRecordingLogger()
+	original := DefaultLogger()
+	SetDefaultLogger(l)
+	defer SetDefaultLogger(original)
+
+	r := NewRobot("Robot1")
+	gobottest.Assert(t, r.Logger.(*recordingLogger).messages, l.messages)
+	gobottest.Assert(t, l.Messages()[0], "[INFO] Initializing Robot robot=Robot1")
+}
+
+func TestRobotLogger(t *testing.T) {
+	l := newRecordingLogger()
+	adaptor := newTestAdaptor("Connection1", "/dev/null")
+	driver := &loggingDriver{testDriver: newTestDriver(adaptor, "Device1", "13")}
+	r := NewRobot("Robot1",
+		[]Connection{adaptor},
+		[]Device{driver},
+		Logger(l),
+	)
+
+	driver.logger.Info("Hello")
+	gobottest.Assert(t, l.Messages()[len(l.Messages())-1], "[INFO] Hello robot=Robot1 device=Device1 pin=13")
+
+	r.Start()
+	r.Stop()
+
+	messages := strings.Join(l.Messages(), "\n")
+	gobottest.Assert(t, strings.Contains(messages, "[INFO] Starting connection robot=Robot1 connection=Connection1 port=/dev/null"), true)
+	gobottest.Assert(t, strings.Contains(messages, "[INFO] Starting device robot=Robot1 device=Device1 pin=13"), true)
+	gobottest.Assert(t, strings.Contains(messages, "[INFO] Stopping Robot robot=Robot1"), true)
+}
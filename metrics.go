@@ -0,0 +1,455 @@
+package gobot
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Labels are the names and values which identify one series of a metric, for
+// example Labels{"robot": "Eve", "device": "led"}.
+type Labels map[string]string
+
+// DefaultBuckets are the upper bounds, in seconds, of the Histogram buckets
+// used for command latency.
+var DefaultBuckets = []float64{.001, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Counter is a metric which only increases.
+type Counter struct {
+	bits uint64
+}
+
+// Inc adds one to the Counter.
+func (c *Counter) Inc() { c.Add(1) }
+
+// Add adds v to the Counter. Negative values are ignored.
+func (c *Counter) Add(v float64) {
+	if v > 0 {
+		addFloat(&c.bits, v)
+	}
+}
+
+// Value returns the current value of the Counter.
+func (c *Counter) Value() float64 {
+	return math.Float64frombits(atomic.LoadUint64(&c.bits))
+}
+
+// Gauge is a metric which can go up and down, such as a sensor reading.
+type Gauge struct {
+	bits uint64
+}
+
+// Set sets the Gauge to v.
+func (g *Gauge) Set(v float64) {
+	atomic.StoreUint64(&g.bits, math.Float64bits(v))
+}
+
+// Add adds v, which may be negative, to the Gauge.
+func (g *Gauge) Add(v float64) { addFloat(&g.bits, v) }
+
+// Value returns the current value of the Gauge.
+func (g *Gauge) Value() float64 {
+	return math.Float64frombits(atomic.LoadUint64(&g.bits))
+}
+
+// addFloat atomically adds v to the float64 stored as bits.
+func addFloat(bits *uint64, v float64) {
+	for {
+		old := atomic.LoadUint64(bits)
+		if atomic.CompareAndSwapUint64(bits, old, math.Float64bits(math.Float64frombits(old)+v)) {
+			return
+		}
+	}
+}
+
+// Histogram counts observations, such as latencies, in buckets.
+type Histogram struct {
+	mutex   sync.Mutex
+	buckets []float64
+	counts  []uint64
+	count   uint64
+	sum     float64
+}
+
+// Observe records v in the Histogram.
+func (h *Histogram) Observe(v float64) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	for i, upper := range h.buckets {
+		if v <= upper {
+			h.counts[i]++
+		}
+	}
+	h.count++
+	h.sum += v
+}
+
+// ObserveSince records the time elapsed since start, in seconds.
+func (h *Histogram) ObserveSince(start time.Time) {
+	h.Observe(time.Since(start).Seconds())
+}
+
+// Count returns the number of observations.
+func (h *Histogram) Count() uint64 {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	return h.count
+}
+
+type metricKind string
+
+const (
+	counterKind   metricKind = "counter"
+	gaugeKind     metricKind = "gauge"
+	histogramKind metricKind = "histogram"
+)
+
+type family struct {
+	name    string
+	help    string
+	kind    metricKind
+	buckets []float64
+	series  map[string]*series
+}
+
+type series struct {
+	labels Labels
+	// metric is a *Counter, *Gauge, *Histogram or func() float64
+	metric interface{}
+}
+
+// Registry is a collection of named metrics which can be written in the
+// Prometheus text exposition format.
+type Registry struct {
+	mutex    sync.Mutex
+	families map[string]*family
+}
+
+// DefaultRegistry is the Registry used by Robots, Commanders and platforms
+// which are not given one, and served by the api package on /metrics.
+var DefaultRegistry = NewRegistry()
+
+// NewRegistry returns a new, empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{families: make(map[string]*family)}
+}
+
+// Counter returns the Counter with the given name and labels, creating it if
+// it does not exist.
+func (r *Registry) Counter(name, help string, labels Labels) *Counter {
+	return r.get(name, help, counterKind, nil, labels, func() interface{} { return &Counter{} }).(*Counter)
+}
+
+// Gauge returns the Gauge with the given name and labels, creating it if it
+// does not exist.
+func (r *Registry) Gauge(name, help string, labels Labels) *Gauge {
+	return r.get(name, help, gaugeKind, nil, labels, func() interface{} { return &Gauge{} }).(*Gauge)
+}
+
+// Histogram returns the Histogram with the given name and labels, creating it
+// with buckets if it does not exist. DefaultBuckets are used if buckets is nil.
+func (r *Registry) Histogram(name, help string, labels Labels, buckets []float64) *Histogram {
+	if buckets == nil {
+		buckets = DefaultBuckets
+	}
+	return r.get(name, help, histogramKind, buckets, labels, func() interface{} {
+		return &Histogram{buckets: buckets, counts: make([]uint64, len(buckets))}
+	}).(*Histogram)
+}
+
+// CounterFunc registers a counter whose value is read from f each time the
+// Registry is written, replacing any existing series with the same labels.
+func (r *Registry) CounterFunc(name, help string, labels Labels, f func() float64) {
+	r.set(name, help, counterKind, labels, f)
+}
+
+// GaugeFunc registers a gauge whose value is read from f each time the
+// Registry is written, replacing any existing series with the same labels.
+func (r *Registry) GaugeFunc(name, help string, labels Labels, f func() float64) {
+	r.set(name, help, gaugeKind, labels, f)
+}
+
+// Unregister removes the series with the given name and labels.
+func (r *Registry) Unregister(name string, labels Labels) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if f, ok := r.families[name]; ok {
+		delete(f.series, labels.key())
+		if len(f.series) == 0 {
+			delete(r.families, name)
+		}
+	}
+}
+
+// family returns the family with the given name, creating it if it does not
+// exist. It panics if the family exists with a different kind.
+func (r *Registry) family(name, help string, kind metricKind, buckets []float64) *family {
+	f, ok := r.families[name]
+	if !ok {
+		f = &family{name: name, help: help, kind: kind, buckets: buckets, series: make(map[string]*series)}
+		r.families[name] = f
+	}
+	if f.kind != kind {
+		panic(fmt.Sprintf("gobot: metric %q is a %v, not a %v", name, f.kind, kind))
+	}
+	return f
+}
+
+func (r *Registry) get(name, help string, kind metricKind, buckets []float64, labels Labels, create func() interface{}) interface{} {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	f := r.family(name, help, kind, buckets)
+	key := labels.key()
+	s, ok := f.series[key]
+	if !ok {
+		s = &series{labels: labels.copy(), metric: create()}
+		f.series[key] = s
+	}
+	if _, isFunc := s.metric.(func() float64); isFunc {
+		panic(fmt.Sprintf("gobot: metric %q%v is a func", name, key))
+	}
+	return s.metric
+}
+
+func (r *Registry) set(name, help string, kind metricKind, labels Labels, fn func() float64) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	f := r.family(name, help, kind, nil)
+	f.series[labels.key()] = &series{labels: labels.copy(), metric: fn}
+}
+
+// WritePrometheus writes every metric in the Registry to w in the Prometheus
+// text exposition format.
+func (r *Registry) WritePrometheus(w io.Writer) error {
+	// copy the families so that metric funcs are called without the lock held
+	r.mutex.Lock()
+	families := make([]family, 0, len(r.families))
+	for _, f := range r.families {
+		c := *f
+		c.series = make(map[string]*series, len(f.series))
+		for k, s := range f.series {
+			c.series[k] = s
+		}
+		families = append(families, c)
+	}
+	r.mutex.Unlock()
+
+	sort.Sort(byName(families))
+	for _, f := range families {
+		if err := f.write(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// byName sorts families by name.
+type byName []family
+
+func (b byName) Len() int           { return len(b) }
+func (b byName) Less(i, j int) bool { return b[i].name < b[j].name }
+func (b byName) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
+
+func (f *family) write(w io.Writer) error {
+	keys := make([]string, 0, len(f.series))
+	for k := range f.series {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b bytes.Buffer
+	if f.help != "" {
+		fmt.Fprintf(&b, "# HELP %s %s\n", f.name, helpEscaper.Replace(f.help))
+	}
+	fmt.Fprintf(&b, "# TYPE %s %s\n", f.name, f.kind)
+	for _, k := range keys {
+		s := f.series[k]
+		switch m := s.metric.(type) {
+		case *Counter:
+			writeSample(&b, f.name, s.labels, "", m.Value())
+		case *Gauge:
+			writeSample(&b, f.name, s.labels, "", m.Value())
+		case func() float64:
+			writeSample(&b, f.name, s.labels, "", m())
+		case *Histogram:
+			m.mutex.Lock()
+			for i, upper := range m.buckets {
+				writeSample(&b, f.name+"_bucket", s.labels, formatFloat(upper), float64(m.counts[i]))
+			}
+			writeSample(&b, f.name+"_bucket", s.labels, "+Inf", float64(m.count))
+			writeSample(&b, f.name+"_sum", s.labels, "", m.sum)
+			writeSample(&b, f.name+"_count", s.labels, "", float64(m.count))
+			m.mutex.Unlock()
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// writeSample writes one sample line. If le is not empty it is added as the
+// histogram bucket label.
+func writeSample(b *bytes.Buffer, name string, labels Labels, le string, v float64) {
+	b.WriteString(name)
+	keys := labels.names()
+	if len(keys) > 0 || le != "" {
+		b.WriteString("{")
+		for i, k := range keys {
+			if i > 0 {
+				b.WriteString(",")
+			}
+			fmt.Fprintf(b, "%s=\"%s\"", k, labelEscaper.Replace(labels[k]))
+		}
+		if le != "" {
+			if len(keys) > 0 {
+				b.WriteString(",")
+			}
+			fmt.Fprintf(b, "le=\"%s\"", le)
+		}
+		b.WriteString("}")
+	}
+	b.WriteString(" ")
+	b.WriteString(formatFloat(v))
+	b.WriteString("\n")
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var (
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+// names returns the label names in sorted order.
+func (l Labels) names() []string {
+	names := make([]string, 0, len(l))
+	for k := range l {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// key returns a string which identifies the set of labels.
+func (l Labels) key() string {
+	var b bytes.Buffer
+	for _, k := range l.names() {
+		fmt.Fprintf(&b, "{%s=%q}", k, l[k])
+	}
+	return b.String()
+}
+
+func (l Labels) copy() Labels {
+	c := make(Labels, len(l))
+	for k, v := range l {
+		c[k] = v
+	}
+	return c
+}
+
+// with returns a copy of l with the name set to value.
+func (l Labels) with(name, value string) Labels {
+	c := l.copy()
+	c[name] = value
+	return c
+}
+
+// instrument registers the event counters of v, if it is an Eventer, and
+// records the invocations of its commands, if it is an InstrumentedCommander.
+func (r *Robot) instrument(labels Labels, v interface{}) {
+	if r.Metrics == nil {
+		return
+	}
+	if c, ok := v.(InstrumentedCommander); ok {
+		c.Instrument(r.Metrics, labels)
+	}
+	if e, ok := v.(Eventer); ok {
+		r.Metrics.CounterFunc("gobot_events_published_total",
+			"Events published.", labels, func() float64 { return float64(e.Published()) })
+		r.Metrics.CounterFunc("gobot_events_dropped_total",
+			"Events dropped because a subscriber was full.", labels, func() float64 { return float64(e.Dropped()) })
+	}
+}
+
+// uninstrument removes the event counters registered by instrument.
+func (r *Robot) uninstrument(labels Labels) {
+	if r.Metrics == nil {
+		return
+	}
+	r.Metrics.Unregister("gobot_events_published_total", labels)
+	r.Metrics.Unregister("gobot_events_dropped_total", labels)
+}
+
+// ioErrors holds the I/O error counters of the Connections added to Robots,
+// so that they are counted in the Robot's Registry.
+var ioErrors = struct {
+	sync.RWMutex
+	counters map[Adaptor]*Counter
+}{counters: make(map[Adaptor]*Counter)}
+
+const ioErrorsName = "gobot_adaptor_io_errors_total"
+const ioErrorsHelp = "I/O errors returned by an adaptor."
+
+// countIOErrors counts the I/O errors of c in the Robot's Registry.
+func (r *Robot) countIOErrors(c Connection, labels Labels) {
+	if r.Metrics == nil || !reflect.TypeOf(c).Comparable() {
+		return
+	}
+	counter := r.Metrics.Counter(ioErrorsName, ioErrorsHelp, labels)
+	ioErrors.Lock()
+	defer ioErrors.Unlock()
+	ioErrors.counters[c] = counter
+}
+
+// uncountIOErrors removes the I/O error counter registered by countIOErrors.
+func (r *Robot) uncountIOErrors(c Connection, labels Labels) {
+	if r.Metrics == nil || !reflect.TypeOf(c).Comparable() {
+		return
+	}
+	r.Metrics.Unregister(ioErrorsName, labels)
+	ioErrors.Lock()
+	defer ioErrors.Unlock()
+	delete(ioErrors.counters, c)
+}
+
+// CountIOError adds one to the I/O error counter of adaptor if *err is not
+// nil. The counter is in the Registry of the Robot the adaptor was added to,
+// or in DefaultRegistry if it has not been added to one. It is meant to be
+// deferred by adaptor methods with a named error result:
+//
+//	func (a *Adaptor) DigitalRead(pin string) (val int, err error) {
+//		defer gobot.CountIOError(a, &err)
+//		...
+//	}
+func CountIOError(adaptor Adaptor, err *error) {
+	if *err == nil {
+		return
+	}
+	var counter *Counter
+	if reflect.TypeOf(adaptor).Comparable() {
+		ioErrors.RLock()
+		counter = ioErrors.counters[adaptor]
+		ioErrors.RUnlock()
+	}
+	if counter == nil {
+		counter = DefaultRegistry.Counter(ioErrorsName, ioErrorsHelp, Labels{"connection": adaptor.Name()})
+	}
+	counter.Inc()
+}
@@ -0,0 +1,126 @@
+package gobot
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/hybridgroup/gobot/gobottest"
+)
+
+func TestRegistryCounterAndGauge(t *testing.T) {
+	r := NewRegistry()
+	c := r.Counter("test_total", "A test counter.", Labels{"robot": "Robot1"})
+	c.Inc()
+	c.Add(2)
+	c.Add(-5)
+	gobottest.Assert(t, c.Value(), 3.0)
+	gobottest.Assert(t, r.Counter("test_total", "", Labels{"robot": "Robot1"}), c)
+
+	g := r.Gauge("test_value", "A test gauge.", nil)
+	g.Set(1.5)
+	g.Add(-0.5)
+	gobottest.Assert(t, g.Value(), 1.0)
+
+	var buf bytes.Buffer
+	gobottest.Assert(t, r.WritePrometheus(&buf), nil)
+	gobottest.Assert(t, buf.String(), `# HELP test_total A test counter.
+# TYPE test_total counter
+test_total{robot="Robot1"} 3
+# HELP test_value A test gauge.
+# TYPE test_value gauge
+test_value 1
+`)
+}
+
+func TestRegistryHistogram(t *testing.T) {
+	r := NewRegistry()
+	h := r.Histogram("test_seconds", "", Labels{"command": "Move"}, []float64{0.1, 1})
+	h.Observe(0.05)
+	h.Observe(0.5)
+	h.Observe(5)
+	gobottest.Assert(t, h.Count(), uint64(3))
+
+	var buf bytes.Buffer
+	r.WritePrometheus(&buf)
+	gobottest.Assert(t, buf.String(), `# TYPE test_seconds histogram
+test_seconds_bucket{command="Move",le="0.1"} 1
+test_seconds_bucket{command="Move",le="1"} 2
+test_seconds_bucket{command="Move",le="+Inf"} 3
+test_seconds_sum{command="Move"} 5.55
+test_seconds_count{command="Move"} 3
+`)
+}
+
+func TestRegistryFuncsAndUnregister(t *testing.T) {
+	r := NewRegistry()
+	value := 1.0
+	r.GaugeFunc("test_func", "", Labels{"device": "a\"b"}, func() float64 { return value })
+	value = 2
+
+	var buf bytes.Buffer
+	r.WritePrometheus(&buf)
+	gobottest.Assert(t, strings.Contains(buf.String(), `test_func{device="a\"b"} 2`), true)
+
+	r.Unregister("test_func", Labels{"device": "a\"b"})
+	buf.Reset()
+	r.WritePrometheus(&buf)
+	gobottest.Assert(t, buf.Len(), 0)
+}
+
+func TestRegistryKindMismatch(t *testing.T) {
+	r := NewRegistry()
+	r.Counter("test", "", nil)
+	defer func() {
+		gobottest.Refute(t, recover(), nil)
+	}()
+	r.Gauge("test", "", nil)
+}
+
+func TestCountIOError(t *testing.T) {
+	adaptor := newTestAdaptor("TestCountIOError", "/dev/null")
+	before := DefaultRegistry.Counter("gobot_adaptor_io_errors_total", "", Labels{"connection": "TestCountIOError"}).Value()
+	read := func(fail bool) (err error) {
+		defer CountIOError(adaptor, &err)
+		if fail {
+			return errors.New("read failed")
+		}
+		return nil
+	}
+	read(true)
+	read(false)
+	gobottest.Assert(t, DefaultRegistry.Counter("gobot_adaptor_io_errors_total", "", Labels{"connection": "TestCountIOError"}).Value(), before+1)
+
+	// once added to a Robot the errors are counted in its Registry
+	reg := NewRegistry()
+	NewRobot("Robot1", []Connection{adaptor}, reg)
+	read(true)
+	gobottest.Assert(t, reg.Counter("gobot_adaptor_io_errors_total", "", Labels{"robot": "Robot1", "connection": "TestCountIOError"}).Value(), float64(1))
+	gobottest.Assert(t, DefaultRegistry.Counter("gobot_adaptor_io_errors_total", "", Labels{"connection": "TestCountIOError"}).Value(), before+1)
+}
+
+func TestRobotMetrics(t *testing.T) {
+	reg := NewRegistry()
+	adaptor := newTestAdaptor("Connection1", "/dev/null")
+	driver := newTestDriver(adaptor, "Device1", "0")
+	r := NewRobot("Robot1", []Connection{adaptor}, []Device{driver}, reg)
+
+	r.AddEvent("test")
+	r.Publish("test", nil)
+	driver.Command("DriverCommand")(nil)
+	driver.Execute("DriverCommand", nil)
+
+	var buf bytes.Buffer
+	reg.WritePrometheus(&buf)
+	out := buf.String()
+	gobottest.Assert(t, strings.Contains(out, `gobot_events_published_total{robot="Robot1"} 1`), true)
+	gobottest.Assert(t, strings.Contains(out, `gobot_command_invocations_total{command="DriverCommand",device="Device1",robot="Robot1"} 2`), true)
+	gobottest.Assert(t, strings.Contains(out, `gobot_command_duration_seconds_count{command="DriverCommand",device="Device1",robot="Robot1"} 2`), true)
+
+	r.AttachDevice(newTestDriver(adaptor, "Device2", "1"))
+	r.DetachDevice("Device1")
+	buf.Reset()
+	reg.WritePrometheus(&buf)
+	gobottest.Assert(t, strings.Contains(buf.String(), `gobot_events_published_total{device="Device1"`), false)
+}
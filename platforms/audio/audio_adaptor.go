@@ -3,24 +3,30 @@ package audio
 
 import (
 	"errors"
-	"log"
 	"os"
 	"os/exec"
 	"path"
+
+	"github.com/hybridgroup/gobot"
 )
 
 type AudioAdaptor struct {
-	name string
+	name   string
+	logger gobot.Logger
 }
 
 func NewAudioAdaptor(name string) *AudioAdaptor {
 	return &AudioAdaptor{
-		name: name,
+		name:   name,
+		logger: gobot.DefaultLogger().With("connection", name),
 	}
 }
 
 func (a *AudioAdaptor) Name() string { return a.name }
 
+// SetLogger sets the Logger used to report errors playing sounds.
+func (a *AudioAdaptor) SetLogger(l gobot.Logger) { a.logger = l }
+
 func (a *AudioAdaptor) Connect() []error { return nil }
 
 func (a *AudioAdaptor) Finalize() []error { return nil }
@@ -29,14 +35,14 @@ func (a *AudioAdaptor) Sound(fileName string) []error {
 	var errorsList []error
 
 	if fileName == "" {
-		log.Println("Requires filename for audio file.")
+		a.logger.Error("Requires filename for audio file.")
 		errorsList = append(errorsList, errors.New("Requires filename for audio file."))
 		return errorsList
 	}
 
 	_, err := os.Stat(fileName)
 	if err != nil {
-		log.Println(err)
+		a.logger.Error(err.Error(), "file", fileName)
 		errorsList = append(errorsList, err)
 		return errorsList
 	}
@@ -44,14 +50,14 @@ func (a *AudioAdaptor) Sound(fileName string) []error {
 	// command to play audio file based on file type
 	commandName, err := CommandName(fileName)
 	if err != nil {
-		log.Println(err)
+		a.logger.Error(err.Error(), "file", fileName)
 		errorsList = append(errorsList, err)
 		return errorsList
 	}
 
 	err = RunCommand(commandName, fileName)
 	if err != nil {
-		log.Println(err)
+		a.logger.Error(err.Error(), "file", fileName)
 		errorsList = append(errorsList, err)
 		return errorsList
 	}
@@ -13,19 +13,19 @@ type AudioDriver struct {
 	interval   time.Duration
 	halt       chan bool
 	gobot.Eventer
-	gobot.Commander
+	gobot.ExtendedCommander
 	filename string
 }
 
 func NewAudioDriver(a *AudioAdaptor, name string, filename string) *AudioDriver {
 	d := &AudioDriver{
-		name:       name,
-		connection: a,
-		interval:   500 * time.Millisecond,
-		filename:   filename,
-		halt:       make(chan bool, 0),
-		Eventer:    gobot.NewEventer(),
-		Commander:  gobot.NewCommander(),
+		name:              name,
+		connection:        a,
+		interval:          500 * time.Millisecond,
+		filename:          filename,
+		halt:              make(chan bool, 0),
+		Eventer:           gobot.NewEventer(),
+		ExtendedCommander: gobot.NewCommander(),
 	}
 	return d
 }
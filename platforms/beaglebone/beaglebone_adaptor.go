@@ -8,14 +8,23 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/hybridgroup/gobot"
 	"github.com/hybridgroup/gobot/sysfs"
 )
 
+// The 3.8 kernels load device tree overlays through the slots of the cape
+// manager, and expose the analog pins through a helper device.
 var slots = "/sys/devices/bone_capemgr.*"
 var ocp = "/sys/devices/ocp.*"
-var usrLed = "/sys/devices/ocp.3/gpio-leds.8/leds/beaglebone:green:"
+var capemgrUsrLed = "/sys/devices/ocp.3/gpio-leds.8/leds/beaglebone:green:"
+
+// Later kernels mux the pins at runtime with the cape-universal overlay, and
+// expose the analog pins through the iio subsystem.
+var usrLed = "/sys/class/leds/beaglebone:green:"
+var pinmux = "/sys/devices/platform/ocp/ocp:%v_pinmux/state"
+var analog = "/sys/bus/iio/devices/iio:device0/in_voltage%v_raw"
 
 var glob = func(pattern string) (matches []string, err error) {
 	return filepath.Glob(pattern)
@@ -89,16 +98,32 @@ var pins = map[string]int{
 	"P9_31": 110,
 }
 
-var pwmPins = map[string]string{
-	"P9_14": "P9_14",
-	"P9_21": "P9_21",
-	"P9_22": "P9_22",
-	"P9_29": "P9_29",
-	"P9_42": "P9_42",
-	"P8_13": "P8_13",
-	"P8_34": "P8_34",
-	"P8_45": "P8_45",
-	"P8_46": "P8_46",
+// pwmPins maps the pwm pins to the channels of the pwm chips which drive them
+var pwmPins = map[string]pwmPinData{
+	"P9_14": {path: "/sys/devices/platform/ocp/48302000.epwmss/48302200.pwm/pwm", channel: 0},
+	"P9_16": {path: "/sys/devices/platform/ocp/48302000.epwmss/48302200.pwm/pwm", channel: 1},
+	"P9_21": {path: "/sys/devices/platform/ocp/48300000.epwmss/48300200.pwm/pwm", channel: 1},
+	"P9_22": {path: "/sys/devices/platform/ocp/48300000.epwmss/48300200.pwm/pwm", channel: 0},
+	"P9_29": {path: "/sys/devices/platform/ocp/48300000.epwmss/48300200.pwm/pwm", channel: 1},
+	"P9_42": {path: "/sys/devices/platform/ocp/48300000.epwmss/48300100.ecap/pwm", channel: 0},
+	"P8_13": {path: "/sys/devices/platform/ocp/48304000.epwmss/48304200.pwm/pwm", channel: 1},
+	"P8_19": {path: "/sys/devices/platform/ocp/48304000.epwmss/48304200.pwm/pwm", channel: 0},
+	"P8_34": {path: "/sys/devices/platform/ocp/48302000.epwmss/48302200.pwm/pwm", channel: 1},
+	"P8_45": {path: "/sys/devices/platform/ocp/48304000.epwmss/48304200.pwm/pwm", channel: 0},
+	"P8_46": {path: "/sys/devices/platform/ocp/48304000.epwmss/48304200.pwm/pwm", channel: 1},
+}
+
+// pwmPinData is the directory holding the pwm chip of a pwm pin and the
+// channel of the pin on that chip
+type pwmPinData struct {
+	path    string
+	channel int
+}
+
+// spiPins maps the spi buses to the pinmux states of their pins
+var spiPins = map[int]map[string]string{
+	0: {"P9_17": "spi_cs", "P9_18": "spi", "P9_21": "spi", "P9_22": "spi_sclk"},
+	1: {"P9_28": "spi_cs", "P9_29": "spi", "P9_30": "spi", "P9_31": "spi_sclk"},
 }
 
 var analogPins = map[string]string{
@@ -111,60 +136,102 @@ var analogPins = map[string]string{
 	"P9_35": "AIN6",
 }
 
+// pwmPin is a pwm output of either kernel layout
+type pwmPin interface {
+	SetPeriodAndDutyCycle(period uint32, duty uint32) error
+	Enable(enable bool) error
+	Unexport() error
+}
+
 // BeagleboneAdaptor is the gobot.Adaptor representation for the Beaglebone
 type BeagleboneAdaptor struct {
 	name        string
 	digitalPins []sysfs.DigitalPin
-	pwmPins     map[string]*pwmPin
+	pwmPins     map[string]pwmPin
 	i2cDevice   sysfs.I2cDevice
+	spiDevices  map[string]sysfs.SPIDevice
 	ocp         string
 	helper      string
 	slots       string
+	gpiochips   sysfs.Gpiochips
+	pinFlags    map[int][]sysfs.LineFlag
+}
+
+// gpiochips maps the gpio numbers to the lines of the gpio character devices
+// of the four gpio banks
+var gpiochips = sysfs.Gpiochips{
+	{Label: "gpio-0-31", Base: 0, Count: 32},
+	{Label: "gpio-32-63", Base: 32, Count: 32},
+	{Label: "gpio-64-95", Base: 64, Count: 32},
+	{Label: "gpio-96-127", Base: 96, Count: 32},
 }
 
 // NewBeagleboneAdaptor returns a new BeagleboneAdaptor with specified name
 func NewBeagleboneAdaptor(name string) *BeagleboneAdaptor {
-	b := &BeagleboneAdaptor{
+	return &BeagleboneAdaptor{
 		name:        name,
 		digitalPins: make([]sysfs.DigitalPin, 120),
-		pwmPins:     make(map[string]*pwmPin),
+		pwmPins:     make(map[string]pwmPin),
+		pinFlags:    make(map[int][]sysfs.LineFlag),
+		spiDevices:  make(map[string]sysfs.SPIDevice),
 	}
-
-	g, _ := glob(ocp)
-	b.ocp = g[0]
-	g, _ = glob(slots)
-	b.slots = fmt.Sprintf("%v/slots", g[0])
-
-	return b
 }
 
 // Name returns the BeagleboneAdaptors name
 func (b *BeagleboneAdaptor) Name() string { return b.name }
 
-// Connect initializes the pwm and analog dts.
+// Connect detects the kernel layout of the board. On 3.8 kernels, it loads
+// the pwm and analog dts through the cape manager. Later kernels need no
+// setup, as the pins are muxed on their first use.
 func (b *BeagleboneAdaptor) Connect() (errs []error) {
-	if err := ensureSlot(b.slots, "cape-bone-iio"); err != nil {
+	g, err := glob(slots)
+	if err != nil {
 		return []error{err}
 	}
+	if len(g) == 0 {
+		return
+	}
+	b.slots = fmt.Sprintf("%v/slots", g[0])
+
+	if g, err = glob(ocp); err != nil {
+		return []error{err}
+	}
+	if len(g) == 0 {
+		return []error{errors.New("No ocp device found")}
+	}
+	b.ocp = g[0]
 
+	if err := ensureSlot(b.slots, "cape-bone-iio"); err != nil {
+		return []error{err}
+	}
 	if err := ensureSlot(b.slots, "am33xx_pwm"); err != nil {
 		return []error{err}
 	}
 
-	g, err := glob(fmt.Sprintf("%v/helper.*", b.ocp))
-	if err != nil {
+	if g, err = glob(fmt.Sprintf("%v/helper.*", b.ocp)); err != nil {
 		return []error{err}
 	}
+	if len(g) == 0 {
+		return []error{errors.New("No analog helper found")}
+	}
 	b.helper = g[0]
 
 	return
 }
 
+// capemgr returns whether the board runs a 3.8 kernel with a cape manager
+func (b *BeagleboneAdaptor) capemgr() bool {
+	return b.slots != ""
+}
+
 // Finalize releases all i2c devices and exported analog, digital, pwm pins.
 func (b *BeagleboneAdaptor) Finalize() (errs []error) {
 	for _, pin := range b.pwmPins {
 		if pin != nil {
-			if err := pin.release(); err != nil {
+			if err := pin.Enable(false); err != nil {
+				errs = append(errs, err)
+			}
+			if err := pin.Unexport(); err != nil {
 				errs = append(errs, err)
 			}
 		}
@@ -176,6 +243,11 @@ func (b *BeagleboneAdaptor) Finalize() (errs []error) {
 			}
 		}
 	}
+	for _, device := range b.spiDevices {
+		if err := device.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
 	if b.i2cDevice != nil {
 		if err := b.i2cDevice.Close(); err != nil {
 			errs = append(errs, err)
@@ -186,22 +258,23 @@ func (b *BeagleboneAdaptor) Finalize() (errs []error) {
 
 // PwmWrite writes the 0-254 value to the specified pin
 func (b *BeagleboneAdaptor) PwmWrite(pin string, val byte) (err error) {
-	return b.pwmWrite(pin, val)
+	defer gobot.CountIOError(b, &err)
+	period := 500000.0
+	duty := gobot.FromScale(float64(val), 0, 255.0)
+	return b.pwmWrite(pin, uint32(period), uint32(period*duty))
 }
 
 // ServoWrite writes the 0-180 degree val to the specified pin.
 func (b *BeagleboneAdaptor) ServoWrite(pin string, val byte) (err error) {
-	i, err := b.pwmPin(pin)
-	if err != nil {
-		return err
-	}
+	defer gobot.CountIOError(b, &err)
 	period := 16666666.0
 	duty := (gobot.FromScale(float64(val), 0, 180.0) * 0.115) + 0.05
-	return b.pwmPins[i].pwmWrite(strconv.Itoa(int(period)), strconv.Itoa(int(period*duty)))
+	return b.pwmWrite(pin, uint32(period), uint32(period*duty))
 }
 
 // DigitalRead returns a digital value from specified pin
 func (b *BeagleboneAdaptor) DigitalRead(pin string) (val int, err error) {
+	defer gobot.CountIOError(b, &err)
 	sysfsPin, err := b.digitalPin(pin, sysfs.IN)
 	if err != nil {
 		return
@@ -212,8 +285,13 @@ func (b *BeagleboneAdaptor) DigitalRead(pin string) (val int, err error) {
 // DigitalWrite writes a digital value to specified pin.
 // valid usr pin values are usr0, usr1, usr2 and usr3
 func (b *BeagleboneAdaptor) DigitalWrite(pin string, val byte) (err error) {
+	defer gobot.CountIOError(b, &err)
 	if strings.Contains(pin, "usr") {
-		fi, err := sysfs.OpenFile(usrLed+pin+"/brightness", os.O_WRONLY|os.O_APPEND, 0666)
+		led := usrLed
+		if b.capemgr() {
+			led = capemgrUsrLed
+		}
+		fi, err := sysfs.OpenFile(led+pin+"/brightness", os.O_WRONLY|os.O_APPEND, 0666)
 		defer fi.Close()
 		if err != nil {
 			return err
@@ -228,13 +306,31 @@ func (b *BeagleboneAdaptor) DigitalWrite(pin string, val byte) (err error) {
 	return sysfsPin.Write(int(val))
 }
 
-// AnalogRead returns an analog value from specified pin
+// WaitForDigitalEdge waits at most timeout for a "rising", "falling" or
+// "both" edge of a digital input, and returns the value of the pin after the
+// edge and the time of the edge. The value is -1 if the timeout passed.
+func (b *BeagleboneAdaptor) WaitForDigitalEdge(pin string, edge string, timeout time.Duration) (val int, t time.Time, err error) {
+	defer gobot.CountIOError(b, &err)
+	sysfsPin, err := b.digitalPin(pin, sysfs.IN)
+	if err != nil {
+		return -1, t, err
+	}
+	return sysfs.WaitForEdge(sysfsPin, edge, timeout)
+}
+
+// AnalogRead returns the voltage of specified pin in millivolts, from 0 to
+// 1800, on every kernel layout
 func (b *BeagleboneAdaptor) AnalogRead(pin string) (val int, err error) {
+	defer gobot.CountIOError(b, &err)
 	analogPin, err := b.translateAnalogPin(pin)
 	if err != nil {
 		return
 	}
-	fi, err := sysfs.OpenFile(fmt.Sprintf("%v/%v", b.helper, analogPin), os.O_RDONLY, 0644)
+	path := fmt.Sprintf("%v/%v", b.helper, analogPin)
+	if !b.capemgr() {
+		path = fmt.Sprintf(analog, strings.TrimPrefix(analogPin, "AIN"))
+	}
+	fi, err := sysfs.OpenFile(path, os.O_RDONLY, 0644)
 	defer fi.Close()
 
 	if err != nil {
@@ -248,11 +344,16 @@ func (b *BeagleboneAdaptor) AnalogRead(pin string) (val int, err error) {
 	}
 
 	val, _ = strconv.Atoi(strings.Split(string(buf), "\n")[0])
+	if !b.capemgr() {
+		// the iio adc returns raw 12-bit samples of its 1.8V range
+		val = val * 1800 / 4095
+	}
 	return
 }
 
 // I2cStart starts a i2c device in specified address on i2c bus /dev/i2c-1
 func (b *BeagleboneAdaptor) I2cStart(address int) (err error) {
+	defer gobot.CountIOError(b, &err)
 	if b.i2cDevice == nil {
 		b.i2cDevice, err = sysfs.NewI2cDevice("/dev/i2c-1", address)
 	}
@@ -261,6 +362,7 @@ func (b *BeagleboneAdaptor) I2cStart(address int) (err error) {
 
 // I2cWrite writes data to i2c device
 func (b *BeagleboneAdaptor) I2cWrite(address int, data []byte) (err error) {
+	defer gobot.CountIOError(b, &err)
 	if err = b.i2cDevice.SetAddress(address); err != nil {
 		return
 	}
@@ -270,6 +372,7 @@ func (b *BeagleboneAdaptor) I2cWrite(address int, data []byte) (err error) {
 
 // I2cRead returns size bytes from the i2c device
 func (b *BeagleboneAdaptor) I2cRead(address int, size int) (data []byte, err error) {
+	defer gobot.CountIOError(b, &err)
 	if err = b.i2cDevice.SetAddress(address); err != nil {
 		return
 	}
@@ -278,6 +381,46 @@ func (b *BeagleboneAdaptor) I2cRead(address int, size int) (data []byte, err err
 	return
 }
 
+// ReadByteData reads a byte from register reg of the i2c device at address
+func (b *BeagleboneAdaptor) ReadByteData(address int, reg uint8) (val uint8, err error) {
+	defer gobot.CountIOError(b, &err)
+	return sysfs.I2cReadByteData(b.i2cDevice, address, reg)
+}
+
+// WriteByteData writes val to register reg of the i2c device at address
+func (b *BeagleboneAdaptor) WriteByteData(address int, reg uint8, val uint8) (err error) {
+	defer gobot.CountIOError(b, &err)
+	return sysfs.I2cWriteByteData(b.i2cDevice, address, reg, val)
+}
+
+// ReadWordData reads a little-endian word from register reg of the i2c
+// device at address
+func (b *BeagleboneAdaptor) ReadWordData(address int, reg uint8) (val uint16, err error) {
+	defer gobot.CountIOError(b, &err)
+	return sysfs.I2cReadWordData(b.i2cDevice, address, reg)
+}
+
+// WriteWordData writes val as a little-endian word to register reg of the
+// i2c device at address
+func (b *BeagleboneAdaptor) WriteWordData(address int, reg uint8, val uint16) (err error) {
+	defer gobot.CountIOError(b, &err)
+	return sysfs.I2cWriteWordData(b.i2cDevice, address, reg, val)
+}
+
+// ReadBlockData reads size bytes starting at register reg of the i2c device
+// at address
+func (b *BeagleboneAdaptor) ReadBlockData(address int, reg uint8, size int) (data []byte, err error) {
+	defer gobot.CountIOError(b, &err)
+	return sysfs.I2cReadBlockData(b.i2cDevice, address, reg, size)
+}
+
+// WriteRead writes buf to the i2c device at address and then reads size bytes
+// from it, without a stop condition in between
+func (b *BeagleboneAdaptor) WriteRead(address int, buf []byte, size int) (data []byte, err error) {
+	defer gobot.CountIOError(b, &err)
+	return sysfs.I2cWriteRead(b.i2cDevice, address, buf, size)
+}
+
 // translatePin converts digital pin name to pin position
 func (b *BeagleboneAdaptor) translatePin(pin string) (value int, err error) {
 	for key, value := range pins {
@@ -289,8 +432,8 @@ func (b *BeagleboneAdaptor) translatePin(pin string) (value int, err error) {
 	return
 }
 
-// translatePwmPin converts pwm pin name to pin position
-func (b *BeagleboneAdaptor) translatePwmPin(pin string) (value string, err error) {
+// translatePwmPin converts pwm pin name to the pwm chip and channel of the pin
+func (b *BeagleboneAdaptor) translatePwmPin(pin string) (value pwmPinData, err error) {
 	for key, value := range pwmPins {
 		if key == pin {
 			return value, nil
@@ -318,7 +461,9 @@ func (b *BeagleboneAdaptor) digitalPin(pin string, dir string) (sysfsPin sysfs.D
 		return
 	}
 	if b.digitalPins[i] == nil {
-		b.digitalPins[i] = sysfs.NewDigitalPin(i)
+		if b.digitalPins[i], err = b.newDigitalPin(i); err != nil {
+			return
+		}
 		err := b.digitalPins[i].Export()
 		if err != nil {
 			return nil, err
@@ -330,34 +475,98 @@ func (b *BeagleboneAdaptor) digitalPin(pin string, dir string) (sysfsPin sysfs.D
 	return b.digitalPins[i], nil
 }
 
-// pwPin retrieves pwm pin value by name
-func (b *BeagleboneAdaptor) pwmPin(pin string) (i string, err error) {
-	i, err = b.translatePwmPin(pin)
+// UseGpiochip makes the adaptor drive its digital pins through the gpio
+// character devices instead of the deprecated sysfs gpio interface. The
+// chips, if given, replace the default mapping of Beaglebone gpio numbers to
+// the lines of the character devices. It needs Linux 5.10 or later, which
+// introduced version 2 of the gpio character device interface.
+func (b *BeagleboneAdaptor) UseGpiochip(chips ...sysfs.GpiochipRange) {
+	b.gpiochips = gpiochips
+	if len(chips) > 0 {
+		b.gpiochips = chips
+	}
+}
+
+// SetPinFlags sets the bias and active-low of a digital pin, which apply
+// from the first use of the pin. They require UseGpiochip.
+func (b *BeagleboneAdaptor) SetPinFlags(pin string, flags ...sysfs.LineFlag) (err error) {
+	i, err := b.translatePin(pin)
 	if err != nil {
 		return
 	}
-	if b.pwmPins[i] == nil {
-		err = ensureSlot(b.slots, fmt.Sprintf("bone_pwm_%v", pin))
-		if err != nil {
+	b.pinFlags[i] = flags
+	return
+}
+
+// newDigitalPin returns the sysfs gpio or gpio character device pin of a gpio
+// number
+func (b *BeagleboneAdaptor) newDigitalPin(i int) (sysfs.DigitalPin, error) {
+	if b.gpiochips == nil {
+		return sysfs.NewDigitalPin(i), nil
+	}
+	return b.gpiochips.NewDigitalPin(i, b.pinFlags[i]...)
+}
+
+// pwmPin muxes the pin to its pwm chip and returns the exported pwm channel,
+// or loads the pwm dts of the pin on 3.8 kernels
+func (b *BeagleboneAdaptor) pwmPin(pin string) (p pwmPin, err error) {
+	data, err := b.translatePwmPin(pin)
+	if err != nil {
+		return
+	}
+	if b.pwmPins[pin] == nil && b.capemgr() {
+		if err = ensureSlot(b.slots, fmt.Sprintf("bone_pwm_%v", pin)); err != nil {
 			return
 		}
-		b.pwmPins[i], err = newPwmPin(i, b.ocp)
-		if err != nil {
+		var capemgrPin *capemgrPwmPin
+		if capemgrPin, err = newCapemgrPwmPin(pin, b.ocp); err != nil {
 			return
 		}
+		b.pwmPins[pin] = capemgrPin
 	}
-	return
+	if b.pwmPins[pin] == nil {
+		if err = muxPin(pin, "pwm"); err != nil {
+			return
+		}
+		var chips []string
+		if chips, err = glob(fmt.Sprintf("%v/pwmchip*", data.path)); err != nil {
+			return
+		}
+		if len(chips) == 0 {
+			return nil, fmt.Errorf("No pwm chip for pin %v", pin)
+		}
+		channel := sysfs.NewPWMPin(data.channel)
+		channel.Path = chips[0]
+		if err = channel.Export(); err != nil {
+			return
+		}
+		b.pwmPins[pin] = channel
+	}
+	return b.pwmPins[pin], nil
 }
 
-// pwmWrite writes pwm value to specified pin
-func (b *BeagleboneAdaptor) pwmWrite(pin string, val byte) (err error) {
-	i, err := b.pwmPin(pin)
+// pwmWrite writes the period and duty cycle in nanoseconds to specified pin
+// and enables its output
+func (b *BeagleboneAdaptor) pwmWrite(pin string, period uint32, duty uint32) (err error) {
+	p, err := b.pwmPin(pin)
 	if err != nil {
 		return
 	}
-	period := 500000.0
-	duty := gobot.FromScale(float64(val), 0, 255.0)
-	return b.pwmPins[i].pwmWrite(strconv.Itoa(int(period)), strconv.Itoa(int(period*duty)))
+	if err = p.SetPeriodAndDutyCycle(period, duty); err != nil {
+		return
+	}
+	return p.Enable(true)
+}
+
+// muxPin selects the function of a pin with the pinmux helper of the pin
+func muxPin(pin, function string) (err error) {
+	fi, err := sysfs.OpenFile(fmt.Sprintf(pinmux, pin), os.O_WRONLY, 0644)
+	defer fi.Close()
+	if err != nil {
+		return
+	}
+	_, err = fi.WriteString(function)
+	return
 }
 
 func ensureSlot(slots, item string) (err error) {
@@ -392,3 +601,45 @@ func ensureSlot(slots, item string) (err error) {
 	}
 	return
 }
+
+// SpiStart muxes the pins of a bus to it and opens the spi device on a chip
+// select of the bus, such as /dev/spidev0.0, with the mode, bits per word and
+// maximum speed in Hz of its transfers
+func (b *BeagleboneAdaptor) SpiStart(bus int, chip int, mode int, bits int, speed int) (err error) {
+	defer gobot.CountIOError(b, &err)
+	location := sysfs.SPIDevicePath(bus, chip)
+	if b.spiDevices[location] != nil {
+		return
+	}
+	if b.capemgr() {
+		err = ensureSlot(b.slots, fmt.Sprintf("BB-SPIDEV%v", bus))
+	} else {
+		for pin, function := range spiPins[bus] {
+			if err = muxPin(pin, function); err != nil {
+				break
+			}
+		}
+	}
+	if err != nil {
+		return
+	}
+	device, err := sysfs.NewSPIDevice(location, byte(mode), byte(bits), uint32(speed))
+	if err != nil {
+		return
+	}
+	b.spiDevices[location] = device
+	return
+}
+
+// SpiTransfer writes data to the spi device on a chip select of a bus and
+// returns the bytes read at the same time
+func (b *BeagleboneAdaptor) SpiTransfer(bus int, chip int, data []byte) (read []byte, err error) {
+	defer gobot.CountIOError(b, &err)
+	device := b.spiDevices[sysfs.SPIDevicePath(bus, chip)]
+	if device == nil {
+		return nil, errors.New("SPI device not started")
+	}
+	read = make([]byte, len(data))
+	err = device.Transfer(data, read)
+	return
+}
@@ -9,18 +9,21 @@ import (
 	"github.com/hybridgroup/gobot/gobottest"
 	"github.com/hybridgroup/gobot/platforms/gpio"
 	"github.com/hybridgroup/gobot/platforms/i2c"
+	"github.com/hybridgroup/gobot/platforms/spi"
 	"github.com/hybridgroup/gobot/sysfs"
 )
 
 var _ gobot.Adaptor = (*BeagleboneAdaptor)(nil)
 
 var _ gpio.DigitalReader = (*BeagleboneAdaptor)(nil)
+var _ gpio.DigitalWatcher = (*BeagleboneAdaptor)(nil)
 var _ gpio.DigitalWriter = (*BeagleboneAdaptor)(nil)
 var _ gpio.AnalogReader = (*BeagleboneAdaptor)(nil)
 var _ gpio.PwmWriter = (*BeagleboneAdaptor)(nil)
 var _ gpio.ServoWriter = (*BeagleboneAdaptor)(nil)
 
 var _ i2c.I2c = (*BeagleboneAdaptor)(nil)
+var _ spi.SPI = (*BeagleboneAdaptor)(nil)
 
 type NullReadWriteCloser struct {
 	contents []byte
@@ -42,6 +45,30 @@ func (n *NullReadWriteCloser) Read(b []byte) (int, error) {
 	return len(b), nil
 }
 
+func (n *NullReadWriteCloser) ReadByteData(reg uint8) (uint8, error) {
+	return 0, nil
+}
+
+func (n *NullReadWriteCloser) WriteByteData(reg uint8, val uint8) error {
+	return nil
+}
+
+func (n *NullReadWriteCloser) ReadWordData(reg uint8) (uint16, error) {
+	return 0, nil
+}
+
+func (n *NullReadWriteCloser) WriteWordData(reg uint8, val uint16) error {
+	return nil
+}
+
+func (n *NullReadWriteCloser) ReadBlockData(reg uint8, b []byte) error {
+	return nil
+}
+
+func (n *NullReadWriteCloser) WriteRead(w []byte, r []byte) error {
+	return nil
+}
+
 var closeErr error = nil
 
 func (n *NullReadWriteCloser) Close() error {
@@ -49,21 +76,16 @@ func (n *NullReadWriteCloser) Close() error {
 }
 
 func TestBeagleboneAdaptor(t *testing.T) {
-	glob = func(pattern string) (matches []string, err error) {
-		return make([]string, 2), nil
-	}
 	fs := sysfs.NewMockFilesystem([]string{
 		"/dev/i2c-1",
-		"/sys/devices/bone_capemgr.4",
-		"/sys/devices/ocp.3",
-		"/sys/devices/ocp.3/gpio-leds.8/leds/beaglebone:green:usr1/brightness",
-		"/sys/devices/ocp.3/helper.5",
-		"/sys/devices/ocp.3/helper.5/AIN1",
-		"/sys/devices/ocp.3/pwm_test_P9_14.5",
-		"/sys/devices/ocp.3/pwm_test_P9_14.5/run",
-		"/sys/devices/ocp.3/pwm_test_P9_14.5/period",
-		"/sys/devices/ocp.3/pwm_test_P9_14.5/polarity",
-		"/sys/devices/ocp.3/pwm_test_P9_14.5/duty",
+		"/sys/class/leds/beaglebone:green:usr1/brightness",
+		"/sys/bus/iio/devices/iio:device0/in_voltage1_raw",
+		"/sys/devices/platform/ocp/ocp:P9_14_pinmux/state",
+		"/sys/devices/platform/ocp/48302000.epwmss/48302200.pwm/pwm/pwmchip5/export",
+		"/sys/devices/platform/ocp/48302000.epwmss/48302200.pwm/pwm/pwmchip5/unexport",
+		"/sys/devices/platform/ocp/48302000.epwmss/48302200.pwm/pwm/pwmchip5/pwm0/enable",
+		"/sys/devices/platform/ocp/48302000.epwmss/48302200.pwm/pwm/pwmchip5/pwm0/period",
+		"/sys/devices/platform/ocp/48302000.epwmss/48302200.pwm/pwm/pwmchip5/pwm0/duty_cycle",
 		"/sys/class/gpio/export",
 		"/sys/class/gpio/unexport",
 		"/sys/class/gpio/gpio60/value",
@@ -73,13 +95,12 @@ func TestBeagleboneAdaptor(t *testing.T) {
 	})
 
 	sysfs.SetFilesystem(fs)
+	glob = func(pattern string) (matches []string, err error) {
+		return nil, nil
+	}
 	a := NewBeagleboneAdaptor("myAdaptor")
-	a.slots = "/sys/devices/bone_capemgr.4"
-	a.ocp = "/sys/devices/ocp.3"
-
-	a.Connect()
-
-	a.helper = "/sys/devices/ocp.3/helper.5"
+	gobottest.Assert(t, len(a.Connect()), 0)
+	gobottest.Assert(t, a.capemgr(), false)
 
 	// PWM
 	glob = func(pattern string) (matches []string, err error) {
@@ -87,34 +108,32 @@ func TestBeagleboneAdaptor(t *testing.T) {
 		return []string{pattern + "5"}, nil
 	}
 
+	pwmchip := "/sys/devices/platform/ocp/48302000.epwmss/48302200.pwm/pwm/pwmchip5"
+	fs.Files[pwmchip+"/pwm0/duty_cycle"].Contents = "0\n"
+
 	gobottest.Assert(t, a.PwmWrite("P9_99", 175), errors.New("Not a valid pin"))
-	a.PwmWrite("P9_14", 175)
-	gobottest.Assert(
-		t,
-		fs.Files["/sys/devices/ocp.3/pwm_test_P9_14.5/period"].Contents,
-		"500000",
-	)
+	gobottest.Assert(t, a.PwmWrite("P9_14", 175), nil)
 	gobottest.Assert(
 		t,
-		fs.Files["/sys/devices/ocp.3/pwm_test_P9_14.5/duty"].Contents,
-		"343137",
+		fs.Files["/sys/devices/platform/ocp/ocp:P9_14_pinmux/state"].Contents,
+		"pwm",
 	)
+	gobottest.Assert(t, fs.Files[pwmchip+"/export"].Contents, "0")
+	gobottest.Assert(t, fs.Files[pwmchip+"/pwm0/period"].Contents, "500000")
+	gobottest.Assert(t, fs.Files[pwmchip+"/pwm0/duty_cycle"].Contents, "343137")
+	gobottest.Assert(t, fs.Files[pwmchip+"/pwm0/enable"].Contents, "1")
 
-	a.ServoWrite("P9_14", 100)
-	gobottest.Assert(
-		t,
-		fs.Files["/sys/devices/ocp.3/pwm_test_P9_14.5/period"].Contents,
-		"16666666",
-	)
-	gobottest.Assert(
-		t,
-		fs.Files["/sys/devices/ocp.3/pwm_test_P9_14.5/duty"].Contents,
-		"1898148",
-	)
+	gobottest.Assert(t, a.ServoWrite("P9_14", 100), nil)
+	gobottest.Assert(t, fs.Files[pwmchip+"/pwm0/period"].Contents, "16666666")
+	gobottest.Assert(t, fs.Files[pwmchip+"/pwm0/duty_cycle"].Contents, "1898148")
 
 	// Analog
-	fs.Files["/sys/devices/ocp.3/helper.5/AIN1"].Contents = "567\n"
+	// raw samples are scaled to millivolts
+	fs.Files["/sys/bus/iio/devices/iio:device0/in_voltage1_raw"].Contents = "4095\n"
 	i, _ := a.AnalogRead("P9_40")
+	gobottest.Assert(t, i, 1800)
+	fs.Files["/sys/bus/iio/devices/iio:device0/in_voltage1_raw"].Contents = "1290\n"
+	i, _ = a.AnalogRead("P9_40")
 	gobottest.Assert(t, i, 567)
 
 	i, err := a.AnalogRead("P9_99")
@@ -123,7 +142,7 @@ func TestBeagleboneAdaptor(t *testing.T) {
 	// DigitalIO
 	a.DigitalWrite("usr1", 1)
 	gobottest.Assert(t,
-		fs.Files["/sys/devices/ocp.3/gpio-leds.8/leds/beaglebone:green:usr1/brightness"].Contents,
+		fs.Files["/sys/class/leds/beaglebone:green:usr1/brightness"].Contents,
 		"1",
 	)
 
@@ -146,5 +165,85 @@ func TestBeagleboneAdaptor(t *testing.T) {
 	data, _ := a.I2cRead(0xff, 2)
 	gobottest.Assert(t, data, []byte{0x00, 0x01})
 
+	gobottest.Assert(t, len(a.Finalize()), 0)
+	gobottest.Assert(t, fs.Files[pwmchip+"/pwm0/enable"].Contents, "0")
+	gobottest.Assert(t, fs.Files[pwmchip+"/unexport"].Contents, "0")
+}
+
+func TestBeagleboneAdaptorCapemgr(t *testing.T) {
+	glob = func(pattern string) (matches []string, err error) {
+		pattern = strings.TrimSuffix(pattern, "*")
+		return []string{pattern + "5"}, nil
+	}
+	fs := sysfs.NewMockFilesystem([]string{
+		"/sys/devices/bone_capemgr.5/slots",
+		"/sys/devices/ocp.3/gpio-leds.8/leds/beaglebone:green:usr1/brightness",
+		"/sys/devices/ocp.5/helper.5/AIN1",
+		"/sys/devices/ocp.5/pwm_test_P9_14.5/run",
+		"/sys/devices/ocp.5/pwm_test_P9_14.5/period",
+		"/sys/devices/ocp.5/pwm_test_P9_14.5/duty",
+		"/sys/devices/ocp.5/pwm_test_P9_14.5/polarity",
+		"/dev/spidev0.0",
+	})
+	sysfs.SetFilesystem(fs)
+	sysfs.SetSyscall(&sysfs.MockSyscall{})
+
+	a := NewBeagleboneAdaptor("myAdaptor")
+	gobottest.Assert(t, len(a.Connect()), 0)
+	gobottest.Assert(t, a.capemgr(), true)
+	gobottest.Assert(t, a.helper, "/sys/devices/ocp.5/helper.5")
+
+	// PWM
+	gobottest.Assert(t, a.PwmWrite("P9_14", 175), nil)
+	pwm := "/sys/devices/ocp.5/pwm_test_P9_14.5"
+	gobottest.Assert(t, fs.Files[pwm+"/period"].Contents, "500000")
+	gobottest.Assert(t, fs.Files[pwm+"/duty"].Contents, "343137")
+	gobottest.Assert(t, fs.Files[pwm+"/run"].Contents, "1")
+	gobottest.Assert(t, a.ServoWrite("P9_14", 100), nil)
+	gobottest.Assert(t, fs.Files[pwm+"/period"].Contents, "16666666")
+	gobottest.Assert(t, fs.Files[pwm+"/duty"].Contents, "1898148")
+	gobottest.Assert(t, fs.Files["/sys/devices/bone_capemgr.5/slots"].Contents, "bone_pwm_P9_14")
+
+	// Analog, in millivolts
+	fs.Files["/sys/devices/ocp.5/helper.5/AIN1"].Contents = "567\n"
+	i, _ := a.AnalogRead("P9_40")
+	gobottest.Assert(t, i, 567)
+
+	// DigitalIO
+	gobottest.Assert(t, a.DigitalWrite("usr1", 1), nil)
+	gobottest.Assert(t,
+		fs.Files["/sys/devices/ocp.3/gpio-leds.8/leds/beaglebone:green:usr1/brightness"].Contents,
+		"1",
+	)
+
+	// SPI loads the spidev dts of the bus
+	gobottest.Assert(t, a.SpiStart(0, 0, spi.Mode0, 8, 1000000), nil)
+	gobottest.Assert(t, fs.Files["/sys/devices/bone_capemgr.5/slots"].Contents, "BB-SPIDEV0")
+
+	gobottest.Assert(t, len(a.Finalize()), 0)
+	gobottest.Assert(t, fs.Files[pwm+"/run"].Contents, "0")
+}
+
+func TestBeagleboneAdaptorSpi(t *testing.T) {
+	fs := sysfs.NewMockFilesystem([]string{
+		"/dev/spidev0.0",
+		"/sys/devices/platform/ocp/ocp:P9_17_pinmux/state",
+		"/sys/devices/platform/ocp/ocp:P9_18_pinmux/state",
+		"/sys/devices/platform/ocp/ocp:P9_21_pinmux/state",
+		"/sys/devices/platform/ocp/ocp:P9_22_pinmux/state",
+	})
+	sysfs.SetFilesystem(fs)
+	sysfs.SetSyscall(&sysfs.MockSyscall{})
+	a := NewBeagleboneAdaptor("myAdaptor")
+
+	gobottest.Refute(t, a.SpiStart(1, 0, spi.Mode0, 8, 1000000), nil)
+
+	gobottest.Assert(t, a.SpiStart(0, 0, spi.Mode0, 8, 1000000), nil)
+	gobottest.Assert(t, fs.Files["/sys/devices/platform/ocp/ocp:P9_17_pinmux/state"].Contents, "spi_cs")
+	gobottest.Assert(t, fs.Files["/sys/devices/platform/ocp/ocp:P9_22_pinmux/state"].Contents, "spi_sclk")
+	data, err := a.SpiTransfer(0, 0, []byte{0x01, 0x02})
+	gobottest.Assert(t, err, nil)
+	gobottest.Assert(t, len(data), 2)
+
 	gobottest.Assert(t, len(a.Finalize()), 0)
 }
@@ -4,21 +4,24 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/hybridgroup/gobot/sysfs"
 )
 
-type pwmPin struct {
+// capemgrPwmPin is a pwm output of the pwm_test driver of 3.8 kernels, which
+// the bone_pwm dts of its pin creates.
+type capemgrPwmPin struct {
 	pinNum    string
 	pwmDevice string
 }
 
-// newPwmPin creates a new pwm pin with specified pin number
-func newPwmPin(pinNum string, ocp string) (p *pwmPin, err error) {
+// newCapemgrPwmPin creates a new pwm pin with specified pin number
+func newCapemgrPwmPin(pinNum string, ocp string) (p *capemgrPwmPin, err error) {
 	done := make(chan error, 0)
-	p = &pwmPin{
+	p = &capemgrPwmPin{
 		pinNum: strings.ToUpper(pinNum),
 	}
 
@@ -26,6 +29,9 @@ func newPwmPin(pinNum string, ocp string) (p *pwmPin, err error) {
 	if err != nil {
 		return
 	}
+	if len(pwmDevice) == 0 {
+		return nil, fmt.Errorf("No pwm device for pin %v", pinNum)
+	}
 
 	p.pwmDevice = pwmDevice[0]
 
@@ -55,56 +61,47 @@ func newPwmPin(pinNum string, ocp string) (p *pwmPin, err error) {
 				break
 			}
 		}
-		for {
-			if fi, err := sysfs.OpenFile(fmt.Sprintf("%v/run", p.pwmDevice), os.O_WRONLY|os.O_APPEND, 0644); err == nil {
-				defer fi.Close()
-				if _, err = fi.WriteString("1"); err != nil {
-					done <- err
-				}
-				fi.Sync()
-				break
-			}
-		}
 		done <- nil
 	}()
 
 	select {
 	case err = <-done:
-		return p, err
+		if err != nil {
+			return nil, err
+		}
+		return p, nil
 	case <-time.After(500 * time.Millisecond):
-		return p, errors.New("could not initialize pwm device")
+		return nil, errors.New("could not initialize pwm device")
 	}
 }
 
-// pwmWrite writes to a pwm pin with specified period and duty
-func (p *pwmPin) pwmWrite(period string, duty string) (err error) {
-	f1, err := sysfs.OpenFile(fmt.Sprintf("%v/period", p.pwmDevice), os.O_WRONLY|os.O_APPEND, 0666)
-	defer f1.Close()
-	if err != nil {
-		return
-	}
-	if _, err = f1.WriteString(period); err != nil {
+// SetPeriodAndDutyCycle writes to a pwm pin with specified period and duty
+// in nanoseconds
+func (p *capemgrPwmPin) SetPeriodAndDutyCycle(period uint32, duty uint32) (err error) {
+	if err = p.write("period", strconv.FormatUint(uint64(period), 10)); err != nil {
 		return
 	}
+	return p.write("duty", strconv.FormatUint(uint64(duty), 10))
+}
 
-	f2, err := sysfs.OpenFile(fmt.Sprintf("%v/duty", p.pwmDevice), os.O_WRONLY|os.O_APPEND, 0666)
-	defer f2.Close()
-	if err != nil {
-		return
+// Enable starts or stops the pwm output
+func (p *capemgrPwmPin) Enable(enable bool) error {
+	if enable {
+		return p.write("run", "1")
 	}
-
-	_, err = f2.WriteString(duty)
-
-	return
+	return p.write("run", "0")
 }
 
-// releae writes string to close a pwm pin
-func (p *pwmPin) release() (err error) {
-	fi, err := sysfs.OpenFile(fmt.Sprintf("%v/run", p.pwmDevice), os.O_WRONLY|os.O_APPEND, 0666)
+// Unexport does nothing, as the pwm_test device stays loaded until the cape
+// manager unloads its dts
+func (p *capemgrPwmPin) Unexport() error { return nil }
+
+func (p *capemgrPwmPin) write(attr string, value string) (err error) {
+	fi, err := sysfs.OpenFile(fmt.Sprintf("%v/%v", p.pwmDevice, attr), os.O_WRONLY|os.O_APPEND, 0666)
 	defer fi.Close()
 	if err != nil {
 		return
 	}
-	_, err = fi.WriteString("0")
+	_, err = fi.WriteString(value)
 	return
 }
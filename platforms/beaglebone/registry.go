@@ -0,0 +1,9 @@
+package beaglebone
+
+import "github.com/hybridgroup/gobot"
+
+func init() {
+	gobot.RegisterAdaptor("beaglebone", func(config gobot.ConnectionConfig) (gobot.Adaptor, error) {
+		return NewBeagleboneAdaptor(config.Name), nil
+	})
+}
@@ -1,6 +1,7 @@
 package bebop
 
 import (
+	"github.com/hybridgroup/gobot"
 	"github.com/hybridgroup/gobot/platforms/bebop/client"
 )
 
@@ -35,13 +36,22 @@ type BebopAdaptor struct {
 
 // NewBebopAdaptor returns a new BebopAdaptor
 func NewBebopAdaptor(name string) *BebopAdaptor {
-	return &BebopAdaptor{
+	a := &BebopAdaptor{
 		name:  name,
 		drone: client.New(),
 		connect: func(a *BebopAdaptor) error {
 			return a.drone.Connect()
 		},
 	}
+	a.SetLogger(gobot.DefaultLogger().With("connection", name))
+	return a
+}
+
+// SetLogger sets the Logger used by the drone client to report network errors.
+func (a *BebopAdaptor) SetLogger(l gobot.Logger) {
+	if setter, ok := a.drone.(gobot.LoggerSetter); ok {
+		setter.SetLogger(l)
+	}
 }
 
 // Name returns the BebopAdaptors Name
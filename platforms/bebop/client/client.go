@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"net"
 	"time"
+
+	"github.com/hybridgroup/gobot"
 )
 
 func validatePitch(val int) int {
@@ -168,6 +170,7 @@ type Bebop struct {
 	networkFrameGenerator func(*bytes.Buffer, byte, byte) *bytes.Buffer
 	video                 chan []byte
 	writeChan             chan []byte
+	logger                gobot.Logger
 }
 
 func New() *Bebop {
@@ -189,9 +192,13 @@ func New() *Bebop {
 		tmpFrame:  tmpFrame{},
 		video:     make(chan []byte),
 		writeChan: make(chan []byte),
+		logger:    gobot.DefaultLogger(),
 	}
 }
 
+// SetLogger sets the Logger used to report network errors.
+func (b *Bebop) SetLogger(l gobot.Logger) { b.logger = l }
+
 func (b *Bebop) write(buf []byte) (int, error) {
 	b.writeChan <- buf
 	return 0, nil
@@ -265,7 +272,7 @@ func (b *Bebop) Connect() error {
 			_, err := b.c2dClient.Write(<-b.writeChan)
 
 			if err != nil {
-				fmt.Println(err)
+				b.logger.Error("Writing to c2dClient failed", "error", err)
 			}
 		}
 	}()
@@ -275,7 +282,7 @@ func (b *Bebop) Connect() error {
 			data := make([]byte, 40960)
 			i, _, err := b.d2cClient.ReadFromUDP(data)
 			if err != nil {
-				fmt.Println("d2cClient error:", err)
+				b.logger.Error("Reading from d2cClient failed", "error", err)
 			}
 
 			b.packetReceiver(data[0:i])
@@ -289,7 +296,7 @@ func (b *Bebop) Connect() error {
 		for {
 			_, err := b.write(b.generatePcmd().Bytes())
 			if err != nil {
-				fmt.Println("pcmd c2dClient.Write", err)
+				b.logger.Error("Writing pcmd failed", "error", err)
 			}
 			<-time.After(25 * time.Millisecond)
 		}
@@ -525,7 +532,7 @@ func (b *Bebop) packetReceiver(buf []byte) {
 		_, err := b.write(ack)
 
 		if err != nil {
-			fmt.Println("ARNETWORKAL_FRAME_TYPE_DATA_WITH_ACK", err)
+			b.logger.Error("Writing ack failed", "frame_type", "ARNETWORKAL_FRAME_TYPE_DATA_WITH_ACK", "error", err)
 		}
 	}
 
@@ -537,7 +544,7 @@ func (b *Bebop) packetReceiver(buf []byte) {
 		ack := b.createARStreamACK(arstreamFrame).Bytes()
 		_, err := b.write(ack)
 		if err != nil {
-			fmt.Println("ARNETWORKAL_FRAME_TYPE_DATA_LOW_LATENCY", err)
+			b.logger.Error("Writing ack failed", "frame_type", "ARNETWORKAL_FRAME_TYPE_DATA_LOW_LATENCY", "error", err)
 		}
 	}
 
@@ -548,7 +555,7 @@ func (b *Bebop) packetReceiver(buf []byte) {
 		pong := b.createPong(frame).Bytes()
 		_, err := b.write(pong)
 		if err != nil {
-			fmt.Println("ARNETWORK_MANAGER_INTERNAL_BUFFER_ID_PING", err)
+			b.logger.Error("Writing pong failed", "error", err)
 		}
 	}
 }
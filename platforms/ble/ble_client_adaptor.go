@@ -1,8 +1,7 @@
 package ble
 
 import (
-	"fmt"
-	"log"
+	"errors"
 	"strings"
 
 	"github.com/currantlabs/gatt"
@@ -20,6 +19,7 @@ type BLEClientAdaptor struct {
 	services   map[string]*BLEService
 	connected  bool
 	ready      chan struct{}
+	logger     gobot.Logger
 }
 
 // NewBLEClientAdaptor returns a new BLEClientAdaptor given a name and uuid
@@ -30,9 +30,13 @@ func NewBLEClientAdaptor(name string, uuid string) *BLEClientAdaptor {
 		connected: false,
 		ready:     make(chan struct{}),
 		services:  make(map[string]*BLEService),
+		logger:    gobot.DefaultLogger().With("connection", name),
 	}
 }
 
+// SetLogger sets the Logger used for connection progress and errors.
+func (b *BLEClientAdaptor) SetLogger(l gobot.Logger) { b.logger = l }
+
 func (b *BLEClientAdaptor) Name() string                { return b.name }
 func (b *BLEClientAdaptor) UUID() string                { return b.uuid }
 func (b *BLEClientAdaptor) Peripheral() gatt.Peripheral { return b.peripheral }
@@ -41,8 +45,8 @@ func (b *BLEClientAdaptor) Peripheral() gatt.Peripheral { return b.peripheral }
 func (b *BLEClientAdaptor) Connect() (errs []error) {
 	device, err := gatt.NewDevice(DefaultClientOptions...)
 	if err != nil {
-		log.Fatalf("Failed to open BLE device, err: %s\n", err)
-		return
+		b.logger.Error("Failed to open BLE device", "error", err)
+		return []error{err}
 	}
 
 	b.device = device
@@ -86,19 +90,19 @@ func (b *BLEClientAdaptor) Finalize() (errs []error) {
 // requested service and characteristic
 func (b *BLEClientAdaptor) ReadCharacteristic(sUUID string, cUUID string) (data []byte, err error) {
 	if !b.connected {
-		log.Fatalf("Cannot read from BLE device until connected")
-		return
+		b.logger.Error("Cannot read from BLE device until connected")
+		return nil, errors.New("Cannot read from BLE device until connected")
 	}
 
 	characteristic := b.lookupCharacteristic(sUUID, cUUID)
 	if characteristic == nil {
-		log.Println("Cannot read from unknown characteristic")
+		b.logger.Warn("Cannot read from unknown characteristic", "service", sUUID, "characteristic", cUUID)
 		return
 	}
 
 	val, err := b.peripheral.ReadCharacteristic(characteristic)
 	if err != nil {
-		fmt.Printf("Failed to read characteristic, err: %s\n", err)
+		b.logger.Error("Failed to read characteristic", "characteristic", cUUID, "error", err)
 		return nil, err
 	}
 
@@ -109,19 +113,19 @@ func (b *BLEClientAdaptor) ReadCharacteristic(sUUID string, cUUID string) (data
 // requested service and characteristic
 func (b *BLEClientAdaptor) WriteCharacteristic(sUUID string, cUUID string, data []byte) (err error) {
 	if !b.connected {
-		log.Fatalf("Cannot write to BLE device until connected")
-		return
+		b.logger.Error("Cannot write to BLE device until connected")
+		return errors.New("Cannot write to BLE device until connected")
 	}
 
 	characteristic := b.lookupCharacteristic(sUUID, cUUID)
 	if characteristic == nil {
-		log.Println("Cannot write to unknown characteristic")
+		b.logger.Warn("Cannot write to unknown characteristic", "service", sUUID, "characteristic", cUUID)
 		return
 	}
 
 	err = b.peripheral.WriteCharacteristic(characteristic, data, true)
 	if err != nil {
-		fmt.Printf("Failed to write characteristic, err: %s\n", err)
+		b.logger.Error("Failed to write characteristic", "characteristic", cUUID, "error", err)
 		return err
 	}
 
@@ -132,13 +136,13 @@ func (b *BLEClientAdaptor) WriteCharacteristic(sUUID string, cUUID string, data
 // requested service and characteristic
 func (b *BLEClientAdaptor) Subscribe(sUUID string, cUUID string, f func([]byte, error)) (err error) {
 	if !b.connected {
-		log.Fatalf("Cannot subscribe to BLE device until connected")
-		return
+		b.logger.Error("Cannot subscribe to BLE device until connected")
+		return errors.New("Cannot subscribe to BLE device until connected")
 	}
 
 	characteristic := b.lookupCharacteristic(sUUID, cUUID)
 	if characteristic == nil {
-		log.Println("Cannot subscribe to unknown characteristic")
+		b.logger.Warn("Cannot subscribe to unknown characteristic", "service", sUUID, "characteristic", cUUID)
 		return
 	}
 
@@ -148,7 +152,7 @@ func (b *BLEClientAdaptor) Subscribe(sUUID string, cUUID string, f func([]byte,
 
 	err = b.peripheral.SetNotifyValue(characteristic, fn)
 	if err != nil {
-		fmt.Printf("Failed to subscribe to characteristic, err: %s\n", err)
+		b.logger.Error("Failed to subscribe to characteristic", "characteristic", cUUID, "error", err)
 		return err
 	}
 
@@ -156,10 +160,10 @@ func (b *BLEClientAdaptor) Subscribe(sUUID string, cUUID string, f func([]byte,
 }
 
 func (b *BLEClientAdaptor) StateChangeHandler(d gatt.Device, s gatt.State) {
-	fmt.Println("State:", s)
+	b.logger.Debug("State changed", "state", s)
 	switch s {
 	case gatt.StatePoweredOn:
-		fmt.Println("scanning...")
+		b.logger.Info("Scanning")
 		d.Scan([]gatt.UUID{}, false)
 		return
 	default:
@@ -187,17 +191,17 @@ func (b *BLEClientAdaptor) DiscoveryHandler(p gatt.Peripheral, a *gatt.Advertise
 }
 
 func (b *BLEClientAdaptor) ConnectHandler(p gatt.Peripheral, err error) {
-	fmt.Printf("\nConnected Peripheral ID:%s, NAME:(%s)\n", p.ID(), p.Name())
+	b.logger.Info("Connected peripheral", "id", p.ID(), "name", p.Name())
 
 	b.peripheral = p
 
 	if err := p.SetMTU(250); err != nil {
-		fmt.Printf("Failed to set MTU, err: %s\n", err)
+		b.logger.Warn("Failed to set MTU", "error", err)
 	}
 
 	ss, err := p.DiscoverServices(nil)
 	if err != nil {
-		fmt.Printf("Failed to discover services, err: %s\n", err)
+		b.logger.Error("Failed to discover services", "error", err)
 		return
 	}
 
@@ -207,14 +211,14 @@ outer:
 
 		cs, err := p.DiscoverCharacteristics(nil, s)
 		if err != nil {
-			fmt.Printf("Failed to discover characteristics, err: %s\n", err)
+			b.logger.Warn("Failed to discover characteristics", "service", s.UUID().String(), "error", err)
 			continue
 		}
 
 		for _, c := range cs {
 			_, err := p.DiscoverDescriptors(nil, c)
 			if err != nil {
-				fmt.Printf("Failed to discover descriptors: %v\n", err)
+				b.logger.Warn("Failed to discover descriptors", "characteristic", c.UUID().String(), "error", err)
 				continue outer
 			}
 			b.services[s.UUID().String()].characteristics[c.UUID().String()] = c
@@ -226,20 +230,20 @@ outer:
 }
 
 func (b *BLEClientAdaptor) DisconnectHandler(p gatt.Peripheral, err error) {
-	fmt.Println("Disconnected")
+	b.logger.Info("Disconnected")
 }
 
 // Finalize finalizes the BLEAdaptor
 func (b *BLEClientAdaptor) lookupCharacteristic(sUUID string, cUUID string) *gatt.Characteristic {
 	service := b.services[sUUID]
 	if service == nil {
-		log.Printf("Unknown service ID: %s\n", sUUID)
+		b.logger.Warn("Unknown service ID", "service", sUUID)
 		return nil
 	}
 
 	characteristic := service.characteristics[cUUID]
 	if characteristic == nil {
-		log.Printf("Unknown characteristic ID: %s\n", cUUID)
+		b.logger.Warn("Unknown characteristic ID", "characteristic", cUUID)
 		return nil
 	}
 
@@ -3,7 +3,6 @@ package ble
 import (
 	"bytes"
 	"encoding/binary"
-	"fmt"
 	"time"
 
 	"github.com/hybridgroup/gobot"
@@ -18,6 +17,7 @@ type BLEMinidroneDriver struct {
 	stepsfa0b  uint16
 	flying     bool
 	Pcmd       Pcmd
+	logger     gobot.Logger
 	gobot.Eventer
 }
 
@@ -78,6 +78,7 @@ func NewBLEMinidroneDriver(a *BLEClientAdaptor, name string) *BLEMinidroneDriver
 			Psi:   0,
 		},
 		Eventer: gobot.NewEventer(),
+		logger:  gobot.DefaultLogger().With("device", name),
 	}
 
 	n.AddEvent(Battery)
@@ -90,6 +91,9 @@ func NewBLEMinidroneDriver(a *BLEClientAdaptor, name string) *BLEMinidroneDriver
 func (b *BLEMinidroneDriver) Connection() gobot.Connection { return b.connection }
 func (b *BLEMinidroneDriver) Name() string                 { return b.name }
 
+// SetLogger sets the Logger used to report errors.
+func (b *BLEMinidroneDriver) SetLogger(l gobot.Logger) { b.logger = l }
+
 // adaptor returns BLE adaptor
 func (b *BLEMinidroneDriver) adaptor() *BLEClientAdaptor {
 	return b.Connection().(*BLEClientAdaptor)
@@ -124,7 +128,7 @@ func (b *BLEMinidroneDriver) Init() (err error) {
 	// subscribe to flying status notifications
 	b.adaptor().Subscribe(DroneNotificationService, FlightStatusCharacteristic, func(data []byte, e error) {
 		if len(data) < 7 || data[2] != 2 {
-			fmt.Println(data)
+			b.logger.Debug("Unexpected flight status", "data", data)
 			return
 		}
 		b.Publish(b.Event(Status), data[6])
@@ -145,7 +149,7 @@ func (b *BLEMinidroneDriver) GenerateAllStates() (err error) {
 	buf := []byte{0x04, byte(b.stepsfa0b), 0x00, 0x04, 0x01, 0x00, 0x32, 0x30, 0x31, 0x34, 0x2D, 0x31, 0x30, 0x2D, 0x32, 0x38, 0x00}
 	err = b.adaptor().WriteCharacteristic(DroneCommandService, CommandCharacteristic, buf)
 	if err != nil {
-		fmt.Println("GenerateAllStates error:", err)
+		b.logger.Error("GenerateAllStates failed", "error", err)
 		return err
 	}
 
@@ -157,7 +161,7 @@ func (b *BLEMinidroneDriver) TakeOff() (err error) {
 	buf := []byte{0x02, byte(b.stepsfa0b) & 0xff, 0x02, 0x00, 0x01, 0x00}
 	err = b.adaptor().WriteCharacteristic(DroneCommandService, CommandCharacteristic, buf)
 	if err != nil {
-		fmt.Println("takeoff error:", err)
+		b.logger.Error("TakeOff failed", "error", err)
 		return err
 	}
 
@@ -187,7 +191,7 @@ func (b *BLEMinidroneDriver) StartPcmd() {
 		for {
 			err := b.adaptor().WriteCharacteristic(DroneCommandService, PcmdCharacteristic, b.generatePcmd().Bytes())
 			if err != nil {
-				fmt.Println("pcmd write error:", err)
+				b.logger.Error("Writing pcmd failed", "error", err)
 			}
 			<-time.After(50 * time.Millisecond)
 		}
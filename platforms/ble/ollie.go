@@ -2,7 +2,6 @@ package ble
 
 import (
 	"bytes"
-	"fmt"
 	"time"
 
 	"github.com/hybridgroup/gobot"
@@ -15,6 +14,7 @@ type SpheroOllieDriver struct {
 	connection    gobot.Connection
 	seq           uint8
 	packetChannel chan *packet
+	logger        gobot.Logger
 	gobot.Eventer
 }
 
@@ -50,6 +50,7 @@ func NewSpheroOllieDriver(a *BLEClientAdaptor, name string) *SpheroOllieDriver {
 		connection:    a,
 		Eventer:       gobot.NewEventer(),
 		packetChannel: make(chan *packet, 1024),
+		logger:        gobot.DefaultLogger().With("device", name),
 	}
 
 	return n
@@ -57,6 +58,9 @@ func NewSpheroOllieDriver(a *BLEClientAdaptor, name string) *SpheroOllieDriver {
 func (b *SpheroOllieDriver) Connection() gobot.Connection { return b.connection }
 func (b *SpheroOllieDriver) Name() string                 { return b.name }
 
+// SetLogger sets the Logger used to report errors and responses.
+func (b *SpheroOllieDriver) SetLogger(l gobot.Logger) { b.logger = l }
+
 // adaptor returns BLE adaptor
 func (b *SpheroOllieDriver) adaptor() *BLEClientAdaptor {
 	return b.Connection().(*BLEClientAdaptor)
@@ -106,7 +110,7 @@ func (b *SpheroOllieDriver) AntiDOSOff() (err error) {
 
 	err = b.adaptor().WriteCharacteristic(SpheroBLEService, AntiDosCharacteristic, buf.Bytes())
 	if err != nil {
-		fmt.Println("AntiDOSOff error:", err)
+		b.logger.Error("AntiDOSOff failed", "error", err)
 		return err
 	}
 
@@ -119,7 +123,7 @@ func (b *SpheroOllieDriver) Wake() (err error) {
 
 	err = b.adaptor().WriteCharacteristic(SpheroBLEService, WakeCharacteristic, buf)
 	if err != nil {
-		fmt.Println("Wake error:", err)
+		b.logger.Error("Wake failed", "error", err)
 		return err
 	}
 
@@ -132,7 +136,7 @@ func (b *SpheroOllieDriver) SetTXPower(level int) (err error) {
 
 	err = b.adaptor().WriteCharacteristic(SpheroBLEService, TXPowerCharacteristic, buf)
 	if err != nil {
-		fmt.Println("SetTXLevel error:", err)
+		b.logger.Error("SetTXPower failed", "level", level, "error", err)
 		return err
 	}
 
@@ -141,7 +145,7 @@ func (b *SpheroOllieDriver) SetTXPower(level int) (err error) {
 
 // Handle responses returned from Ollie
 func (b *SpheroOllieDriver) HandleResponses(data []byte, e error) {
-	fmt.Println("response data:", data)
+	b.logger.Debug("Response received", "data", data)
 
 	return
 }
@@ -175,7 +179,7 @@ func (s *SpheroOllieDriver) write(packet *packet) (err error) {
 	buf = append(buf, packet.checksum)
 	err = s.adaptor().WriteCharacteristic(RobotControlService, CommandsCharacteristic, buf)
 	if err != nil {
-		fmt.Println("send command error:", err)
+		s.logger.Error("Sending command failed", "error", err)
 		return err
 	}
 
@@ -2,7 +2,9 @@ package chip
 
 import (
 	"errors"
+	"time"
 
+	"github.com/hybridgroup/gobot"
 	"github.com/hybridgroup/gobot/sysfs"
 )
 
@@ -10,6 +12,9 @@ type ChipAdaptor struct {
 	name        string
 	digitalPins map[int]sysfs.DigitalPin
 	i2cDevice   sysfs.I2cDevice
+	spiDevices  map[string]sysfs.SPIDevice
+	gpiochips   sysfs.Gpiochips
+	pinFlags    map[int][]sysfs.LineFlag
 }
 
 var pins = map[string]int{
@@ -23,11 +28,19 @@ var pins = map[string]int{
 	"XIO-P7": 415,
 }
 
+// gpiochips maps the XIO gpio numbers to the lines of the gpio character
+// device of the PCF8574A expander
+var gpiochips = sysfs.Gpiochips{
+	{Label: "pcf8574a", Base: 408, Count: 8},
+}
+
 // NewChipAdaptor creates a ChipAdaptor with the specified name
 func NewChipAdaptor(name string) *ChipAdaptor {
 	c := &ChipAdaptor{
 		name:        name,
 		digitalPins: make(map[int]sysfs.DigitalPin),
+		pinFlags:    make(map[int][]sysfs.LineFlag),
+		spiDevices:  make(map[string]sysfs.SPIDevice),
 	}
 	return c
 }
@@ -49,6 +62,11 @@ func (c *ChipAdaptor) Finalize() (errs []error) {
 			}
 		}
 	}
+	for _, device := range c.spiDevices {
+		if err := device.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
 	if c.i2cDevice != nil {
 		if err := c.i2cDevice.Close(); err != nil {
 			errs = append(errs, err)
@@ -75,7 +93,9 @@ func (c *ChipAdaptor) digitalPin(pin string, dir string) (sysfsPin sysfs.Digital
 	}
 
 	if c.digitalPins[i] == nil {
-		c.digitalPins[i] = sysfs.NewDigitalPin(i)
+		if c.digitalPins[i], err = c.newDigitalPin(i); err != nil {
+			return
+		}
 		if err = c.digitalPins[i].Export(); err != nil {
 			return
 		}
@@ -88,9 +108,43 @@ func (c *ChipAdaptor) digitalPin(pin string, dir string) (sysfsPin sysfs.Digital
 	return c.digitalPins[i], nil
 }
 
+// UseGpiochip makes the adaptor drive its digital pins through the gpio
+// character devices instead of the deprecated sysfs gpio interface. The
+// chips, if given, replace the default mapping of XIO gpio numbers to
+// the lines of the character devices. It needs Linux 5.10 or later, which
+// introduced version 2 of the gpio character device interface, so not the
+// 4.4 kernel of the stock C.H.I.P. images.
+func (c *ChipAdaptor) UseGpiochip(chips ...sysfs.GpiochipRange) {
+	c.gpiochips = gpiochips
+	if len(chips) > 0 {
+		c.gpiochips = chips
+	}
+}
+
+// SetPinFlags sets the bias and active-low of a digital pin, which apply
+// from the first use of the pin. They require UseGpiochip.
+func (c *ChipAdaptor) SetPinFlags(pin string, flags ...sysfs.LineFlag) (err error) {
+	i, err := c.translatePin(pin)
+	if err != nil {
+		return
+	}
+	c.pinFlags[i] = flags
+	return
+}
+
+// newDigitalPin returns the sysfs gpio or gpio character device pin of a gpio
+// number
+func (c *ChipAdaptor) newDigitalPin(i int) (sysfs.DigitalPin, error) {
+	if c.gpiochips == nil {
+		return sysfs.NewDigitalPin(i), nil
+	}
+	return c.gpiochips.NewDigitalPin(i, c.pinFlags[i]...)
+}
+
 // DigitalRead reads digital value from the specified pin.
 // Valids pins are XIO-P0 through XIO-P7 (pins 13-20 on header 14).
 func (c *ChipAdaptor) DigitalRead(pin string) (val int, err error) {
+	defer gobot.CountIOError(c, &err)
 	sysfsPin, err := c.digitalPin(pin, sysfs.IN)
 	if err != nil {
 		return
@@ -101,6 +155,7 @@ func (c *ChipAdaptor) DigitalRead(pin string) (val int, err error) {
 // DigitalWrite writes digital value to the specified pin.
 // Valids pins are XIO-P0 through XIO-P7 (pins 13-20 on header 14).
 func (c *ChipAdaptor) DigitalWrite(pin string, val byte) (err error) {
+	defer gobot.CountIOError(c, &err)
 	sysfsPin, err := c.digitalPin(pin, sysfs.OUT)
 	if err != nil {
 		return err
@@ -108,10 +163,23 @@ func (c *ChipAdaptor) DigitalWrite(pin string, val byte) (err error) {
 	return sysfsPin.Write(int(val))
 }
 
+// WaitForDigitalEdge waits at most timeout for a "rising", "falling" or
+// "both" edge of a digital input, and returns the value of the pin after the
+// edge and the time of the edge. The value is -1 if the timeout passed.
+func (c *ChipAdaptor) WaitForDigitalEdge(pin string, edge string, timeout time.Duration) (val int, t time.Time, err error) {
+	defer gobot.CountIOError(c, &err)
+	sysfsPin, err := c.digitalPin(pin, sysfs.IN)
+	if err != nil {
+		return -1, t, err
+	}
+	return sysfs.WaitForEdge(sysfsPin, edge, timeout)
+}
+
 // I2cStart starts an i2c device in specified address.
 // This assumes that the bus used is /dev/i2c-1, which corresponds to
 // pins labeled TWI1-SDA and TW1-SCK (pins 9 and 11 on header 13).
 func (c *ChipAdaptor) I2cStart(address int) (err error) {
+	defer gobot.CountIOError(c, &err)
 	if c.i2cDevice == nil {
 		c.i2cDevice, err = sysfs.NewI2cDevice("/dev/i2c-1", address)
 	}
@@ -120,6 +188,7 @@ func (c *ChipAdaptor) I2cStart(address int) (err error) {
 
 // I2cWrite writes data to i2c device
 func (c *ChipAdaptor) I2cWrite(address int, data []byte) (err error) {
+	defer gobot.CountIOError(c, &err)
 	if err = c.i2cDevice.SetAddress(address); err != nil {
 		return
 	}
@@ -129,6 +198,7 @@ func (c *ChipAdaptor) I2cWrite(address int, data []byte) (err error) {
 
 // I2cRead returns value from i2c device using specified size
 func (c *ChipAdaptor) I2cRead(address int, size int) (data []byte, err error) {
+	defer gobot.CountIOError(c, &err)
 	if err = c.i2cDevice.SetAddress(address); err != nil {
 		return
 	}
@@ -136,3 +206,73 @@ func (c *ChipAdaptor) I2cRead(address int, size int) (data []byte, err error) {
 	_, err = c.i2cDevice.Read(data)
 	return
 }
+
+// ReadByteData reads a byte from register reg of the i2c device at address
+func (c *ChipAdaptor) ReadByteData(address int, reg uint8) (val uint8, err error) {
+	defer gobot.CountIOError(c, &err)
+	return sysfs.I2cReadByteData(c.i2cDevice, address, reg)
+}
+
+// WriteByteData writes val to register reg of the i2c device at address
+func (c *ChipAdaptor) WriteByteData(address int, reg uint8, val uint8) (err error) {
+	defer gobot.CountIOError(c, &err)
+	return sysfs.I2cWriteByteData(c.i2cDevice, address, reg, val)
+}
+
+// ReadWordData reads a little-endian word from register reg of the i2c
+// device at address
+func (c *ChipAdaptor) ReadWordData(address int, reg uint8) (val uint16, err error) {
+	defer gobot.CountIOError(c, &err)
+	return sysfs.I2cReadWordData(c.i2cDevice, address, reg)
+}
+
+// WriteWordData writes val as a little-endian word to register reg of the
+// i2c device at address
+func (c *ChipAdaptor) WriteWordData(address int, reg uint8, val uint16) (err error) {
+	defer gobot.CountIOError(c, &err)
+	return sysfs.I2cWriteWordData(c.i2cDevice, address, reg, val)
+}
+
+// ReadBlockData reads size bytes starting at register reg of the i2c device
+// at address
+func (c *ChipAdaptor) ReadBlockData(address int, reg uint8, size int) (data []byte, err error) {
+	defer gobot.CountIOError(c, &err)
+	return sysfs.I2cReadBlockData(c.i2cDevice, address, reg, size)
+}
+
+// WriteRead writes buf to the i2c device at address and then reads size bytes
+// from it, without a stop condition in between
+func (c *ChipAdaptor) WriteRead(address int, buf []byte, size int) (data []byte, err error) {
+	defer gobot.CountIOError(c, &err)
+	return sysfs.I2cWriteRead(c.i2cDevice, address, buf, size)
+}
+
+// SpiStart opens the spi device on a chip select of a bus, such as
+// /dev/spidev32766.0 once the spi device tree overlay is loaded, with the
+// mode, bits per word and maximum speed in Hz of its transfers
+func (c *ChipAdaptor) SpiStart(bus int, chip int, mode int, bits int, speed int) (err error) {
+	defer gobot.CountIOError(c, &err)
+	location := sysfs.SPIDevicePath(bus, chip)
+	if c.spiDevices[location] != nil {
+		return
+	}
+	device, err := sysfs.NewSPIDevice(location, byte(mode), byte(bits), uint32(speed))
+	if err != nil {
+		return
+	}
+	c.spiDevices[location] = device
+	return
+}
+
+// SpiTransfer writes data to the spi device on a chip select of a bus and
+// returns the bytes read at the same time
+func (c *ChipAdaptor) SpiTransfer(bus int, chip int, data []byte) (read []byte, err error) {
+	defer gobot.CountIOError(c, &err)
+	device := c.spiDevices[sysfs.SPIDevicePath(bus, chip)]
+	if device == nil {
+		return nil, errors.New("SPI device not started")
+	}
+	read = make([]byte, len(data))
+	err = device.Transfer(data, read)
+	return
+}
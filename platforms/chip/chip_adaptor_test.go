@@ -8,15 +8,18 @@ import (
 	"github.com/hybridgroup/gobot/gobottest"
 	"github.com/hybridgroup/gobot/platforms/gpio"
 	"github.com/hybridgroup/gobot/platforms/i2c"
+	"github.com/hybridgroup/gobot/platforms/spi"
 	"github.com/hybridgroup/gobot/sysfs"
 )
 
 var _ gobot.Adaptor = (*ChipAdaptor)(nil)
 
 var _ gpio.DigitalReader = (*ChipAdaptor)(nil)
+var _ gpio.DigitalWatcher = (*ChipAdaptor)(nil)
 var _ gpio.DigitalWriter = (*ChipAdaptor)(nil)
 
 var _ i2c.I2c = (*ChipAdaptor)(nil)
+var _ spi.SPI = (*ChipAdaptor)(nil)
 
 type NullReadWriteCloser struct {
 	contents []byte
@@ -38,6 +41,30 @@ func (n *NullReadWriteCloser) Read(b []byte) (int, error) {
 	return len(b), nil
 }
 
+func (n *NullReadWriteCloser) ReadByteData(reg uint8) (uint8, error) {
+	return 0, nil
+}
+
+func (n *NullReadWriteCloser) WriteByteData(reg uint8, val uint8) error {
+	return nil
+}
+
+func (n *NullReadWriteCloser) ReadWordData(reg uint8) (uint16, error) {
+	return 0, nil
+}
+
+func (n *NullReadWriteCloser) WriteWordData(reg uint8, val uint16) error {
+	return nil
+}
+
+func (n *NullReadWriteCloser) ReadBlockData(reg uint8, b []byte) error {
+	return nil
+}
+
+func (n *NullReadWriteCloser) WriteRead(w []byte, r []byte) error {
+	return nil
+}
+
 var closeErr error = nil
 
 func (n *NullReadWriteCloser) Close() error {
@@ -73,6 +100,26 @@ func TestChipAdaptorDigitalIO(t *testing.T) {
 	gobottest.Assert(t, a.DigitalWrite("XIO-P10", 1), errors.New("Not a valid pin"))
 }
 
+func TestChipAdaptorGpiochip(t *testing.T) {
+	a := initTestChipAdaptor()
+	// the expander is found by its label wherever the kernel numbered it
+	fs := sysfs.NewMockFilesystem([]string{"/dev/gpiochip0", "/dev/gpiochip1"})
+	sysfs.SetFilesystem(fs)
+	labels := map[string]string{"/dev/gpiochip0": "1c20800.pinctrl", "/dev/gpiochip1": "pcf8574a"}
+	sysfs.SetSyscall(&sysfs.MockSyscall{Impl: sysfs.MockGpiochipLabels(fs, labels, nil)})
+
+	a.UseGpiochip()
+	gobottest.Assert(t, a.SetPinFlags("XIO-P7", sysfs.PullDown, sysfs.ActiveLow), nil)
+	gobottest.Assert(t, a.SetPinFlags("XIO-P10"), errors.New("Not a valid pin"))
+
+	gobottest.Assert(t, a.DigitalWrite("XIO-P0", 1), nil)
+	gobottest.Assert(t, fs.Files["/dev/gpiochip1"].Opened, true)
+	i, err := a.DigitalRead("XIO-P7")
+	gobottest.Assert(t, err, nil)
+	gobottest.Assert(t, i, 0)
+	gobottest.Assert(t, len(a.Finalize()), 0)
+}
+
 func TestChipAdaptorI2c(t *testing.T) {
 	a := initTestChipAdaptor()
 	fs := sysfs.NewMockFilesystem([]string{
@@ -89,3 +136,24 @@ func TestChipAdaptorI2c(t *testing.T) {
 
 	gobottest.Assert(t, len(a.Finalize()), 0)
 }
+
+func TestChipAdaptorSpi(t *testing.T) {
+	a := initTestChipAdaptor()
+	fs := sysfs.NewMockFilesystem([]string{
+		"/dev/spidev32766.0",
+	})
+	sysfs.SetFilesystem(fs)
+	sysfs.SetSyscall(&sysfs.MockSyscall{})
+
+	_, err := a.SpiTransfer(32766, 0, []byte{0x01})
+	gobottest.Assert(t, err, errors.New("SPI device not started"))
+	gobottest.Refute(t, a.SpiStart(32766, 9, spi.Mode0, 8, 1000000), nil)
+
+	gobottest.Assert(t, a.SpiStart(32766, 0, spi.Mode0, 8, 1000000), nil)
+	gobottest.Assert(t, a.SpiStart(32766, 0, spi.Mode0, 8, 1000000), nil)
+	data, err := a.SpiTransfer(32766, 0, []byte{0x01, 0x02})
+	gobottest.Assert(t, err, nil)
+	gobottest.Assert(t, len(data), 2)
+
+	gobottest.Assert(t, len(a.Finalize()), 0)
+}
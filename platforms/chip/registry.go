@@ -0,0 +1,9 @@
+package chip
+
+import "github.com/hybridgroup/gobot"
+
+func init() {
+	gobot.RegisterAdaptor("chip", func(config gobot.ConnectionConfig) (gobot.Adaptor, error) {
+		return NewChipAdaptor(config.Name), nil
+	})
+}
@@ -51,6 +51,7 @@ const (
 	I2CModeRead              byte = 0x01
 	I2CModeContinuousRead    byte = 0x02
 	I2CModeStopReading       byte = 0x03
+	I2CRestartTransmission   byte = 0x40
 	ServoConfig              byte = 0x70
 )
 
@@ -275,6 +276,15 @@ func (b *Client) I2cRead(address int, numBytes int) error {
 		byte(numBytes) & 0x7F, (byte(numBytes) >> 7) & 0x7F})
 }
 
+// I2cReadRegister reads numBytes from register of address once. The register
+// is written and read without a stop condition in between.
+func (b *Client) I2cReadRegister(address int, register int, numBytes int) error {
+	return b.writeSysex([]byte{I2CRequest, byte(address),
+		(I2CModeRead << 3) | I2CRestartTransmission,
+		byte(register) & 0x7F, (byte(register) >> 7) & 0x7F,
+		byte(numBytes) & 0x7F, (byte(numBytes) >> 7) & 0x7F})
+}
+
 // I2cWrite writes data to address.
 func (b *Client) I2cWrite(address int, data []byte) error {
 	ret := []byte{I2CRequest, byte(address), (I2CModeWrite << 3)}
@@ -343,3 +343,14 @@ func TestServoConfig(t *testing.T) {
 		gobottest.Assert(t, err, test.result)
 	}
 }
+
+func TestI2cReadRegister(t *testing.T) {
+	b := New()
+	b.connection = readWriteCloser{}
+
+	testWriteData.Reset()
+	err := b.I2cReadRegister(0x68, 0x3B, 6)
+	gobottest.Assert(t, err, nil)
+	gobottest.Assert(t, testWriteData.Bytes(),
+		[]byte{0xF0, 0x76, 0x68, 0x48, 0x3B, 0, 6, 0, 0xF7})
+}
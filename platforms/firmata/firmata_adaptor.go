@@ -1,12 +1,14 @@
 package firmata
 
 import (
+	"errors"
 	"io"
 	"strconv"
 	"time"
 
 	"github.com/hybridgroup/gobot"
 	"github.com/hybridgroup/gobot/platforms/firmata/client"
+	"github.com/hybridgroup/gobot/platforms/i2c"
 	"github.com/tarm/goserial"
 )
 
@@ -20,12 +22,17 @@ type firmataBoard interface {
 	ReportDigital(int, int) error
 	DigitalWrite(int, int) error
 	I2cRead(int, int) error
+	I2cReadRegister(int, int, int) error
 	I2cWrite(int, []byte) error
 	I2cConfig(int) error
 	ServoConfig(int, int, int) error
 	Event(string) string
 }
 
+// ErrI2cWriteRead is returned by WriteRead for writes longer than the single
+// register byte firmata sends before a read
+var ErrI2cWriteRead = errors.New("Firmata can only write a register byte before an i2c read")
+
 // FirmataAdaptor is the Gobot Adaptor for Firmata based boards
 type FirmataAdaptor struct {
 	name   string
@@ -118,6 +125,7 @@ func (f *FirmataAdaptor) ServoConfig(pin string, min, max int) error {
 
 // ServoWrite writes the 0-180 degree angle to the specified pin.
 func (f *FirmataAdaptor) ServoWrite(pin string, angle byte) (err error) {
+	defer gobot.CountIOError(f, &err)
 	p, err := strconv.Atoi(pin)
 	if err != nil {
 		return err
@@ -135,6 +143,7 @@ func (f *FirmataAdaptor) ServoWrite(pin string, angle byte) (err error) {
 
 // PwmWrite writes the 0-254 value to the specified pin
 func (f *FirmataAdaptor) PwmWrite(pin string, level byte) (err error) {
+	defer gobot.CountIOError(f, &err)
 	p, err := strconv.Atoi(pin)
 	if err != nil {
 		return err
@@ -152,6 +161,7 @@ func (f *FirmataAdaptor) PwmWrite(pin string, level byte) (err error) {
 
 // DigitalWrite writes a value to the pin. Acceptable values are 1 or 0.
 func (f *FirmataAdaptor) DigitalWrite(pin string, level byte) (err error) {
+	defer gobot.CountIOError(f, &err)
 	p, err := strconv.Atoi(pin)
 	if err != nil {
 		return
@@ -171,6 +181,7 @@ func (f *FirmataAdaptor) DigitalWrite(pin string, level byte) (err error) {
 // DigitalRead retrieves digital value from specified pin.
 // Returns -1 if the response from the board has timed out
 func (f *FirmataAdaptor) DigitalRead(pin string) (val int, err error) {
+	defer gobot.CountIOError(f, &err)
 	p, err := strconv.Atoi(pin)
 	if err != nil {
 		return
@@ -192,6 +203,7 @@ func (f *FirmataAdaptor) DigitalRead(pin string) (val int, err error) {
 // AnalogRead retrieves value from analog pin.
 // Returns -1 if the response from the board has timed out
 func (f *FirmataAdaptor) AnalogRead(pin string) (val int, err error) {
+	defer gobot.CountIOError(f, &err)
 	p, err := strconv.Atoi(pin)
 	if err != nil {
 		return
@@ -220,15 +232,80 @@ func (f *FirmataAdaptor) digitalPin(pin int) int {
 
 // I2cStart starts an i2c device at specified address
 func (f *FirmataAdaptor) I2cStart(address int) (err error) {
+	defer gobot.CountIOError(f, &err)
 	return f.board.I2cConfig(0)
 }
 
 // I2cRead returns size bytes from the i2c device
 // Returns an empty array if the response from the board has timed out
 func (f *FirmataAdaptor) I2cRead(address int, size int) (data []byte, err error) {
+	defer gobot.CountIOError(f, &err)
+	return f.i2cReply(func() error {
+		return f.board.I2cRead(address, size)
+	})
+}
+
+// ReadByteData reads a byte from register reg of the i2c device at address
+func (f *FirmataAdaptor) ReadByteData(address int, reg uint8) (val uint8, err error) {
+	data, err := f.ReadBlockData(address, reg, 1)
+	if err != nil {
+		return
+	}
+	if len(data) < 1 {
+		return 0, i2c.ErrNotEnoughBytes
+	}
+	return data[0], nil
+}
+
+// WriteByteData writes val to register reg of the i2c device at address
+func (f *FirmataAdaptor) WriteByteData(address int, reg uint8, val uint8) (err error) {
+	return f.I2cWrite(address, []byte{reg, val})
+}
+
+// ReadWordData reads a little-endian word from register reg of the i2c
+// device at address
+func (f *FirmataAdaptor) ReadWordData(address int, reg uint8) (val uint16, err error) {
+	data, err := f.ReadBlockData(address, reg, 2)
+	if err != nil {
+		return
+	}
+	if len(data) < 2 {
+		return 0, i2c.ErrNotEnoughBytes
+	}
+	return uint16(data[0]) | uint16(data[1])<<8, nil
+}
+
+// WriteWordData writes val as a little-endian word to register reg of the
+// i2c device at address
+func (f *FirmataAdaptor) WriteWordData(address int, reg uint8, val uint16) (err error) {
+	return f.I2cWrite(address, []byte{reg, byte(val), byte(val >> 8)})
+}
+
+// ReadBlockData reads size bytes starting at register reg of the i2c device
+// at address
+func (f *FirmataAdaptor) ReadBlockData(address int, reg uint8, size int) (data []byte, err error) {
+	defer gobot.CountIOError(f, &err)
+	return f.i2cReply(func() error {
+		return f.board.I2cReadRegister(address, int(reg), size)
+	})
+}
+
+// WriteRead writes buf to the i2c device at address and then reads size bytes
+// from it, without a stop condition in between. Firmata only supports writing
+// the single register byte of a register read.
+func (f *FirmataAdaptor) WriteRead(address int, buf []byte, size int) (data []byte, err error) {
+	if len(buf) != 1 {
+		err = ErrI2cWriteRead
+		return
+	}
+	return f.ReadBlockData(address, buf[0], size)
+}
+
+// i2cReply sends an i2c read request and waits for the data of its reply
+func (f *FirmataAdaptor) i2cReply(request func() error) (data []byte, err error) {
 	ret := make(chan []byte)
 
-	if err = f.board.I2cRead(address, size); err != nil {
+	if err = request(); err != nil {
 		return
 	}
 
@@ -243,5 +320,6 @@ func (f *FirmataAdaptor) I2cRead(address int, size int) (data []byte, err error)
 
 // I2cWrite writes data to i2c device
 func (f *FirmataAdaptor) I2cWrite(address int, data []byte) (err error) {
+	defer gobot.CountIOError(f, &err)
 	return f.board.I2cWrite(address, data)
 }
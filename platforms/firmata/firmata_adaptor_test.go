@@ -53,7 +53,8 @@ func (readWriteCloser) Close() error {
 type mockFirmataBoard struct {
 	disconnectError error
 	gobot.Eventer
-	pins []client.Pin
+	pins        []client.Pin
+	i2cRegister int
 }
 
 func newMockFirmataBoard() *mockFirmataBoard {
@@ -86,6 +87,10 @@ func (mockFirmataBoard) I2cRead(int, int) error          { return nil }
 func (mockFirmataBoard) I2cWrite(int, []byte) error      { return nil }
 func (mockFirmataBoard) I2cConfig(int) error             { return nil }
 func (mockFirmataBoard) ServoConfig(int, int, int) error { return nil }
+func (m *mockFirmataBoard) I2cReadRegister(address int, register int, numBytes int) error {
+	m.i2cRegister = register
+	return nil
+}
 
 func initTestFirmataAdaptor() *FirmataAdaptor {
 	a := NewFirmataAdaptor("board", "/dev/null")
@@ -183,6 +188,47 @@ func TestFirmataAdaptorI2cWrite(t *testing.T) {
 	a := initTestFirmataAdaptor()
 	a.I2cWrite(0x00, []byte{0x00, 0x01})
 }
+func TestFirmataAdaptorSMBus(t *testing.T) {
+	a := initTestFirmataAdaptor()
+	reply := func(data []byte) {
+		go func() {
+			<-time.After(10 * time.Millisecond)
+			a.Publish(a.board.Event("I2cReply"), client.I2cReply{Data: data})
+		}()
+	}
+
+	reply([]byte{0xab})
+	val, err := a.ReadByteData(0x68, 0x3B)
+	gobottest.Assert(t, err, nil)
+	gobottest.Assert(t, val, uint8(0xab))
+	gobottest.Assert(t, a.board.(*mockFirmataBoard).i2cRegister, 0x3B)
+
+	reply([]byte{0x34, 0x12})
+	word, err := a.ReadWordData(0x68, 0x3C)
+	gobottest.Assert(t, err, nil)
+	gobottest.Assert(t, word, uint16(0x1234))
+	gobottest.Assert(t, a.board.(*mockFirmataBoard).i2cRegister, 0x3C)
+
+	reply([]byte{0x01, 0x02})
+	data, err := a.WriteRead(0x68, []byte{0x43}, 2)
+	gobottest.Assert(t, err, nil)
+	gobottest.Assert(t, data, []byte{0x01, 0x02})
+	gobottest.Assert(t, a.board.(*mockFirmataBoard).i2cRegister, 0x43)
+
+	_, err = a.WriteRead(0x68, []byte{0x43, 0x44}, 2)
+	gobottest.Assert(t, err, ErrI2cWriteRead)
+
+	reply([]byte{})
+	_, err = a.ReadByteData(0x68, 0x3B)
+	gobottest.Assert(t, err, i2c.ErrNotEnoughBytes)
+
+	reply([]byte{0x34})
+	_, err = a.ReadWordData(0x68, 0x3C)
+	gobottest.Assert(t, err, i2c.ErrNotEnoughBytes)
+
+	gobottest.Assert(t, a.WriteByteData(0x68, 0x6B, 0x00), nil)
+	gobottest.Assert(t, a.WriteWordData(0x68, 0x6B, 0x0100), nil)
+}
 
 func TestServoConfig(t *testing.T) {
 	a := initTestFirmataAdaptor()
@@ -0,0 +1,9 @@
+package firmata
+
+import "github.com/hybridgroup/gobot"
+
+func init() {
+	gobot.RegisterAdaptor("firmata", func(config gobot.ConnectionConfig) (gobot.Adaptor, error) {
+		return NewFirmataAdaptor(config.Name, config.Port), nil
+	})
+}
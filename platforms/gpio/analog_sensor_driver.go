@@ -1,6 +1,7 @@
 package gpio
 
 import (
+	"sync"
 	"time"
 
 	"github.com/hybridgroup/gobot"
@@ -13,8 +14,11 @@ type AnalogSensorDriver struct {
 	halt       chan bool
 	interval   time.Duration
 	connection AnalogReader
+	mutex      sync.Mutex
+	value      int
+	gauge      *gobot.Gauge
 	gobot.Eventer
-	gobot.Commander
+	gobot.ExtendedCommander
 }
 
 // NewAnalogSensorDriver returns a new AnalogSensorDriver with a polling interval of
@@ -27,13 +31,13 @@ type AnalogSensorDriver struct {
 // 	"Read" - See AnalogSensor.Read
 func NewAnalogSensorDriver(a AnalogReader, name string, pin string, v ...time.Duration) *AnalogSensorDriver {
 	d := &AnalogSensorDriver{
-		name:       name,
-		connection: a,
-		pin:        pin,
-		Eventer:    gobot.NewEventer(),
-		Commander:  gobot.NewCommander(),
-		interval:   10 * time.Millisecond,
-		halt:       make(chan bool),
+		name:              name,
+		connection:        a,
+		pin:               pin,
+		Eventer:           gobot.NewEventer(),
+		ExtendedCommander: gobot.NewCommander(),
+		interval:          10 * time.Millisecond,
+		halt:              make(chan bool),
 	}
 
 	if len(v) > 0 {
@@ -55,16 +59,28 @@ func NewAnalogSensorDriver(a AnalogReader, name string, pin string, v ...time.Du
 // Emits the Events:
 //	Data int - Event is emitted on change and represents the current reading from the sensor.
 //	Error error - Event is emitted on error reading from the sensor.
+// Each reading is also recorded in the gobot_sensor_value gauge of the
+// Registry of its Robot, or of gobot.DefaultRegistry if it has none.
 func (a *AnalogSensorDriver) Start() (errs []error) {
-	value := 0
+	a.setValue(0)
+	gauge := a.gauge
+	if gauge == nil {
+		gauge = gobot.DefaultRegistry.Gauge("gobot_sensor_value",
+			"The latest reading of a sensor.", gobot.Labels{"device": a.name, "pin": a.pin})
+	}
 	go func() {
+		value := 0
 		for {
 			newValue, err := a.Read()
 			if err != nil {
 				a.Publish(a.Event(Error), err)
-			} else if newValue != value && newValue != -1 {
-				value = newValue
-				a.Publish(a.Event(Data), value)
+			} else if newValue != -1 {
+				gauge.Set(float64(newValue))
+				if newValue != value {
+					value = newValue
+					a.setValue(value)
+					a.Publish(a.Event(Data), value)
+				}
 			}
 			select {
 			case <-time.After(a.interval):
@@ -76,6 +92,17 @@ func (a *AnalogSensorDriver) Start() (errs []error) {
 	return
 }
 
+// Instrument records the invocations of the commands and the readings of the
+// AnalogSensorDriver in metrics.
+func (a *AnalogSensorDriver) Instrument(metrics *gobot.Registry, labels gobot.Labels) {
+	a.ExtendedCommander.Instrument(metrics, labels)
+	gaugeLabels := gobot.Labels{"pin": a.pin}
+	for k, v := range labels {
+		gaugeLabels[k] = v
+	}
+	a.gauge = metrics.Gauge("gobot_sensor_value", "The latest reading of a sensor.", gaugeLabels)
+}
+
 // Halt stops polling the analog sensor for new information
 func (a *AnalogSensorDriver) Halt() (errs []error) {
 	a.halt <- true
@@ -91,6 +118,19 @@ func (a *AnalogSensorDriver) Pin() string { return a.pin }
 // Connection returns the AnalogSensorDrivers Connection
 func (a *AnalogSensorDriver) Connection() gobot.Connection { return a.connection.(gobot.Connection) }
 
+// Snapshot returns the last reading of the sensor
+func (a *AnalogSensorDriver) Snapshot() map[string]interface{} {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	return map[string]interface{}{"value": a.value}
+}
+
+func (a *AnalogSensorDriver) setValue(value int) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	a.value = value
+}
+
 // Read returns the current reading from the Analog Sensor
 func (a *AnalogSensorDriver) Read() (val int, err error) {
 	return a.connection.AnalogRead(a.Pin())
@@ -56,6 +56,7 @@ func TestAnalogSensorDriverStart(t *testing.T) {
 	case <-time.After(10 * time.Second):
 		t.Errorf("AnalogSensor Event \"Data\" was not published")
 	}
+	gobottest.Assert(t, d.Snapshot(), map[string]interface{}{"value": 100})
 
 	// read error
 	d.Once(d.Event(Error), func(data interface{}) {
@@ -100,3 +101,21 @@ func TestAnalogSensorDriverHalt(t *testing.T) {
 	}()
 	gobottest.Assert(t, len(d.Halt()), 0)
 }
+
+func TestAnalogSensorDriverMetrics(t *testing.T) {
+	reg := gobot.NewRegistry()
+	a := newGpioTestAdaptor("adaptor")
+	d := NewAnalogSensorDriver(a, "sensor", "1")
+	gobot.NewRobot("bot", []gobot.Connection{a}, []gobot.Device{d}, reg)
+
+	testAdaptorAnalogRead = func() (val int, err error) {
+		val = 42
+		return
+	}
+	gobottest.Assert(t, len(d.Start()), 0)
+	<-time.After(20 * time.Millisecond)
+	gobottest.Assert(t, len(d.Halt()), 0)
+
+	gauge := reg.Gauge("gobot_sensor_value", "", gobot.Labels{"robot": "bot", "device": "sensor", "pin": "1"})
+	gobottest.Assert(t, gauge.Value(), float64(42))
+}
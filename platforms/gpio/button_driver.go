@@ -1,8 +1,10 @@
 package gpio
 
 import (
-	"github.com/hybridgroup/gobot"
+	"sync"
 	"time"
+
+	"github.com/hybridgroup/gobot"
 )
 
 // ButtonDriver Represents a digital Button
@@ -13,6 +15,7 @@ type ButtonDriver struct {
 	halt       chan bool
 	interval   time.Duration
 	connection DigitalReader
+	mutex      sync.Mutex
 	gobot.Eventer
 }
 
@@ -43,34 +46,22 @@ func NewButtonDriver(a DigitalReader, name string, pin string, v ...time.Duratio
 	return b
 }
 
-// Start starts the ButtonDriver and polls the state of the button at the given interval.
+// Start starts the ButtonDriver, which waits for the edges of the button if
+// the adaptor is a DigitalWatcher, or else polls the state of the button at
+// the given interval.
 //
 // Emits the Events:
 // 	Push int - On button push
 //	Release int - On button release
 //	Error error - On button error
 func (b *ButtonDriver) Start() (errs []error) {
-	state := 0
-	go func() {
-		for {
-			newValue, err := b.connection.DigitalRead(b.Pin())
-			if err != nil {
-				b.Publish(Error, err)
-			} else if newValue != state && newValue != -1 {
-				state = newValue
-				b.update(newValue)
-			}
-			select {
-			case <-time.After(b.interval):
-			case <-b.halt:
-				return
-			}
-		}
-	}()
+	go watchDigital(b.connection, b.Pin(), 0, b.interval, b.halt, b.update, func(err error) {
+		b.Publish(Error, err)
+	})
 	return
 }
 
-// Halt stops polling the button for new information
+// Halt stops watching the button for new information
 func (b *ButtonDriver) Halt() (errs []error) {
 	b.halt <- true
 	return
@@ -85,12 +76,20 @@ func (b *ButtonDriver) Pin() string { return b.pin }
 // Connection returns the ButtonDrivers Connection
 func (b *ButtonDriver) Connection() gobot.Connection { return b.connection.(gobot.Connection) }
 
+// Snapshot returns whether the button is pushed
+func (b *ButtonDriver) Snapshot() map[string]interface{} {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return map[string]interface{}{"active": b.Active}
+}
+
 func (b *ButtonDriver) update(newValue int) {
+	b.mutex.Lock()
+	b.Active = newValue == 1
+	b.mutex.Unlock()
 	if newValue == 1 {
-		b.Active = true
 		b.Publish(ButtonPush, newValue)
 	} else {
-		b.Active = false
 		b.Publish(ButtonRelease, newValue)
 	}
 }
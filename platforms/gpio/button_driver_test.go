@@ -104,3 +104,33 @@ func TestButtonDriverStart(t *testing.T) {
 	}
 
 }
+
+func TestButtonDriverWatch(t *testing.T) {
+	a := newGpioTestWatcherAdaptor("adaptor")
+	d := NewButtonDriver(a, "bot", "1", time.Hour)
+	events := make(chan string, 2)
+	d.On(ButtonPush, func(data interface{}) { events <- ButtonPush })
+	d.On(ButtonRelease, func(data interface{}) { events <- ButtonRelease })
+	gobottest.Assert(t, len(d.Start()), 0)
+
+	// edges are reported without waiting for the polling interval
+	for _, edge := range []int{1, 0} {
+		a.edges <- edge
+		select {
+		case event := <-events:
+			gobottest.Assert(t, event, map[int]string{1: ButtonPush, 0: ButtonRelease}[edge])
+		case <-time.After(BUTTON_TEST_DELAY * time.Millisecond):
+			t.Fatalf("Button event for edge %v was not published", edge)
+		}
+	}
+	gobottest.Assert(t, d.Active, false)
+	gobottest.Assert(t, len(d.Halt()), 0)
+
+	// adaptors which can't detect the edges of the pin are polled
+	a = newGpioTestWatcherAdaptor("adaptor")
+	a.err = errors.New("Edge detection is not supported by this pin")
+	d = NewButtonDriver(a, "bot", "1")
+	gobottest.Assert(t, len(d.Start()), 0)
+	time.Sleep(20 * time.Millisecond)
+	gobottest.Assert(t, len(d.Halt()), 0)
+}
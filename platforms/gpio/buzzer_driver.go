@@ -1,6 +1,7 @@
 package gpio
 
 import (
+	"sync"
 	"time"
 
 	"github.com/hybridgroup/gobot"
@@ -133,6 +134,7 @@ type BuzzerDriver struct {
 	name       string
 	connection DigitalWriter
 	high       bool
+	mutex      sync.Mutex
 	BPM        float64
 }
 
@@ -166,36 +168,51 @@ func (l *BuzzerDriver) Connection() gobot.Connection {
 	return l.connection.(gobot.Connection)
 }
 
+// Snapshot returns the current state and tempo of the buzzer
+func (l *BuzzerDriver) Snapshot() map[string]interface{} {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return map[string]interface{}{"on": l.high, "bpm": l.BPM}
+}
+
 // State return true if the buzzer is On and false if the led is Off
 func (l *BuzzerDriver) State() bool {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
 	return l.high
 }
 
 // On sets the buzzer to a high state.
 func (l *BuzzerDriver) On() (err error) {
-	if err = l.connection.DigitalWrite(l.Pin(), 1); err != nil {
-		return
-	}
-	l.high = true
-	return
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return l.write(true)
 }
 
 // Off sets the buzzer to a low state.
 func (l *BuzzerDriver) Off() (err error) {
-	if err = l.connection.DigitalWrite(l.Pin(), 0); err != nil {
-		return
-	}
-	l.high = false
-	return
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return l.write(false)
 }
 
 // Toggle sets the buzzer to the opposite of it's current state
 func (l *BuzzerDriver) Toggle() (err error) {
-	if l.State() {
-		err = l.Off()
-	} else {
-		err = l.On()
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return l.write(!l.high)
+}
+
+// write sets the buzzer to a high or low state. The mutex must be held.
+func (l *BuzzerDriver) write(high bool) (err error) {
+	level := byte(0)
+	if high {
+		level = 1
+	}
+	if err = l.connection.DigitalWrite(l.Pin(), level); err != nil {
+		return
 	}
+	l.high = high
 	return
 }
 
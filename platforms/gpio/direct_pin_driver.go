@@ -1,17 +1,13 @@
 package gpio
 
-import (
-	"strconv"
-
-	"github.com/hybridgroup/gobot"
-)
+import "github.com/hybridgroup/gobot"
 
 // DirectPinDriver represents a GPIO pin
 type DirectPinDriver struct {
 	name       string
 	pin        string
 	connection gobot.Connection
-	gobot.Commander
+	gobot.ExtendedCommander
 }
 
 // NewDirectPinDriver return a new DirectPinDriver given a Connection, name and pin.
@@ -25,31 +21,28 @@ type DirectPinDriver struct {
 // 	"ServoWrite" - See DirectPinDriver.ServoWrite
 func NewDirectPinDriver(a gobot.Connection, name string, pin string) *DirectPinDriver {
 	d := &DirectPinDriver{
-		name:       name,
-		connection: a,
-		pin:        pin,
-		Commander:  gobot.NewCommander(),
+		name:              name,
+		connection:        a,
+		pin:               pin,
+		ExtendedCommander: gobot.NewCommander(),
 	}
 
 	d.AddCommand("DigitalRead", func(params map[string]interface{}) interface{} {
 		val, err := d.DigitalRead()
 		return map[string]interface{}{"val": val, "err": err}
 	})
-	d.AddCommand("DigitalWrite", func(params map[string]interface{}) interface{} {
-		level, _ := strconv.Atoi(params["level"].(string))
-		return d.DigitalWrite(byte(level))
+	d.AddCommandWithSchema("DigitalWrite", levelSchema("Write a digital level to the pin", 1), func(params map[string]interface{}) interface{} {
+		return d.DigitalWrite(byte(params["level"].(int)))
 	})
 	d.AddCommand("AnalogRead", func(params map[string]interface{}) interface{} {
 		val, err := d.AnalogRead()
 		return map[string]interface{}{"val": val, "err": err}
 	})
-	d.AddCommand("PwmWrite", func(params map[string]interface{}) interface{} {
-		level, _ := strconv.Atoi(params["level"].(string))
-		return d.PwmWrite(byte(level))
+	d.AddCommandWithSchema("PwmWrite", levelSchema("Write a PWM level to the pin", 255), func(params map[string]interface{}) interface{} {
+		return d.PwmWrite(byte(params["level"].(int)))
 	})
-	d.AddCommand("ServoWrite", func(params map[string]interface{}) interface{} {
-		level, _ := strconv.Atoi(params["level"].(string))
-		return d.ServoWrite(byte(level))
+	d.AddCommandWithSchema("ServoWrite", levelSchema("Write a servo angle to the pin", 180), func(params map[string]interface{}) interface{} {
+		return d.ServoWrite(byte(params["level"].(int)))
 	})
 
 	return d
@@ -132,3 +125,14 @@ func (d *DirectPinDriver) ServoWrite(level byte) (err error) {
 	err = ErrServoWriteUnsupported
 	return
 }
+
+// levelSchema returns the schema of a command which writes a "level" between
+// 0 and max to the pin.
+func levelSchema(description string, max float64) gobot.CommandSchema {
+	return gobot.CommandSchema{
+		Description: description,
+		Params: []gobot.Param{
+			{Name: "level", Type: gobot.ParamInteger, Required: true, Range: &gobot.Range{Min: 0, Max: max}},
+		},
+	}
+}
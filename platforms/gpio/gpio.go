@@ -2,6 +2,7 @@ package gpio
 
 import (
 	"errors"
+	"time"
 
 	"github.com/hybridgroup/gobot"
 )
@@ -40,6 +41,15 @@ const (
 	Vibration = "vibration"
 )
 
+const (
+	// RisingEdge of a digital input, from low to high
+	RisingEdge = "rising"
+	// FallingEdge of a digital input, from high to low
+	FallingEdge = "falling"
+	// BothEdges of a digital input
+	BothEdges = "both"
+)
+
 // PwmWriter interface represents an Adaptor which has Pwm capabilities
 type PwmWriter interface {
 	gobot.Adaptor
@@ -69,3 +79,54 @@ type DigitalReader interface {
 	gobot.Adaptor
 	DigitalRead(string) (val int, err error)
 }
+
+// DigitalWatcher interface represents an Adaptor which detects the edges of
+// digital inputs
+type DigitalWatcher interface {
+	gobot.Adaptor
+	// WaitForDigitalEdge waits at most timeout for a RisingEdge, FallingEdge
+	// or BothEdges of the pin, and returns the value of the pin after the edge
+	// and the time of the edge. The value is -1 if the timeout passed.
+	WaitForDigitalEdge(pin string, edge string, timeout time.Duration) (val int, t time.Time, err error)
+}
+
+// edgeTimeout is how long the drivers wait for an edge before checking whether
+// they were halted
+const edgeTimeout = 100 * time.Millisecond
+
+// watchDigital calls update with each new value of a digital input until halt
+// receives. It waits for the edges of the input if the connection is a
+// DigitalWatcher which supports the pin, and otherwise polls it at interval.
+func watchDigital(c DigitalReader, pin string, state int, interval time.Duration,
+	halt chan bool, update func(int), fail func(error)) {
+	watcher, watch := c.(DigitalWatcher)
+	newValue, err := c.DigitalRead(pin)
+	for {
+		if err != nil {
+			fail(err)
+		} else if newValue != state && newValue != -1 {
+			state = newValue
+			update(newValue)
+		}
+
+		if watch {
+			if newValue, _, err = watcher.WaitForDigitalEdge(pin, BothEdges, edgeTimeout); err == nil {
+				select {
+				case <-halt:
+					return
+				default:
+				}
+				continue
+			}
+			// the pin can't detect edges, so poll it
+			watch = false
+		}
+
+		select {
+		case <-time.After(interval):
+		case <-halt:
+			return
+		}
+		newValue, err = c.DigitalRead(pin)
+	}
+}
@@ -2,6 +2,7 @@ package gpio
 
 import (
 	"math"
+	"sync"
 	"time"
 
 	"github.com/hybridgroup/gobot"
@@ -14,6 +15,7 @@ type GroveTemperatureSensorDriver struct {
 	name        string
 	pin         string
 	halt        chan bool
+	mutex       sync.Mutex
 	temperature float64
 	interval    time.Duration
 	connection  AnalogReader
@@ -54,9 +56,10 @@ func NewGroveTemperatureSensorDriver(a AnalogReader, name string, pin string, v
 //	Error error - Event is emitted on error reading from the sensor.
 func (a *GroveTemperatureSensorDriver) Start() (errs []error) {
 	thermistor := 3975.0
-	a.temperature = 0
+	a.setTemperature(0)
 
 	go func() {
+		temperature := 0.0
 		for {
 			rawValue, err := a.Read()
 
@@ -65,9 +68,10 @@ func (a *GroveTemperatureSensorDriver) Start() (errs []error) {
 
 			if err != nil {
 				a.Publish(Error, err)
-			} else if newValue != a.temperature && newValue != -1 {
-				a.temperature = newValue
-				a.Publish(Data, a.temperature)
+			} else if newValue != temperature && newValue != -1 {
+				temperature = newValue
+				a.setTemperature(temperature)
+				a.Publish(Data, temperature)
 			}
 			select {
 			case <-time.After(a.interval):
@@ -96,11 +100,24 @@ func (a *GroveTemperatureSensorDriver) Connection() gobot.Connection {
 	return a.connection.(gobot.Connection)
 }
 
+// Snapshot returns the last temperature read in celsius
+func (a *GroveTemperatureSensorDriver) Snapshot() map[string]interface{} {
+	return map[string]interface{}{"temperature": a.Temperature()}
+}
+
 // Read returns the current Temperature from the Sensor
 func (a *GroveTemperatureSensorDriver) Temperature() (val float64) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
 	return a.temperature
 }
 
+func (a *GroveTemperatureSensorDriver) setTemperature(temperature float64) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	a.temperature = temperature
+}
+
 // Read returns the raw reading from the Sensor
 func (a *GroveTemperatureSensorDriver) Read() (val int, err error) {
 	return a.connection.AnalogRead(a.Pin())
@@ -1,5 +1,9 @@
 package gpio
 
+import (
+	"time"
+)
+
 type gpioTestBareAdaptor struct{}
 
 func (t *gpioTestBareAdaptor) Connect() (errs []error)  { return }
@@ -59,3 +63,34 @@ func newGpioTestAdaptor(name string) *gpioTestAdaptor {
 		port: "/dev/null",
 	}
 }
+
+// gpioTestWatcherAdaptor is a DigitalWatcher which reports the values sent
+// to edges
+type gpioTestWatcherAdaptor struct {
+	gpioTestAdaptor
+	edges chan int
+	err   error
+}
+
+func (t *gpioTestWatcherAdaptor) DigitalRead(string) (val int, err error) {
+	return 0, nil
+}
+
+func (t *gpioTestWatcherAdaptor) WaitForDigitalEdge(pin string, edge string, timeout time.Duration) (val int, ts time.Time, err error) {
+	if t.err != nil {
+		return -1, ts, t.err
+	}
+	select {
+	case val = <-t.edges:
+		return val, time.Now(), nil
+	case <-time.After(timeout):
+		return -1, ts, nil
+	}
+}
+
+func newGpioTestWatcherAdaptor(name string) *gpioTestWatcherAdaptor {
+	return &gpioTestWatcherAdaptor{
+		gpioTestAdaptor: gpioTestAdaptor{name: name, port: "/dev/null"},
+		edges:           make(chan int),
+	}
+}
@@ -1,6 +1,10 @@
 package gpio
 
-import "github.com/hybridgroup/gobot"
+import (
+	"sync"
+
+	"github.com/hybridgroup/gobot"
+)
 
 var _ gobot.Driver = (*LedDriver)(nil)
 
@@ -10,7 +14,8 @@ type LedDriver struct {
 	name       string
 	connection DigitalWriter
 	high       bool
-	gobot.Commander
+	mutex      sync.Mutex
+	gobot.ExtendedCommander
 }
 
 // NewLedDriver return a new LedDriver given a DigitalWriter, name and pin.
@@ -22,15 +27,20 @@ type LedDriver struct {
 //	"Off" - See LedDriver.Off
 func NewLedDriver(a DigitalWriter, name string, pin string) *LedDriver {
 	l := &LedDriver{
-		name:       name,
-		pin:        pin,
-		connection: a,
-		high:       false,
-		Commander:  gobot.NewCommander(),
+		name:              name,
+		pin:               pin,
+		connection:        a,
+		high:              false,
+		ExtendedCommander: gobot.NewCommander(),
 	}
 
-	l.AddCommand("Brightness", func(params map[string]interface{}) interface{} {
-		level := byte(params["level"].(float64))
+	l.AddCommandWithSchema("Brightness", gobot.CommandSchema{
+		Description: "Set the LED brightness",
+		Params: []gobot.Param{
+			{Name: "level", Type: gobot.ParamInteger, Required: true, Range: &gobot.Range{Min: 0, Max: 255}},
+		},
+	}, func(params map[string]interface{}) interface{} {
+		level := byte(params["level"].(int))
 		return l.Brightness(level)
 	})
 
@@ -66,36 +76,51 @@ func (l *LedDriver) Connection() gobot.Connection {
 	return l.connection.(gobot.Connection)
 }
 
+// Snapshot returns the current state of the led
+func (l *LedDriver) Snapshot() map[string]interface{} {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return map[string]interface{}{"on": l.high}
+}
+
 // State return true if the led is On and false if the led is Off
 func (l *LedDriver) State() bool {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
 	return l.high
 }
 
 // On sets the led to a high state.
 func (l *LedDriver) On() (err error) {
-	if err = l.connection.DigitalWrite(l.Pin(), 1); err != nil {
-		return
-	}
-	l.high = true
-	return
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return l.write(true)
 }
 
 // Off sets the led to a low state.
 func (l *LedDriver) Off() (err error) {
-	if err = l.connection.DigitalWrite(l.Pin(), 0); err != nil {
-		return
-	}
-	l.high = false
-	return
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return l.write(false)
 }
 
 // Toggle sets the led to the opposite of it's current state
 func (l *LedDriver) Toggle() (err error) {
-	if l.State() {
-		err = l.Off()
-	} else {
-		err = l.On()
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return l.write(!l.high)
+}
+
+// write sets the led to a high or low state. The mutex must be held.
+func (l *LedDriver) write(high bool) (err error) {
+	level := byte(0)
+	if high {
+		level = 1
+	}
+	if err = l.connection.DigitalWrite(l.Pin(), level); err != nil {
+		return
 	}
+	l.high = high
 	return
 }
 
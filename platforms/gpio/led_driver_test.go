@@ -57,6 +57,29 @@ func TestLedDriverHalt(t *testing.T) {
 	gobottest.Assert(t, len(d.Halt()), 0)
 }
 
+func TestLedDriverSnapshot(t *testing.T) {
+	d := initTestLedDriver(newGpioTestAdaptor("adaptor"))
+	gobottest.Assert(t, d.Snapshot(), map[string]interface{}{"on": false})
+	d.On()
+	gobottest.Assert(t, d.Snapshot(), map[string]interface{}{"on": true})
+}
+
+func TestLedDriverSnapshotWhileToggling(t *testing.T) {
+	d := initTestLedDriver(newGpioTestAdaptor("adaptor"))
+	done := make(chan bool)
+	go func() {
+		for i := 0; i < 100; i++ {
+			d.Command("Toggle")(nil)
+		}
+		done <- true
+	}()
+	for i := 0; i < 100; i++ {
+		d.Snapshot()
+	}
+	<-done
+	gobottest.Assert(t, d.Snapshot(), map[string]interface{}{"on": false})
+}
+
 func TestLedDriverToggle(t *testing.T) {
 	d := initTestLedDriver(newGpioTestAdaptor("adaptor"))
 	d.Off()
@@ -1,6 +1,7 @@
 package gpio
 
 import (
+	"sync"
 	"time"
 
 	"github.com/hybridgroup/gobot"
@@ -13,6 +14,7 @@ type MakeyButtonDriver struct {
 	halt       chan bool
 	connection DigitalReader
 	Active     bool
+	mutex      sync.Mutex
 	interval   time.Duration
 	gobot.Eventer
 }
@@ -53,40 +55,40 @@ func (b *MakeyButtonDriver) Pin() string { return b.pin }
 // Connection returns the MakeyButtonDrivers Connection
 func (b *MakeyButtonDriver) Connection() gobot.Connection { return b.connection.(gobot.Connection) }
 
-// Start starts the MakeyButtonDriver and polls the state of the button at the given interval.
+// Snapshot returns whether the button is pushed
+func (b *MakeyButtonDriver) Snapshot() map[string]interface{} {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return map[string]interface{}{"active": b.Active}
+}
+
+// Start starts the MakeyButtonDriver, which waits for the edges of the button
+// if the adaptor is a DigitalWatcher, or else polls the state of the button
+// at the given interval.
 //
 // Emits the Events:
 // 	Push int - On button push
 //	Release int - On button release
 //	Error error - On button error
 func (b *MakeyButtonDriver) Start() (errs []error) {
-	state := 1
-	go func() {
-		for {
-			newValue, err := b.connection.DigitalRead(b.Pin())
-			if err != nil {
-				b.Publish(Error, err)
-			} else if newValue != state && newValue != -1 {
-				state = newValue
-				if newValue == 0 {
-					b.Active = true
-					b.Publish(ButtonPush, newValue)
-				} else {
-					b.Active = false
-					b.Publish(ButtonRelease, newValue)
-				}
-			}
-			select {
-			case <-time.After(b.interval):
-			case <-b.halt:
-				return
-			}
-		}
-	}()
+	go watchDigital(b.connection, b.Pin(), 1, b.interval, b.halt, b.update, func(err error) {
+		b.Publish(Error, err)
+	})
 	return
 }
 
-// Halt stops polling the makey button for new information
+func (b *MakeyButtonDriver) update(newValue int) {
+	b.mutex.Lock()
+	b.Active = newValue == 0
+	b.mutex.Unlock()
+	if newValue == 0 {
+		b.Publish(ButtonPush, newValue)
+	} else {
+		b.Publish(ButtonRelease, newValue)
+	}
+}
+
+// Halt stops watching the makey button for new information
 func (b *MakeyButtonDriver) Halt() (errs []error) {
 	b.halt <- true
 	return
@@ -38,6 +38,16 @@ func (m *MotorDriver) Name() string { return m.name }
 // Connection returns the MotorDrivers Connection
 func (m *MotorDriver) Connection() gobot.Connection { return m.connection.(gobot.Connection) }
 
+// Snapshot returns the current state, speed, mode and direction of the motor
+func (m *MotorDriver) Snapshot() map[string]interface{} {
+	return map[string]interface{}{
+		"on":        m.IsOn(),
+		"speed":     m.CurrentSpeed,
+		"mode":      m.CurrentMode,
+		"direction": m.CurrentDirection,
+	}
+}
+
 // Start implements the Driver interface
 func (m *MotorDriver) Start() (errs []error) { return }
 
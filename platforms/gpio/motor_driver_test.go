@@ -29,6 +29,25 @@ func TestMotorDriverHalt(t *testing.T) {
 	gobottest.Assert(t, len(d.Halt()), 0)
 }
 
+func TestMotorDriverSnapshot(t *testing.T) {
+	d := initTestMotorDriver()
+	gobottest.Assert(t, d.Snapshot(), map[string]interface{}{
+		"on":        false,
+		"speed":     byte(0),
+		"mode":      "digital",
+		"direction": "forward",
+	})
+
+	d.Speed(100)
+	d.Direction("backward")
+	gobottest.Assert(t, d.Snapshot(), map[string]interface{}{
+		"on":        true,
+		"speed":     byte(100),
+		"mode":      "analog",
+		"direction": "backward",
+	})
+}
+
 func TestMotorDriverIsOn(t *testing.T) {
 	d := initTestMotorDriver()
 	d.CurrentMode = "digital"
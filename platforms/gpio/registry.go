@@ -0,0 +1,101 @@
+package gpio
+
+import "github.com/hybridgroup/gobot"
+
+func init() {
+	gobot.RegisterDriver("analog_sensor", func(c gobot.Connection, config gobot.DeviceConfig) (gobot.Driver, error) {
+		a, ok := c.(AnalogReader)
+		if !ok {
+			return nil, ErrAnalogReadUnsupported
+		}
+		v, err := config.Intervals()
+		if err != nil {
+			return nil, err
+		}
+		return NewAnalogSensorDriver(a, config.Name, config.Pin, v...), nil
+	})
+
+	gobot.RegisterDriver("button", func(c gobot.Connection, config gobot.DeviceConfig) (gobot.Driver, error) {
+		a, ok := c.(DigitalReader)
+		if !ok {
+			return nil, ErrDigitalReadUnsupported
+		}
+		v, err := config.Intervals()
+		if err != nil {
+			return nil, err
+		}
+		return NewButtonDriver(a, config.Name, config.Pin, v...), nil
+	})
+
+	gobot.RegisterDriver("makey_button", func(c gobot.Connection, config gobot.DeviceConfig) (gobot.Driver, error) {
+		a, ok := c.(DigitalReader)
+		if !ok {
+			return nil, ErrDigitalReadUnsupported
+		}
+		v, err := config.Intervals()
+		if err != nil {
+			return nil, err
+		}
+		return NewMakeyButtonDriver(a, config.Name, config.Pin, v...), nil
+	})
+
+	gobot.RegisterDriver("buzzer", func(c gobot.Connection, config gobot.DeviceConfig) (gobot.Driver, error) {
+		a, ok := c.(DigitalWriter)
+		if !ok {
+			return nil, ErrDigitalWriteUnsupported
+		}
+		return NewBuzzerDriver(a, config.Name, config.Pin), nil
+	})
+
+	gobot.RegisterDriver("direct_pin", func(c gobot.Connection, config gobot.DeviceConfig) (gobot.Driver, error) {
+		return NewDirectPinDriver(c, config.Name, config.Pin), nil
+	})
+
+	gobot.RegisterDriver("led", func(c gobot.Connection, config gobot.DeviceConfig) (gobot.Driver, error) {
+		a, ok := c.(DigitalWriter)
+		if !ok {
+			return nil, ErrDigitalWriteUnsupported
+		}
+		return NewLedDriver(a, config.Name, config.Pin), nil
+	})
+
+	gobot.RegisterDriver("motor", func(c gobot.Connection, config gobot.DeviceConfig) (gobot.Driver, error) {
+		a, ok := c.(DigitalWriter)
+		if !ok {
+			return nil, ErrDigitalWriteUnsupported
+		}
+		m := NewMotorDriver(a, config.Name, config.Pin)
+		m.DirectionPin = config.Params.String("direction_pin", m.DirectionPin)
+		m.ForwardPin = config.Params.String("forward_pin", m.ForwardPin)
+		m.BackwardPin = config.Params.String("backward_pin", m.BackwardPin)
+		return m, nil
+	})
+
+	gobot.RegisterDriver("relay", func(c gobot.Connection, config gobot.DeviceConfig) (gobot.Driver, error) {
+		a, ok := c.(DigitalWriter)
+		if !ok {
+			return nil, ErrDigitalWriteUnsupported
+		}
+		return NewRelayDriver(a, config.Name, config.Pin), nil
+	})
+
+	gobot.RegisterDriver("rgb_led", func(c gobot.Connection, config gobot.DeviceConfig) (gobot.Driver, error) {
+		a, ok := c.(DigitalWriter)
+		if !ok {
+			return nil, ErrDigitalWriteUnsupported
+		}
+		return NewRgbLedDriver(a, config.Name,
+			config.Params.String("red_pin", ""),
+			config.Params.String("green_pin", ""),
+			config.Params.String("blue_pin", ""),
+		), nil
+	})
+
+	gobot.RegisterDriver("servo", func(c gobot.Connection, config gobot.DeviceConfig) (gobot.Driver, error) {
+		a, ok := c.(ServoWriter)
+		if !ok {
+			return nil, ErrServoWriteUnsupported
+		}
+		return NewServoDriver(a, config.Name, config.Pin), nil
+	})
+}
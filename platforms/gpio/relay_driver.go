@@ -8,7 +8,7 @@ type RelayDriver struct {
 	name       string
 	connection DigitalWriter
 	high       bool
-	gobot.Commander
+	gobot.ExtendedCommander
 }
 
 // NewRelayDriver return a new RelayDriver given a DigitalWriter, name and pin.
@@ -19,11 +19,11 @@ type RelayDriver struct {
 //	"Off" - See RelayDriver.Off
 func NewRelayDriver(a DigitalWriter, name string, pin string) *RelayDriver {
 	l := &RelayDriver{
-		name:       name,
-		pin:        pin,
-		connection: a,
-		high:       false,
-		Commander:  gobot.NewCommander(),
+		name:              name,
+		pin:               pin,
+		connection:        a,
+		high:              false,
+		ExtendedCommander: gobot.NewCommander(),
 	}
 
 	l.AddCommand("Toggle", func(params map[string]interface{}) interface{} {
@@ -58,6 +58,11 @@ func (l *RelayDriver) Connection() gobot.Connection {
 	return l.connection.(gobot.Connection)
 }
 
+// Snapshot returns the current state of the relay
+func (l *RelayDriver) Snapshot() map[string]interface{} {
+	return map[string]interface{}{"on": l.high}
+}
+
 // State return true if the relay is On and false if the relay is Off
 func (l *RelayDriver) State() bool {
 	return l.high
@@ -1,6 +1,10 @@
 package gpio
 
-import "github.com/hybridgroup/gobot"
+import (
+	"sync"
+
+	"github.com/hybridgroup/gobot"
+)
 
 // RgbLedDriver represents a digital RGB Led
 type RgbLedDriver struct {
@@ -13,7 +17,8 @@ type RgbLedDriver struct {
 	name       string
 	connection DigitalWriter
 	high       bool
-	gobot.Commander
+	mutex      sync.Mutex
+	gobot.ExtendedCommander
 }
 
 // NewRgbLedDriver return a new RgbLedDriver given a DigitalWriter, name and
@@ -26,16 +31,23 @@ type RgbLedDriver struct {
 //	"Off" - See RgbLedDriver.Off
 func NewRgbLedDriver(a DigitalWriter, name string, redPin string, greenPin string, bluePin string) *RgbLedDriver {
 	l := &RgbLedDriver{
-		name:       name,
-		pinRed:     redPin,
-		pinGreen:   greenPin,
-		pinBlue:    bluePin,
-		connection: a,
-		high:       false,
-		Commander:  gobot.NewCommander(),
+		name:              name,
+		pinRed:            redPin,
+		pinGreen:          greenPin,
+		pinBlue:           bluePin,
+		connection:        a,
+		high:              false,
+		ExtendedCommander: gobot.NewCommander(),
 	}
 
-	l.AddCommand("SetRGB", func(params map[string]interface{}) interface{} {
+	l.AddCommandWithSchema("SetRGB", gobot.CommandSchema{
+		Description: "Set the LED color",
+		Params: []gobot.Param{
+			{Name: "r", Type: gobot.ParamInteger, Required: true, Range: &gobot.Range{Min: 0, Max: 255}},
+			{Name: "g", Type: gobot.ParamInteger, Required: true, Range: &gobot.Range{Min: 0, Max: 255}},
+			{Name: "b", Type: gobot.ParamInteger, Required: true, Range: &gobot.Range{Min: 0, Max: 255}},
+		},
+	}, func(params map[string]interface{}) interface{} {
 		r := byte(params["r"].(int))
 		g := byte(params["g"].(int))
 		b := byte(params["b"].(int))
@@ -67,7 +79,9 @@ func (l *RgbLedDriver) Halt() (errs []error) { return }
 func (l *RgbLedDriver) Name() string { return l.name }
 
 // Pin returns the RgbLedDrivers pins
-func (l *RgbLedDriver) Pin() string { return "r=" + l.pinRed + ", g=" + l.pinGreen + ", b=" + l.pinBlue }
+func (l *RgbLedDriver) Pin() string {
+	return "r=" + l.pinRed + ", g=" + l.pinGreen + ", b=" + l.pinBlue
+}
 
 // RedPin returns the RgbLedDrivers redPin
 func (l *RgbLedDriver) RedPin() string { return l.pinRed }
@@ -83,13 +97,51 @@ func (l *RgbLedDriver) Connection() gobot.Connection {
 	return l.connection.(gobot.Connection)
 }
 
+// Snapshot returns the current state and color of the led
+func (l *RgbLedDriver) Snapshot() map[string]interface{} {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return map[string]interface{}{
+		"on":    l.high,
+		"red":   l.redColor,
+		"green": l.greenColor,
+		"blue":  l.blueColor,
+	}
+}
+
 // State return true if the led is On and false if the led is Off
 func (l *RgbLedDriver) State() bool {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
 	return l.high
 }
 
 // On sets the led's pins to their various states
 func (l *RgbLedDriver) On() (err error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return l.on()
+}
+
+// Off sets the led to black.
+func (l *RgbLedDriver) Off() (err error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return l.off()
+}
+
+// Toggle sets the led to the opposite of it's current state
+func (l *RgbLedDriver) Toggle() (err error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if l.high {
+		return l.off()
+	}
+	return l.on()
+}
+
+// on sets the led's pins to its color. The mutex must be held.
+func (l *RgbLedDriver) on() (err error) {
 	if err = l.SetLevel(l.pinRed, l.redColor); err != nil {
 		return
 	}
@@ -106,8 +158,8 @@ func (l *RgbLedDriver) On() (err error) {
 	return
 }
 
-// Off sets the led to black.
-func (l *RgbLedDriver) Off() (err error) {
+// off sets the led's pins to black. The mutex must be held.
+func (l *RgbLedDriver) off() (err error) {
 	if err = l.SetLevel(l.pinRed, 0); err != nil {
 		return
 	}
@@ -124,16 +176,6 @@ func (l *RgbLedDriver) Off() (err error) {
 	return
 }
 
-// Toggle sets the led to the opposite of it's current state
-func (l *RgbLedDriver) Toggle() (err error) {
-	if l.State() {
-		err = l.Off()
-	} else {
-		err = l.On()
-	}
-	return
-}
-
 // SetLevel sets the led to the specified color level
 func (l *RgbLedDriver) SetLevel(pin string, level byte) (err error) {
 	if writer, ok := l.connection.(PwmWriter); ok {
@@ -144,9 +186,11 @@ func (l *RgbLedDriver) SetLevel(pin string, level byte) (err error) {
 
 // SetRGB sets the Red Green Blue value of the LED.
 func (l *RgbLedDriver) SetRGB(r, g, b byte) error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
 	l.redColor = r
 	l.greenColor = g
 	l.blueColor = b
 
-	return l.On()
+	return l.on()
 }
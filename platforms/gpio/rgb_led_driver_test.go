@@ -63,6 +63,17 @@ func TestRgbLedDriverHalt(t *testing.T) {
 	gobottest.Assert(t, len(d.Halt()), 0)
 }
 
+func TestRgbLedDriverSnapshot(t *testing.T) {
+	d := initTestRgbLedDriver(newGpioTestAdaptor("adaptor"))
+	d.SetRGB(10, 20, 30)
+	gobottest.Assert(t, d.Snapshot(), map[string]interface{}{
+		"on":    true,
+		"red":   byte(10),
+		"green": byte(20),
+		"blue":  byte(30),
+	})
+}
+
 func TestRgbLedDriverToggle(t *testing.T) {
 	d := initTestRgbLedDriver(newGpioTestAdaptor("adaptor"))
 	d.Off()
@@ -1,13 +1,18 @@
 package gpio
 
-import "github.com/hybridgroup/gobot"
+import (
+	"sync"
+
+	"github.com/hybridgroup/gobot"
+)
 
 // ServoDriver Represents a Servo
 type ServoDriver struct {
 	name       string
 	pin        string
 	connection ServoWriter
-	gobot.Commander
+	mutex      sync.Mutex
+	gobot.ExtendedCommander
 	CurrentAngle byte
 }
 
@@ -20,15 +25,20 @@ type ServoDriver struct {
 //	"Max" - See ServoDriver.Max
 func NewServoDriver(a ServoWriter, name string, pin string) *ServoDriver {
 	s := &ServoDriver{
-		name:         name,
-		connection:   a,
-		pin:          pin,
-		Commander:    gobot.NewCommander(),
-		CurrentAngle: 0,
+		name:              name,
+		connection:        a,
+		pin:               pin,
+		ExtendedCommander: gobot.NewCommander(),
+		CurrentAngle:      0,
 	}
 
-	s.AddCommand("Move", func(params map[string]interface{}) interface{} {
-		angle := byte(params["angle"].(float64))
+	s.AddCommandWithSchema("Move", gobot.CommandSchema{
+		Description: "Move the servo to an angle",
+		Params: []gobot.Param{
+			{Name: "angle", Type: gobot.ParamInteger, Required: true, Range: &gobot.Range{Min: 0, Max: 180}},
+		},
+	}, func(params map[string]interface{}) interface{} {
+		angle := byte(params["angle"].(int))
 		return s.Move(angle)
 	})
 	s.AddCommand("Min", func(params map[string]interface{}) interface{} {
@@ -54,6 +64,13 @@ func (s *ServoDriver) Pin() string { return s.pin }
 // Connection returns the ServoDrivers connection
 func (s *ServoDriver) Connection() gobot.Connection { return s.connection.(gobot.Connection) }
 
+// Snapshot returns the current angle of the servo
+func (s *ServoDriver) Snapshot() map[string]interface{} {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return map[string]interface{}{"angle": s.CurrentAngle}
+}
+
 // Start implements the Driver interface
 func (s *ServoDriver) Start() (errs []error) { return }
 
@@ -65,6 +82,8 @@ func (s *ServoDriver) Move(angle uint8) (err error) {
 	if !(angle >= 0 && angle <= 180) {
 		return ErrServoOutOfRange
 	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
 	s.CurrentAngle = angle
 	return s.connection.ServoWrite(s.Pin(), s.angleToSpan(angle))
 }
@@ -51,6 +51,15 @@ func TestServoDriverHalt(t *testing.T) {
 	gobottest.Assert(t, len(d.Halt()), 0)
 }
 
+func TestServoDriverSnapshot(t *testing.T) {
+	d := initTestServoDriver()
+	testAdaptorServoWrite = func() (err error) {
+		return nil
+	}
+	d.Move(90)
+	gobottest.Assert(t, d.Snapshot(), map[string]interface{}{"angle": uint8(90)})
+}
+
 func TestServoDriverMove(t *testing.T) {
 	d := initTestServoDriver()
 	d.Move(100)
@@ -1,7 +1,6 @@
 package i2c
 
 import (
-	"log"
 	"math"
 	"time"
 
@@ -99,7 +98,8 @@ type adaFruitStepperMotor struct {
 type AdafruitMotorHatDriver struct {
 	name       string
 	connection I2c
-	gobot.Commander
+	logger     gobot.Logger
+	gobot.ExtendedCommander
 	dcMotors      []adaFruitDCMotor
 	stepperMotors []adaFruitStepperMotor
 }
@@ -122,7 +122,9 @@ func (a *AdafruitMotorHatDriver) SetServoHatAddress(addr int) (errs []error) {
 func (a *AdafruitMotorHatDriver) Name() string { return a.name }
 
 // Connection identifies the particular adapter object
-func (a *AdafruitMotorHatDriver) Connection() gobot.Connection { return a.connection.(gobot.Connection) }
+func (a *AdafruitMotorHatDriver) Connection() gobot.Connection {
+	return a.connection.(gobot.Connection)
+}
 
 // NewAdafruitMotorHatDriver initializes the internal DCMotor and StepperMotor types.
 // Again the Adafruit Motor Hat supports up to four DC motors and up to two stepper motors.
@@ -146,11 +148,12 @@ func NewAdafruitMotorHatDriver(a I2c, name string) *AdafruitMotorHatDriver {
 		}
 	}
 	driver := &AdafruitMotorHatDriver{
-		name:          name,
-		connection:    a,
-		Commander:     gobot.NewCommander(),
-		dcMotors:      dc,
-		stepperMotors: st,
+		name:              name,
+		connection:        a,
+		logger:            gobot.DefaultLogger().With("device", name),
+		ExtendedCommander: gobot.NewCommander(),
+		dcMotors:          dc,
+		stepperMotors:     st,
 	}
 	// TODO: add API funcs?
 	return driver
@@ -200,6 +203,9 @@ func (a *AdafruitMotorHatDriver) Start() (errs []error) {
 // Halt returns true if devices is halted successfully
 func (a *AdafruitMotorHatDriver) Halt() (errs []error) { return }
 
+// SetLogger sets the Logger used for debugging output.
+func (a *AdafruitMotorHatDriver) SetLogger(l gobot.Logger) { a.logger = l }
+
 // setPWM sets the start (on) and end (off) of the high-segment of the PWM pulse
 // on the specific channel (pin).
 func (a *AdafruitMotorHatDriver) setPWM(i2cAddr int, pin byte, on, off int32) (err error) {
@@ -247,9 +253,7 @@ func (a *AdafruitMotorHatDriver) setPWMFreq(i2cAddr int, freq float64) (err erro
 	preScaleVal -= 1.0
 	preScale := math.Floor(preScaleVal + 0.5)
 	if adafruitDebug {
-		log.Printf("Setting PWM frequency to:	%.2f Hz", freq)
-		log.Printf("Estimated pre-scale: 		%.2f", preScaleVal)
-		log.Printf("Final pre-scale: 			%.2f", preScale)
+		a.logger.Debug("Setting PWM frequency", "hz", freq, "estimated_prescale", preScaleVal, "prescale", preScale)
 	}
 	// default (and only) reads register 0
 	oldMode, err := a.connection.I2cRead(i2cAddr, 1)
@@ -444,9 +448,7 @@ func (a *AdafruitMotorHatDriver) oneStep(motor int, dir AdafruitDirection, style
 		coils = step2coils[(currStep / (stepperMicrosteps / 2))]
 	}
 	if adafruitDebug {
-		log.Printf("[adafruit_driver] currStep: %d, index into step2coils: %d\n",
-			currStep, (currStep / (stepperMicrosteps / 2)))
-		log.Printf("[adafruit_driver] coils state = %v", coils)
+		a.logger.Debug("Stepping", "step", currStep, "step2coils_index", currStep/(stepperMicrosteps/2), "coils", coils)
 	}
 	if err = a.setPin(motorHatAddress, a.stepperMotors[motor].ain2, coils[0]); err != nil {
 		return
@@ -483,7 +485,7 @@ func (a *AdafruitMotorHatDriver) Step(motor, steps int, dir AdafruitDirection, s
 		steps *= stepperMicrosteps
 	}
 	if adafruitDebug {
-		log.Printf("[adafruit_driver] %f seconds per step", secPerStep)
+		a.logger.Debug("Stepping", "seconds_per_step", secPerStep)
 	}
 	for i := 0; i < steps; i++ {
 		if latestStep, err = a.oneStep(motor, dir, style); err != nil {
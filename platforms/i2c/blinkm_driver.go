@@ -13,7 +13,7 @@ const blinkmAddress = 0x09
 type BlinkMDriver struct {
 	name       string
 	connection I2c
-	gobot.Commander
+	gobot.ExtendedCommander
 }
 
 // NewBlinkMDriver creates a new BlinkMDriver with specified name.
@@ -25,9 +25,9 @@ type BlinkMDriver struct {
 //	Color - returns the color of the LED.
 func NewBlinkMDriver(a I2c, name string) *BlinkMDriver {
 	b := &BlinkMDriver{
-		name:       name,
-		connection: a,
-		Commander:  gobot.NewCommander(),
+		name:              name,
+		connection:        a,
+		ExtendedCommander: gobot.NewCommander(),
 	}
 
 	b.AddCommand("Rgb", func(params map[string]interface{}) interface{} {
@@ -30,6 +30,32 @@ func (t *i2cTestAdaptor) I2cRead(int, int) (data []byte, err error) {
 func (t *i2cTestAdaptor) I2cWrite(int, []byte) (err error) {
 	return t.i2cWriteImpl()
 }
+func (t *i2cTestAdaptor) ReadByteData(int, uint8) (val uint8, err error) {
+	data, err := t.i2cReadImpl()
+	if len(data) > 0 {
+		val = data[0]
+	}
+	return
+}
+func (t *i2cTestAdaptor) WriteByteData(int, uint8, uint8) (err error) {
+	return t.i2cWriteImpl()
+}
+func (t *i2cTestAdaptor) ReadWordData(int, uint8) (val uint16, err error) {
+	data, err := t.i2cReadImpl()
+	if len(data) > 1 {
+		val = uint16(data[0]) | uint16(data[1])<<8
+	}
+	return
+}
+func (t *i2cTestAdaptor) WriteWordData(int, uint8, uint16) (err error) {
+	return t.i2cWriteImpl()
+}
+func (t *i2cTestAdaptor) ReadBlockData(int, uint8, int) (data []byte, err error) {
+	return t.i2cReadImpl()
+}
+func (t *i2cTestAdaptor) WriteRead(int, []byte, int) (data []byte, err error) {
+	return t.i2cReadImpl()
+}
 func (t *i2cTestAdaptor) Name() string             { return t.name }
 func (t *i2cTestAdaptor) Connect() (errs []error)  { return }
 func (t *i2cTestAdaptor) Finalize() (errs []error) { return }
@@ -11,6 +11,7 @@ var (
 	ErrNotEnoughBytes  = errors.New("Not enough bytes read")
 	ErrNotReady        = errors.New("Device is not ready")
 	ErrInvalidPosition = errors.New("Invalid position value")
+	ErrI2cUnsupported  = errors.New("I2c is not supported by this platform")
 )
 
 const (
@@ -32,9 +33,27 @@ type I2cWriter interface {
 	I2cWrite(address int, buf []byte) (err error)
 }
 
+// SMBus reads and writes the registers of a device with the transactions of
+// the System Management Bus protocol. Words are little-endian.
+type SMBus interface {
+	ReadByteData(address int, reg uint8) (val uint8, err error)
+	WriteByteData(address int, reg uint8, val uint8) (err error)
+	ReadWordData(address int, reg uint8) (val uint16, err error)
+	WriteWordData(address int, reg uint8, val uint16) (err error)
+	ReadBlockData(address int, reg uint8, size int) (data []byte, err error)
+}
+
+// I2cWriteReader writes buf and then reads size bytes in one transaction,
+// with a repeated start instead of a stop condition in between
+type I2cWriteReader interface {
+	WriteRead(address int, buf []byte, size int) (data []byte, err error)
+}
+
 type I2c interface {
 	gobot.Adaptor
 	I2cStarter
 	I2cReader
 	I2cWriter
+	SMBus
+	I2cWriteReader
 }
@@ -18,7 +18,6 @@ package i2c
 
 import (
 	"fmt"
-	"log"
 	"strings"
 	"time"
 
@@ -85,19 +84,21 @@ type MCP23017Driver struct {
 	conf            MCP23017Config
 	mcp23017Address int
 	interval        time.Duration
-	gobot.Commander
+	logger          gobot.Logger
+	gobot.ExtendedCommander
 	gobot.Eventer
 }
 
 // NewMCP23017Driver creates a new driver with specified name and i2c interface.
 func NewMCP23017Driver(a I2c, name string, conf MCP23017Config, deviceAddress int, v ...time.Duration) *MCP23017Driver {
 	m := &MCP23017Driver{
-		name:            name,
-		connection:      a,
-		conf:            conf,
-		mcp23017Address: deviceAddress,
-		Commander:       gobot.NewCommander(),
-		Eventer:         gobot.NewEventer(),
+		name:              name,
+		connection:        a,
+		conf:              conf,
+		mcp23017Address:   deviceAddress,
+		logger:            gobot.DefaultLogger().With("device", name),
+		ExtendedCommander: gobot.NewCommander(),
+		Eventer:           gobot.NewEventer(),
 	}
 
 	m.AddCommand("WriteGPIO", func(params map[string]interface{}) interface{} {
@@ -127,6 +128,9 @@ func (m *MCP23017Driver) Connection() gobot.Connection { return m.connection.(go
 // Halt stops the driver.
 func (m *MCP23017Driver) Halt() (err []error) { return }
 
+// SetLogger sets the Logger used for debugging output.
+func (m *MCP23017Driver) SetLogger(l gobot.Logger) { m.logger = l }
+
 // Start writes the device configuration.
 func (m *MCP23017Driver) Start() (errs []error) {
 	if err := m.connection.I2cStart(m.mcp23017Address); err != nil {
@@ -208,7 +212,7 @@ func (m *MCP23017Driver) write(reg uint8, pin uint8, val uint8) (err error) {
 		ioval = setBit(iodir, uint8(pin))
 	}
 	if debug {
-		log.Printf("Writing: MCP address: 0x%X, register: 0x%X\t, value: 0x%X\n", m.mcp23017Address, reg, ioval)
+		m.logger.Debug("Writing", "address", fmt.Sprintf("0x%X", m.mcp23017Address), "register", fmt.Sprintf("0x%X", reg), "value", fmt.Sprintf("0x%X", ioval))
 	}
 	if err = m.connection.I2cWrite(m.mcp23017Address, []uint8{reg, ioval}); err != nil {
 		return err
@@ -231,7 +235,7 @@ func (m *MCP23017Driver) read(reg uint8) (val uint8, err error) {
 		return val, fmt.Errorf("Read was unable to get %d bytes for register: 0x%X\n", bytesToRead, reg)
 	}
 	if debug {
-		log.Printf("Reading: MCP address: 0x%X, register:0x%X\t,value: 0x%X\n", m.mcp23017Address, reg, v[register])
+		m.logger.Debug("Reading", "address", fmt.Sprintf("0x%X", m.mcp23017Address), "register", fmt.Sprintf("0x%X", reg), "value", fmt.Sprintf("0x%X", v[register]))
 	}
 	return v[register], nil
 }
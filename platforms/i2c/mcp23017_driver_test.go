@@ -26,6 +26,32 @@ func (t *i2cMcpTestAdaptor) I2cRead(address int, numBytes int) (data []byte, err
 func (t *i2cMcpTestAdaptor) I2cWrite(int, []byte) (err error) {
 	return t.i2cMcpWriteImpl()
 }
+func (t *i2cMcpTestAdaptor) ReadByteData(address int, reg uint8) (val uint8, err error) {
+	data, err := t.i2cMcpReadImpl(address, 1)
+	if len(data) > 0 {
+		val = data[0]
+	}
+	return
+}
+func (t *i2cMcpTestAdaptor) WriteByteData(int, uint8, uint8) (err error) {
+	return t.i2cMcpWriteImpl()
+}
+func (t *i2cMcpTestAdaptor) ReadWordData(address int, reg uint8) (val uint16, err error) {
+	data, err := t.i2cMcpReadImpl(address, 2)
+	if len(data) > 1 {
+		val = uint16(data[0]) | uint16(data[1])<<8
+	}
+	return
+}
+func (t *i2cMcpTestAdaptor) WriteWordData(int, uint8, uint16) (err error) {
+	return t.i2cMcpWriteImpl()
+}
+func (t *i2cMcpTestAdaptor) ReadBlockData(address int, reg uint8, size int) (data []byte, err error) {
+	return t.i2cMcpReadImpl(address, size)
+}
+func (t *i2cMcpTestAdaptor) WriteRead(address int, buf []byte, size int) (data []byte, err error) {
+	return t.i2cMcpReadImpl(address, size)
+}
 func (t *i2cMcpTestAdaptor) Name() string             { return t.name }
 func (t *i2cMcpTestAdaptor) Connect() (errs []error)  { return }
 func (t *i2cMcpTestAdaptor) Finalize() (errs []error) { return }
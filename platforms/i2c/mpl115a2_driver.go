@@ -5,6 +5,7 @@ import (
 
 	"bytes"
 	"encoding/binary"
+	"sync"
 	"time"
 )
 
@@ -30,6 +31,7 @@ type MPL115A2Driver struct {
 	name       string
 	connection I2c
 	interval   time.Duration
+	mutex      sync.Mutex
 	gobot.Eventer
 	A0          float32
 	B1          float32
@@ -58,6 +60,13 @@ func NewMPL115A2Driver(a I2c, name string, v ...time.Duration) *MPL115A2Driver {
 func (h *MPL115A2Driver) Name() string                 { return h.name }
 func (h *MPL115A2Driver) Connection() gobot.Connection { return h.connection.(gobot.Connection) }
 
+// Snapshot returns the last pressure and temperature read
+func (h *MPL115A2Driver) Snapshot() map[string]interface{} {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	return map[string]interface{}{"pressure": h.Pressure, "temperature": h.Temperature}
+}
+
 // Start writes initialization bytes and reads from adaptor
 // using specified interval to accelerometer andtemperature data
 func (h *MPL115A2Driver) Start() (errs []error) {
@@ -97,8 +106,10 @@ func (h *MPL115A2Driver) Start() (errs []error) {
 				pressure = pressure >> 6
 
 				pressureComp = float32(h.A0) + (float32(h.B1)+float32(h.C12)*float32(temperature))*float32(pressure) + float32(h.B2)*float32(temperature)
+				h.mutex.Lock()
 				h.Pressure = (65.0/1023.0)*pressureComp + 50.0
 				h.Temperature = ((float32(temperature) - 498.0) / -5.35) + 25.0
+				h.mutex.Unlock()
 			}
 			<-time.After(h.interval)
 		}
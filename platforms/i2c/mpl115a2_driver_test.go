@@ -51,6 +51,10 @@ func TestMPL115A2DriverStart(t *testing.T) {
 	<-time.After(100 * time.Millisecond)
 	gobottest.Assert(t, mpl.Pressure, float32(50.007942))
 	gobottest.Assert(t, mpl.Temperature, float32(116.58878))
+	gobottest.Assert(t, mpl.Snapshot(), map[string]interface{}{
+		"pressure":    float32(50.007942),
+		"temperature": float32(116.58878),
+	})
 }
 
 func TestMPL115A2DriverHalt(t *testing.T) {
@@ -3,6 +3,7 @@ package i2c
 import (
 	"bytes"
 	"encoding/binary"
+	"sync"
 	"time"
 
 	"github.com/hybridgroup/gobot"
@@ -38,6 +39,7 @@ type MPU6050Driver struct {
 	name          string
 	connection    I2c
 	interval      time.Duration
+	mutex         sync.Mutex
 	Accelerometer ThreeDData
 	Gyroscope     ThreeDData
 	Temperature   int16
@@ -64,6 +66,17 @@ func NewMPU6050Driver(a I2c, name string, v ...time.Duration) *MPU6050Driver {
 func (h *MPU6050Driver) Name() string                 { return h.name }
 func (h *MPU6050Driver) Connection() gobot.Connection { return h.connection.(gobot.Connection) }
 
+// Snapshot returns the last accelerometer, gyroscope and temperature data read
+func (h *MPU6050Driver) Snapshot() map[string]interface{} {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	return map[string]interface{}{
+		"accelerometer": h.Accelerometer,
+		"gyroscope":     h.Gyroscope,
+		"temperature":   h.Temperature,
+	}
+}
+
 // Start writes initialization bytes and reads from adaptor
 // using specified interval to accelerometer andtemperature data
 func (h *MPU6050Driver) Start() (errs []error) {
@@ -83,11 +96,18 @@ func (h *MPU6050Driver) Start() (errs []error) {
 				h.Publish(h.Event(Error), err)
 				continue
 			}
+			var accelerometer, gyroscope ThreeDData
+			var temperature int16
 			buf := bytes.NewBuffer(ret)
-			binary.Read(buf, binary.BigEndian, &h.Accelerometer)
-			binary.Read(buf, binary.BigEndian, &h.Temperature)
-			binary.Read(buf, binary.BigEndian, &h.Gyroscope)
+			binary.Read(buf, binary.BigEndian, &accelerometer)
+			binary.Read(buf, binary.BigEndian, &temperature)
+			binary.Read(buf, binary.BigEndian, &gyroscope)
+			h.mutex.Lock()
+			h.Accelerometer = accelerometer
+			h.Temperature = temperature
+			h.Gyroscope = gyroscope
 			h.convertToCelsius()
+			h.mutex.Unlock()
 			<-time.After(h.interval)
 		}
 	}()
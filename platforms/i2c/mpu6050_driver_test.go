@@ -46,6 +46,18 @@ func TestMPU6050DriverStart(t *testing.T) {
 	gobottest.Assert(t, len(mpu.Start()), 0)
 }
 
+func TestMPU6050DriverSnapshot(t *testing.T) {
+	mpu := initTestMPU6050Driver()
+	mpu.Accelerometer = ThreeDData{X: 1, Y: 2, Z: 3}
+	mpu.Temperature = 21
+
+	gobottest.Assert(t, mpu.Snapshot(), map[string]interface{}{
+		"accelerometer": ThreeDData{X: 1, Y: 2, Z: 3},
+		"gyroscope":     ThreeDData{},
+		"temperature":   int16(21),
+	})
+}
+
 func TestMPU6050DriverHalt(t *testing.T) {
 	mpu := initTestMPU6050Driver()
 
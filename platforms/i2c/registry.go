@@ -0,0 +1,57 @@
+package i2c
+
+import (
+	"time"
+
+	"github.com/hybridgroup/gobot"
+)
+
+func init() {
+	register := func(name string, f func(a I2c, name string, v ...time.Duration) gobot.Driver) {
+		gobot.RegisterDriver(name, func(c gobot.Connection, config gobot.DeviceConfig) (gobot.Driver, error) {
+			a, ok := c.(I2c)
+			if !ok {
+				return nil, ErrI2cUnsupported
+			}
+			v, err := config.Intervals()
+			if err != nil {
+				return nil, err
+			}
+			return f(a, config.Name, v...), nil
+		})
+	}
+
+	register("adafruit_motor_hat", func(a I2c, name string, v ...time.Duration) gobot.Driver {
+		return NewAdafruitMotorHatDriver(a, name)
+	})
+	register("blinkm", func(a I2c, name string, v ...time.Duration) gobot.Driver {
+		return NewBlinkMDriver(a, name)
+	})
+	register("grove_accelerometer", func(a I2c, name string, v ...time.Duration) gobot.Driver {
+		return NewGroveAccelerometerDriver(a, name)
+	})
+	register("grove_lcd", func(a I2c, name string, v ...time.Duration) gobot.Driver {
+		return NewGroveLcdDriver(a, name)
+	})
+	register("hmc6352", func(a I2c, name string, v ...time.Duration) gobot.Driver {
+		return NewHMC6352Driver(a, name)
+	})
+	register("jhd1313m1", func(a I2c, name string, v ...time.Duration) gobot.Driver {
+		return NewJHD1313M1Driver(a, name)
+	})
+	register("lidarlite", func(a I2c, name string, v ...time.Duration) gobot.Driver {
+		return NewLIDARLiteDriver(a, name)
+	})
+	register("mma7660", func(a I2c, name string, v ...time.Duration) gobot.Driver {
+		return NewMMA7660Driver(a, name)
+	})
+	register("mpl115a2", func(a I2c, name string, v ...time.Duration) gobot.Driver {
+		return NewMPL115A2Driver(a, name, v...)
+	})
+	register("mpu6050", func(a I2c, name string, v ...time.Duration) gobot.Driver {
+		return NewMPU6050Driver(a, name, v...)
+	})
+	register("wiichuck", func(a I2c, name string, v ...time.Duration) gobot.Driver {
+		return NewWiichuckDriver(a, name, v...)
+	})
+}
@@ -1,6 +1,7 @@
 package i2c
 
 import (
+	"sync"
 	"time"
 
 	"github.com/hybridgroup/gobot"
@@ -16,6 +17,7 @@ type WiichuckDriver struct {
 	interval   time.Duration
 	pauseTime  time.Duration
 	gobot.Eventer
+	mutex    sync.Mutex
 	joystick map[string]float64
 	data     map[string]float64
 }
@@ -59,6 +61,24 @@ func NewWiichuckDriver(a I2c, name string, v ...time.Duration) *WiichuckDriver {
 func (w *WiichuckDriver) Name() string                 { return w.name }
 func (w *WiichuckDriver) Connection() gobot.Connection { return w.connection.(gobot.Connection) }
 
+// Snapshot returns the last joystick position and whether the c and z
+// buttons are pressed
+func (w *WiichuckDriver) Snapshot() map[string]interface{} {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	joystick := map[string]float64{"x": 0, "y": 0}
+	if w.joystick["sx_origin"] != -1 && w.joystick["sy_origin"] != -1 {
+		joystick["x"] = w.calculateJoystickValue(w.data["sx"], w.joystick["sx_origin"])
+		joystick["y"] = w.calculateJoystickValue(w.data["sy"], w.joystick["sy_origin"])
+	}
+	return map[string]interface{}{
+		"joystick": joystick,
+		"c":        w.data["c"] == 0,
+		"z":        w.data["z"] == 0,
+	}
+}
+
 // Start initilizes i2c and reads from adaptor
 // using specified interval to update with new value
 func (w *WiichuckDriver) Start() (errs []error) {
@@ -104,6 +124,8 @@ func (w *WiichuckDriver) update(value []byte) (err error) {
 	if w.isEncrypted(value) {
 		return ErrEncryptedBytes
 	} else {
+		w.mutex.Lock()
+		defer w.mutex.Unlock()
 		w.parse(value)
 		w.adjustOrigins()
 		w.updateButtons()
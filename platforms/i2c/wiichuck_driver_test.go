@@ -177,6 +177,19 @@ func TestWiichuckDriverUpdateJoystick(t *testing.T) {
 	}
 }
 
+func TestWiichuckDriverSnapshot(t *testing.T) {
+	wii := initTestWiichuckDriver()
+	gobottest.Assert(t, wii.Snapshot()["joystick"], map[string]float64{"x": 0, "y": 0})
+
+	wii.update([]byte{1, 2, 3, 4, 5, 6})
+	wii.update([]byte{3, 2, 3, 4, 5, 0x16})
+	gobottest.Assert(t, wii.Snapshot(), map[string]interface{}{
+		"joystick": map[string]float64{"x": -2, "y": 0},
+		"c":        true,
+		"z":        true,
+	})
+}
+
 func TestWiichuckDriverEncrypted(t *testing.T) {
 	wii := initTestWiichuckDriver()
 
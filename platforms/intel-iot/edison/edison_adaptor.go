@@ -4,6 +4,7 @@ import (
 	"errors"
 	"os"
 	"strconv"
+	"time"
 
 	"github.com/hybridgroup/gobot"
 	"github.com/hybridgroup/gobot/sysfs"
@@ -52,9 +53,23 @@ type EdisonAdaptor struct {
 	name        string
 	tristate    sysfs.DigitalPin
 	digitalPins map[int]sysfs.DigitalPin
-	pwmPins     map[int]*pwmPin
+	pwmPins     map[int]*sysfs.PWMPin
 	i2cDevice   sysfs.I2cDevice
+	spiDevices  map[string]sysfs.SPIDevice
 	connect     func(e *EdisonAdaptor) (err error)
+	gpiochips   sysfs.Gpiochips
+	pinFlags    map[int][]sysfs.LineFlag
+}
+
+// gpiochips maps the gpio numbers to the lines of the gpio character devices
+// of the SoC and of the four PCAL9555A expanders of the Arduino breakout board.
+// The expanders all have the same label, so they are found by their number.
+var gpiochips = sysfs.Gpiochips{
+	{Label: "0000:00:0c.0", Base: 0, Count: 192},
+	{Chip: "/dev/gpiochip1", Base: 200, Count: 16},
+	{Chip: "/dev/gpiochip2", Base: 216, Count: 16},
+	{Chip: "/dev/gpiochip3", Base: 232, Count: 16},
+	{Chip: "/dev/gpiochip4", Base: 248, Count: 16},
 }
 
 var sysfsPinMap = map[string]sysfsPin{
@@ -181,11 +196,14 @@ func changePinMode(pin, mode string) (err error) {
 // NewEdisonAdaptor returns a new EdisonAdaptor with specified name
 func NewEdisonAdaptor(name string) *EdisonAdaptor {
 	return &EdisonAdaptor{
-		name: name,
+		name:     name,
+		pinFlags: make(map[int][]sysfs.LineFlag),
 		//i2cDevices: make(map[int]io.ReadWriteCloser),
 		//i2cDevices: make(map[int]io.ReadWriteCloser),
 		connect: func(e *EdisonAdaptor) (err error) {
-			e.tristate = sysfs.NewDigitalPin(214)
+			if e.tristate, err = e.newDigitalPin(214); err != nil {
+				return err
+			}
 			if err = e.tristate.Export(); err != nil {
 				return err
 			}
@@ -197,7 +215,10 @@ func NewEdisonAdaptor(name string) *EdisonAdaptor {
 			}
 
 			for _, i := range []int{263, 262} {
-				io := sysfs.NewDigitalPin(i)
+				var io sysfs.DigitalPin
+				if io, err = e.newDigitalPin(i); err != nil {
+					return err
+				}
 				if err = io.Export(); err != nil {
 					return err
 				}
@@ -213,7 +234,10 @@ func NewEdisonAdaptor(name string) *EdisonAdaptor {
 			}
 
 			for _, i := range []int{240, 241, 242, 243} {
-				io := sysfs.NewDigitalPin(i)
+				var io sysfs.DigitalPin
+				if io, err = e.newDigitalPin(i); err != nil {
+					return err
+				}
 				if err = io.Export(); err != nil {
 					return err
 				}
@@ -253,7 +277,8 @@ func (e *EdisonAdaptor) Name() string { return e.name }
 // Connect initializes the Edison for use with the Arduino beakout board
 func (e *EdisonAdaptor) Connect() (errs []error) {
 	e.digitalPins = make(map[int]sysfs.DigitalPin)
-	e.pwmPins = make(map[int]*pwmPin)
+	e.pwmPins = make(map[int]*sysfs.PWMPin)
+	e.spiDevices = make(map[string]sysfs.SPIDevice)
 	if err := e.connect(e); err != nil {
 		return []error{err}
 	}
@@ -274,14 +299,19 @@ func (e *EdisonAdaptor) Finalize() (errs []error) {
 	}
 	for _, pin := range e.pwmPins {
 		if pin != nil {
-			if err := pin.enable("0"); err != nil {
+			if err := pin.Enable(false); err != nil {
 				errs = append(errs, err)
 			}
-			if err := pin.unexport(); err != nil {
+			if err := pin.Unexport(); err != nil {
 				errs = append(errs, err)
 			}
 		}
 	}
+	for _, device := range e.spiDevices {
+		if err := device.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
 	if e.i2cDevice != nil {
 		if err := e.i2cDevice.Close(); errs != nil {
 			errs = append(errs, err)
@@ -294,24 +324,32 @@ func (e *EdisonAdaptor) Finalize() (errs []error) {
 func (e *EdisonAdaptor) digitalPin(pin string, dir string) (sysfsPin sysfs.DigitalPin, err error) {
 	i := sysfsPinMap[pin]
 	if e.digitalPins[i.pin] == nil {
-		e.digitalPins[i.pin] = sysfs.NewDigitalPin(i.pin)
+		if e.digitalPins[i.pin], err = e.newDigitalPin(i.pin); err != nil {
+			return
+		}
 		if err = e.digitalPins[i.pin].Export(); err != nil {
 			return
 		}
 
-		e.digitalPins[i.resistor] = sysfs.NewDigitalPin(i.resistor)
+		if e.digitalPins[i.resistor], err = e.newDigitalPin(i.resistor); err != nil {
+			return
+		}
 		if err = e.digitalPins[i.resistor].Export(); err != nil {
 			return
 		}
 
-		e.digitalPins[i.levelShifter] = sysfs.NewDigitalPin(i.levelShifter)
+		if e.digitalPins[i.levelShifter], err = e.newDigitalPin(i.levelShifter); err != nil {
+			return
+		}
 		if err = e.digitalPins[i.levelShifter].Export(); err != nil {
 			return
 		}
 
 		if len(i.mux) > 0 {
 			for _, mux := range i.mux {
-				e.digitalPins[mux.pin] = sysfs.NewDigitalPin(mux.pin)
+				if e.digitalPins[mux.pin], err = e.newDigitalPin(mux.pin); err != nil {
+					return
+				}
 				if err = e.digitalPins[mux.pin].Export(); err != nil {
 					return
 				}
@@ -370,8 +408,43 @@ func (e *EdisonAdaptor) digitalPin(pin string, dir string) (sysfsPin sysfs.Digit
 	return e.digitalPins[i.pin], nil
 }
 
+// UseGpiochip makes the adaptor drive its digital pins through the gpio
+// character devices instead of the deprecated sysfs gpio interface. The
+// chips, if given, replace the default mapping of Edison gpio numbers to
+// the lines of the character devices. It needs Linux 5.10 or later, which
+// introduced version 2 of the gpio character device interface, so not the
+// 3.10 kernel of the stock Edison images.
+func (e *EdisonAdaptor) UseGpiochip(chips ...sysfs.GpiochipRange) {
+	e.gpiochips = gpiochips
+	if len(chips) > 0 {
+		e.gpiochips = chips
+	}
+}
+
+// SetPinFlags sets the bias and active-low of a digital pin, which apply
+// from the first use of the pin. They require UseGpiochip.
+func (e *EdisonAdaptor) SetPinFlags(pin string, flags ...sysfs.LineFlag) (err error) {
+	p, ok := sysfsPinMap[pin]
+	if !ok {
+		return errors.New("Not a valid pin")
+	}
+	i := p.pin
+	e.pinFlags[i] = flags
+	return
+}
+
+// newDigitalPin returns the sysfs gpio or gpio character device pin of a gpio
+// number
+func (e *EdisonAdaptor) newDigitalPin(i int) (sysfs.DigitalPin, error) {
+	if e.gpiochips == nil {
+		return sysfs.NewDigitalPin(i), nil
+	}
+	return e.gpiochips.NewDigitalPin(i, e.pinFlags[i]...)
+}
+
 // DigitalRead reads digital value from pin
 func (e *EdisonAdaptor) DigitalRead(pin string) (i int, err error) {
+	defer gobot.CountIOError(e, &err)
 	sysfsPin, err := e.digitalPin(pin, "in")
 	if err != nil {
 		return
@@ -381,6 +454,7 @@ func (e *EdisonAdaptor) DigitalRead(pin string) (i int, err error) {
 
 // DigitalWrite writes a value to the pin. Acceptable values are 1 or 0.
 func (e *EdisonAdaptor) DigitalWrite(pin string, val byte) (err error) {
+	defer gobot.CountIOError(e, &err)
 	sysfsPin, err := e.digitalPin(pin, "out")
 	if err != nil {
 		return
@@ -388,41 +462,72 @@ func (e *EdisonAdaptor) DigitalWrite(pin string, val byte) (err error) {
 	return sysfsPin.Write(int(val))
 }
 
+// WaitForDigitalEdge waits at most timeout for a "rising", "falling" or
+// "both" edge of a digital input, and returns the value of the pin after the
+// edge and the time of the edge. The value is -1 if the timeout passed.
+func (e *EdisonAdaptor) WaitForDigitalEdge(pin string, edge string, timeout time.Duration) (val int, t time.Time, err error) {
+	defer gobot.CountIOError(e, &err)
+	sysfsPin, err := e.digitalPin(pin, "in")
+	if err != nil {
+		return -1, t, err
+	}
+	return sysfs.WaitForEdge(sysfsPin, edge, timeout)
+}
+
 // PwmWrite writes the 0-254 value to the specified pin
 func (e *EdisonAdaptor) PwmWrite(pin string, val byte) (err error) {
+	defer gobot.CountIOError(e, &err)
+	pwmPin, err := e.pwmPin(pin)
+	if err != nil {
+		return
+	}
+	period, err := pwmPin.Period()
+	if err != nil {
+		return
+	}
+	duty := gobot.FromScale(float64(val), 0, 255.0)
+	return pwmPin.SetDutyCycle(uint32(float64(period) * duty))
+}
+
+// ServoWrite writes the 0-180 degree angle to the specified pin as a pulse of
+// 0.5 to 2.5ms every 20ms
+func (e *EdisonAdaptor) ServoWrite(pin string, angle byte) (err error) {
+	defer gobot.CountIOError(e, &err)
+	pwmPin, err := e.pwmPin(pin)
+	if err != nil {
+		return
+	}
+	duty := gobot.ToScale(gobot.FromScale(float64(angle), 0, 180.0), 500000, 2500000)
+	return pwmPin.SetPeriodAndDutyCycle(20000000, uint32(duty))
+}
+
+// pwmPin returns the exported and enabled pwm channel of the specified pin
+func (e *EdisonAdaptor) pwmPin(pin string) (pwmPin *sysfs.PWMPin, err error) {
 	sysPin := sysfsPinMap[pin]
-	if sysPin.pwmPin != -1 {
-		if e.pwmPins[sysPin.pwmPin] == nil {
-			if err = e.DigitalWrite(pin, 1); err != nil {
-				return
-			}
-			if err = changePinMode(strconv.Itoa(int(sysPin.pin)), "1"); err != nil {
-				return
-			}
-			e.pwmPins[sysPin.pwmPin] = newPwmPin(sysPin.pwmPin)
-			if err = e.pwmPins[sysPin.pwmPin].export(); err != nil {
-				return
-			}
-			if err = e.pwmPins[sysPin.pwmPin].enable("1"); err != nil {
-				return
-			}
+	if sysPin.pwmPin == -1 {
+		return nil, errors.New("Not a PWM pin")
+	}
+	if e.pwmPins[sysPin.pwmPin] == nil {
+		if err = e.DigitalWrite(pin, 1); err != nil {
+			return
+		}
+		if err = changePinMode(strconv.Itoa(int(sysPin.pin)), "1"); err != nil {
+			return
 		}
-		p, err := e.pwmPins[sysPin.pwmPin].period()
-		if err != nil {
-			return err
+		e.pwmPins[sysPin.pwmPin] = sysfs.NewPWMPin(sysPin.pwmPin)
+		if err = e.pwmPins[sysPin.pwmPin].Export(); err != nil {
+			return
 		}
-		period, err := strconv.Atoi(p)
-		if err != nil {
-			return err
+		if err = e.pwmPins[sysPin.pwmPin].Enable(true); err != nil {
+			return
 		}
-		duty := gobot.FromScale(float64(val), 0, 255.0)
-		return e.pwmPins[sysPin.pwmPin].writeDuty(strconv.Itoa(int(float64(period) * duty)))
 	}
-	return errors.New("Not a PWM pin")
+	return e.pwmPins[sysPin.pwmPin], nil
 }
 
 // AnalogRead returns value from analog reading of specified pin
 func (e *EdisonAdaptor) AnalogRead(pin string) (val int, err error) {
+	defer gobot.CountIOError(e, &err)
 	buf, err := readFile(
 		"/sys/bus/iio/devices/iio:device1/in_voltage" + pin + "_raw",
 	)
@@ -437,6 +542,7 @@ func (e *EdisonAdaptor) AnalogRead(pin string) (val int, err error) {
 
 // I2cStart initializes i2c device for addresss
 func (e *EdisonAdaptor) I2cStart(address int) (err error) {
+	defer gobot.CountIOError(e, &err)
 	if e.i2cDevice != nil {
 		return
 	}
@@ -446,7 +552,10 @@ func (e *EdisonAdaptor) I2cStart(address int) (err error) {
 	}
 
 	for _, i := range []int{14, 165, 212, 213} {
-		io := sysfs.NewDigitalPin(i)
+		var io sysfs.DigitalPin
+		if io, err = e.newDigitalPin(i); err != nil {
+			return
+		}
 		if err = io.Export(); err != nil {
 			return
 		}
@@ -459,7 +568,10 @@ func (e *EdisonAdaptor) I2cStart(address int) (err error) {
 	}
 
 	for _, i := range []int{236, 237, 204, 205} {
-		io := sysfs.NewDigitalPin(i)
+		var io sysfs.DigitalPin
+		if io, err = e.newDigitalPin(i); err != nil {
+			return
+		}
 		if err = io.Export(); err != nil {
 			return
 		}
@@ -490,6 +602,7 @@ func (e *EdisonAdaptor) I2cStart(address int) (err error) {
 
 // I2cWrite writes data to i2c device
 func (e *EdisonAdaptor) I2cWrite(address int, data []byte) (err error) {
+	defer gobot.CountIOError(e, &err)
 	if err = e.i2cDevice.SetAddress(address); err != nil {
 		return err
 	}
@@ -499,6 +612,7 @@ func (e *EdisonAdaptor) I2cWrite(address int, data []byte) (err error) {
 
 // I2cRead returns size bytes from the i2c device
 func (e *EdisonAdaptor) I2cRead(address int, size int) (data []byte, err error) {
+	defer gobot.CountIOError(e, &err)
 	data = make([]byte, size)
 	if err = e.i2cDevice.SetAddress(address); err != nil {
 		return
@@ -506,3 +620,116 @@ func (e *EdisonAdaptor) I2cRead(address int, size int) (data []byte, err error)
 	_, err = e.i2cDevice.Read(data)
 	return
 }
+
+// ReadByteData reads a byte from register reg of the i2c device at address
+func (e *EdisonAdaptor) ReadByteData(address int, reg uint8) (val uint8, err error) {
+	defer gobot.CountIOError(e, &err)
+	return sysfs.I2cReadByteData(e.i2cDevice, address, reg)
+}
+
+// WriteByteData writes val to register reg of the i2c device at address
+func (e *EdisonAdaptor) WriteByteData(address int, reg uint8, val uint8) (err error) {
+	defer gobot.CountIOError(e, &err)
+	return sysfs.I2cWriteByteData(e.i2cDevice, address, reg, val)
+}
+
+// ReadWordData reads a little-endian word from register reg of the i2c
+// device at address
+func (e *EdisonAdaptor) ReadWordData(address int, reg uint8) (val uint16, err error) {
+	defer gobot.CountIOError(e, &err)
+	return sysfs.I2cReadWordData(e.i2cDevice, address, reg)
+}
+
+// WriteWordData writes val as a little-endian word to register reg of the
+// i2c device at address
+func (e *EdisonAdaptor) WriteWordData(address int, reg uint8, val uint16) (err error) {
+	defer gobot.CountIOError(e, &err)
+	return sysfs.I2cWriteWordData(e.i2cDevice, address, reg, val)
+}
+
+// ReadBlockData reads size bytes starting at register reg of the i2c device
+// at address
+func (e *EdisonAdaptor) ReadBlockData(address int, reg uint8, size int) (data []byte, err error) {
+	defer gobot.CountIOError(e, &err)
+	return sysfs.I2cReadBlockData(e.i2cDevice, address, reg, size)
+}
+
+// WriteRead writes buf to the i2c device at address and then reads size bytes
+// from it, without a stop condition in between
+func (e *EdisonAdaptor) WriteRead(address int, buf []byte, size int) (data []byte, err error) {
+	defer gobot.CountIOError(e, &err)
+	return sysfs.I2cWriteRead(e.i2cDevice, address, buf, size)
+}
+
+// SpiStart opens the spi device on a chip select of a bus, with the mode, bits
+// per word and maximum speed in Hz of its transfers. Bus 5 is muxed to pins 10
+// to 13 of the Arduino breakout, with chip select 1 on pin 10.
+func (e *EdisonAdaptor) SpiStart(bus int, chip int, mode int, bits int, speed int) (err error) {
+	defer gobot.CountIOError(e, &err)
+	location := sysfs.SPIDevicePath(bus, chip)
+	if e.spiDevices[location] != nil {
+		return
+	}
+	if bus == 5 {
+		if err = e.muxSpi(); err != nil {
+			return
+		}
+	}
+	device, err := sysfs.NewSPIDevice(location, byte(mode), byte(bits), uint32(speed))
+	if err != nil {
+		return
+	}
+	e.spiDevices[location] = device
+	return
+}
+
+// SpiTransfer writes data to the spi device on a chip select of a bus and
+// returns the bytes read at the same time
+func (e *EdisonAdaptor) SpiTransfer(bus int, chip int, data []byte) (read []byte, err error) {
+	defer gobot.CountIOError(e, &err)
+	device := e.spiDevices[sysfs.SPIDevicePath(bus, chip)]
+	if device == nil {
+		return nil, errors.New("SPI device not started")
+	}
+	read = make([]byte, len(data))
+	err = device.Transfer(data, read)
+	return
+}
+
+// muxSpi switches pins 10 to 13 of the Arduino breakout to spi bus 5
+func (e *EdisonAdaptor) muxSpi() (err error) {
+	if err = e.tristate.Write(sysfs.LOW); err != nil {
+		return
+	}
+
+	// select the spi functions, and make pin 12, MISO, an input
+	for _, m := range []mux{
+		{240, sysfs.HIGH},
+		{241, sysfs.HIGH},
+		{242, sysfs.HIGH},
+		{243, sysfs.HIGH},
+		{258, sysfs.HIGH},
+		{259, sysfs.HIGH},
+		{260, sysfs.LOW},
+		{261, sysfs.HIGH},
+	} {
+		var io sysfs.DigitalPin
+		if io, err = e.newDigitalPin(m.pin); err != nil {
+			return
+		}
+		if err = io.Export(); err != nil {
+			return
+		}
+		if err = io.Direction(sysfs.OUT); err != nil {
+			return
+		}
+		if err = io.Write(m.value); err != nil {
+			return
+		}
+		if err = io.Unexport(); err != nil {
+			return
+		}
+	}
+
+	return e.tristate.Write(sysfs.HIGH)
+}
@@ -8,17 +8,21 @@ import (
 	"github.com/hybridgroup/gobot/gobottest"
 	"github.com/hybridgroup/gobot/platforms/gpio"
 	"github.com/hybridgroup/gobot/platforms/i2c"
+	"github.com/hybridgroup/gobot/platforms/spi"
 	"github.com/hybridgroup/gobot/sysfs"
 )
 
 var _ gobot.Adaptor = (*EdisonAdaptor)(nil)
 
 var _ gpio.DigitalReader = (*EdisonAdaptor)(nil)
+var _ gpio.DigitalWatcher = (*EdisonAdaptor)(nil)
 var _ gpio.DigitalWriter = (*EdisonAdaptor)(nil)
 var _ gpio.AnalogReader = (*EdisonAdaptor)(nil)
 var _ gpio.PwmWriter = (*EdisonAdaptor)(nil)
+var _ gpio.ServoWriter = (*EdisonAdaptor)(nil)
 
 var _ i2c.I2c = (*EdisonAdaptor)(nil)
+var _ spi.SPI = (*EdisonAdaptor)(nil)
 
 type NullReadWriteCloser struct {
 	contents []byte
@@ -40,6 +44,30 @@ func (n *NullReadWriteCloser) Read(b []byte) (int, error) {
 	return len(b), nil
 }
 
+func (n *NullReadWriteCloser) ReadByteData(reg uint8) (uint8, error) {
+	return 0, nil
+}
+
+func (n *NullReadWriteCloser) WriteByteData(reg uint8, val uint8) error {
+	return nil
+}
+
+func (n *NullReadWriteCloser) ReadWordData(reg uint8) (uint16, error) {
+	return 0, nil
+}
+
+func (n *NullReadWriteCloser) WriteWordData(reg uint8, val uint16) error {
+	return nil
+}
+
+func (n *NullReadWriteCloser) ReadBlockData(reg uint8, b []byte) error {
+	return nil
+}
+
+func (n *NullReadWriteCloser) WriteRead(w []byte, r []byte) error {
+	return nil
+}
+
 var closeErr error = nil
 
 func (n *NullReadWriteCloser) Close() error {
@@ -188,6 +216,19 @@ func TestEdisonAdaptorPwm(t *testing.T) {
 	gobottest.Assert(t, err, errors.New("Not a PWM pin"))
 }
 
+func TestEdisonAdaptorServo(t *testing.T) {
+	a, fs := initTestEdisonAdaptor()
+	fs.Files["/sys/class/pwm/pwmchip0/pwm1/duty_cycle"].Contents = "0\n"
+
+	err := a.ServoWrite("5", 90)
+	gobottest.Assert(t, err, nil)
+	gobottest.Assert(t, fs.Files["/sys/class/pwm/pwmchip0/pwm1/period"].Contents, "20000000")
+	gobottest.Assert(t, fs.Files["/sys/class/pwm/pwmchip0/pwm1/duty_cycle"].Contents, "1500000")
+
+	err = a.ServoWrite("7", 90)
+	gobottest.Assert(t, err, errors.New("Not a PWM pin"))
+}
+
 func TestEdisonAdaptorAnalog(t *testing.T) {
 	a, fs := initTestEdisonAdaptor()
 
@@ -195,3 +236,26 @@ func TestEdisonAdaptorAnalog(t *testing.T) {
 	i, _ := a.AnalogRead("0")
 	gobottest.Assert(t, i, 250)
 }
+
+func TestEdisonAdaptorSpi(t *testing.T) {
+	a, fs := initTestEdisonAdaptor()
+	fs.Add("/dev/spidev5.1")
+	for _, i := range []string{"240", "241", "242", "243", "258", "259", "260", "261"} {
+		fs.Add("/sys/class/gpio/gpio" + i + "/direction")
+		fs.Add("/sys/class/gpio/gpio" + i + "/value")
+	}
+	sysfs.SetSyscall(&sysfs.MockSyscall{})
+
+	_, err := a.SpiTransfer(5, 1, []byte{0x01})
+	gobottest.Assert(t, err, errors.New("SPI device not started"))
+	gobottest.Refute(t, a.SpiStart(5, 9, spi.Mode0, 8, 1000000), nil)
+
+	gobottest.Assert(t, a.SpiStart(5, 1, spi.Mode0, 8, 1000000), nil)
+	gobottest.Assert(t, fs.Files["/sys/class/gpio/gpio240/value"].Contents, "1")
+	gobottest.Assert(t, fs.Files["/sys/class/gpio/gpio260/value"].Contents, "0")
+	data, err := a.SpiTransfer(5, 1, []byte{0x01, 0x02})
+	gobottest.Assert(t, err, nil)
+	gobottest.Assert(t, len(data), 2)
+
+	gobottest.Assert(t, len(a.Finalize()), 0)
+}
@@ -0,0 +1,9 @@
+package edison
+
+import "github.com/hybridgroup/gobot"
+
+func init() {
+	gobot.RegisterAdaptor("edison", func(config gobot.ConnectionConfig) (gobot.Adaptor, error) {
+		return NewEdisonAdaptor(config.Name), nil
+	})
+}
@@ -3,7 +3,7 @@ package joule
 import (
 	"errors"
 	"os"
-	"strconv"
+	"time"
 
 	"github.com/hybridgroup/gobot"
 	"github.com/hybridgroup/gobot/sysfs"
@@ -44,9 +44,21 @@ type sysfsPin struct {
 type JouleAdaptor struct {
 	name        string
 	digitalPins map[int]sysfs.DigitalPin
-	pwmPins     map[int]*pwmPin
+	pwmPins     map[int]*sysfs.PWMPin
 	i2cDevice   sysfs.I2cDevice
+	spiDevices  map[string]sysfs.SPIDevice
 	connect     func(e *JouleAdaptor) (err error)
+	gpiochips   sysfs.Gpiochips
+	pinFlags    map[int][]sysfs.LineFlag
+}
+
+// gpiochips maps the gpio numbers to the lines of the gpio character devices
+// of the north, northwest, west and southwest gpio communities
+var gpiochips = sysfs.Gpiochips{
+	{Label: "INT3452:00", Base: 434, Count: 78},
+	{Label: "INT3452:01", Base: 357, Count: 77},
+	{Label: "INT3452:02", Base: 310, Count: 47},
+	{Label: "INT3452:03", Base: 267, Count: 43},
 }
 
 var sysfsPinMap = map[string]sysfsPin{
@@ -452,7 +464,8 @@ var sysfsPinMap = map[string]sysfsPin{
 // NewJouleAdaptor returns a new JouleAdaptor with specified name
 func NewJouleAdaptor(name string) *JouleAdaptor {
 	return &JouleAdaptor{
-		name: name,
+		name:     name,
+		pinFlags: make(map[int][]sysfs.LineFlag),
 		connect: func(e *JouleAdaptor) (err error) {
 			return
 		},
@@ -465,7 +478,8 @@ func (e *JouleAdaptor) Name() string { return e.name }
 // Connect initializes the Joule for use with the Arduino beakout board
 func (e *JouleAdaptor) Connect() (errs []error) {
 	e.digitalPins = make(map[int]sysfs.DigitalPin)
-	e.pwmPins = make(map[int]*pwmPin)
+	e.pwmPins = make(map[int]*sysfs.PWMPin)
+	e.spiDevices = make(map[string]sysfs.SPIDevice)
 	if err := e.connect(e); err != nil {
 		return []error{err}
 	}
@@ -483,14 +497,19 @@ func (e *JouleAdaptor) Finalize() (errs []error) {
 	}
 	for _, pin := range e.pwmPins {
 		if pin != nil {
-			if err := pin.enable("0"); err != nil {
+			if err := pin.Enable(false); err != nil {
 				errs = append(errs, err)
 			}
-			if err := pin.unexport(); err != nil {
+			if err := pin.Unexport(); err != nil {
 				errs = append(errs, err)
 			}
 		}
 	}
+	for _, device := range e.spiDevices {
+		if err := device.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
 	if e.i2cDevice != nil {
 		if err := e.i2cDevice.Close(); errs != nil {
 			errs = append(errs, err)
@@ -503,7 +522,9 @@ func (e *JouleAdaptor) Finalize() (errs []error) {
 func (e *JouleAdaptor) digitalPin(pin string, dir string) (sysfsPin sysfs.DigitalPin, err error) {
 	i := sysfsPinMap[pin]
 	if e.digitalPins[i.pin] == nil {
-		e.digitalPins[i.pin] = sysfs.NewDigitalPin(i.pin)
+		if e.digitalPins[i.pin], err = e.newDigitalPin(i.pin); err != nil {
+			return
+		}
 		if err = e.digitalPins[i.pin].Export(); err != nil {
 			// TODO: log error
 			return
@@ -522,8 +543,43 @@ func (e *JouleAdaptor) digitalPin(pin string, dir string) (sysfsPin sysfs.Digita
 	return e.digitalPins[i.pin], nil
 }
 
+// UseGpiochip makes the adaptor drive its digital pins through the gpio
+// character devices instead of the deprecated sysfs gpio interface. The
+// chips, if given, replace the default mapping of Joule gpio numbers to
+// the lines of the character devices. It needs Linux 5.10 or later, which
+// introduced version 2 of the gpio character device interface, so not the
+// 4.x kernels of the stock Joule images.
+func (e *JouleAdaptor) UseGpiochip(chips ...sysfs.GpiochipRange) {
+	e.gpiochips = gpiochips
+	if len(chips) > 0 {
+		e.gpiochips = chips
+	}
+}
+
+// SetPinFlags sets the bias and active-low of a digital pin, which apply
+// from the first use of the pin. They require UseGpiochip.
+func (e *JouleAdaptor) SetPinFlags(pin string, flags ...sysfs.LineFlag) (err error) {
+	p, ok := sysfsPinMap[pin]
+	if !ok || p.pin < 0 {
+		return errors.New("Not a valid pin")
+	}
+	i := p.pin
+	e.pinFlags[i] = flags
+	return
+}
+
+// newDigitalPin returns the sysfs gpio or gpio character device pin of a gpio
+// number
+func (e *JouleAdaptor) newDigitalPin(i int) (sysfs.DigitalPin, error) {
+	if e.gpiochips == nil {
+		return sysfs.NewDigitalPin(i), nil
+	}
+	return e.gpiochips.NewDigitalPin(i, e.pinFlags[i]...)
+}
+
 // DigitalRead reads digital value from pin
 func (e *JouleAdaptor) DigitalRead(pin string) (i int, err error) {
+	defer gobot.CountIOError(e, &err)
 	sysfsPin, err := e.digitalPin(pin, "in")
 	if err != nil {
 		return
@@ -533,6 +589,7 @@ func (e *JouleAdaptor) DigitalRead(pin string) (i int, err error) {
 
 // DigitalWrite writes a value to the pin. Acceptable values are 1 or 0.
 func (e *JouleAdaptor) DigitalWrite(pin string, val byte) (err error) {
+	defer gobot.CountIOError(e, &err)
 	sysfsPin, err := e.digitalPin(pin, "out")
 	if err != nil {
 		return
@@ -540,38 +597,69 @@ func (e *JouleAdaptor) DigitalWrite(pin string, val byte) (err error) {
 	return sysfsPin.Write(int(val))
 }
 
+// WaitForDigitalEdge waits at most timeout for a "rising", "falling" or
+// "both" edge of a digital input, and returns the value of the pin after the
+// edge and the time of the edge. The value is -1 if the timeout passed.
+func (e *JouleAdaptor) WaitForDigitalEdge(pin string, edge string, timeout time.Duration) (val int, t time.Time, err error) {
+	defer gobot.CountIOError(e, &err)
+	sysfsPin, err := e.digitalPin(pin, "in")
+	if err != nil {
+		return -1, t, err
+	}
+	return sysfs.WaitForEdge(sysfsPin, edge, timeout)
+}
+
 // PwmWrite writes the 0-254 value to the specified pin
 func (e *JouleAdaptor) PwmWrite(pin string, val byte) (err error) {
+	defer gobot.CountIOError(e, &err)
+	pwmPin, err := e.pwmPin(pin)
+	if err != nil {
+		return
+	}
+	period, err := pwmPin.Period()
+	if err != nil {
+		return
+	}
+	duty := gobot.FromScale(float64(val), 0, 255.0)
+	return pwmPin.SetDutyCycle(uint32(float64(period) * duty))
+}
+
+// ServoWrite writes the 0-180 degree angle to the specified pin as a pulse of
+// 0.5 to 2.5ms every 20ms
+func (e *JouleAdaptor) ServoWrite(pin string, angle byte) (err error) {
+	defer gobot.CountIOError(e, &err)
+	pwmPin, err := e.pwmPin(pin)
+	if err != nil {
+		return
+	}
+	duty := gobot.ToScale(gobot.FromScale(float64(angle), 0, 180.0), 500000, 2500000)
+	return pwmPin.SetPeriodAndDutyCycle(20000000, uint32(duty))
+}
+
+// pwmPin returns the exported and enabled pwm channel of the specified pin
+func (e *JouleAdaptor) pwmPin(pin string) (pwmPin *sysfs.PWMPin, err error) {
 	sysPin := sysfsPinMap[pin]
-	if sysPin.pwmPin != -1 {
-		if e.pwmPins[sysPin.pwmPin] == nil {
-			if err = e.DigitalWrite(pin, 1); err != nil {
-				return
-			}
-			e.pwmPins[sysPin.pwmPin] = newPwmPin(sysPin.pwmPin)
-			if err = e.pwmPins[sysPin.pwmPin].export(); err != nil {
-				return
-			}
-			if err = e.pwmPins[sysPin.pwmPin].enable("1"); err != nil {
-				return
-			}
+	if sysPin.pwmPin == -1 {
+		return nil, errors.New("Not a PWM pin")
+	}
+	if e.pwmPins[sysPin.pwmPin] == nil {
+		if err = e.DigitalWrite(pin, 1); err != nil {
+			return
 		}
-		p, err := e.pwmPins[sysPin.pwmPin].period()
-		if err != nil {
-			return err
+		e.pwmPins[sysPin.pwmPin] = sysfs.NewPWMPin(sysPin.pwmPin)
+		if err = e.pwmPins[sysPin.pwmPin].Export(); err != nil {
+			return
 		}
-		period, err := strconv.Atoi(p)
-		if err != nil {
-			return err
+		if err = e.pwmPins[sysPin.pwmPin].Enable(true); err != nil {
+			return
 		}
-		duty := gobot.FromScale(float64(val), 0, 255.0)
-		return e.pwmPins[sysPin.pwmPin].writeDuty(strconv.Itoa(int(float64(period) * duty)))
 	}
-	return errors.New("Not a PWM pin")
+	return e.pwmPins[sysPin.pwmPin], nil
 }
 
 // I2cStart initializes i2c device for addresss
 func (e *JouleAdaptor) I2cStart(address int) (err error) {
+	defer gobot.CountIOError(e, &err)
 	if e.i2cDevice != nil {
 		return
 	}
@@ -583,6 +671,7 @@ func (e *JouleAdaptor) I2cStart(address int) (err error) {
 
 // I2cWrite writes data to i2c device
 func (e *JouleAdaptor) I2cWrite(address int, data []byte) (err error) {
+	defer gobot.CountIOError(e, &err)
 	if err = e.i2cDevice.SetAddress(address); err != nil {
 		return err
 	}
@@ -592,6 +681,7 @@ func (e *JouleAdaptor) I2cWrite(address int, data []byte) (err error) {
 
 // I2cRead returns size bytes from the i2c device
 func (e *JouleAdaptor) I2cRead(address int, size int) (data []byte, err error) {
+	defer gobot.CountIOError(e, &err)
 	data = make([]byte, size)
 	if err = e.i2cDevice.SetAddress(address); err != nil {
 		return
@@ -599,3 +689,73 @@ func (e *JouleAdaptor) I2cRead(address int, size int) (data []byte, err error) {
 	_, err = e.i2cDevice.Read(data)
 	return
 }
+
+// ReadByteData reads a byte from register reg of the i2c device at address
+func (e *JouleAdaptor) ReadByteData(address int, reg uint8) (val uint8, err error) {
+	defer gobot.CountIOError(e, &err)
+	return sysfs.I2cReadByteData(e.i2cDevice, address, reg)
+}
+
+// WriteByteData writes val to register reg of the i2c device at address
+func (e *JouleAdaptor) WriteByteData(address int, reg uint8, val uint8) (err error) {
+	defer gobot.CountIOError(e, &err)
+	return sysfs.I2cWriteByteData(e.i2cDevice, address, reg, val)
+}
+
+// ReadWordData reads a little-endian word from register reg of the i2c
+// device at address
+func (e *JouleAdaptor) ReadWordData(address int, reg uint8) (val uint16, err error) {
+	defer gobot.CountIOError(e, &err)
+	return sysfs.I2cReadWordData(e.i2cDevice, address, reg)
+}
+
+// WriteWordData writes val as a little-endian word to register reg of the
+// i2c device at address
+func (e *JouleAdaptor) WriteWordData(address int, reg uint8, val uint16) (err error) {
+	defer gobot.CountIOError(e, &err)
+	return sysfs.I2cWriteWordData(e.i2cDevice, address, reg, val)
+}
+
+// ReadBlockData reads size bytes starting at register reg of the i2c device
+// at address
+func (e *JouleAdaptor) ReadBlockData(address int, reg uint8, size int) (data []byte, err error) {
+	defer gobot.CountIOError(e, &err)
+	return sysfs.I2cReadBlockData(e.i2cDevice, address, reg, size)
+}
+
+// WriteRead writes buf to the i2c device at address and then reads size bytes
+// from it, without a stop condition in between
+func (e *JouleAdaptor) WriteRead(address int, buf []byte, size int) (data []byte, err error) {
+	defer gobot.CountIOError(e, &err)
+	return sysfs.I2cWriteRead(e.i2cDevice, address, buf, size)
+}
+
+// SpiStart opens the spi device on a chip select of a bus, such as
+// /dev/spidev32765.0, with the mode, bits per word and maximum speed in Hz of
+// its transfers
+func (e *JouleAdaptor) SpiStart(bus int, chip int, mode int, bits int, speed int) (err error) {
+	defer gobot.CountIOError(e, &err)
+	location := sysfs.SPIDevicePath(bus, chip)
+	if e.spiDevices[location] != nil {
+		return
+	}
+	device, err := sysfs.NewSPIDevice(location, byte(mode), byte(bits), uint32(speed))
+	if err != nil {
+		return
+	}
+	e.spiDevices[location] = device
+	return
+}
+
+// SpiTransfer writes data to the spi device on a chip select of a bus and
+// returns the bytes read at the same time
+func (e *JouleAdaptor) SpiTransfer(bus int, chip int, data []byte) (read []byte, err error) {
+	defer gobot.CountIOError(e, &err)
+	device := e.spiDevices[sysfs.SPIDevicePath(bus, chip)]
+	if device == nil {
+		return nil, errors.New("SPI device not started")
+	}
+	read = make([]byte, len(data))
+	err = device.Transfer(data, read)
+	return
+}
@@ -8,16 +8,20 @@ import (
 	"github.com/hybridgroup/gobot/gobottest"
 	"github.com/hybridgroup/gobot/platforms/gpio"
 	"github.com/hybridgroup/gobot/platforms/i2c"
+	"github.com/hybridgroup/gobot/platforms/spi"
 	"github.com/hybridgroup/gobot/sysfs"
 )
 
 var _ gobot.Adaptor = (*JouleAdaptor)(nil)
 
 var _ gpio.DigitalReader = (*JouleAdaptor)(nil)
+var _ gpio.DigitalWatcher = (*JouleAdaptor)(nil)
 var _ gpio.DigitalWriter = (*JouleAdaptor)(nil)
 var _ gpio.PwmWriter = (*JouleAdaptor)(nil)
+var _ gpio.ServoWriter = (*JouleAdaptor)(nil)
 
 var _ i2c.I2c = (*JouleAdaptor)(nil)
+var _ spi.SPI = (*JouleAdaptor)(nil)
 
 type NullReadWriteCloser struct {
 	contents []byte
@@ -39,6 +43,30 @@ func (n *NullReadWriteCloser) Read(b []byte) (int, error) {
 	return len(b), nil
 }
 
+func (n *NullReadWriteCloser) ReadByteData(reg uint8) (uint8, error) {
+	return 0, nil
+}
+
+func (n *NullReadWriteCloser) WriteByteData(reg uint8, val uint8) error {
+	return nil
+}
+
+func (n *NullReadWriteCloser) ReadWordData(reg uint8) (uint16, error) {
+	return 0, nil
+}
+
+func (n *NullReadWriteCloser) WriteWordData(reg uint8, val uint16) error {
+	return nil
+}
+
+func (n *NullReadWriteCloser) ReadBlockData(reg uint8, b []byte) error {
+	return nil
+}
+
+func (n *NullReadWriteCloser) WriteRead(w []byte, r []byte) error {
+	return nil
+}
+
 var closeErr error = nil
 
 func (n *NullReadWriteCloser) Close() error {
@@ -163,6 +191,25 @@ func TestJouleAdaptorDigitalIO(t *testing.T) {
 	gobottest.Assert(t, i, 0)
 }
 
+func TestJouleAdaptorGpiochip(t *testing.T) {
+	a, _ := initTestJouleAdaptor()
+	fs := sysfs.NewMockFilesystem([]string{"/dev/gpiochip0"})
+	sysfs.SetFilesystem(fs)
+	labels := map[string]string{"/dev/gpiochip0": "INT3452:00"}
+	sysfs.SetSyscall(&sysfs.MockSyscall{Impl: sysfs.MockGpiochipLabels(fs, labels, nil)})
+
+	a.UseGpiochip()
+	gobottest.Assert(t, a.SetPinFlags("1", sysfs.PullUp), nil)
+	gobottest.Assert(t, a.SetPinFlags("0", sysfs.PullUp), errors.New("Not a valid pin"))
+
+	// pin 1 is gpio 446, line 12 of the north community
+	gobottest.Assert(t, a.DigitalWrite("1", 1), nil)
+	gobottest.Assert(t, fs.Files["/dev/gpiochip0"].Opened, true)
+
+	// pin 5 is gpio 356 of the west community
+	gobottest.Refute(t, a.DigitalWrite("5", 1), nil)
+}
+
 func TestJouleAdaptorI2c(t *testing.T) {
 	a, _ := initTestJouleAdaptor()
 
@@ -186,3 +233,33 @@ func TestJouleAdaptorPwm(t *testing.T) {
 	err = a.PwmWrite("4", 100)
 	gobottest.Assert(t, err, errors.New("Not a PWM pin"))
 }
+
+func TestJouleAdaptorServo(t *testing.T) {
+	a, fs := initTestJouleAdaptor()
+	fs.Files["/sys/class/pwm/pwmchip0/pwm0/duty_cycle"].Contents = "0\n"
+
+	err := a.ServoWrite("25", 90)
+	gobottest.Assert(t, err, nil)
+	gobottest.Assert(t, fs.Files["/sys/class/pwm/pwmchip0/pwm0/period"].Contents, "20000000")
+	gobottest.Assert(t, fs.Files["/sys/class/pwm/pwmchip0/pwm0/duty_cycle"].Contents, "1500000")
+
+	err = a.ServoWrite("4", 90)
+	gobottest.Assert(t, err, errors.New("Not a PWM pin"))
+}
+
+func TestJouleAdaptorSpi(t *testing.T) {
+	a, fs := initTestJouleAdaptor()
+	fs.Add("/dev/spidev32765.0")
+	sysfs.SetSyscall(&sysfs.MockSyscall{})
+
+	_, err := a.SpiTransfer(32765, 0, []byte{0x01})
+	gobottest.Assert(t, err, errors.New("SPI device not started"))
+	gobottest.Refute(t, a.SpiStart(32765, 9, spi.Mode0, 8, 1000000), nil)
+
+	gobottest.Assert(t, a.SpiStart(32765, 0, spi.Mode0, 8, 1000000), nil)
+	data, err := a.SpiTransfer(32765, 0, []byte{0x01, 0x02})
+	gobottest.Assert(t, err, nil)
+	gobottest.Assert(t, len(data), 2)
+
+	gobottest.Assert(t, len(a.Finalize()), 0)
+}
@@ -0,0 +1,9 @@
+package joule
+
+import "github.com/hybridgroup/gobot"
+
+func init() {
+	gobot.RegisterAdaptor("joule", func(config gobot.ConnectionConfig) (gobot.Adaptor, error) {
+		return NewJouleAdaptor(config.Name), nil
+	})
+}
@@ -1,7 +1,6 @@
 package keyboard
 
 import (
-	"log"
 	"os"
 
 	"github.com/hybridgroup/gobot"
@@ -17,12 +16,14 @@ type KeyboardDriver struct {
 	connect func(*KeyboardDriver) (err error)
 	listen  func(*KeyboardDriver)
 	stdin   *os.File
+	logger  gobot.Logger
 	gobot.Eventer
 }
 
 func NewKeyboardDriver(name string) *KeyboardDriver {
 	k := &KeyboardDriver{
-		name: name,
+		name:   name,
+		logger: gobot.DefaultLogger().With("device", name),
 		connect: func(k *KeyboardDriver) (err error) {
 			if err := configure(); err != nil {
 				return err
@@ -41,7 +42,8 @@ func NewKeyboardDriver(name string) *KeyboardDriver {
 				if keybuf == ctrlc {
 					proc, err := os.FindProcess(os.Getpid())
 					if err != nil {
-						log.Fatal(err)
+						k.logger.Error("Unable to interrupt process", "error", err)
+						break
 					}
 
 					proc.Signal(os.Interrupt)
@@ -63,6 +65,9 @@ func NewKeyboardDriver(name string) *KeyboardDriver {
 func (k *KeyboardDriver) Name() string                 { return k.name }
 func (k *KeyboardDriver) Connection() gobot.Connection { return nil }
 
+// SetLogger sets the Logger used to report errors.
+func (k *KeyboardDriver) SetLogger(l gobot.Logger) { k.logger = l }
+
 // Start initializes keyboard by grabbing key events as they come in and
 // publishing a key event
 func (k *KeyboardDriver) Start() (errs []error) {
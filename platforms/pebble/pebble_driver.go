@@ -7,7 +7,7 @@ import (
 type PebbleDriver struct {
 	name       string
 	connection gobot.Connection
-	gobot.Commander
+	gobot.ExtendedCommander
 	gobot.Eventer
 	Messages []string
 }
@@ -23,11 +23,11 @@ type PebbleDriver struct {
 //		"pending_message"
 func NewPebbleDriver(adaptor *PebbleAdaptor, name string) *PebbleDriver {
 	p := &PebbleDriver{
-		name:       name,
-		connection: adaptor,
-		Messages:   []string{},
-		Eventer:    gobot.NewEventer(),
-		Commander:  gobot.NewCommander(),
+		name:              name,
+		connection:        adaptor,
+		Messages:          []string{},
+		Eventer:           gobot.NewEventer(),
+		ExtendedCommander: gobot.NewCommander(),
 	}
 
 	p.AddEvent("button")
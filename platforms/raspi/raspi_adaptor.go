@@ -7,6 +7,7 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/hybridgroup/gobot"
 	"github.com/hybridgroup/gobot/sysfs"
@@ -23,6 +24,26 @@ type RaspiAdaptor struct {
 	digitalPins map[int]sysfs.DigitalPin
 	pwmPins     []int
 	i2cDevice   sysfs.I2cDevice
+	spiDevices  map[string]sysfs.SPIDevice
+	hardwarePwm map[int]*sysfs.PWMPin
+	gpiochips   sysfs.Gpiochips
+	pinFlags    map[int][]sysfs.LineFlag
+}
+
+// gpiochips maps the BCM gpio numbers to the lines of the gpio character
+// device of the BCM2711 of the Pi 4, or else of the BCM2835 family
+var gpiochips = sysfs.Gpiochips{
+	{Label: "pinctrl-bcm2711", Base: 0, Count: 58},
+	{Label: "pinctrl-bcm2835", Base: 0, Count: 54},
+}
+
+// hardwarePwms maps the BCM gpio numbers which the pwm-2chan device tree
+// overlay muxes to the hardware pwm chip to their pwm channel
+var hardwarePwms = map[int]int{
+	12: 0,
+	18: 0,
+	13: 1,
+	19: 1,
 }
 
 var pins = map[string]map[string]int{
@@ -118,6 +139,8 @@ func NewRaspiAdaptor(name string) *RaspiAdaptor {
 		name:        name,
 		digitalPins: make(map[int]sysfs.DigitalPin),
 		pwmPins:     []int{},
+		pinFlags:    make(map[int][]sysfs.LineFlag),
+		spiDevices:  make(map[string]sysfs.SPIDevice),
 	}
 	content, _ := readFile()
 	for _, v := range strings.Split(string(content), "\n") {
@@ -160,6 +183,19 @@ func (r *RaspiAdaptor) Finalize() (errs []error) {
 			errs = append(errs, err)
 		}
 	}
+	for _, pin := range r.hardwarePwm {
+		if err := pin.Enable(false); err != nil {
+			errs = append(errs, err)
+		}
+		if err := pin.Unexport(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for _, device := range r.spiDevices {
+		if err := device.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
 	if r.i2cDevice != nil {
 		if err := r.i2cDevice.Close(); err != nil {
 			errs = append(errs, err)
@@ -180,12 +216,26 @@ func (r *RaspiAdaptor) translatePin(pin string) (i int, err error) {
 	return
 }
 
-func (r *RaspiAdaptor) pwmPin(pin string) (i int, err error) {
+// pwmPin returns the BCM gpio of a pin and, with UseHardwarePwm, the exported
+// channel of the hardware pwm chip driving the gpio. Other gpios are driven by
+// pi-blaster.
+func (r *RaspiAdaptor) pwmPin(pin string) (i int, hardwarePin *sysfs.PWMPin, err error) {
 	i, err = r.translatePin(pin)
 	if err != nil {
 		return
 	}
 
+	if channel, ok := hardwarePwms[i]; ok && r.hardwarePwm != nil {
+		if r.hardwarePwm[channel] == nil {
+			hardwarePin = sysfs.NewPWMPin(channel)
+			if err = hardwarePin.Export(); err != nil {
+				return
+			}
+			r.hardwarePwm[channel] = hardwarePin
+		}
+		return i, r.hardwarePwm[channel], nil
+	}
+
 	newPin := true
 	for _, pin := range r.pwmPins {
 		if i == pin {
@@ -210,7 +260,9 @@ func (r *RaspiAdaptor) digitalPin(pin string, dir string) (sysfsPin sysfs.Digita
 	}
 
 	if r.digitalPins[i] == nil {
-		r.digitalPins[i] = sysfs.NewDigitalPin(i)
+		if r.digitalPins[i], err = r.newDigitalPin(i); err != nil {
+			return
+		}
 		if err = r.digitalPins[i].Export(); err != nil {
 			return
 		}
@@ -223,8 +275,48 @@ func (r *RaspiAdaptor) digitalPin(pin string, dir string) (sysfsPin sysfs.Digita
 	return r.digitalPins[i], nil
 }
 
+// UseGpiochip makes the adaptor drive its digital pins through the gpio
+// character devices instead of the deprecated sysfs gpio interface. The
+// chips, if given, replace the default mapping of BCM gpio numbers to
+// the lines of the character devices. It needs Linux 5.10 or later, which
+// introduced version 2 of the gpio character device interface.
+func (r *RaspiAdaptor) UseGpiochip(chips ...sysfs.GpiochipRange) {
+	r.gpiochips = gpiochips
+	if len(chips) > 0 {
+		r.gpiochips = chips
+	}
+}
+
+// UseHardwarePwm makes the adaptor drive the gpios 12, 13, 18 and 19 through
+// the two channels of the hardware pwm chip instead of pi-blaster. The gpios
+// must be muxed to the chip, e.g. with the pwm-2chan device tree overlay.
+func (r *RaspiAdaptor) UseHardwarePwm() {
+	r.hardwarePwm = make(map[int]*sysfs.PWMPin)
+}
+
+// SetPinFlags sets the bias and active-low of a digital pin, which apply
+// from the first use of the pin. They require UseGpiochip.
+func (r *RaspiAdaptor) SetPinFlags(pin string, flags ...sysfs.LineFlag) (err error) {
+	i, err := r.translatePin(pin)
+	if err != nil {
+		return
+	}
+	r.pinFlags[i] = flags
+	return
+}
+
+// newDigitalPin returns the sysfs gpio or gpio character device pin of a gpio
+// number
+func (r *RaspiAdaptor) newDigitalPin(i int) (sysfs.DigitalPin, error) {
+	if r.gpiochips == nil {
+		return sysfs.NewDigitalPin(i), nil
+	}
+	return r.gpiochips.NewDigitalPin(i, r.pinFlags[i]...)
+}
+
 // DigitalRead reads digital value from pin
 func (r *RaspiAdaptor) DigitalRead(pin string) (val int, err error) {
+	defer gobot.CountIOError(r, &err)
 	sysfsPin, err := r.digitalPin(pin, sysfs.IN)
 	if err != nil {
 		return
@@ -234,6 +326,7 @@ func (r *RaspiAdaptor) DigitalRead(pin string) (val int, err error) {
 
 // DigitalWrite writes digital value to specified pin
 func (r *RaspiAdaptor) DigitalWrite(pin string, val byte) (err error) {
+	defer gobot.CountIOError(r, &err)
 	sysfsPin, err := r.digitalPin(pin, sysfs.OUT)
 	if err != nil {
 		return err
@@ -241,8 +334,21 @@ func (r *RaspiAdaptor) DigitalWrite(pin string, val byte) (err error) {
 	return sysfsPin.Write(int(val))
 }
 
+// WaitForDigitalEdge waits at most timeout for a "rising", "falling" or
+// "both" edge of a digital input, and returns the value of the pin after the
+// edge and the time of the edge. The value is -1 if the timeout passed.
+func (r *RaspiAdaptor) WaitForDigitalEdge(pin string, edge string, timeout time.Duration) (val int, t time.Time, err error) {
+	defer gobot.CountIOError(r, &err)
+	sysfsPin, err := r.digitalPin(pin, sysfs.IN)
+	if err != nil {
+		return -1, t, err
+	}
+	return sysfs.WaitForEdge(sysfsPin, edge, timeout)
+}
+
 // I2cStart starts a i2c device in specified address
 func (r *RaspiAdaptor) I2cStart(address int) (err error) {
+	defer gobot.CountIOError(r, &err)
 	if r.i2cDevice == nil {
 		r.i2cDevice, err = sysfs.NewI2cDevice(r.i2cLocation, address)
 	}
@@ -251,6 +357,7 @@ func (r *RaspiAdaptor) I2cStart(address int) (err error) {
 
 // I2CWrite writes data to i2c device
 func (r *RaspiAdaptor) I2cWrite(address int, data []byte) (err error) {
+	defer gobot.CountIOError(r, &err)
 	if err = r.i2cDevice.SetAddress(address); err != nil {
 		return
 	}
@@ -260,6 +367,7 @@ func (r *RaspiAdaptor) I2cWrite(address int, data []byte) (err error) {
 
 // I2cRead returns value from i2c device using specified size
 func (r *RaspiAdaptor) I2cRead(address int, size int) (data []byte, err error) {
+	defer gobot.CountIOError(r, &err)
 	if err = r.i2cDevice.SetAddress(address); err != nil {
 		return
 	}
@@ -268,25 +376,84 @@ func (r *RaspiAdaptor) I2cRead(address int, size int) (data []byte, err error) {
 	return
 }
 
+// ReadByteData reads a byte from register reg of the i2c device at address
+func (r *RaspiAdaptor) ReadByteData(address int, reg uint8) (val uint8, err error) {
+	defer gobot.CountIOError(r, &err)
+	return sysfs.I2cReadByteData(r.i2cDevice, address, reg)
+}
+
+// WriteByteData writes val to register reg of the i2c device at address
+func (r *RaspiAdaptor) WriteByteData(address int, reg uint8, val uint8) (err error) {
+	defer gobot.CountIOError(r, &err)
+	return sysfs.I2cWriteByteData(r.i2cDevice, address, reg, val)
+}
+
+// ReadWordData reads a little-endian word from register reg of the i2c
+// device at address
+func (r *RaspiAdaptor) ReadWordData(address int, reg uint8) (val uint16, err error) {
+	defer gobot.CountIOError(r, &err)
+	return sysfs.I2cReadWordData(r.i2cDevice, address, reg)
+}
+
+// WriteWordData writes val as a little-endian word to register reg of the
+// i2c device at address
+func (r *RaspiAdaptor) WriteWordData(address int, reg uint8, val uint16) (err error) {
+	defer gobot.CountIOError(r, &err)
+	return sysfs.I2cWriteWordData(r.i2cDevice, address, reg, val)
+}
+
+// ReadBlockData reads size bytes starting at register reg of the i2c device
+// at address
+func (r *RaspiAdaptor) ReadBlockData(address int, reg uint8, size int) (data []byte, err error) {
+	defer gobot.CountIOError(r, &err)
+	return sysfs.I2cReadBlockData(r.i2cDevice, address, reg, size)
+}
+
+// WriteRead writes buf to the i2c device at address and then reads size bytes
+// from it, without a stop condition in between
+func (r *RaspiAdaptor) WriteRead(address int, buf []byte, size int) (data []byte, err error) {
+	defer gobot.CountIOError(r, &err)
+	return sysfs.I2cWriteRead(r.i2cDevice, address, buf, size)
+}
+
 func (r *RaspiAdaptor) PwmWrite(pin string, val byte) (err error) {
-	sysfsPin, err := r.pwmPin(pin)
+	defer gobot.CountIOError(r, &err)
+	sysfsPin, hardwarePin, err := r.pwmPin(pin)
 	if err != nil {
 		return err
 	}
+	if hardwarePin != nil {
+		duty := gobot.FromScale(float64(val), 0, 255)
+		return hardwarePwmWrite(hardwarePin, 10000000, uint32(10000000*duty))
+	}
 	return r.piBlaster(fmt.Sprintf("%v=%v\n", sysfsPin, gobot.FromScale(float64(val), 0, 255)))
 }
 
 func (r *RaspiAdaptor) ServoWrite(pin string, angle byte) (err error) {
-	sysfsPin, err := r.pwmPin(pin)
+	defer gobot.CountIOError(r, &err)
+	sysfsPin, hardwarePin, err := r.pwmPin(pin)
 	if err != nil {
 		return err
 	}
+	if hardwarePin != nil {
+		duty := gobot.ToScale(gobot.FromScale(float64(angle), 0, 180), 500000, 2500000)
+		return hardwarePwmWrite(hardwarePin, 20000000, uint32(duty))
+	}
 
 	val := (gobot.ToScale(gobot.FromScale(float64(angle), 0, 180), 0, 200) / 1000.0) + 0.05
 
 	return r.piBlaster(fmt.Sprintf("%v=%v\n", sysfsPin, val))
 }
 
+// hardwarePwmWrite writes the period and duty cycle in nanoseconds to a
+// channel of the hardware pwm chip and enables its output
+func hardwarePwmWrite(pin *sysfs.PWMPin, period uint32, duty uint32) (err error) {
+	if err = pin.SetPeriodAndDutyCycle(period, duty); err != nil {
+		return
+	}
+	return pin.Enable(true)
+}
+
 func (r *RaspiAdaptor) piBlaster(data string) (err error) {
 	fi, err := sysfs.OpenFile("/dev/pi-blaster", os.O_WRONLY|os.O_APPEND, 0644)
 	defer fi.Close()
@@ -298,3 +465,33 @@ func (r *RaspiAdaptor) piBlaster(data string) (err error) {
 	_, err = fi.WriteString(data)
 	return
 }
+
+// SpiStart opens the spi device on a chip select of a bus, such as
+// /dev/spidev0.0 once SPI is enabled, with the mode, bits per word and maximum
+// speed in Hz of its transfers
+func (r *RaspiAdaptor) SpiStart(bus int, chip int, mode int, bits int, speed int) (err error) {
+	defer gobot.CountIOError(r, &err)
+	location := sysfs.SPIDevicePath(bus, chip)
+	if r.spiDevices[location] != nil {
+		return
+	}
+	device, err := sysfs.NewSPIDevice(location, byte(mode), byte(bits), uint32(speed))
+	if err != nil {
+		return
+	}
+	r.spiDevices[location] = device
+	return
+}
+
+// SpiTransfer writes data to the spi device on a chip select of a bus and
+// returns the bytes read at the same time
+func (r *RaspiAdaptor) SpiTransfer(bus int, chip int, data []byte) (read []byte, err error) {
+	defer gobot.CountIOError(r, &err)
+	device := r.spiDevices[sysfs.SPIDevicePath(bus, chip)]
+	if device == nil {
+		return nil, errors.New("SPI device not started")
+	}
+	read = make([]byte, len(data))
+	err = device.Transfer(data, read)
+	return
+}
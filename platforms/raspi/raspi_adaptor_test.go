@@ -1,22 +1,30 @@
 package raspi
 
 import (
+	"errors"
 	"strings"
+	"syscall"
 	"testing"
+	"time"
 
 	"github.com/hybridgroup/gobot"
 	"github.com/hybridgroup/gobot/gobottest"
 	"github.com/hybridgroup/gobot/platforms/gpio"
 	"github.com/hybridgroup/gobot/platforms/i2c"
+	"github.com/hybridgroup/gobot/platforms/spi"
 	"github.com/hybridgroup/gobot/sysfs"
 )
 
 var _ gobot.Adaptor = (*RaspiAdaptor)(nil)
 
 var _ gpio.DigitalReader = (*RaspiAdaptor)(nil)
+var _ gpio.DigitalWatcher = (*RaspiAdaptor)(nil)
 var _ gpio.DigitalWriter = (*RaspiAdaptor)(nil)
+var _ gpio.PwmWriter = (*RaspiAdaptor)(nil)
+var _ gpio.ServoWriter = (*RaspiAdaptor)(nil)
 
 var _ i2c.I2c = (*RaspiAdaptor)(nil)
+var _ spi.SPI = (*RaspiAdaptor)(nil)
 
 type NullReadWriteCloser struct {
 	contents []byte
@@ -38,6 +46,30 @@ func (n *NullReadWriteCloser) Read(b []byte) (int, error) {
 	return len(b), nil
 }
 
+func (n *NullReadWriteCloser) ReadByteData(reg uint8) (uint8, error) {
+	return 0, nil
+}
+
+func (n *NullReadWriteCloser) WriteByteData(reg uint8, val uint8) error {
+	return nil
+}
+
+func (n *NullReadWriteCloser) ReadWordData(reg uint8) (uint16, error) {
+	return 0, nil
+}
+
+func (n *NullReadWriteCloser) WriteWordData(reg uint8, val uint16) error {
+	return nil
+}
+
+func (n *NullReadWriteCloser) ReadBlockData(reg uint8, b []byte) error {
+	return nil
+}
+
+func (n *NullReadWriteCloser) WriteRead(w []byte, r []byte) error {
+	return nil
+}
+
 var closeErr error = nil
 
 func (n *NullReadWriteCloser) Close() error {
@@ -133,6 +165,40 @@ func TestRaspiAdaptorDigitalPWM(t *testing.T) {
 	gobottest.Assert(t, strings.Split(fs.Files["/dev/pi-blaster"].Contents, "\n")[0], "17=0.25")
 }
 
+func TestRaspiAdaptorHardwarePWM(t *testing.T) {
+	a := initTestRaspiAdaptor()
+	a.UseHardwarePwm()
+
+	fs := sysfs.NewMockFilesystem([]string{
+		"/dev/pi-blaster",
+		"/sys/class/pwm/pwmchip0/export",
+		"/sys/class/pwm/pwmchip0/unexport",
+		"/sys/class/pwm/pwmchip0/pwm0/enable",
+		"/sys/class/pwm/pwmchip0/pwm0/period",
+		"/sys/class/pwm/pwmchip0/pwm0/duty_cycle",
+	})
+	sysfs.SetFilesystem(fs)
+	fs.Files["/sys/class/pwm/pwmchip0/pwm0/duty_cycle"].Contents = "0\n"
+
+	gobottest.Assert(t, a.PwmWrite("12", 51), nil)
+	gobottest.Assert(t, fs.Files["/sys/class/pwm/pwmchip0/export"].Contents, "0")
+	gobottest.Assert(t, fs.Files["/sys/class/pwm/pwmchip0/pwm0/period"].Contents, "10000000")
+	gobottest.Assert(t, fs.Files["/sys/class/pwm/pwmchip0/pwm0/duty_cycle"].Contents, "2000000")
+	gobottest.Assert(t, fs.Files["/sys/class/pwm/pwmchip0/pwm0/enable"].Contents, "1")
+
+	gobottest.Assert(t, a.ServoWrite("12", 45), nil)
+	gobottest.Assert(t, fs.Files["/sys/class/pwm/pwmchip0/pwm0/period"].Contents, "20000000")
+	gobottest.Assert(t, fs.Files["/sys/class/pwm/pwmchip0/pwm0/duty_cycle"].Contents, "1000000")
+
+	// other gpios still use pi-blaster
+	gobottest.Assert(t, a.PwmWrite("7", 255), nil)
+	gobottest.Assert(t, strings.Split(fs.Files["/dev/pi-blaster"].Contents, "\n")[0], "4=1")
+
+	gobottest.Assert(t, len(a.Finalize()), 0)
+	gobottest.Assert(t, fs.Files["/sys/class/pwm/pwmchip0/pwm0/enable"].Contents, "0")
+	gobottest.Assert(t, fs.Files["/sys/class/pwm/pwmchip0/unexport"].Contents, "0")
+}
+
 func TestRaspiAdaptorDigitalIO(t *testing.T) {
 	a := initTestRaspiAdaptor()
 	fs := sysfs.NewMockFilesystem([]string{
@@ -154,6 +220,54 @@ func TestRaspiAdaptorDigitalIO(t *testing.T) {
 	gobottest.Assert(t, i, 1)
 }
 
+func TestRaspiAdaptorGpiochip(t *testing.T) {
+	a := initTestRaspiAdaptor()
+	fs := sysfs.NewMockFilesystem([]string{"/dev/gpiochip0"})
+	sysfs.SetFilesystem(fs)
+	ioctls := []uintptr{}
+	labels := map[string]string{"/dev/gpiochip0": "pinctrl-bcm2835"}
+	sysfs.SetSyscall(&sysfs.MockSyscall{Impl: sysfs.MockGpiochipLabels(fs, labels, func(trap, a1, a2, a3 uintptr) (r1, r2 uintptr, err syscall.Errno) {
+		ioctls = append(ioctls, a2)
+		return 0, 0, 0
+	})})
+	defer sysfs.SetSyscall(&sysfs.NativeSyscall{})
+
+	a.UseGpiochip()
+	gobottest.Assert(t, a.SetPinFlags("7", sysfs.PullUp), nil)
+	gobottest.Assert(t, a.SetPinFlags("99", sysfs.PullUp), errors.New("Not a valid pin"))
+
+	gobottest.Assert(t, a.DigitalWrite("7", 1), nil)
+	gobottest.Assert(t, fs.Files["/dev/gpiochip0"].Opened, true)
+	gobottest.Assert(t, ioctls, []uintptr{
+		sysfs.GPIO_V2_GET_LINE_IOCTL,
+		sysfs.GPIO_V2_LINE_SET_CONFIG_IOCTL,
+		sysfs.GPIO_V2_LINE_SET_VALUES_IOCTL,
+	})
+
+	a.UseGpiochip(sysfs.GpiochipRange{Chip: "/dev/gpiochip0", Base: 0, Count: 4})
+	gobottest.Refute(t, a.DigitalWrite("11", 1), nil)
+}
+
+func TestRaspiAdaptorWaitForDigitalEdge(t *testing.T) {
+	a := initTestRaspiAdaptor()
+	fs := sysfs.NewMockFilesystem([]string{"/dev/gpiochip0"})
+	sysfs.SetFilesystem(fs)
+	labels := map[string]string{"/dev/gpiochip0": "pinctrl-bcm2711"}
+	sysfs.SetSyscall(&sysfs.MockSyscall{Impl: sysfs.MockGpiochipLabels(fs, labels, nil)})
+	defer sysfs.SetSyscall(&sysfs.NativeSyscall{})
+	a.UseGpiochip()
+
+	// the mock syscall never reports an edge
+	val, _, err := a.WaitForDigitalEdge("7", "both", time.Millisecond)
+	gobottest.Assert(t, err, nil)
+	gobottest.Assert(t, val, -1)
+
+	_, _, err = a.WaitForDigitalEdge("7", "sideways", time.Millisecond)
+	gobottest.Refute(t, err, nil)
+	_, _, err = a.WaitForDigitalEdge("99", "both", time.Millisecond)
+	gobottest.Assert(t, err, errors.New("Not a valid pin"))
+}
+
 func TestRaspiAdaptorI2c(t *testing.T) {
 	a := initTestRaspiAdaptor()
 	fs := sysfs.NewMockFilesystem([]string{
@@ -168,3 +282,24 @@ func TestRaspiAdaptorI2c(t *testing.T) {
 	data, _ := a.I2cRead(0xff, 2)
 	gobottest.Assert(t, data, []byte{0x00, 0x01})
 }
+
+func TestRaspiAdaptorSpi(t *testing.T) {
+	a := initTestRaspiAdaptor()
+	fs := sysfs.NewMockFilesystem([]string{
+		"/dev/spidev0.1",
+	})
+	sysfs.SetFilesystem(fs)
+	sysfs.SetSyscall(&sysfs.MockSyscall{})
+
+	_, err := a.SpiTransfer(0, 1, []byte{0x01})
+	gobottest.Assert(t, err, errors.New("SPI device not started"))
+	gobottest.Refute(t, a.SpiStart(0, 9, spi.Mode0, 8, 1000000), nil)
+
+	gobottest.Assert(t, a.SpiStart(0, 1, spi.Mode0, 8, 1000000), nil)
+	gobottest.Assert(t, a.SpiStart(0, 1, spi.Mode0, 8, 1000000), nil)
+	data, err := a.SpiTransfer(0, 1, []byte{0x01, 0x02})
+	gobottest.Assert(t, err, nil)
+	gobottest.Assert(t, len(data), 2)
+
+	gobottest.Assert(t, len(a.Finalize()), 0)
+}
@@ -0,0 +1,9 @@
+package raspi
+
+import "github.com/hybridgroup/gobot"
+
+func init() {
+	gobot.RegisterAdaptor("raspi", func(config gobot.ConnectionConfig) (gobot.Adaptor, error) {
+		return NewRaspiAdaptor(config.Name), nil
+	})
+}
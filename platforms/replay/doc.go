@@ -0,0 +1,49 @@
+/*
+Package replay provides the Gobot adaptor and driver for replaying events
+recorded with a gobot.Recorder, so that a work function can be debugged
+without the hardware it was recorded from.
+
+Installing:
+
+	go get github.com/hybridgroup/gobot/platforms/replay
+
+Example:
+
+	package main
+
+	import (
+		"fmt"
+
+		"github.com/hybridgroup/gobot"
+		"github.com/hybridgroup/gobot/platforms/gpio"
+		"github.com/hybridgroup/gobot/platforms/replay"
+	)
+
+	func main() {
+		gbot := gobot.NewGobot()
+
+		replayer := replay.NewReplayAdaptor("replayer", "session.jsonl")
+		button := replay.NewReplayDriver(replayer, "button")
+
+		work := func() {
+			button.On(gpio.ButtonPush, func(data interface{}) {
+				fmt.Println("button pressed")
+			})
+			replayer.Play()
+		}
+
+		robot := gobot.NewRobot("buttonBot",
+			[]gobot.Connection{replayer},
+			[]gobot.Device{button},
+			work,
+		)
+
+		gbot.AddRobot(robot)
+
+		gbot.Start()
+	}
+
+For further information refer to replay README:
+https://github.com/hybridgroup/gobot/blob/master/platforms/replay/README.md
+*/
+package replay
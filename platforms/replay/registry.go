@@ -0,0 +1,21 @@
+package replay
+
+import (
+	"errors"
+
+	"github.com/hybridgroup/gobot"
+)
+
+func init() {
+	gobot.RegisterAdaptor("replay", func(config gobot.ConnectionConfig) (gobot.Adaptor, error) {
+		return NewReplayAdaptor(config.Name, config.Port, config.Params.Float("speed", 1)), nil
+	})
+
+	gobot.RegisterDriver("replay", func(c gobot.Connection, config gobot.DeviceConfig) (gobot.Driver, error) {
+		a, ok := c.(*ReplayAdaptor)
+		if !ok {
+			return nil, errors.New("Replay driver requires a Replay adaptor")
+		}
+		return NewReplayDriver(a, config.Name), nil
+	})
+}
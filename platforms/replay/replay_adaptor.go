@@ -0,0 +1,161 @@
+package replay
+
+import (
+	"os"
+	"sync"
+	"time"
+
+	"github.com/hybridgroup/gobot"
+)
+
+const (
+	// Done event is published by the adaptor when a replay has finished
+	Done = "done"
+)
+
+var _ gobot.Adaptor = (*ReplayAdaptor)(nil)
+
+// ReplayAdaptor plays back a recording made by a gobot.Recorder, publishing
+// each recorded event on the Eventer attached to the device it was recorded
+// from.
+type ReplayAdaptor struct {
+	name string
+	path string
+	// Speed scales the time between events. 1 replays in real time, 2 twice
+	// as fast, and 0 as fast as possible.
+	Speed   float64
+	events  []gobot.RecordedEvent
+	mutex   sync.Mutex
+	devices map[string]gobot.Eventer
+	halt    chan bool
+	wg      sync.WaitGroup
+	gobot.Eventer
+}
+
+// NewReplayAdaptor returns a new ReplayAdaptor given a name and the path of a
+// recording in either format.
+//
+// Optionally accepts:
+//
+//	float64: Speed at which the recording is replayed
+func NewReplayAdaptor(name string, path string, v ...float64) *ReplayAdaptor {
+	r := &ReplayAdaptor{
+		name:    name,
+		path:    path,
+		Speed:   1,
+		devices: make(map[string]gobot.Eventer),
+		Eventer: gobot.NewEventer(),
+	}
+
+	if len(v) > 0 {
+		r.Speed = v[0]
+	}
+
+	r.AddEvent(Done)
+
+	return r
+}
+
+// Name returns the adaptor name
+func (r *ReplayAdaptor) Name() string { return r.name }
+
+// Port returns the path of the recording
+func (r *ReplayAdaptor) Port() string { return r.path }
+
+// Connect reads the recording
+func (r *ReplayAdaptor) Connect() (errs []error) {
+	f, err := os.Open(r.path)
+	if err != nil {
+		return []error{err}
+	}
+	defer f.Close()
+
+	events, err := gobot.ReadRecording(f)
+	if err != nil {
+		return []error{err}
+	}
+
+	r.mutex.Lock()
+	r.events = events
+	r.mutex.Unlock()
+	return
+}
+
+// Finalize stops any replay in progress
+func (r *ReplayAdaptor) Finalize() (errs []error) {
+	r.Stop()
+	return
+}
+
+// Attach publishes the events recorded from the named device on e.
+func (r *ReplayAdaptor) Attach(device string, e gobot.Eventer) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.devices[device] = e
+}
+
+// RecordedEvents returns the events read from the recording.
+func (r *ReplayAdaptor) RecordedEvents() []gobot.RecordedEvent {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return append([]gobot.RecordedEvent{}, r.events...)
+}
+
+// Play starts replaying the recording from the beginning, stopping any
+// replay already in progress. Events recorded from devices which have not
+// been attached are skipped. The Done event is published when every event
+// has been replayed.
+//
+// Play is usually called at the end of the work function, once its event
+// handlers have been registered.
+func (r *ReplayAdaptor) Play() {
+	r.Stop()
+
+	r.mutex.Lock()
+	halt := make(chan bool)
+	r.halt = halt
+	events := r.events
+	speed := r.Speed
+	r.mutex.Unlock()
+
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		start := time.Now()
+		for _, evt := range events {
+			if speed > 0 {
+				offset := time.Duration(float64(evt.Time.Sub(events[0].Time)) / speed)
+				select {
+				case <-time.After(time.Until(start.Add(offset))):
+				case <-halt:
+					return
+				}
+			} else {
+				select {
+				case <-halt:
+					return
+				default:
+				}
+			}
+
+			r.mutex.Lock()
+			e, ok := r.devices[evt.Device]
+			r.mutex.Unlock()
+			if ok {
+				e.Publish(evt.Name, evt.Data)
+			}
+		}
+		r.Publish(r.Event(Done), len(events))
+	}()
+}
+
+// Stop stops any replay in progress and waits for it to return.
+func (r *ReplayAdaptor) Stop() {
+	r.mutex.Lock()
+	if r.halt != nil {
+		close(r.halt)
+		r.halt = nil
+	}
+	r.mutex.Unlock()
+	r.wg.Wait()
+}
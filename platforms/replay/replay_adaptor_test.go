@@ -0,0 +1,125 @@
+package replay
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/hybridgroup/gobot"
+	"github.com/hybridgroup/gobot/gobottest"
+)
+
+const testRecording = `{"time":"2016-01-01T00:00:00Z","device":"button","name":"push","type":"int","data":1}
+{"time":"2016-01-01T00:00:00.2Z","device":"button","name":"release","type":"int","data":0}
+{"time":"2016-01-01T00:00:00.4Z","device":"sensor","name":"data","type":"int","data":512}
+`
+
+func writeTestRecording(t *testing.T) string {
+	f, err := ioutil.TempFile("", "replay")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(testRecording)
+	f.Close()
+	return f.Name()
+}
+
+func TestReplayAdaptor(t *testing.T) {
+	a := NewReplayAdaptor("replayer", "session.jsonl", 2)
+	gobottest.Assert(t, a.Name(), "replayer")
+	gobottest.Assert(t, a.Port(), "session.jsonl")
+	gobottest.Assert(t, a.Speed, 2.0)
+	gobottest.Assert(t, NewReplayAdaptor("replayer", "session.jsonl").Speed, 1.0)
+}
+
+func TestReplayAdaptorConnect(t *testing.T) {
+	path := writeTestRecording(t)
+	defer os.Remove(path)
+
+	a := NewReplayAdaptor("replayer", path)
+	gobottest.Assert(t, len(a.Connect()), 0)
+	gobottest.Assert(t, len(a.RecordedEvents()), 3)
+	gobottest.Assert(t, len(a.Finalize()), 0)
+
+	a = NewReplayAdaptor("replayer", path+".missing")
+	gobottest.Assert(t, len(a.Connect()), 1)
+}
+
+func TestReplayAdaptorPlay(t *testing.T) {
+	path := writeTestRecording(t)
+	defer os.Remove(path)
+
+	a := NewReplayAdaptor("replayer", path, 10)
+	button := NewReplayDriver(a, "button")
+	gobottest.Assert(t, len(a.Connect()), 0)
+	gobottest.Assert(t, len(button.Start()), 0)
+	gobottest.Assert(t, button.Event("push"), "push")
+	gobottest.Assert(t, button.Event("data"), "")
+
+	pushed := make(chan interface{}, 1)
+	button.Once("push", func(data interface{}) {
+		pushed <- data
+	})
+	done := make(chan interface{}, 1)
+	a.Once(Done, func(data interface{}) {
+		done <- data
+	})
+
+	start := time.Now()
+	a.Play()
+
+	select {
+	case data := <-pushed:
+		gobottest.Assert(t, data, 1)
+	case <-time.After(time.Second):
+		t.Errorf("push event was not replayed")
+	}
+
+	select {
+	case data := <-done:
+		gobottest.Assert(t, data, 3)
+		// 400ms of events at 10 times speed
+		gobottest.Assert(t, time.Since(start) >= 40*time.Millisecond, true)
+	case <-time.After(time.Second):
+		t.Errorf("replay did not finish")
+	}
+}
+
+func TestReplayAdaptorStop(t *testing.T) {
+	path := writeTestRecording(t)
+	defer os.Remove(path)
+
+	a := NewReplayAdaptor("replayer", path)
+	NewReplayDriver(a, "button")
+	a.Connect()
+
+	done := make(chan interface{}, 1)
+	a.Once(Done, func(data interface{}) {
+		done <- data
+	})
+
+	a.Play()
+	a.Finalize()
+
+	select {
+	case <-done:
+		t.Errorf("stopped replay should not finish")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestReplayRegistry(t *testing.T) {
+	c, err := gobot.NewAdaptor(gobot.ConnectionConfig{
+		Name:    "replayer",
+		Adaptor: "replay",
+		Port:    "session.jsonl",
+		Params:  gobot.ConfigParams{"speed": 0.0},
+	})
+	gobottest.Assert(t, err, nil)
+	gobottest.Assert(t, c.(*ReplayAdaptor).Speed, 0.0)
+
+	d, err := gobot.NewDriver(c, gobot.DeviceConfig{Name: "button", Driver: "replay"})
+	gobottest.Assert(t, err, nil)
+	gobottest.Assert(t, d.Name(), "button")
+}
@@ -0,0 +1,48 @@
+package replay
+
+import "github.com/hybridgroup/gobot"
+
+var _ gobot.Driver = (*ReplayDriver)(nil)
+
+// ReplayDriver stands in for a recorded device, publishing the events which
+// were recorded from the device with the same name.
+type ReplayDriver struct {
+	name       string
+	connection *ReplayAdaptor
+	gobot.Eventer
+	gobot.ExtendedCommander
+}
+
+// NewReplayDriver returns a new ReplayDriver given a ReplayAdaptor and the
+// name of the recorded device.
+func NewReplayDriver(a *ReplayAdaptor, name string) *ReplayDriver {
+	d := &ReplayDriver{
+		name:              name,
+		connection:        a,
+		Eventer:           gobot.NewEventer(),
+		ExtendedCommander: gobot.NewCommander(),
+	}
+
+	a.Attach(name, d)
+
+	return d
+}
+
+// Name returns the driver name
+func (d *ReplayDriver) Name() string { return d.name }
+
+// Connection returns the driver connection
+func (d *ReplayDriver) Connection() gobot.Connection { return d.connection }
+
+// Start registers the names of the events recorded from the device
+func (d *ReplayDriver) Start() (errs []error) {
+	for _, evt := range d.connection.RecordedEvents() {
+		if evt.Device == d.name && d.Event(evt.Name) == "" {
+			d.AddEvent(evt.Name)
+		}
+	}
+	return
+}
+
+// Halt stops the driver
+func (d *ReplayDriver) Halt() (errs []error) { return }
@@ -0,0 +1,21 @@
+package sphero
+
+import (
+	"errors"
+
+	"github.com/hybridgroup/gobot"
+)
+
+func init() {
+	gobot.RegisterAdaptor("sphero", func(config gobot.ConnectionConfig) (gobot.Adaptor, error) {
+		return NewSpheroAdaptor(config.Name, config.Port), nil
+	})
+
+	gobot.RegisterDriver("sphero", func(c gobot.Connection, config gobot.DeviceConfig) (gobot.Driver, error) {
+		a, ok := c.(*SpheroAdaptor)
+		if !ok {
+			return nil, errors.New("Sphero driver requires a Sphero adaptor")
+		}
+		return NewSpheroDriver(a, config.Name), nil
+	})
+}
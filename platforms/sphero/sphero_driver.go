@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/binary"
 	"errors"
+	"sync"
 	"time"
 
 	"github.com/hybridgroup/gobot"
@@ -33,8 +34,14 @@ type SpheroDriver struct {
 	syncResponse    [][]uint8
 	packetChannel   chan *packet
 	responseChannel chan []uint8
+	mutex           sync.Mutex
+	color           [3]uint8
+	backLED         uint8
+	speed           uint8
+	heading         uint16
+	sensorData      *DataStreamingPacket
 	gobot.Eventer
-	gobot.Commander
+	gobot.ExtendedCommander
 }
 
 // NewSpheroDriver returns a new SpheroDriver given a SpheroAdaptor and name.
@@ -52,29 +59,42 @@ type SpheroDriver struct {
 //  "SetRotationRate" - See SpheroDriver.SetRotationRate
 func NewSpheroDriver(a *SpheroAdaptor, name string) *SpheroDriver {
 	s := &SpheroDriver{
-		name:            name,
-		connection:      a,
-		Eventer:         gobot.NewEventer(),
-		Commander:       gobot.NewCommander(),
-		packetChannel:   make(chan *packet, 1024),
-		responseChannel: make(chan []uint8, 1024),
+		name:              name,
+		connection:        a,
+		Eventer:           gobot.NewEventer(),
+		ExtendedCommander: gobot.NewCommander(),
+		packetChannel:     make(chan *packet, 1024),
+		responseChannel:   make(chan []uint8, 1024),
 	}
 
 	s.AddEvent(Error)
 	s.AddEvent(Collision)
 	s.AddEvent(SensorData)
 
-	s.AddCommand("SetRGB", func(params map[string]interface{}) interface{} {
-		r := uint8(params["r"].(float64))
-		g := uint8(params["g"].(float64))
-		b := uint8(params["b"].(float64))
+	s.AddCommandWithSchema("SetRGB", gobot.CommandSchema{
+		Description: "Set the color of the Sphero",
+		Params: []gobot.Param{
+			{Name: "r", Type: gobot.ParamInteger, Required: true, Range: &gobot.Range{Min: 0, Max: 255}},
+			{Name: "g", Type: gobot.ParamInteger, Required: true, Range: &gobot.Range{Min: 0, Max: 255}},
+			{Name: "b", Type: gobot.ParamInteger, Required: true, Range: &gobot.Range{Min: 0, Max: 255}},
+		},
+	}, func(params map[string]interface{}) interface{} {
+		r := uint8(params["r"].(int))
+		g := uint8(params["g"].(int))
+		b := uint8(params["b"].(int))
 		s.SetRGB(r, g, b)
 		return nil
 	})
 
-	s.AddCommand("Roll", func(params map[string]interface{}) interface{} {
-		speed := uint8(params["speed"].(float64))
-		heading := uint16(params["heading"].(float64))
+	s.AddCommandWithSchema("Roll", gobot.CommandSchema{
+		Description: "Roll the Sphero at a speed towards a heading",
+		Params: []gobot.Param{
+			{Name: "speed", Type: gobot.ParamInteger, Required: true, Range: &gobot.Range{Min: 0, Max: 255}},
+			{Name: "heading", Type: gobot.ParamInteger, Required: true, Range: &gobot.Range{Min: 0, Max: 359}},
+		},
+	}, func(params map[string]interface{}) interface{} {
+		speed := uint8(params["speed"].(int))
+		heading := uint16(params["heading"].(int))
 		s.Roll(speed, heading)
 		return nil
 	})
@@ -92,46 +112,85 @@ func NewSpheroDriver(a *SpheroAdaptor, name string) *SpheroDriver {
 		return s.ReadLocator()
 	})
 
-	s.AddCommand("SetBackLED", func(params map[string]interface{}) interface{} {
-		level := uint8(params["level"].(float64))
+	s.AddCommandWithSchema("SetBackLED", gobot.CommandSchema{
+		Description: "Set the brightness of the back LED",
+		Params: []gobot.Param{
+			{Name: "level", Type: gobot.ParamInteger, Required: true, Range: &gobot.Range{Min: 0, Max: 255}},
+		},
+	}, func(params map[string]interface{}) interface{} {
+		level := uint8(params["level"].(int))
 		s.SetBackLED(level)
 		return nil
 	})
 
-	s.AddCommand("SetRotationRate", func(params map[string]interface{}) interface{} {
-		level := uint8(params["level"].(float64))
+	s.AddCommandWithSchema("SetRotationRate", gobot.CommandSchema{
+		Description: "Set the rotation rate of the Sphero",
+		Params: []gobot.Param{
+			{Name: "level", Type: gobot.ParamInteger, Required: true, Range: &gobot.Range{Min: 0, Max: 255}},
+		},
+	}, func(params map[string]interface{}) interface{} {
+		level := uint8(params["level"].(int))
 		s.SetRotationRate(level)
 		return nil
 	})
 
-	s.AddCommand("SetHeading", func(params map[string]interface{}) interface{} {
-		heading := uint16(params["heading"].(float64))
+	s.AddCommandWithSchema("SetHeading", gobot.CommandSchema{
+		Description: "Set the current heading of the Sphero",
+		Params: []gobot.Param{
+			{Name: "heading", Type: gobot.ParamInteger, Required: true, Range: &gobot.Range{Min: 0, Max: 359}},
+		},
+	}, func(params map[string]interface{}) interface{} {
+		heading := uint16(params["heading"].(int))
 		s.SetHeading(heading)
 		return nil
 	})
 
-	s.AddCommand("SetStabilization", func(params map[string]interface{}) interface{} {
+	s.AddCommandWithSchema("SetStabilization", gobot.CommandSchema{
+		Description: "Enable or disable the stabilization system",
+		Params: []gobot.Param{
+			{Name: "enable", Type: gobot.ParamBool, Required: true},
+		},
+	}, func(params map[string]interface{}) interface{} {
 		on := params["enable"].(bool)
 		s.SetStabilization(on)
 		return nil
 	})
 
-	s.AddCommand("SetDataStreaming", func(params map[string]interface{}) interface{} {
-		N := uint16(params["N"].(float64))
-		M := uint16(params["M"].(float64))
+	// the masks are numbers, as their high bits do not fit in an int on
+	// 32-bit platforms
+	s.AddCommandWithSchema("SetDataStreaming", gobot.CommandSchema{
+		Description: "Configure sensor data streaming",
+		Params: []gobot.Param{
+			{Name: "N", Type: gobot.ParamInteger, Required: true, Range: &gobot.Range{Min: 0, Max: 65535}},
+			{Name: "M", Type: gobot.ParamInteger, Required: true, Range: &gobot.Range{Min: 0, Max: 65535}},
+			{Name: "Mask", Type: gobot.ParamNumber, Required: true, Range: &gobot.Range{Min: 0, Max: 4294967295}},
+			{Name: "Pcnt", Type: gobot.ParamInteger, Required: true, Range: &gobot.Range{Min: 0, Max: 255}},
+			{Name: "Mask2", Type: gobot.ParamNumber, Required: true, Range: &gobot.Range{Min: 0, Max: 4294967295}},
+		},
+	}, func(params map[string]interface{}) interface{} {
+		N := uint16(params["N"].(int))
+		M := uint16(params["M"].(int))
 		Mask := uint32(params["Mask"].(float64))
-		Pcnt := uint8(params["Pcnt"].(float64))
+		Pcnt := uint8(params["Pcnt"].(int))
 		Mask2 := uint32(params["Mask2"].(float64))
 
 		s.SetDataStreaming(DataStreamingConfig{N: N, M: M, Mask2: Mask2, Pcnt: Pcnt, Mask: Mask})
 		return nil
 	})
 
-	s.AddCommand("ConfigureLocator", func(params map[string]interface{}) interface{} {
-		Flags := uint8(params["Flags"].(float64))
-		X := int16(params["X"].(float64))
-		Y := int16(params["Y"].(float64))
-		YawTare := int16(params["YawTare"].(float64))
+	s.AddCommandWithSchema("ConfigureLocator", gobot.CommandSchema{
+		Description: "Configure the locator",
+		Params: []gobot.Param{
+			{Name: "Flags", Type: gobot.ParamInteger, Required: true, Range: &gobot.Range{Min: 0, Max: 255}},
+			{Name: "X", Type: gobot.ParamInteger, Required: true, Range: &gobot.Range{Min: -32768, Max: 32767}},
+			{Name: "Y", Type: gobot.ParamInteger, Required: true, Range: &gobot.Range{Min: -32768, Max: 32767}},
+			{Name: "YawTare", Type: gobot.ParamInteger, Required: true, Range: &gobot.Range{Min: -32768, Max: 32767}},
+		},
+	}, func(params map[string]interface{}) interface{} {
+		Flags := uint8(params["Flags"].(int))
+		X := int16(params["X"].(int))
+		Y := int16(params["Y"].(int))
+		YawTare := int16(params["YawTare"].(int))
 
 		s.ConfigureLocator(LocatorConfig{Flags: Flags, X: X, Y: Y, YawTare: YawTare})
 		return nil
@@ -143,6 +202,24 @@ func NewSpheroDriver(a *SpheroAdaptor, name string) *SpheroDriver {
 func (s *SpheroDriver) Name() string                 { return s.name }
 func (s *SpheroDriver) Connection() gobot.Connection { return s.connection }
 
+// Snapshot returns the last color, back LED level, speed and heading set,
+// and the last sensor data streamed by the Sphero
+func (s *SpheroDriver) Snapshot() map[string]interface{} {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	state := map[string]interface{}{
+		"color":    map[string]int{"r": int(s.color[0]), "g": int(s.color[1]), "b": int(s.color[2])},
+		"back_led": s.backLED,
+		"speed":    s.speed,
+		"heading":  s.heading,
+	}
+	if s.sensorData != nil {
+		state["sensor_data"] = *s.sensorData
+	}
+	return state
+}
+
 func (s *SpheroDriver) adaptor() *SpheroAdaptor {
 	return s.Connection().(*SpheroAdaptor)
 }
@@ -227,6 +304,9 @@ func (s *SpheroDriver) Halt() (errs []error) {
 
 // SetRGB sets the Sphero to the given r, g, and b values
 func (s *SpheroDriver) SetRGB(r uint8, g uint8, b uint8) {
+	s.mutex.Lock()
+	s.color = [3]uint8{r, g, b}
+	s.mutex.Unlock()
 	s.packetChannel <- s.craftPacket([]uint8{r, g, b, 0x01}, 0x02, 0x20)
 }
 
@@ -252,6 +332,9 @@ func (s *SpheroDriver) ReadLocator() []int16 {
 
 // SetBackLED sets the Sphero Back LED to the specified brightness
 func (s *SpheroDriver) SetBackLED(level uint8) {
+	s.mutex.Lock()
+	s.backLED = level
+	s.mutex.Unlock()
 	s.packetChannel <- s.craftPacket([]uint8{level}, 0x02, 0x21)
 }
 
@@ -277,6 +360,9 @@ func (s *SpheroDriver) SetStabilization(on bool) {
 
 // Roll sends a roll command to the Sphero gives a speed and heading
 func (s *SpheroDriver) Roll(speed uint8, heading uint16) {
+	s.mutex.Lock()
+	s.speed, s.heading = speed, heading
+	s.mutex.Unlock()
 	s.packetChannel <- s.craftPacket([]uint8{speed, uint8(heading >> 8), uint8(heading & 0xFF), 0x01}, 0x02, 0x30)
 }
 
@@ -329,6 +415,9 @@ func (s *SpheroDriver) handleDataStreaming(data []uint8) {
 	var dataPacket DataStreamingPacket
 	buffer := bytes.NewBuffer(data[5:]) // skip header
 	binary.Read(buffer, binary.BigEndian, &dataPacket)
+	s.mutex.Lock()
+	s.sensorData = &dataPacket
+	s.mutex.Unlock()
 	s.Publish(SensorData, dataPacket)
 }
 
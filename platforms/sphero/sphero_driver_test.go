@@ -85,6 +85,23 @@ func TestSpheroDriverHalt(t *testing.T) {
 	gobottest.Assert(t, len(d.Halt()), 0)
 }
 
+func TestSpheroDriverSnapshot(t *testing.T) {
+	d := initTestSpheroDriver()
+	d.SetRGB(10, 20, 30)
+	d.SetBackLED(255)
+	d.Roll(100, 270)
+
+	gobottest.Assert(t, d.Snapshot(), map[string]interface{}{
+		"color":    map[string]int{"r": 10, "g": 20, "b": 30},
+		"back_led": uint8(255),
+		"speed":    uint8(100),
+		"heading":  uint16(270),
+	})
+
+	d.handleDataStreaming(make([]uint8, 90))
+	gobottest.Assert(t, d.Snapshot()["sensor_data"], DataStreamingPacket{})
+}
+
 func TestSpheroDriverSetDataStreaming(t *testing.T) {
 	d := initTestSpheroDriver()
 	d.SetDataStreaming(DefaultDataStreamingConfig())
@@ -102,13 +119,13 @@ func TestSpheroDriverSetDataStreaming(t *testing.T) {
 			"M":     200.0,
 			"Mask":  300.0,
 			"Pcnt":  255.0,
-			"Mask2": 400.0,
+			"Mask2": 4294967295.0,
 		},
 	)
 	gobottest.Assert(t, ret, nil)
 	data = <-d.packetChannel
 
-	dconfig := DataStreamingConfig{N: 100, M: 200, Mask: 300, Pcnt: 255, Mask2: 400}
+	dconfig := DataStreamingConfig{N: 100, M: 200, Mask: 300, Pcnt: 255, Mask2: 4294967295}
 	buf = new(bytes.Buffer)
 	binary.Write(buf, binary.BigEndian, dconfig)
 
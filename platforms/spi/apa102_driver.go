@@ -0,0 +1,127 @@
+package spi
+
+import (
+	"image/color"
+	"sync"
+
+	"github.com/hybridgroup/gobot"
+)
+
+var _ gobot.Driver = (*APA102Driver)(nil)
+
+// APA102Driver is a driver for a strip of APA102 RGB LEDs
+type APA102Driver struct {
+	name       string
+	connection SPI
+	vals       []color.RGBA
+	mutex      sync.Mutex
+	// Bus and Chip select the device, Speed is the clock speed in Hz
+	Bus   int
+	Chip  int
+	Speed int
+	gobot.ExtendedCommander
+}
+
+// NewAPA102Driver creates a new APA102Driver with specified name for a strip
+// of count LEDs, which talks to the strip on bus 0 at 4MHz. The strip has no
+// chip select input, so it takes the whole bus.
+//
+// Adds the following API commands:
+//	SetRGBA - sets the color and brightness of a LED
+//	Draw - shows the colors on the strip
+func NewAPA102Driver(a SPI, name string, count int) *APA102Driver {
+	d := &APA102Driver{
+		name:              name,
+		connection:        a,
+		vals:              make([]color.RGBA, count),
+		Speed:             4000000,
+		ExtendedCommander: gobot.NewCommander(),
+	}
+
+	level := func(name string) gobot.Param {
+		return gobot.Param{Name: name, Type: gobot.ParamInteger, Required: true, Range: &gobot.Range{Min: 0, Max: 255}}
+	}
+	d.AddCommandWithSchema("SetRGBA", gobot.CommandSchema{
+		Description: "Set the color and brightness of a LED",
+		Params: []gobot.Param{
+			{Name: "index", Type: gobot.ParamInteger, Required: true, Range: &gobot.Range{Min: 0, Max: float64(count - 1)}},
+			level("red"),
+			level("green"),
+			level("blue"),
+			{Name: "alpha", Type: gobot.ParamInteger, Default: 255, Range: &gobot.Range{Min: 0, Max: 255}},
+		},
+	}, func(params map[string]interface{}) interface{} {
+		return d.SetRGBA(params["index"].(int), color.RGBA{
+			R: uint8(params["red"].(int)),
+			G: uint8(params["green"].(int)),
+			B: uint8(params["blue"].(int)),
+			A: uint8(params["alpha"].(int)),
+		})
+	})
+	d.AddCommand("Draw", func(params map[string]interface{}) interface{} {
+		return d.Draw()
+	})
+
+	return d
+}
+
+// Name returns the APA102Drivers name
+func (d *APA102Driver) Name() string { return d.name }
+
+// Connection returns the APA102Drivers Connection
+func (d *APA102Driver) Connection() gobot.Connection { return d.connection.(gobot.Connection) }
+
+// Count returns the number of LEDs of the strip
+func (d *APA102Driver) Count() int { return len(d.vals) }
+
+// Start opens the spi device
+func (d *APA102Driver) Start() (errs []error) {
+	if err := d.connection.SpiStart(d.Bus, d.Chip, Mode0, 8, d.Speed); err != nil {
+		return []error{err}
+	}
+	return
+}
+
+// Halt returns true if device is halted successfully
+func (d *APA102Driver) Halt() (errs []error) { return }
+
+// Snapshot returns the colors of the LEDs
+func (d *APA102Driver) Snapshot() map[string]interface{} {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	leds := make([]map[string]interface{}, len(d.vals))
+	for i, c := range d.vals {
+		leds[i] = map[string]interface{}{"red": c.R, "green": c.G, "blue": c.B, "alpha": c.A}
+	}
+	return map[string]interface{}{"leds": leds}
+}
+
+// SetRGBA sets the color of the LED at index i, which is shown by the next
+// Draw. The alpha of the color is the brightness of the LED.
+func (d *APA102Driver) SetRGBA(i int, c color.RGBA) (err error) {
+	if i < 0 || i >= len(d.vals) {
+		return ErrInvalidChannel
+	}
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.vals[i] = c
+	return
+}
+
+// Draw shows the colors on the strip
+func (d *APA102Driver) Draw() (err error) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	// a start frame of zeros, a frame per LED with its 5 bit brightness and
+	// blue, green and red, then an end frame which clocks the data through
+	// to the end of the strip
+	tx := make([]byte, 4, 4+4*len(d.vals)+len(d.vals)/16+1)
+	for _, c := range d.vals {
+		tx = append(tx, 0xe0|c.A>>3, c.B, c.G, c.R)
+	}
+	for i := 0; i <= len(d.vals)/16; i++ {
+		tx = append(tx, 0xff)
+	}
+	_, err = d.connection.SpiTransfer(d.Bus, d.Chip, tx)
+	return
+}
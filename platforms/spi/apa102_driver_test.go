@@ -0,0 +1,66 @@
+package spi
+
+import (
+	"errors"
+	"image/color"
+	"testing"
+
+	"github.com/hybridgroup/gobot"
+	"github.com/hybridgroup/gobot/gobottest"
+)
+
+var _ gobot.Stater = (*APA102Driver)(nil)
+
+func initTestAPA102DriverWithStubbedAdaptor() (*APA102Driver, *spiTestAdaptor) {
+	adaptor := newSpiTestAdaptor("adaptor")
+	return NewAPA102Driver(adaptor, "bot", 2), adaptor
+}
+
+func TestAPA102Driver(t *testing.T) {
+	d, adaptor := initTestAPA102DriverWithStubbedAdaptor()
+	gobottest.Assert(t, d.Name(), "bot")
+	gobottest.Assert(t, d.Connection().Name(), "adaptor")
+	gobottest.Assert(t, d.Count(), 2)
+
+	var speed int
+	adaptor.spiStartImpl = func(b, c, m, bits, s int) error {
+		speed = s
+		return nil
+	}
+	gobottest.Assert(t, len(d.Start()), 0)
+	gobottest.Assert(t, speed, 4000000)
+	gobottest.Assert(t, len(d.Halt()), 0)
+}
+
+func TestAPA102DriverDraw(t *testing.T) {
+	d, adaptor := initTestAPA102DriverWithStubbedAdaptor()
+
+	gobottest.Assert(t, d.SetRGBA(1, color.RGBA{R: 1, G: 2, B: 3, A: 255}), nil)
+	gobottest.Assert(t, d.SetRGBA(2, color.RGBA{}), ErrInvalidChannel)
+	gobottest.Assert(t, d.Draw(), nil)
+	gobottest.Assert(t, adaptor.written[0], []byte{
+		0x00, 0x00, 0x00, 0x00,
+		0xe0, 0x00, 0x00, 0x00,
+		0xff, 0x03, 0x02, 0x01,
+		0xff,
+	})
+
+	leds := d.Snapshot()["leds"].([]map[string]interface{})
+	gobottest.Assert(t, leds[1]["red"], uint8(1))
+
+	adaptor.spiTransferImpl = func(data []byte) ([]byte, error) {
+		return nil, errors.New("transfer error")
+	}
+	gobottest.Assert(t, d.Draw(), errors.New("transfer error"))
+}
+
+func TestAPA102DriverCommands(t *testing.T) {
+	d, adaptor := initTestAPA102DriverWithStubbedAdaptor()
+
+	result := d.Command("SetRGBA")(map[string]interface{}{"index": 0.0, "red": 255.0, "green": 0.0, "blue": 16.0, "alpha": 64.0})
+	gobottest.Assert(t, result, nil)
+	gobottest.Assert(t, d.Command("Draw")(map[string]interface{}{}), nil)
+	gobottest.Assert(t, adaptor.written[0][4:8], []byte{0xe8, 0x10, 0x00, 0xff})
+
+	gobottest.Refute(t, d.Command("SetRGBA")(map[string]interface{}{"index": 2.0, "red": 0.0, "green": 0.0, "blue": 0.0}), nil)
+}
@@ -0,0 +1,31 @@
+package spi
+
+type spiTestAdaptor struct {
+	name            string
+	written         [][]byte
+	spiStartImpl    func(bus, chip, mode, bits, speed int) error
+	spiTransferImpl func(data []byte) ([]byte, error)
+}
+
+func (t *spiTestAdaptor) SpiStart(bus, chip, mode, bits, speed int) (err error) {
+	return t.spiStartImpl(bus, chip, mode, bits, speed)
+}
+func (t *spiTestAdaptor) SpiTransfer(bus, chip int, data []byte) ([]byte, error) {
+	t.written = append(t.written, data)
+	return t.spiTransferImpl(data)
+}
+func (t *spiTestAdaptor) Name() string             { return t.name }
+func (t *spiTestAdaptor) Connect() (errs []error)  { return }
+func (t *spiTestAdaptor) Finalize() (errs []error) { return }
+
+func newSpiTestAdaptor(name string) *spiTestAdaptor {
+	return &spiTestAdaptor{
+		name: name,
+		spiStartImpl: func(bus, chip, mode, bits, speed int) error {
+			return nil
+		},
+		spiTransferImpl: func(data []byte) ([]byte, error) {
+			return make([]byte, len(data)), nil
+		},
+	}
+}
@@ -0,0 +1,162 @@
+package spi
+
+import "github.com/hybridgroup/gobot"
+
+var _ gobot.Driver = (*MAX7219Driver)(nil)
+
+// MAX7219 registers
+const (
+	MAX7219NoOp        = 0x00
+	MAX7219Digit0      = 0x01
+	MAX7219DecodeMode  = 0x09
+	MAX7219Intensity   = 0x0a
+	MAX7219ScanLimit   = 0x0b
+	MAX7219Shutdown    = 0x0c
+	MAX7219DisplayTest = 0x0f
+)
+
+// MAX7219Driver is a driver for a chain of MAX7219 LED matrix and 7-segment
+// display drivers, each of which drives 8 rows or digits of 8 LEDs
+type MAX7219Driver struct {
+	name       string
+	connection SPI
+	count      int
+	// Bus and Chip select the device, Speed is the clock speed in Hz
+	Bus   int
+	Chip  int
+	Speed int
+	gobot.ExtendedCommander
+}
+
+// NewMAX7219Driver creates a new MAX7219Driver with specified name for a
+// chain of count devices, which talks to the chain on chip select 0 of bus 0
+// at 1MHz.
+//
+// Adds the following API commands:
+//	SetIntensity - sets the brightness of all devices
+//	SetRow - sets the LEDs of a row of a device
+//	ClearAll - turns off all LEDs
+func NewMAX7219Driver(a SPI, name string, count int) *MAX7219Driver {
+	m := &MAX7219Driver{
+		name:              name,
+		connection:        a,
+		count:             count,
+		Speed:             1000000,
+		ExtendedCommander: gobot.NewCommander(),
+	}
+
+	m.AddCommandWithSchema("SetIntensity", gobot.CommandSchema{
+		Description: "Set the brightness of all devices",
+		Params: []gobot.Param{
+			{Name: "level", Type: gobot.ParamInteger, Required: true, Range: &gobot.Range{Min: 0, Max: 15}},
+		},
+	}, func(params map[string]interface{}) interface{} {
+		return m.SetIntensity(byte(params["level"].(int)))
+	})
+	m.AddCommandWithSchema("SetRow", gobot.CommandSchema{
+		Description: "Set the LEDs of a row of a device",
+		Params: []gobot.Param{
+			{Name: "device", Type: gobot.ParamInteger, Default: 0, Range: &gobot.Range{Min: 0, Max: float64(count - 1)}},
+			{Name: "row", Type: gobot.ParamInteger, Required: true, Range: &gobot.Range{Min: 0, Max: 7}},
+			{Name: "leds", Type: gobot.ParamInteger, Required: true, Range: &gobot.Range{Min: 0, Max: 255}},
+		},
+	}, func(params map[string]interface{}) interface{} {
+		return m.SetRow(params["device"].(int), params["row"].(int), byte(params["leds"].(int)))
+	})
+	m.AddCommand("ClearAll", func(params map[string]interface{}) interface{} {
+		return m.ClearAll()
+	})
+
+	return m
+}
+
+// Name returns the MAX7219Drivers name
+func (m *MAX7219Driver) Name() string { return m.name }
+
+// Connection returns the MAX7219Drivers Connection
+func (m *MAX7219Driver) Connection() gobot.Connection { return m.connection.(gobot.Connection) }
+
+// Count returns the number of devices in the chain
+func (m *MAX7219Driver) Count() int { return m.count }
+
+// Start opens the spi device, and sets up all devices to show the raw rows
+// of their registers, which are cleared
+func (m *MAX7219Driver) Start() (errs []error) {
+	if err := m.connection.SpiStart(m.Bus, m.Chip, Mode0, 8, m.Speed); err != nil {
+		return []error{err}
+	}
+	for _, reg := range [][]byte{
+		{MAX7219ScanLimit, 0x07},
+		{MAX7219DecodeMode, 0x00},
+		{MAX7219DisplayTest, 0x00},
+		{MAX7219Shutdown, 0x01},
+	} {
+		if err := m.All(reg[0], reg[1]); err != nil {
+			return []error{err}
+		}
+	}
+	if err := m.ClearAll(); err != nil {
+		return []error{err}
+	}
+	return
+}
+
+// Halt shuts all devices down
+func (m *MAX7219Driver) Halt() (errs []error) {
+	if err := m.All(MAX7219Shutdown, 0x00); err != nil {
+		return []error{err}
+	}
+	return
+}
+
+// All writes data to a register of all devices
+func (m *MAX7219Driver) All(address byte, data byte) (err error) {
+	buf := make([]byte, 0, 2*m.count)
+	for i := 0; i < m.count; i++ {
+		buf = append(buf, address, data)
+	}
+	_, err = m.connection.SpiTransfer(m.Bus, m.Chip, buf)
+	return
+}
+
+// One writes data to a register of the device at index which of the chain,
+// where device 0 is the one connected to the bus. The other devices get a
+// no-op.
+func (m *MAX7219Driver) One(which int, address byte, data byte) (err error) {
+	if which < 0 || which >= m.count {
+		return ErrInvalidChannel
+	}
+	// the first words shift through to the end of the chain
+	buf := make([]byte, 2*m.count)
+	pos := 2 * (m.count - 1 - which)
+	buf[pos] = address
+	buf[pos+1] = data
+	_, err = m.connection.SpiTransfer(m.Bus, m.Chip, buf)
+	return
+}
+
+// SetIntensity sets the 0-15 brightness of all devices
+func (m *MAX7219Driver) SetIntensity(level byte) (err error) {
+	if level > 15 {
+		level = 15
+	}
+	return m.All(MAX7219Intensity, level)
+}
+
+// SetRow sets the LEDs of a row 0-7 of a device, one bit per LED
+func (m *MAX7219Driver) SetRow(which int, row int, leds byte) (err error) {
+	if row < 0 || row > 7 {
+		return ErrInvalidChannel
+	}
+	return m.One(which, byte(MAX7219Digit0+row), leds)
+}
+
+// ClearAll turns off all LEDs of all devices
+func (m *MAX7219Driver) ClearAll() (err error) {
+	for row := 0; row < 8; row++ {
+		if err = m.All(byte(MAX7219Digit0+row), 0x00); err != nil {
+			return
+		}
+	}
+	return
+}
@@ -0,0 +1,65 @@
+package spi
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/hybridgroup/gobot/gobottest"
+)
+
+func initTestMAX7219DriverWithStubbedAdaptor() (*MAX7219Driver, *spiTestAdaptor) {
+	adaptor := newSpiTestAdaptor("adaptor")
+	return NewMAX7219Driver(adaptor, "bot", 2), adaptor
+}
+
+func TestMAX7219Driver(t *testing.T) {
+	d, adaptor := initTestMAX7219DriverWithStubbedAdaptor()
+	gobottest.Assert(t, d.Name(), "bot")
+	gobottest.Assert(t, d.Connection().Name(), "adaptor")
+	gobottest.Assert(t, d.Count(), 2)
+
+	gobottest.Assert(t, len(d.Start()), 0)
+	gobottest.Assert(t, len(adaptor.written), 12)
+	gobottest.Assert(t, adaptor.written[0], []byte{MAX7219ScanLimit, 0x07, MAX7219ScanLimit, 0x07})
+	gobottest.Assert(t, adaptor.written[3], []byte{MAX7219Shutdown, 0x01, MAX7219Shutdown, 0x01})
+	gobottest.Assert(t, adaptor.written[11], []byte{0x08, 0x00, 0x08, 0x00})
+
+	gobottest.Assert(t, len(d.Halt()), 0)
+	gobottest.Assert(t, adaptor.written[12], []byte{MAX7219Shutdown, 0x00, MAX7219Shutdown, 0x00})
+
+	adaptor.spiTransferImpl = func(data []byte) ([]byte, error) {
+		return nil, errors.New("transfer error")
+	}
+	gobottest.Assert(t, d.Start()[0], errors.New("transfer error"))
+	gobottest.Assert(t, d.Halt()[0], errors.New("transfer error"))
+}
+
+func TestMAX7219DriverOne(t *testing.T) {
+	d, adaptor := initTestMAX7219DriverWithStubbedAdaptor()
+
+	gobottest.Assert(t, d.SetRow(0, 2, 0x81), nil)
+	gobottest.Assert(t, adaptor.written[0], []byte{MAX7219NoOp, 0x00, 0x03, 0x81})
+	gobottest.Assert(t, d.SetRow(1, 7, 0xff), nil)
+	gobottest.Assert(t, adaptor.written[1], []byte{0x08, 0xff, MAX7219NoOp, 0x00})
+
+	gobottest.Assert(t, d.SetRow(2, 0, 0xff), ErrInvalidChannel)
+	gobottest.Assert(t, d.SetRow(0, 8, 0xff), ErrInvalidChannel)
+
+	gobottest.Assert(t, d.SetIntensity(20), nil)
+	gobottest.Assert(t, adaptor.written[2], []byte{MAX7219Intensity, 0x0f, MAX7219Intensity, 0x0f})
+}
+
+func TestMAX7219DriverCommands(t *testing.T) {
+	d, adaptor := initTestMAX7219DriverWithStubbedAdaptor()
+
+	gobottest.Assert(t, d.Command("SetIntensity")(map[string]interface{}{"level": 3.0}), nil)
+	gobottest.Assert(t, adaptor.written[0], []byte{MAX7219Intensity, 0x03, MAX7219Intensity, 0x03})
+
+	gobottest.Assert(t, d.Command("SetRow")(map[string]interface{}{"row": 1.0, "leds": 255.0}), nil)
+	gobottest.Assert(t, adaptor.written[1], []byte{MAX7219NoOp, 0x00, 0x02, 0xff})
+
+	gobottest.Refute(t, d.Command("SetRow")(map[string]interface{}{"device": 2.0, "row": 1.0, "leds": 255.0}), nil)
+
+	gobottest.Assert(t, d.Command("ClearAll")(map[string]interface{}{}), nil)
+	gobottest.Assert(t, len(adaptor.written), 10)
+}
@@ -0,0 +1,76 @@
+package spi
+
+import "github.com/hybridgroup/gobot"
+
+var _ gobot.Driver = (*MCP3008Driver)(nil)
+
+// MCP3008Driver is a driver for the MCP3008 8-channel 10-bit analog to
+// digital converter
+type MCP3008Driver struct {
+	name       string
+	connection SPI
+	// Bus and Chip select the device, Speed is the clock speed in Hz
+	Bus   int
+	Chip  int
+	Speed int
+	gobot.ExtendedCommander
+}
+
+// NewMCP3008Driver creates a new MCP3008Driver with specified name, which
+// talks to the device on chip select 0 of bus 0 at 1MHz.
+//
+// Adds the following API commands:
+//	Read - returns the value of a channel
+func NewMCP3008Driver(a SPI, name string) *MCP3008Driver {
+	m := &MCP3008Driver{
+		name:              name,
+		connection:        a,
+		Speed:             1000000,
+		ExtendedCommander: gobot.NewCommander(),
+	}
+
+	m.AddCommandWithSchema("Read", gobot.CommandSchema{
+		Description: "Read the value of a channel",
+		Params: []gobot.Param{
+			{Name: "channel", Type: gobot.ParamInteger, Required: true, Range: &gobot.Range{Min: 0, Max: 7}},
+		},
+	}, func(params map[string]interface{}) interface{} {
+		val, err := m.Read(params["channel"].(int))
+		return map[string]interface{}{"val": val, "err": err}
+	})
+
+	return m
+}
+
+// Name returns the MCP3008Drivers name
+func (m *MCP3008Driver) Name() string { return m.name }
+
+// Connection returns the MCP3008Drivers Connection
+func (m *MCP3008Driver) Connection() gobot.Connection { return m.connection.(gobot.Connection) }
+
+// Start opens the spi device
+func (m *MCP3008Driver) Start() (errs []error) {
+	if err := m.connection.SpiStart(m.Bus, m.Chip, Mode0, 8, m.Speed); err != nil {
+		return []error{err}
+	}
+	return
+}
+
+// Halt returns true if device is halted successfully
+func (m *MCP3008Driver) Halt() (errs []error) { return }
+
+// Read returns the 0-1023 value of a single-ended channel 0-7
+func (m *MCP3008Driver) Read(channel int) (val int, err error) {
+	if channel < 0 || channel > 7 {
+		return 0, ErrInvalidChannel
+	}
+	// start bit, single-ended mode and channel, then clock out the result
+	data, err := m.connection.SpiTransfer(m.Bus, m.Chip, []byte{0x01, byte(8+channel) << 4, 0x00})
+	if err != nil {
+		return
+	}
+	if len(data) != 3 {
+		return 0, ErrNotEnoughBytes
+	}
+	return int(data[1]&0x03)<<8 | int(data[2]), nil
+}
@@ -0,0 +1,78 @@
+package spi
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/hybridgroup/gobot"
+	"github.com/hybridgroup/gobot/gobottest"
+)
+
+func initTestMCP3008DriverWithStubbedAdaptor() (*MCP3008Driver, *spiTestAdaptor) {
+	adaptor := newSpiTestAdaptor("adaptor")
+	return NewMCP3008Driver(adaptor, "bot"), adaptor
+}
+
+func TestMCP3008Driver(t *testing.T) {
+	d, adaptor := initTestMCP3008DriverWithStubbedAdaptor()
+	gobottest.Assert(t, d.Name(), "bot")
+	gobottest.Assert(t, d.Connection().Name(), "adaptor")
+
+	var bus, chip, mode, speed int
+	adaptor.spiStartImpl = func(b, c, m, bits, s int) error {
+		bus, chip, mode, speed = b, c, m, s
+		return nil
+	}
+	d.Bus, d.Chip = 1, 2
+	gobottest.Assert(t, len(d.Start()), 0)
+	gobottest.Assert(t, []int{bus, chip, mode, speed}, []int{1, 2, Mode0, 1000000})
+	gobottest.Assert(t, len(d.Halt()), 0)
+
+	adaptor.spiStartImpl = func(b, c, m, bits, s int) error {
+		return errors.New("start error")
+	}
+	gobottest.Assert(t, d.Start()[0], errors.New("start error"))
+}
+
+func TestMCP3008DriverRead(t *testing.T) {
+	d, adaptor := initTestMCP3008DriverWithStubbedAdaptor()
+	adaptor.spiTransferImpl = func(data []byte) ([]byte, error) {
+		return []byte{0xff, 0xfe, 0x34}, nil
+	}
+
+	val, err := d.Read(5)
+	gobottest.Assert(t, err, nil)
+	gobottest.Assert(t, val, 0x234)
+	gobottest.Assert(t, adaptor.written[0], []byte{0x01, 0xd0, 0x00})
+
+	_, err = d.Read(8)
+	gobottest.Assert(t, err, ErrInvalidChannel)
+
+	result := d.Command("Read")(map[string]interface{}{"channel": 0.0})
+	gobottest.Assert(t, result.(map[string]interface{})["val"], 0x234)
+	gobottest.Assert(t, adaptor.written[1], []byte{0x01, 0x80, 0x00})
+
+	adaptor.spiTransferImpl = func(data []byte) ([]byte, error) {
+		return []byte{}, nil
+	}
+	_, err = d.Read(0)
+	gobottest.Assert(t, err, ErrNotEnoughBytes)
+
+	adaptor.spiTransferImpl = func(data []byte) ([]byte, error) {
+		return nil, errors.New("transfer error")
+	}
+	_, err = d.Read(0)
+	gobottest.Assert(t, err, errors.New("transfer error"))
+}
+
+func TestMCP3008DriverRegistry(t *testing.T) {
+	d, err := gobot.NewDriver(newSpiTestAdaptor("adaptor"), gobot.DeviceConfig{
+		Name:   "adc",
+		Driver: "mcp3008",
+		Params: gobot.ConfigParams{"bus": 1, "chip": 1},
+	})
+	gobottest.Assert(t, err, nil)
+	gobottest.Assert(t, d.(*MCP3008Driver).Bus, 1)
+	gobottest.Assert(t, d.(*MCP3008Driver).Chip, 1)
+	gobottest.Assert(t, d.(*MCP3008Driver).Speed, 1000000)
+}
@@ -0,0 +1,37 @@
+package spi
+
+import "github.com/hybridgroup/gobot"
+
+func init() {
+	register := func(name string, f func(a SPI, config gobot.DeviceConfig) gobot.Driver) {
+		gobot.RegisterDriver(name, func(c gobot.Connection, config gobot.DeviceConfig) (gobot.Driver, error) {
+			a, ok := c.(SPI)
+			if !ok {
+				return nil, ErrSpiUnsupported
+			}
+			return f(a, config), nil
+		})
+	}
+
+	register("apa102", func(a SPI, config gobot.DeviceConfig) gobot.Driver {
+		d := NewAPA102Driver(a, config.Name, config.Params.Int("count", 1))
+		d.Bus, d.Chip, d.Speed = busParams(config, d.Bus, d.Chip, d.Speed)
+		return d
+	})
+	register("max7219", func(a SPI, config gobot.DeviceConfig) gobot.Driver {
+		d := NewMAX7219Driver(a, config.Name, config.Params.Int("count", 1))
+		d.Bus, d.Chip, d.Speed = busParams(config, d.Bus, d.Chip, d.Speed)
+		return d
+	})
+	register("mcp3008", func(a SPI, config gobot.DeviceConfig) gobot.Driver {
+		d := NewMCP3008Driver(a, config.Name)
+		d.Bus, d.Chip, d.Speed = busParams(config, d.Bus, d.Chip, d.Speed)
+		return d
+	})
+}
+
+// busParams returns the "bus", "chip" and "speed" params of a device, or the
+// given defaults
+func busParams(config gobot.DeviceConfig, bus int, chip int, speed int) (int, int, int) {
+	return config.Params.Int("bus", bus), config.Params.Int("chip", chip), config.Params.Int("speed", speed)
+}
@@ -0,0 +1,50 @@
+package spi
+
+import (
+	"errors"
+
+	"github.com/hybridgroup/gobot"
+)
+
+var (
+	// ErrSpiUnsupported is the error resulting when a driver attempts to use
+	// hardware capabilities which a connection does not support
+	ErrSpiUnsupported = errors.New("SPI is not supported by this platform")
+	// ErrInvalidChannel is the error resulting when a driver is given a
+	// channel, row or device index which is out of range
+	ErrInvalidChannel = errors.New("Invalid channel")
+	// ErrNotEnoughBytes is the error resulting when a transfer returns less
+	// bytes than were written
+	ErrNotEnoughBytes = errors.New("Not enough bytes read")
+)
+
+const (
+	// Mode0 samples on the rising edge of an idle low clock
+	Mode0 = 0
+	// Mode1 samples on the falling edge of an idle low clock
+	Mode1 = 1
+	// Mode2 samples on the falling edge of an idle high clock
+	Mode2 = 2
+	// Mode3 samples on the rising edge of an idle high clock
+	Mode3 = 3
+)
+
+// SpiStarter interface represents an Adaptor which can open the device on a
+// chip select of one of its spi buses, with the mode, bits per word and
+// maximum speed in Hz of the transfers with the device
+type SpiStarter interface {
+	SpiStart(bus int, chip int, mode int, bits int, speed int) (err error)
+}
+
+// SpiTransferer interface represents an Adaptor which can write data to a
+// device on one of its spi buses while reading as many bytes from it
+type SpiTransferer interface {
+	SpiTransfer(bus int, chip int, data []byte) (read []byte, err error)
+}
+
+// SPI interface represents an Adaptor which has SPI capabilities
+type SPI interface {
+	gobot.Adaptor
+	SpiStarter
+	SpiTransferer
+}
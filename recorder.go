@@ -0,0 +1,276 @@
+package gobot
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// RecordFormat is the file format of a recording.
+type RecordFormat int
+
+const (
+	// RecordJSON writes one JSON object per line.
+	RecordJSON RecordFormat = iota
+	// RecordBinary writes a compact binary stream, which is described in
+	// recorder_binary.go.
+	RecordBinary
+)
+
+// binaryMagic starts every recording in the binary format.
+const binaryMagic = "GOBOTREC"
+
+// RecordedEvent is an Event published by a Device, and the time at which it
+// was published.
+type RecordedEvent struct {
+	Time   time.Time
+	Device string
+	Name   string
+	Data   interface{}
+}
+
+// recordedLine is how a RecordedEvent is written in the JSON format. The data
+// is stored as JSON, along with the name of its type when that is needed to
+// restore it exactly.
+type recordedLine struct {
+	Time   time.Time       `json:"time"`
+	Device string          `json:"device"`
+	Name   string          `json:"name"`
+	Type   string          `json:"type,omitempty"`
+	Data   json.RawMessage `json:"data"`
+}
+
+// recordedTypes are the types which are restored exactly on replay. Data of
+// any other type is replayed as the result of decoding its JSON into an
+// interface{}.
+var recordedTypes = map[string]reflect.Type{}
+
+func init() {
+	for _, v := range []interface{}{
+		int(0), int8(0), int16(0), int32(0), int64(0),
+		uint(0), uint8(0), uint16(0), uint32(0), uint64(0),
+		float32(0), float64(0), "", false, []byte{},
+	} {
+		recordedTypes[reflect.TypeOf(v).String()] = reflect.TypeOf(v)
+	}
+}
+
+// Recorder writes the Events published by every Device of a Robot, with the
+// time they were published, to an io.Writer.
+//
+// The Recorder never blocks a Device which publishes faster than events can
+// be written. Events which arrive while its buffer is full are dropped, and
+// counted by Dropped.
+type Recorder struct {
+	mutex   sync.Mutex
+	subs    []recorderSubscription
+	dropped uint64
+	wg      sync.WaitGroup
+	// writeMutex guards the writer, which may block for as long as w does
+	writeMutex sync.Mutex
+	w          *bufio.Writer
+	encode     func(*RecordedEvent) error
+	err        error
+}
+
+// recorderSubscription is the subscription of a Recorder to a Device.
+type recorderSubscription struct {
+	eventer Eventer
+	events  eventChannel
+}
+
+// recorderBufferSize is the number of Events buffered for each Device.
+const recorderBufferSize = 1024
+
+// NewRecorder returns a Recorder which writes to w in the given format.
+func NewRecorder(w io.Writer, format RecordFormat) *Recorder {
+	rec := &Recorder{w: bufio.NewWriter(w)}
+	if format == RecordBinary {
+		rec.encode = newBinaryEncoder(rec.w).encode
+	} else {
+		enc := json.NewEncoder(rec.w)
+		rec.encode = func(evt *RecordedEvent) error {
+			line, err := newRecordedLine(evt)
+			if err != nil {
+				return err
+			}
+			return enc.Encode(line)
+		}
+	}
+	return rec
+}
+
+// Record starts recording the Events of every Device of r which is an
+// Eventer.
+func (rec *Recorder) Record(r *Robot) {
+	r.Devices().Each(func(d Device) {
+		eventer, ok := d.(Eventer)
+		if !ok {
+			return
+		}
+		events := eventer.SubscribeWithOptions(SubscribeOptions{
+			BufferSize: recorderBufferSize,
+			Policy:     DropNewest,
+		})
+		name := d.Name()
+
+		rec.mutex.Lock()
+		rec.subs = append(rec.subs, recorderSubscription{eventer: eventer, events: events})
+		rec.mutex.Unlock()
+
+		rec.wg.Add(1)
+		go func() {
+			defer rec.wg.Done()
+			for evt := range events {
+				rec.write(name, evt)
+			}
+		}()
+	})
+}
+
+// Dropped returns the number of events which were not recorded because the
+// Recorder fell behind.
+func (rec *Recorder) Dropped() uint64 {
+	rec.mutex.Lock()
+	defer rec.mutex.Unlock()
+	dropped := rec.dropped
+	for _, sub := range rec.subs {
+		dropped += sub.eventer.DroppedFor(sub.events)
+	}
+	return dropped
+}
+
+// Close stops recording, flushes the recording and returns the first error
+// which occurred while writing it.
+func (rec *Recorder) Close() error {
+	rec.mutex.Lock()
+	subs := rec.subs
+	rec.subs = nil
+	for _, sub := range subs {
+		rec.dropped += sub.eventer.DroppedFor(sub.events)
+	}
+	rec.mutex.Unlock()
+
+	for _, sub := range subs {
+		sub.eventer.Unsubscribe(sub.events)
+	}
+	rec.wg.Wait()
+
+	rec.writeMutex.Lock()
+	defer rec.writeMutex.Unlock()
+	if err := rec.w.Flush(); err != nil && rec.err == nil {
+		rec.err = err
+	}
+	return rec.err
+}
+
+func (rec *Recorder) write(device string, evt *Event) {
+	rec.writeMutex.Lock()
+	defer rec.writeMutex.Unlock()
+	err := rec.encode(&RecordedEvent{
+		Time:   evt.Time,
+		Device: device,
+		Name:   evt.Name,
+		Data:   evt.Data,
+	})
+	if err != nil && rec.err == nil {
+		rec.err = err
+	}
+}
+
+// newRecordedLine returns the line which records evt in the JSON format.
+func newRecordedLine(evt *RecordedEvent) (*recordedLine, error) {
+	line := &recordedLine{Time: evt.Time, Device: evt.Device, Name: evt.Name}
+	data := evt.Data
+	if err, ok := data.(error); ok {
+		line.Type = "error"
+		data = err.Error()
+	} else if data != nil {
+		if _, ok := recordedTypes[reflect.TypeOf(data).String()]; ok {
+			line.Type = reflect.TypeOf(data).String()
+		}
+	}
+
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	line.Data = raw
+	return line, nil
+}
+
+// ReadRecording reads every RecordedEvent written by a Recorder in either
+// format.
+func ReadRecording(r io.Reader) (events []RecordedEvent, err error) {
+	br := bufio.NewReader(r)
+	magic, err := br.Peek(len(binaryMagic))
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	var decode func(*RecordedEvent) error
+	if string(magic) == binaryMagic {
+		br.Discard(len(binaryMagic))
+		dec, err := newBinaryDecoder(br)
+		if err != nil {
+			return nil, err
+		}
+		decode = dec.decode
+	} else {
+		dec := json.NewDecoder(br)
+		decode = func(evt *RecordedEvent) error {
+			var line recordedLine
+			if err := dec.Decode(&line); err != nil {
+				return err
+			}
+			data, err := line.data()
+			if err != nil {
+				return err
+			}
+			*evt = RecordedEvent{
+				Time:   line.Time,
+				Device: line.Device,
+				Name:   line.Name,
+				Data:   data,
+			}
+			return nil
+		}
+	}
+
+	for {
+		var evt RecordedEvent
+		if err = decode(&evt); err == io.EOF {
+			return events, nil
+		} else if err != nil {
+			return events, err
+		}
+		events = append(events, evt)
+	}
+}
+
+// data restores the Event data of the line.
+func (l *recordedLine) data() (interface{}, error) {
+	if l.Type == "error" {
+		var s string
+		if err := json.Unmarshal(l.Data, &s); err != nil {
+			return nil, err
+		}
+		return errors.New(s), nil
+	}
+	if t, ok := recordedTypes[l.Type]; ok {
+		v := reflect.New(t)
+		if err := json.Unmarshal(l.Data, v.Interface()); err != nil {
+			return nil, err
+		}
+		return v.Elem().Interface(), nil
+	}
+	var v interface{}
+	if err := json.Unmarshal(l.Data, &v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
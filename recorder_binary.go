@@ -0,0 +1,297 @@
+package gobot
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"time"
+)
+
+// The binary format starts with binaryMagic and a version byte, followed by
+// one record per Event:
+//
+//	varint   time, in nanoseconds since the Unix epoch
+//	string   device
+//	string   name
+//	byte     kind of the data
+//	...      the data, encoded according to its kind
+//
+// Strings and byte slices are written as a uvarint length followed by their
+// bytes. Signed integers are varints, unsigned integers uvarints and floats
+// their IEEE 754 bits in little endian order. Data of any other type is
+// written as a string holding its JSON.
+const binaryVersion = 1
+
+const (
+	kindNil byte = iota
+	kindBool
+	kindInt
+	kindInt8
+	kindInt16
+	kindInt32
+	kindInt64
+	kindUint
+	kindUint8
+	kindUint16
+	kindUint32
+	kindUint64
+	kindFloat32
+	kindFloat64
+	kindString
+	kindBytes
+	kindError
+	kindJSON
+)
+
+// binaryEncoder writes RecordedEvents in the binary format.
+type binaryEncoder struct {
+	w   *bufio.Writer
+	buf [binary.MaxVarintLen64]byte
+}
+
+func newBinaryEncoder(w *bufio.Writer) *binaryEncoder {
+	w.WriteString(binaryMagic)
+	w.WriteByte(binaryVersion)
+	return &binaryEncoder{w: w}
+}
+
+func (b *binaryEncoder) encode(evt *RecordedEvent) error {
+	b.varint(evt.Time.UnixNano())
+	b.string(evt.Device)
+	b.string(evt.Name)
+
+	switch v := evt.Data.(type) {
+	case nil:
+		b.w.WriteByte(kindNil)
+	case bool:
+		b.w.WriteByte(kindBool)
+		if v {
+			b.w.WriteByte(1)
+		} else {
+			b.w.WriteByte(0)
+		}
+	case int:
+		b.w.WriteByte(kindInt)
+		b.varint(int64(v))
+	case int8:
+		b.w.WriteByte(kindInt8)
+		b.varint(int64(v))
+	case int16:
+		b.w.WriteByte(kindInt16)
+		b.varint(int64(v))
+	case int32:
+		b.w.WriteByte(kindInt32)
+		b.varint(int64(v))
+	case int64:
+		b.w.WriteByte(kindInt64)
+		b.varint(v)
+	case uint:
+		b.w.WriteByte(kindUint)
+		b.uvarint(uint64(v))
+	case uint8:
+		b.w.WriteByte(kindUint8)
+		b.uvarint(uint64(v))
+	case uint16:
+		b.w.WriteByte(kindUint16)
+		b.uvarint(uint64(v))
+	case uint32:
+		b.w.WriteByte(kindUint32)
+		b.uvarint(uint64(v))
+	case uint64:
+		b.w.WriteByte(kindUint64)
+		b.uvarint(v)
+	case float32:
+		b.w.WriteByte(kindFloat32)
+		binary.LittleEndian.PutUint32(b.buf[:4], math.Float32bits(v))
+		b.w.Write(b.buf[:4])
+	case float64:
+		b.w.WriteByte(kindFloat64)
+		binary.LittleEndian.PutUint64(b.buf[:8], math.Float64bits(v))
+		b.w.Write(b.buf[:8])
+	case string:
+		b.w.WriteByte(kindString)
+		b.string(v)
+	case []byte:
+		b.w.WriteByte(kindBytes)
+		b.uvarint(uint64(len(v)))
+		b.w.Write(v)
+	case error:
+		b.w.WriteByte(kindError)
+		b.string(v.Error())
+	default:
+		raw, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		b.w.WriteByte(kindJSON)
+		b.uvarint(uint64(len(raw)))
+		b.w.Write(raw)
+	}
+	return nil
+}
+
+func (b *binaryEncoder) varint(v int64) {
+	n := binary.PutVarint(b.buf[:], v)
+	b.w.Write(b.buf[:n])
+}
+
+func (b *binaryEncoder) uvarint(v uint64) {
+	n := binary.PutUvarint(b.buf[:], v)
+	b.w.Write(b.buf[:n])
+}
+
+func (b *binaryEncoder) string(s string) {
+	b.uvarint(uint64(len(s)))
+	b.w.WriteString(s)
+}
+
+// binaryDecoder reads RecordedEvents written by a binaryEncoder.
+type binaryDecoder struct {
+	r *bufio.Reader
+}
+
+// newBinaryDecoder returns a binaryDecoder for r, which must already be
+// positioned after binaryMagic.
+func newBinaryDecoder(r *bufio.Reader) (*binaryDecoder, error) {
+	version, err := r.ReadByte()
+	if err != nil {
+		return nil, unexpectedEOF(err)
+	}
+	if version != binaryVersion {
+		return nil, fmt.Errorf("Unsupported recording version %v", version)
+	}
+	return &binaryDecoder{r: r}, nil
+}
+
+// decode reads the next RecordedEvent, and returns io.EOF if there are no
+// more.
+func (b *binaryDecoder) decode(evt *RecordedEvent) error {
+	nanos, err := binary.ReadVarint(b.r)
+	if err != nil {
+		return err
+	}
+	evt.Time = time.Unix(0, nanos)
+	if evt.Device, err = b.string(); err != nil {
+		return err
+	}
+	if evt.Name, err = b.string(); err != nil {
+		return err
+	}
+	evt.Data, err = b.data()
+	return err
+}
+
+func (b *binaryDecoder) data() (interface{}, error) {
+	kind, err := b.r.ReadByte()
+	if err != nil {
+		return nil, unexpectedEOF(err)
+	}
+
+	switch kind {
+	case kindNil:
+		return nil, nil
+	case kindBool:
+		v, err := b.r.ReadByte()
+		return v != 0, unexpectedEOF(err)
+	case kindInt, kindInt8, kindInt16, kindInt32, kindInt64:
+		v, err := binary.ReadVarint(b.r)
+		if err != nil {
+			return nil, unexpectedEOF(err)
+		}
+		switch kind {
+		case kindInt:
+			return int(v), nil
+		case kindInt8:
+			return int8(v), nil
+		case kindInt16:
+			return int16(v), nil
+		case kindInt32:
+			return int32(v), nil
+		}
+		return v, nil
+	case kindUint, kindUint8, kindUint16, kindUint32, kindUint64:
+		v, err := binary.ReadUvarint(b.r)
+		if err != nil {
+			return nil, unexpectedEOF(err)
+		}
+		switch kind {
+		case kindUint:
+			return uint(v), nil
+		case kindUint8:
+			return uint8(v), nil
+		case kindUint16:
+			return uint16(v), nil
+		case kindUint32:
+			return uint32(v), nil
+		}
+		return v, nil
+	case kindFloat32:
+		buf, err := b.read(4)
+		if err != nil {
+			return nil, err
+		}
+		return math.Float32frombits(binary.LittleEndian.Uint32(buf)), nil
+	case kindFloat64:
+		buf, err := b.read(8)
+		if err != nil {
+			return nil, err
+		}
+		return math.Float64frombits(binary.LittleEndian.Uint64(buf)), nil
+	case kindString:
+		return b.string()
+	case kindBytes:
+		return b.bytes()
+	case kindError:
+		s, err := b.string()
+		if err != nil {
+			return nil, err
+		}
+		return errors.New(s), nil
+	case kindJSON:
+		raw, err := b.bytes()
+		if err != nil {
+			return nil, err
+		}
+		var v interface{}
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return nil, err
+		}
+		return v, nil
+	}
+	return nil, fmt.Errorf("Unknown recorded data kind %v", kind)
+}
+
+func (b *binaryDecoder) bytes() ([]byte, error) {
+	n, err := binary.ReadUvarint(b.r)
+	if err != nil {
+		return nil, unexpectedEOF(err)
+	}
+	return b.read(n)
+}
+
+func (b *binaryDecoder) string() (string, error) {
+	buf, err := b.bytes()
+	return string(buf), err
+}
+
+func (b *binaryDecoder) read(n uint64) ([]byte, error) {
+	if n > math.MaxInt32 {
+		return nil, errors.New("Recorded value is too large")
+	}
+	buf := make([]byte, n)
+	_, err := io.ReadFull(b.r, buf)
+	return buf, unexpectedEOF(err)
+}
+
+// unexpectedEOF reports io.EOF in the middle of a record as
+// io.ErrUnexpectedEOF.
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
@@ -0,0 +1,185 @@
+package gobot
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hybridgroup/gobot/gobottest"
+)
+
+type eventingDriver struct {
+	*testDriver
+	Eventer
+}
+
+func newRecordedRobot() (*Robot, *eventingDriver) {
+	adaptor := newTestAdaptor("Connection1", "/dev/null")
+	driver := &eventingDriver{
+		testDriver: newTestDriver(adaptor, "Device1", "0"),
+		Eventer:    NewEventer(),
+	}
+	r := NewRobot("Robot1", []Connection{adaptor}, []Device{driver}, NewRegistry())
+	return r, driver
+}
+
+func testRecording(t *testing.T, format RecordFormat) {
+	r, driver := newRecordedRobot()
+
+	var buf bytes.Buffer
+	rec := NewRecorder(&buf, format)
+	rec.Record(r)
+
+	driver.Publish("data", 42)
+	driver.Publish("data", 1.5)
+	driver.Publish("error", errors.New("read failed"))
+	driver.Publish("map", map[string]interface{}{"x": 1})
+	driver.Publish("bytes", []byte{1, 2})
+	published := time.Now()
+
+	// wait for the recorder to receive every event
+	time.Sleep(20 * time.Millisecond)
+	gobottest.Assert(t, rec.Close(), nil)
+
+	events, err := ReadRecording(&buf)
+	gobottest.Assert(t, err, nil)
+	gobottest.Assert(t, len(events), 5)
+	gobottest.Assert(t, events[0].Device, "Device1")
+	gobottest.Assert(t, events[0].Name, "data")
+	gobottest.Assert(t, events[0].Data, 42)
+	gobottest.Assert(t, events[1].Data, 1.5)
+	gobottest.Assert(t, events[2].Data.(error).Error(), "read failed")
+	gobottest.Assert(t, events[3].Data, map[string]interface{}{"x": 1.0})
+	gobottest.Assert(t, events[4].Data, []byte{1, 2})
+	gobottest.Assert(t, events[0].Time.After(events[4].Time), false)
+	gobottest.Assert(t, events[4].Time.After(published), false)
+}
+
+func TestRecorderJSON(t *testing.T) {
+	testRecording(t, RecordJSON)
+}
+
+func TestRecorderBinary(t *testing.T) {
+	testRecording(t, RecordBinary)
+}
+
+func TestRecorderJSONLines(t *testing.T) {
+	r, driver := newRecordedRobot()
+
+	var buf bytes.Buffer
+	rec := NewRecorder(&buf, RecordJSON)
+	rec.Record(r)
+	driver.Publish("data", 1)
+	time.Sleep(10 * time.Millisecond)
+	rec.Close()
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	gobottest.Assert(t, len(lines), 1)
+	gobottest.Assert(t, strings.Contains(lines[0], `"device":"Device1","name":"data","type":"int","data":1}`), true)
+
+	// no more events are recorded once the recorder is closed
+	driver.Publish("data", 2)
+	gobottest.Assert(t, len(strings.Split(strings.TrimSpace(buf.String()), "\n")), 1)
+}
+
+func TestReadRecordingEmpty(t *testing.T) {
+	events, err := ReadRecording(&bytes.Buffer{})
+	gobottest.Assert(t, err, nil)
+	gobottest.Assert(t, len(events), 0)
+}
+
+func TestRecorderBinaryKinds(t *testing.T) {
+	data := []interface{}{
+		nil, true, false, -1, int8(-8), int16(-16), int32(-32), int64(-64),
+		uint(1), uint8(8), uint16(16), uint32(32), uint64(64),
+		float32(1.5), -2.25, "text", []byte{}, []byte{0, 255},
+	}
+
+	var buf bytes.Buffer
+	w := bufio.NewWriter(&buf)
+	enc := newBinaryEncoder(w)
+	at := time.Unix(1500000000, 123456789)
+	for _, d := range data {
+		gobottest.Assert(t, enc.encode(&RecordedEvent{Time: at, Device: "d", Name: "n", Data: d}), nil)
+	}
+	w.Flush()
+
+	events, err := ReadRecording(bytes.NewReader(buf.Bytes()))
+	gobottest.Assert(t, err, nil)
+	gobottest.Assert(t, len(events), len(data))
+	for i, evt := range events {
+		gobottest.Assert(t, evt.Time.Equal(at), true)
+		gobottest.Assert(t, evt.Data, data[i])
+	}
+
+	// a recording which was cut short is reported
+	_, err = ReadRecording(bytes.NewReader(buf.Bytes()[:buf.Len()-1]))
+	gobottest.Assert(t, err, io.ErrUnexpectedEOF)
+}
+
+func TestRecorderBinaryIsCompact(t *testing.T) {
+	sizes := map[RecordFormat]int{}
+	for _, format := range []RecordFormat{RecordJSON, RecordBinary} {
+		var buf bytes.Buffer
+		w := bufio.NewWriter(&buf)
+		rec := NewRecorder(w, format)
+		for i := 0; i < 100; i++ {
+			rec.encode(&RecordedEvent{Time: time.Now(), Device: "Device1", Name: "data", Data: i})
+		}
+		rec.Close()
+		w.Flush()
+		sizes[format] = buf.Len()
+	}
+	gobottest.Assert(t, sizes[RecordBinary]*3 < sizes[RecordJSON], true)
+}
+
+func TestReadRecordingBadVersion(t *testing.T) {
+	_, err := ReadRecording(strings.NewReader(binaryMagic + "\x09"))
+	gobottest.Assert(t, err.Error(), "Unsupported recording version 9")
+}
+
+// blockingWriter blocks every Write until it is released.
+type blockingWriter struct {
+	release chan bool
+	buf     bytes.Buffer
+}
+
+func (w *blockingWriter) Write(p []byte) (int, error) {
+	<-w.release
+	return w.buf.Write(p)
+}
+
+func TestRecorderDropsWhenBehind(t *testing.T) {
+	r, driver := newRecordedRobot()
+
+	w := &blockingWriter{release: make(chan bool)}
+	rec := NewRecorder(w, RecordJSON)
+	rec.Record(r)
+
+	total := recorderBufferSize * 2
+	done := make(chan bool)
+	go func() {
+		for i := 0; i < total; i++ {
+			driver.Publish("data", i)
+		}
+		done <- true
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("Publish was blocked by the recorder")
+	}
+	gobottest.Assert(t, rec.Dropped() > 0, true)
+
+	close(w.release)
+	gobottest.Assert(t, rec.Close(), nil)
+
+	events, err := ReadRecording(&w.buf)
+	gobottest.Assert(t, err, nil)
+	gobottest.Assert(t, uint64(len(events))+rec.Dropped(), uint64(total))
+}
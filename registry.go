@@ -0,0 +1,90 @@
+package gobot
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// AdaptorFactory creates an Adaptor from its configuration.
+type AdaptorFactory func(config ConnectionConfig) (Adaptor, error)
+
+// DriverFactory creates a Driver for a Connection from its configuration.
+type DriverFactory func(c Connection, config DeviceConfig) (Driver, error)
+
+var registry = struct {
+	sync.RWMutex
+	adaptors map[string]AdaptorFactory
+	drivers  map[string]DriverFactory
+}{
+	adaptors: make(map[string]AdaptorFactory),
+	drivers:  make(map[string]DriverFactory),
+}
+
+// RegisterAdaptor makes an adaptor available to robot configuration files
+// under the given name. It is usually called from a platform package's init
+// function, and panics if the name is already registered.
+func RegisterAdaptor(name string, f AdaptorFactory) {
+	registry.Lock()
+	defer registry.Unlock()
+	if _, ok := registry.adaptors[name]; ok {
+		panic("gobot: RegisterAdaptor called twice for " + name)
+	}
+	registry.adaptors[name] = f
+}
+
+// RegisterDriver makes a driver available to robot configuration files
+// under the given name. It is usually called from a platform package's init
+// function, and panics if the name is already registered.
+func RegisterDriver(name string, f DriverFactory) {
+	registry.Lock()
+	defer registry.Unlock()
+	if _, ok := registry.drivers[name]; ok {
+		panic("gobot: RegisterDriver called twice for " + name)
+	}
+	registry.drivers[name] = f
+}
+
+// RegisteredAdaptors returns the sorted names of all registered adaptors.
+func RegisteredAdaptors() (names []string) {
+	registry.RLock()
+	defer registry.RUnlock()
+	for name := range registry.adaptors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return
+}
+
+// RegisteredDrivers returns the sorted names of all registered drivers.
+func RegisteredDrivers() (names []string) {
+	registry.RLock()
+	defer registry.RUnlock()
+	for name := range registry.drivers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return
+}
+
+// NewAdaptor creates an Adaptor using the factory registered for config.Adaptor.
+func NewAdaptor(config ConnectionConfig) (Adaptor, error) {
+	registry.RLock()
+	f, ok := registry.adaptors[config.Adaptor]
+	registry.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("Unknown adaptor %q", config.Adaptor)
+	}
+	return f(config)
+}
+
+// NewDriver creates a Driver using the factory registered for config.Driver.
+func NewDriver(c Connection, config DeviceConfig) (Driver, error) {
+	registry.RLock()
+	f, ok := registry.drivers[config.Driver]
+	registry.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("Unknown driver %q", config.Driver)
+	}
+	return f(c, config)
+}
@@ -0,0 +1,51 @@
+package gobot
+
+import (
+	"testing"
+
+	"github.com/hybridgroup/gobot/gobottest"
+)
+
+func init() {
+	RegisterAdaptor("test", func(config ConnectionConfig) (Adaptor, error) {
+		return newTestAdaptor(config.Name, config.Port), nil
+	})
+	RegisterDriver("test", func(c Connection, config DeviceConfig) (Driver, error) {
+		d := newTestDriver(c.(*testAdaptor), config.Name, config.Pin)
+		d.pin = config.Params.String("pin_override", d.pin)
+		return d, nil
+	})
+}
+
+func TestRegisteredAdaptors(t *testing.T) {
+	gobottest.Assert(t, RegisteredAdaptors(), []string{"test"})
+	gobottest.Assert(t, RegisteredDrivers(), []string{"test"})
+}
+
+func TestRegisterTwice(t *testing.T) {
+	defer func() {
+		gobottest.Refute(t, recover(), nil)
+	}()
+	RegisterAdaptor("test", nil)
+}
+
+func TestNewAdaptor(t *testing.T) {
+	a, err := NewAdaptor(ConnectionConfig{Name: "conn", Adaptor: "test", Port: "/dev/null"})
+	gobottest.Assert(t, err, nil)
+	gobottest.Assert(t, a.Name(), "conn")
+	gobottest.Assert(t, a.(Porter).Port(), "/dev/null")
+
+	_, err = NewAdaptor(ConnectionConfig{Name: "conn", Adaptor: "unknown"})
+	gobottest.Assert(t, err.Error(), "Unknown adaptor \"unknown\"")
+}
+
+func TestNewDriver(t *testing.T) {
+	a := newTestAdaptor("conn", "/dev/null")
+	d, err := NewDriver(a, DeviceConfig{Name: "dev", Driver: "test", Pin: "13"})
+	gobottest.Assert(t, err, nil)
+	gobottest.Assert(t, d.Name(), "dev")
+	gobottest.Assert(t, d.(Pinner).Pin(), "13")
+
+	_, err = NewDriver(a, DeviceConfig{Name: "dev", Driver: "unknown"})
+	gobottest.Assert(t, err.Error(), "Unknown driver \"unknown\"")
+}
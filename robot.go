@@ -1,16 +1,19 @@
 package gobot
 
 import (
+	"context"
 	"fmt"
-	"log"
+	"sync"
+	"time"
 )
 
 // JSONRobot a JSON representation of a Robot.
 type JSONRobot struct {
-	Name        string            `json:"name"`
-	Commands    []string          `json:"commands"`
-	Connections []*JSONConnection `json:"connections"`
-	Devices     []*JSONDevice     `json:"devices"`
+	Name           string                    `json:"name"`
+	Commands       []string                  `json:"commands"`
+	CommandSchemas map[string]*CommandSchema `json:"command_schemas,omitempty"`
+	Connections    []*JSONConnection         `json:"connections"`
+	Devices        []*JSONDevice             `json:"devices"`
 }
 
 // NewJSONRobot returns a JSONRobot given a Robot.
@@ -25,6 +28,7 @@ func NewJSONRobot(robot *Robot) *JSONRobot {
 	for command := range robot.Commands() {
 		jsonRobot.Commands = append(jsonRobot.Commands, command)
 	}
+	jsonRobot.CommandSchemas = commandSchemas(robot)
 
 	robot.Devices().Each(func(device Device) {
 		jsonDevice := NewJSONDevice(device)
@@ -37,12 +41,21 @@ func NewJSONRobot(robot *Robot) *JSONRobot {
 // Robot is a named entity that manages a collection of connections and devices.
 // It contains its own work routine and a collection of
 // custom commands to control a robot remotely via the Gobot api.
+// HaltTimeout is the time Stop waits for each Device to halt and each
+// Connection to finalize, or forever if it is zero.
 type Robot struct {
-	Name        string
-	Work        func()
-	connections *Connections
-	devices     *Devices
-	Commander
+	Name         string
+	Work         func()
+	Supervisor   *Supervisor
+	HaltTimeout  time.Duration
+	Logger       Logger
+	Metrics      *Registry
+	haltTimeouts map[string]time.Duration
+	mutex        sync.RWMutex
+	connections  *Connections
+	devices      *Devices
+	lifecycle    *lifecycle
+	ExtendedCommander
 	Eventer
 }
 
@@ -67,15 +80,10 @@ func (r *Robots) Start() (errs []error) {
 	return
 }
 
-// Stop calls the Stop method of each Robot in the collection
+// Stop calls the Stop method of each Robot in the collection in parallel
 func (r *Robots) Stop() (errs []error) {
-	for _, robot := range *r {
-		if errs = robot.Stop(); len(errs) > 0 {
-			for i, err := range errs {
-				errs[i] = fmt.Errorf("Robot %q: %v", robot.Name, err)
-			}
-			return
-		}
+	for _, f := range r.StopContext(context.Background()).Failures {
+		errs = append(errs, fmt.Errorf("Robot %q: %v", f.Robot, f.Err))
 	}
 	return
 }
@@ -92,6 +100,9 @@ func (r *Robots) Each(f func(*Robot)) {
 // 	[]Connection: Connections which are automatically started and stopped with the robot
 //	[]Device: Devices which are automatically started and stopped with the robot
 //	func(): The work routine the robot will execute once all devices and connections have been initialized and started
+//	*Supervisor: Health checks and automatic reconnection for connections which implement Pinger
+//	Logger: The Logger used for the robot's output, instead of DefaultLogger
+//	*Registry: The Registry used for the robot's metrics, instead of DefaultRegistry
 // A name will be automaically generated if no name is supplied.
 func NewRobot(name string, v ...interface{}) *Robot {
 	if name == "" {
@@ -99,81 +110,146 @@ func NewRobot(name string, v ...interface{}) *Robot {
 	}
 
 	r := &Robot{
-		Name:        name,
-		connections: &Connections{},
-		devices:     &Devices{},
-		Work:        nil,
-		Eventer:     NewEventer(),
-		Commander:   NewCommander(),
+		Name:              name,
+		connections:       &Connections{},
+		devices:           &Devices{},
+		Work:              nil,
+		HaltTimeout:       DefaultHaltTimeout,
+		lifecycle:         newLifecycle(),
+		Eventer:           NewEventer(),
+		ExtendedCommander: NewCommander(),
+	}
+
+	r.AddEvent(RobotStateEvent)
+	r.AddEvent(ConnectionStateEvent)
+	r.AddEvent(DeviceStateEvent)
+
+	logger := DefaultLogger()
+	r.Metrics = DefaultRegistry
+	for i := range v {
+		switch o := v[i].(type) {
+		case Logger:
+			logger = o
+		case *Registry:
+			r.Metrics = o
+		}
 	}
+	r.Logger = logger.With("robot", r.Name)
+	r.instrument(Labels{"robot": r.Name}, r)
 
-	log.Println("Initializing Robot", r.Name, "...")
+	r.Logger.Info("Initializing Robot")
 
 	for i := range v {
 		switch v[i].(type) {
 		case []Connection:
-			log.Println("Initializing connections...")
+			r.Logger.Debug("Initializing connections")
 			for _, connection := range v[i].([]Connection) {
 				c := r.AddConnection(connection)
-				log.Println("Initializing connection", c.Name(), "...")
+				r.Logger.Debug("Initializing connection", "connection", c.Name())
 			}
 		case []Device:
-			log.Println("Initializing devices...")
+			r.Logger.Debug("Initializing devices")
 			for _, device := range v[i].([]Device) {
 				d := r.AddDevice(device)
-				log.Println("Initializing device", d.Name(), "...")
+				r.Logger.Debug("Initializing device", "device", d.Name())
 			}
 		case func():
 			r.Work = v[i].(func())
+		case *Supervisor:
+			r.Supervisor = v[i].(*Supervisor)
 		}
 	}
 
 	return r
 }
 
-// Start a Robot's Connections, Devices, and work.
+// Start a Robot's Connections, Devices, and work. If the Robot has a
+// Supervisor, it begins checking the health of the Connections once they
+// have all started.
 func (r *Robot) Start() (errs []error) {
-	log.Println("Starting Robot", r.Name, "...")
-	if cerrs := r.Connections().Start(); len(cerrs) > 0 {
+	r.Logger.Info("Starting Robot")
+	r.setState(StateStarting, nil)
+
+	r.Connections().Each(func(c Connection) { r.setConnectionState(c, StateStarting, nil) })
+	if cerrs := r.Connections().start(r.Logger); len(cerrs) > 0 {
 		errs = append(errs, cerrs...)
+		r.Connections().Each(func(c Connection) { r.setConnectionState(c, StateHalted, cerrs[0]) })
+		r.setState(StateHalted, cerrs[0])
 		return
 	}
-	if derrs := r.Devices().Start(); len(derrs) > 0 {
+	r.Connections().Each(func(c Connection) { r.setConnectionState(c, StateRunning, nil) })
+
+	r.Devices().Each(func(d Device) { r.setDeviceState(d, StateStarting, nil) })
+	if derrs := r.Devices().start(r.Logger); len(derrs) > 0 {
 		errs = append(errs, derrs...)
+		r.Devices().Each(func(d Device) { r.setDeviceState(d, StateHalted, derrs[0]) })
+		r.setState(StateHalted, derrs[0])
 		return
 	}
+	r.Devices().Each(func(d Device) { r.setDeviceState(d, StateRunning, nil) })
+
+	if r.Supervisor != nil {
+		r.Supervisor.start(r)
+	}
+	r.setState(StateRunning, nil)
+
 	if r.Work != nil {
-		log.Println("Starting work...")
+		r.Logger.Info("Starting work")
 		r.Work()
 	}
 	return
 }
 
-// Stop stops a Robot's connections and Devices
+// Stop stops a Robot's Supervisor, connections and Devices, waiting up to
+// the halt timeout for each of them
 func (r *Robot) Stop() (errs []error) {
-	log.Println("Stopping Robot", r.Name, "...")
-	errs = append(errs, r.Devices().Halt()...)
-	errs = append(errs, r.Connections().Finalize()...)
-	return errs
+	return r.StopContext(context.Background()).Errors()
 }
 
-// Devices returns all devices associated with this Robot.
+// Devices returns a snapshot of all devices associated with this Robot.
 func (r *Robot) Devices() *Devices {
-	return r.devices
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	devices := append(Devices{}, *r.devices...)
+	return &devices
 }
 
-// AddDevice adds a new Device to the robots collection of devices. Returns the
-// added device.
+// AddDevice adds a new Device to the robots collection of devices. If the
+// Device is a LoggerSetter, it is given the robot's Logger. Returns the added
+// device.
 func (r *Robot) AddDevice(d Device) Device {
-	*r.devices = append(*r.Devices(), d)
+	r.addDevice(d, false)
 	return d
 }
 
+// addDevice adds d to the robots collection of devices. If unique is true, d
+// is only added if there is no Device with the same name, which is checked
+// under the same lock. Returns whether d was added.
+func (r *Robot) addDevice(d Device, unique bool) bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if unique {
+		for _, device := range *r.devices {
+			if device.Name() == d.Name() {
+				return false
+			}
+		}
+	}
+	if setter, ok := d.(LoggerSetter); ok && r.Logger != nil {
+		setter.SetLogger(r.Logger.With(deviceKeyvals(d)...))
+	}
+	r.instrument(Labels{"robot": r.Name, "device": d.Name()}, d)
+	*r.devices = append(*r.devices, d)
+	return true
+}
+
 // Device returns a device given a name. Returns nil if the Device does not exist.
 func (r *Robot) Device(name string) Device {
 	if r == nil {
 		return nil
 	}
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
 	for _, device := range *r.devices {
 		if device.Name() == name {
 			return device
@@ -182,24 +258,67 @@ func (r *Robot) Device(name string) Device {
 	return nil
 }
 
-// Connections returns all connections associated with this robot.
+// removeDevice removes a Device from the robots collection of devices given a
+// name. Returns the removed Device, or nil if it does not exist.
+func (r *Robot) removeDevice(name string) Device {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	for i, device := range *r.devices {
+		if device.Name() == name {
+			*r.devices = append(append(Devices{}, (*r.devices)[:i]...), (*r.devices)[i+1:]...)
+			r.uninstrument(Labels{"robot": r.Name, "device": name})
+			return device
+		}
+	}
+	return nil
+}
+
+// Connections returns a snapshot of all connections associated with this robot.
 func (r *Robot) Connections() *Connections {
-	return r.connections
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	connections := append(Connections{}, *r.connections...)
+	return &connections
 }
 
 // AddConnection adds a new connection to the robots collection of connections.
+// If the Connection is a LoggerSetter, it is given the robot's Logger.
 // Returns the added connection.
 func (r *Robot) AddConnection(c Connection) Connection {
-	*r.connections = append(*r.Connections(), c)
+	r.addConnection(c, false)
 	return c
 }
 
+// addConnection adds c to the robots collection of connections. If unique is
+// true, c is only added if there is no Connection with the same name, which
+// is checked under the same lock. Returns whether c was added.
+func (r *Robot) addConnection(c Connection, unique bool) bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if unique {
+		for _, connection := range *r.connections {
+			if connection.Name() == c.Name() {
+				return false
+			}
+		}
+	}
+	if setter, ok := c.(LoggerSetter); ok && r.Logger != nil {
+		setter.SetLogger(r.Logger.With(connectionKeyvals(c)...))
+	}
+	r.instrument(Labels{"robot": r.Name, "connection": c.Name()}, c)
+	r.countIOErrors(c, Labels{"robot": r.Name, "connection": c.Name()})
+	*r.connections = append(*r.connections, c)
+	return true
+}
+
 // Connection returns a connection given a name. Returns nil if the Connection
 // does not exist.
 func (r *Robot) Connection(name string) Connection {
 	if r == nil {
 		return nil
 	}
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
 	for _, connection := range *r.connections {
 		if connection.Name() == name {
 			return connection
@@ -207,3 +326,20 @@ func (r *Robot) Connection(name string) Connection {
 	}
 	return nil
 }
+
+// removeConnection removes a Connection from the robots collection of
+// connections given a name. Returns the removed Connection, or nil if it does
+// not exist.
+func (r *Robot) removeConnection(name string) Connection {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	for i, connection := range *r.connections {
+		if connection.Name() == name {
+			*r.connections = append(append(Connections{}, (*r.connections)[:i]...), (*r.connections)[i+1:]...)
+			r.uninstrument(Labels{"robot": r.Name, "connection": name})
+			r.uncountIOErrors(connection, Labels{"robot": r.Name, "connection": name})
+			return connection
+		}
+	}
+	return nil
+}
@@ -0,0 +1,172 @@
+package gobot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// DefaultHaltTimeout is the time a Robot waits for each Device to halt and
+// each Connection to finalize before giving up on it.
+const DefaultHaltTimeout = 5 * time.Second
+
+var (
+	// ErrHaltTimeout is the error resulting if a Device or Connection does not
+	// halt within its halt timeout
+	ErrHaltTimeout = errors.New("Halt timed out")
+)
+
+// ShutdownFailure describes a Device or Connection which failed to stop.
+type ShutdownFailure struct {
+	Robot string `json:"robot"`
+	// Kind is either "device" or "connection".
+	Kind     string `json:"kind"`
+	Name     string `json:"name"`
+	TimedOut bool   `json:"timed_out"`
+	Err      error  `json:"-"`
+}
+
+// Error returns the failure in the same form as the errors returned by Stop.
+func (f ShutdownFailure) Error() string {
+	return f.Err.Error()
+}
+
+// ShutdownReport is the result of stopping one or more Robots.
+type ShutdownReport struct {
+	Failures []ShutdownFailure `json:"failures"`
+}
+
+// OK returns true if everything stopped cleanly.
+func (s *ShutdownReport) OK() bool {
+	return len(s.Failures) == 0
+}
+
+// Errors returns the error of each failure.
+func (s *ShutdownReport) Errors() (errs []error) {
+	for _, f := range s.Failures {
+		errs = append(errs, f.Err)
+	}
+	return
+}
+
+// merge appends the failures of o to s.
+func (s *ShutdownReport) merge(o *ShutdownReport) {
+	s.Failures = append(s.Failures, o.Failures...)
+}
+
+// SetHaltTimeout overrides the Robot's HaltTimeout for the Device or
+// Connection with the given name.
+func (r *Robot) SetHaltTimeout(name string, timeout time.Duration) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.haltTimeouts == nil {
+		r.haltTimeouts = make(map[string]time.Duration)
+	}
+	r.haltTimeouts[name] = timeout
+}
+
+// haltTimeout returns the halt timeout of the Device or Connection with the
+// given name.
+func (r *Robot) haltTimeout(name string) time.Duration {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	if timeout, ok := r.haltTimeouts[name]; ok {
+		return timeout
+	}
+	return r.HaltTimeout
+}
+
+// StopContext stops a Robot's Supervisor, Devices and Connections. Each
+// Device and Connection is given its halt timeout to stop, and any which
+// have not been stopped when ctx is done are abandoned.
+func (r *Robot) StopContext(ctx context.Context) *ShutdownReport {
+	r.Logger.Info("Stopping Robot")
+	report := &ShutdownReport{}
+	if r.Supervisor != nil {
+		r.Supervisor.stop()
+	}
+
+	r.Devices().Each(func(d Device) {
+		errs, timedOut := haltWithin(ctx, r.haltTimeout(d.Name()), d.Halt)
+		for _, err := range errs {
+			report.Failures = append(report.Failures, ShutdownFailure{
+				Robot:    r.Name,
+				Kind:     "device",
+				Name:     d.Name(),
+				TimedOut: timedOut,
+				Err:      fmt.Errorf("Device %q: %v", d.Name(), err),
+			})
+		}
+		r.setDeviceState(d, StateHalted, nil)
+	})
+
+	r.Connections().Each(func(c Connection) {
+		errs, timedOut := haltWithin(ctx, r.haltTimeout(c.Name()), c.Finalize)
+		for _, err := range errs {
+			report.Failures = append(report.Failures, ShutdownFailure{
+				Robot:    r.Name,
+				Kind:     "connection",
+				Name:     c.Name(),
+				TimedOut: timedOut,
+				Err:      fmt.Errorf("Connection %q: %v", c.Name(), err),
+			})
+		}
+		r.setConnectionState(c, StateHalted, nil)
+	})
+
+	r.setState(StateHalted, nil)
+	return report
+}
+
+// StopContext stops each Robot in the collection in parallel.
+func (r *Robots) StopContext(ctx context.Context) *ShutdownReport {
+	reports := make([]*ShutdownReport, len(*r))
+	var wg sync.WaitGroup
+	for i, robot := range *r {
+		wg.Add(1)
+		go func(i int, robot *Robot) {
+			defer wg.Done()
+			reports[i] = robot.StopContext(ctx)
+		}(i, robot)
+	}
+	wg.Wait()
+
+	report := &ShutdownReport{}
+	for _, rr := range reports {
+		report.merge(rr)
+	}
+	return report
+}
+
+// haltWithin calls halt and waits for it to return for up to timeout, or
+// until ctx is done. A timeout of zero waits until ctx is done. If halt does
+// not return in time it is left running and ErrHaltTimeout, or the ctx
+// error, is returned.
+func haltWithin(ctx context.Context, timeout time.Duration, halt func() []error) (errs []error, timedOut bool) {
+	if err := ctx.Err(); err != nil {
+		return []error{err}, true
+	}
+
+	done := make(chan []error, 1)
+	go func() {
+		done <- halt()
+	}()
+
+	var expired <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		expired = timer.C
+	}
+
+	select {
+	case errs = <-done:
+		return errs, false
+	case <-expired:
+		return []error{ErrHaltTimeout}, true
+	case <-ctx.Done():
+		return []error{ctx.Err()}, true
+	}
+}
@@ -0,0 +1,126 @@
+package gobot
+
+import (
+	"context"
+	"log"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/hybridgroup/gobot/gobottest"
+)
+
+// hangingDriver is a testDriver whose Halt blocks until release is closed.
+type hangingDriver struct {
+	*testDriver
+	release chan bool
+}
+
+func (h *hangingDriver) Halt() (errs []error) {
+	<-h.release
+	return
+}
+
+func newHangingRobot(name string) (*Robot, chan bool) {
+	release := make(chan bool)
+	adaptor := newTestAdaptor("Connection1", "/dev/null")
+	driver := &hangingDriver{
+		testDriver: newTestDriver(adaptor, "Hanging", "0"),
+		release:    release,
+	}
+	r := NewRobot(name,
+		[]Connection{adaptor},
+		[]Device{driver},
+	)
+	return r, release
+}
+
+func TestRobotStopContextHaltTimeout(t *testing.T) {
+	log.SetOutput(&NullReadWriteCloser{})
+	r, release := newHangingRobot("Robot1")
+	defer close(release)
+	r.HaltTimeout = 10 * time.Millisecond
+	r.Start()
+
+	report := r.StopContext(context.Background())
+	gobottest.Assert(t, report.OK(), false)
+	gobottest.Assert(t, report.Failures[0].Robot, "Robot1")
+	gobottest.Assert(t, report.Failures[0].Kind, "device")
+	gobottest.Assert(t, report.Failures[0].Name, "Hanging")
+	gobottest.Assert(t, report.Failures[0].TimedOut, true)
+	gobottest.Assert(t, report.Failures[0].Error(), "Device \"Hanging\": Halt timed out")
+	gobottest.Assert(t, r.State(), StateHalted)
+}
+
+func TestRobotSetHaltTimeout(t *testing.T) {
+	log.SetOutput(&NullReadWriteCloser{})
+	r, release := newHangingRobot("Robot1")
+	defer close(release)
+	r.HaltTimeout = 0
+	r.SetHaltTimeout("Hanging", 10*time.Millisecond)
+
+	gobottest.Assert(t, r.haltTimeout("Hanging"), 10*time.Millisecond)
+	gobottest.Assert(t, r.haltTimeout("Connection1"), time.Duration(0))
+
+	r.Start()
+	gobottest.Assert(t, r.StopContext(context.Background()).Failures[0].TimedOut, true)
+}
+
+func TestRobotStopContextCancel(t *testing.T) {
+	log.SetOutput(&NullReadWriteCloser{})
+	r, release := newHangingRobot("Robot1")
+	defer close(release)
+	r.HaltTimeout = 0
+	r.Start()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	report := r.StopContext(ctx)
+	gobottest.Assert(t, report.Failures[0].Err.Error(), "Device \"Hanging\": "+context.DeadlineExceeded.Error())
+	gobottest.Assert(t, report.Failures[1].Kind, "connection")
+}
+
+func TestRobotsStopContextParallel(t *testing.T) {
+	log.SetOutput(&NullReadWriteCloser{})
+	r1, release1 := newHangingRobot("Robot1")
+	defer close(release1)
+	r2, release2 := newHangingRobot("Robot2")
+	defer close(release2)
+	r1.HaltTimeout = 50 * time.Millisecond
+	r2.HaltTimeout = 50 * time.Millisecond
+	robots := &Robots{r1, r2}
+
+	start := time.Now()
+	report := robots.StopContext(context.Background())
+	gobottest.Assert(t, time.Since(start) < 100*time.Millisecond, true)
+	gobottest.Assert(t, len(report.Errors()), 2)
+	gobottest.Assert(t, report.Failures[0].Robot, "Robot1")
+	gobottest.Assert(t, report.Failures[1].Robot, "Robot2")
+}
+
+func TestGobotStartContext(t *testing.T) {
+	log.SetOutput(&NullReadWriteCloser{})
+	g := NewGobot()
+	g.trap = func(c chan os.Signal) {}
+	r, release := newHangingRobot("Robot1")
+	defer close(release)
+	r.HaltTimeout = 0
+	g.AddRobot(r)
+	g.ShutdownTimeout = 10 * time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan []error)
+	go func() {
+		done <- g.StartContext(ctx)
+	}()
+
+	cancel()
+	select {
+	case errs := <-done:
+		gobottest.Assert(t, len(errs), 0)
+	case <-time.After(time.Second):
+		t.Errorf("StartContext did not return after ctx was cancelled")
+	}
+	gobottest.Assert(t, r.State(), StateHalted)
+}
@@ -0,0 +1,55 @@
+package gobot
+
+import (
+	"context"
+	"path"
+	"strings"
+)
+
+// EventFilter reports whether the data of a published Event should be
+// passed on to an event handler.
+type EventFilter func(data interface{}) bool
+
+// Subscription is a handle to an event handler registered with On or Once.
+type Subscription struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// Cancel stops the event handler and releases its goroutine. Calling Cancel
+// more than once has no effect.
+func (s *Subscription) Cancel() {
+	s.cancel()
+}
+
+// Done returns a channel which is closed once the event handler has stopped,
+// either because it was cancelled or because a Once handler has run.
+func (s *Subscription) Done() <-chan struct{} {
+	return s.done
+}
+
+// eventMatcher returns a function which matches Event names against pattern.
+// Patterns use the syntax of path.Match, so "button.*" matches both
+// "button.push" and "button.release".
+func eventMatcher(pattern string) (func(name string) bool, error) {
+	if !strings.ContainsAny(pattern, `*?[\`) {
+		return func(name string) bool { return name == pattern }, nil
+	}
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, err
+	}
+	return func(name string) bool {
+		matched, _ := path.Match(pattern, name)
+		return matched
+	}, nil
+}
+
+// acceptEvent returns true if data passes every filter.
+func acceptEvent(data interface{}, filters []EventFilter) bool {
+	for _, filter := range filters {
+		if !filter(data) {
+			return false
+		}
+	}
+	return true
+}
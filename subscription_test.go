@@ -0,0 +1,36 @@
+package gobot
+
+import (
+	"testing"
+
+	"github.com/hybridgroup/gobot/gobottest"
+)
+
+func TestEventMatcher(t *testing.T) {
+	match, err := eventMatcher("button")
+	gobottest.Assert(t, err, nil)
+	gobottest.Assert(t, match("button"), true)
+	gobottest.Assert(t, match("button.push"), false)
+
+	match, err = eventMatcher("button.*")
+	gobottest.Assert(t, err, nil)
+	gobottest.Assert(t, match("button.push"), true)
+	gobottest.Assert(t, match("button"), false)
+
+	match, err = eventMatcher("sensor?")
+	gobottest.Assert(t, err, nil)
+	gobottest.Assert(t, match("sensor1"), true)
+
+	_, err = eventMatcher("[")
+	gobottest.Refute(t, err, nil)
+}
+
+func TestAcceptEvent(t *testing.T) {
+	positive := func(data interface{}) bool { return data.(int) > 0 }
+	even := func(data interface{}) bool { return data.(int)%2 == 0 }
+
+	gobottest.Assert(t, acceptEvent(1, nil), true)
+	gobottest.Assert(t, acceptEvent(2, []EventFilter{positive, even}), true)
+	gobottest.Assert(t, acceptEvent(3, []EventFilter{positive, even}), false)
+	gobottest.Assert(t, acceptEvent(-2, []EventFilter{positive, even}), false)
+}
@@ -0,0 +1,210 @@
+package gobot
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrPingTimeout is the error of a health check whose Ping did not return
+// within the PingTimeout of the Supervisor.
+var ErrPingTimeout = errors.New("Ping timed out")
+
+// Supervisor periodically checks the health of a Robot's Connections which
+// implement Pinger. When a check fails, the Connection is reconnected with
+// exponential backoff and the Devices which use it are restarted, while the
+// other Connections keep being checked. Zero durations are replaced by the
+// defaults of NewSupervisor when it starts.
+type Supervisor struct {
+	// Interval is the time between health checks.
+	Interval time.Duration
+	// PingTimeout is how long a Ping may take before the check fails.
+	PingTimeout time.Duration
+	// MinBackoff is the delay before the first reconnection attempt.
+	MinBackoff time.Duration
+	// MaxBackoff is the longest delay between reconnection attempts.
+	MaxBackoff time.Duration
+	// MaxAttempts is the number of reconnection attempts before a Connection
+	// is halted. Zero means retry forever.
+	MaxAttempts int
+
+	done       chan bool
+	wg         sync.WaitGroup
+	mutex      sync.Mutex
+	recovering map[string]bool
+}
+
+const (
+	defaultSupervisorInterval = 1 * time.Second
+	defaultPingTimeout        = 5 * time.Second
+	defaultMinBackoff         = 100 * time.Millisecond
+	defaultMaxBackoff         = 30 * time.Second
+)
+
+// NewSupervisor returns a new Supervisor which checks connections every second.
+func NewSupervisor() *Supervisor {
+	return &Supervisor{
+		Interval:    defaultSupervisorInterval,
+		PingTimeout: defaultPingTimeout,
+		MinBackoff:  defaultMinBackoff,
+		MaxBackoff:  defaultMaxBackoff,
+	}
+}
+
+// start begins supervising r until stop is called.
+func (s *Supervisor) start(r *Robot) {
+	if s.Interval <= 0 {
+		s.Interval = defaultSupervisorInterval
+	}
+	if s.PingTimeout <= 0 {
+		s.PingTimeout = defaultPingTimeout
+	}
+	if s.MinBackoff <= 0 {
+		s.MinBackoff = defaultMinBackoff
+	}
+	if s.MaxBackoff <= 0 {
+		s.MaxBackoff = defaultMaxBackoff
+	}
+	s.done = make(chan bool)
+	s.recovering = make(map[string]bool)
+	s.wg.Add(1)
+
+	go func() {
+		defer s.wg.Done()
+		ticker := time.NewTicker(s.Interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-s.done:
+				return
+			case <-ticker.C:
+				s.check(r)
+			}
+		}
+	}()
+}
+
+// stop ends supervision and waits for the reconnections in progress to return.
+func (s *Supervisor) stop() {
+	if s.done == nil {
+		return
+	}
+	close(s.done)
+	s.wg.Wait()
+	s.done = nil
+}
+
+// check pings each Connection and recovers those which fail, each in its own
+// goroutine.
+func (s *Supervisor) check(r *Robot) {
+	r.Connections().Each(func(c Connection) {
+		pinger, ok := c.(Pinger)
+		if !ok || r.ConnectionState(c.Name()) != StateRunning || s.isRecovering(c) {
+			return
+		}
+		if err := s.ping(pinger); err != nil {
+			r.Logger.Warn("Connection failed health check", "connection", c.Name(), "error", err)
+			if r.Metrics != nil {
+				r.Metrics.Counter("gobot_connection_health_check_failures_total",
+					"Failed connection health checks.", Labels{"robot": r.Name, "connection": c.Name()}).Inc()
+			}
+			r.setConnectionState(c, StateDegraded, err)
+			r.Devices().Each(func(d Device) {
+				if d.Connection() == c {
+					r.setDeviceState(d, StateDegraded, err)
+				}
+			})
+			r.setState(StateDegraded, err)
+
+			s.mutex.Lock()
+			s.recovering[c.Name()] = true
+			s.mutex.Unlock()
+			s.wg.Add(1)
+			go func() {
+				defer s.wg.Done()
+				s.recover(r, c)
+				s.mutex.Lock()
+				delete(s.recovering, c.Name())
+				s.mutex.Unlock()
+			}()
+		}
+	})
+}
+
+// isRecovering returns whether c is being reconnected.
+func (s *Supervisor) isRecovering(c Connection) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.recovering[c.Name()]
+}
+
+// ping calls p.Ping, and fails with ErrPingTimeout once PingTimeout passes.
+// A Ping which never returns leaks its goroutine.
+func (s *Supervisor) ping(p Pinger) error {
+	result := make(chan error, 1)
+	go func() {
+		result <- p.Ping()
+	}()
+	select {
+	case err := <-result:
+		return err
+	case <-time.After(s.PingTimeout):
+		return ErrPingTimeout
+	}
+}
+
+// recover reconnects c and restarts the Devices which use it.
+func (s *Supervisor) recover(r *Robot, c Connection) {
+	r.setConnectionState(c, StateReconnecting, nil)
+	c.Finalize()
+
+	backoff := s.MinBackoff
+	for attempt := 1; s.MaxAttempts == 0 || attempt <= s.MaxAttempts; attempt++ {
+		select {
+		case <-s.done:
+			return
+		case <-time.After(backoff):
+		}
+
+		r.Logger.Info("Reconnecting", "connection", c.Name(), "attempt", attempt)
+		if errs := c.Connect(); len(errs) == 0 {
+			if r.Metrics != nil {
+				r.Metrics.Counter("gobot_connection_reconnects_total",
+					"Successful connection reconnects.", Labels{"robot": r.Name, "connection": c.Name()}).Inc()
+			}
+			r.setConnectionState(c, StateRunning, nil)
+			s.restartDevices(r, c)
+			r.refreshState()
+			return
+		}
+
+		if backoff *= 2; backoff > s.MaxBackoff {
+			backoff = s.MaxBackoff
+		}
+	}
+
+	r.Logger.Error("Giving up reconnecting", "connection", c.Name())
+	r.setConnectionState(c, StateHalted, nil)
+	r.Devices().Each(func(d Device) {
+		if d.Connection() == c {
+			r.setDeviceState(d, StateHalted, nil)
+		}
+	})
+}
+
+// restartDevices halts and starts each Device which uses c.
+func (s *Supervisor) restartDevices(r *Robot, c Connection) {
+	r.Devices().Each(func(d Device) {
+		if d.Connection() != c {
+			return
+		}
+		r.setDeviceState(d, StateStarting, nil)
+		d.Halt()
+		if errs := d.Start(); len(errs) > 0 {
+			r.setDeviceState(d, StateDegraded, errs[0])
+			return
+		}
+		r.setDeviceState(d, StateRunning, nil)
+	})
+}
@@ -0,0 +1,192 @@
+package gobot
+
+import (
+	"errors"
+	"log"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hybridgroup/gobot/gobottest"
+)
+
+type pingAdaptor struct {
+	testAdaptor
+	mutex    sync.Mutex
+	pingErr  error
+	failures int
+	connects int
+	hang     chan bool
+}
+
+func (p *pingAdaptor) Ping() error {
+	p.mutex.Lock()
+	hang := p.hang
+	p.mutex.Unlock()
+	if hang != nil {
+		<-hang
+	}
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return p.pingErr
+}
+
+func (p *pingAdaptor) Connect() (errs []error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.connects++
+	if p.failures > 0 {
+		p.failures--
+		return []error{errors.New("connect error")}
+	}
+	p.pingErr = nil
+	return
+}
+
+func (p *pingAdaptor) fail(failures int) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.pingErr = errors.New("ping error")
+	p.failures = failures
+}
+
+func newSupervisedRobot(maxAttempts int) (*Robot, *pingAdaptor) {
+	log.SetOutput(&NullReadWriteCloser{})
+	adaptor := &pingAdaptor{testAdaptor: testAdaptor{name: "Connection1"}}
+	driver := &testDriver{name: "Device1", connection: adaptor, ExtendedCommander: NewCommander()}
+	s := NewSupervisor()
+	s.Interval = time.Millisecond
+	s.MinBackoff = time.Millisecond
+	s.MaxBackoff = 2 * time.Millisecond
+	s.MaxAttempts = maxAttempts
+	r := NewRobot("Robot1", []Connection{adaptor}, []Device{driver}, s)
+	return r, adaptor
+}
+
+func waitForState(r *Robot, state State) bool {
+	timeout := time.After(100 * time.Millisecond)
+	for {
+		if r.State() == state {
+			return true
+		}
+		select {
+		case <-timeout:
+			return false
+		case <-time.After(time.Millisecond):
+		}
+	}
+}
+
+func TestSupervisorReconnect(t *testing.T) {
+	r, adaptor := newSupervisedRobot(0)
+	gobottest.Assert(t, len(r.Start()), 0)
+	defer r.Stop()
+
+	reconnecting := make(chan bool, 1)
+	r.On(ConnectionStateEvent, func(data interface{}) {
+		if data.(StateChange).To == StateReconnecting {
+			reconnecting <- true
+		}
+	})
+
+	adaptor.fail(2)
+
+	select {
+	case <-reconnecting:
+	case <-time.After(100 * time.Millisecond):
+		t.Errorf("connection was not reconnected")
+	}
+
+	gobottest.Assert(t, waitForState(r, StateDegraded) || waitForState(r, StateRunning), true)
+	gobottest.Assert(t, waitForState(r, StateRunning), true)
+	gobottest.Assert(t, r.ConnectionState("Connection1"), StateRunning)
+	gobottest.Assert(t, r.DeviceState("Device1"), StateRunning)
+
+	adaptor.mutex.Lock()
+	gobottest.Assert(t, adaptor.connects, 4)
+	adaptor.mutex.Unlock()
+}
+
+func TestSupervisorRecoversConcurrently(t *testing.T) {
+	r, adaptor := newSupervisedRobot(0)
+	hung := &pingAdaptor{testAdaptor: testAdaptor{name: "Connection2"}}
+	r.AddConnection(hung)
+	r.Supervisor.PingTimeout = 5 * time.Millisecond
+	gobottest.Assert(t, len(r.Start()), 0)
+	defer r.Stop()
+
+	// a connection which never reconnects does not stop the checks of the
+	// others, nor does a Ping which never returns
+	hung.mutex.Lock()
+	hung.hang = make(chan bool)
+	hung.failures = 1 << 30
+	hung.mutex.Unlock()
+	timeout := time.After(100 * time.Millisecond)
+	for r.ConnectionState("Connection2") != StateReconnecting {
+		select {
+		case <-timeout:
+			t.Fatalf("hung connection was not reconnected")
+		case <-time.After(time.Millisecond):
+		}
+	}
+
+	adaptor.fail(1)
+	timeout = time.After(100 * time.Millisecond)
+	for {
+		adaptor.mutex.Lock()
+		connects := adaptor.connects
+		adaptor.mutex.Unlock()
+		if connects == 3 && r.ConnectionState("Connection1") == StateRunning {
+			break
+		}
+		select {
+		case <-timeout:
+			t.Fatalf("connection was not reconnected while another one was failing")
+		case <-time.After(time.Millisecond):
+		}
+	}
+	gobottest.Assert(t, r.ConnectionState("Connection2"), StateReconnecting)
+	close(hung.hang)
+}
+
+func TestSupervisorGiveUp(t *testing.T) {
+	r, adaptor := newSupervisedRobot(2)
+	gobottest.Assert(t, len(r.Start()), 0)
+	defer r.Stop()
+
+	adaptor.fail(5)
+
+	gobottest.Assert(t, waitForState(r, StateDegraded), true)
+	timeout := time.After(100 * time.Millisecond)
+	for r.ConnectionState("Connection1") != StateHalted {
+		select {
+		case <-timeout:
+			t.Fatalf("connection was not halted")
+		case <-time.After(time.Millisecond):
+		}
+	}
+	gobottest.Assert(t, r.DeviceState("Device1"), StateHalted)
+	gobottest.Assert(t, r.State(), StateDegraded)
+}
+
+func TestSupervisorStop(t *testing.T) {
+	r, _ := newSupervisedRobot(0)
+	r.Start()
+	r.Stop()
+	gobottest.Assert(t, r.State(), StateHalted)
+
+	// stopping twice is harmless
+	r.Supervisor.stop()
+}
+
+func TestSupervisorDefaults(t *testing.T) {
+	log.SetOutput(&NullReadWriteCloser{})
+	s := &Supervisor{}
+	r := NewRobot("Robot1", []Connection{&pingAdaptor{testAdaptor: testAdaptor{name: "Connection1"}}}, s)
+	r.Start()
+	gobottest.Assert(t, s.Interval, time.Second)
+	gobottest.Assert(t, s.PingTimeout, 5*time.Second)
+	gobottest.Assert(t, s.MinBackoff, 100*time.Millisecond)
+	gobottest.Assert(t, s.MaxBackoff, 30*time.Second)
+	r.Stop()
+}
@@ -6,6 +6,7 @@ import (
 	"os"
 	"strconv"
 	"syscall"
+	"time"
 )
 
 const (
@@ -19,6 +20,14 @@ const (
 	LOW = 0
 	// GPIOPATH default linux gpio path
 	GPIOPATH = "/sys/class/gpio"
+	// NONE gpio edge
+	NONE = "none"
+	// RISING gpio edge
+	RISING = "rising"
+	// FALLING gpio edge
+	FALLING = "falling"
+	// BOTH gpio edges
+	BOTH = "both"
 )
 
 // DigitalPin is the interface for sysfs gpio interactions
@@ -35,9 +44,37 @@ type DigitalPin interface {
 	Write(int) error
 }
 
+// EdgeDetector is the interface for DigitalPins which detect the edges of
+// an input
+type EdgeDetector interface {
+	// Edge sets the edges to detect: NONE, RISING, FALLING or BOTH
+	Edge(string) error
+	// WaitForEdge waits at most timeout for an edge, and returns the value of
+	// the pin after the edge and the time of the edge. The value is -1 if the
+	// timeout passed. A negative timeout waits forever.
+	WaitForEdge(timeout time.Duration) (int, time.Time, error)
+}
+
+// ErrEdgeUnsupported is the error resulting when a pin can not detect edges
+var ErrEdgeUnsupported = errors.New("Edge detection is not supported by this pin")
+
+// WaitForEdge sets the edges detected by pin, and waits at most timeout for
+// one of them, see EdgeDetector.
+func WaitForEdge(pin DigitalPin, edge string, timeout time.Duration) (int, time.Time, error) {
+	e, ok := pin.(EdgeDetector)
+	if !ok {
+		return -1, time.Time{}, ErrEdgeUnsupported
+	}
+	if err := e.Edge(edge); err != nil {
+		return -1, time.Time{}, err
+	}
+	return e.WaitForEdge(timeout)
+}
+
 type digitalPin struct {
 	pin   string
 	label string
+	edge  string
 
 	value     File
 	direction File
@@ -58,6 +95,7 @@ func NewDigitalPin(pin int, v ...string) DigitalPin {
 }
 
 var notExportedError = errors.New("pin has not been exported")
+var noEdgeError = errors.New("pin has no edge to detect")
 
 func (d *digitalPin) Direction(dir string) error {
 	_, err := writeFile(d.direction, []byte(dir))
@@ -77,6 +115,50 @@ func (d *digitalPin) Read() (n int, err error) {
 	return strconv.Atoi(string(buf[0]))
 }
 
+func (d *digitalPin) Edge(edge string) error {
+	if d.value == nil {
+		return notExportedError
+	}
+	if edge == d.edge {
+		return nil
+	}
+	f, err := fs.OpenFile(fmt.Sprintf("%v/%v/edge", GPIOPATH, d.label), os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if _, err = writeFile(f, []byte(edge)); err != nil {
+		return err
+	}
+	d.edge = edge
+
+	// The value file is ready to read as soon as it is opened, which must be
+	// cleared with a read before waiting for the first edge
+	_, err = readFile(d.value)
+	return err
+}
+
+func (d *digitalPin) WaitForEdge(timeout time.Duration) (int, time.Time, error) {
+	if d.value == nil {
+		return -1, time.Time{}, notExportedError
+	}
+	if d.edge == "" || d.edge == NONE {
+		return -1, time.Time{}, noEdgeError
+	}
+
+	ready, err := pollFd(d.value.Fd(), pollPri|pollErr, timeout)
+	if err != nil || !ready {
+		return -1, time.Time{}, err
+	}
+	t := time.Now()
+	val, err := d.Read()
+	if err != nil {
+		return -1, t, err
+	}
+	return val, t, nil
+}
+
 func (d *digitalPin) Export() error {
 	export, err := fs.OpenFile(GPIOPATH+"/export", os.O_WRONLY, 0644)
 	if err != nil {
@@ -129,6 +211,7 @@ func (d *digitalPin) Unexport() error {
 		d.value.Close()
 		d.value = nil
 	}
+	d.edge = ""
 
 	_, err = writeFile(unexport, []byte(d.pin))
 	if err != nil {
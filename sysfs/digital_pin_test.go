@@ -5,10 +5,65 @@ import (
 	"os"
 	"syscall"
 	"testing"
+	"time"
 
 	"github.com/hybridgroup/gobot/gobottest"
 )
 
+func TestDigitalPinEdge(t *testing.T) {
+	fs := NewMockFilesystem([]string{
+		"/sys/class/gpio/export",
+		"/sys/class/gpio/unexport",
+		"/sys/class/gpio/gpio10/value",
+		"/sys/class/gpio/gpio10/direction",
+		"/sys/class/gpio/gpio10/edge",
+	})
+	SetFilesystem(fs)
+	polls := 0
+	SetSyscall(&MockSyscall{Impl: func(trap, a1, a2, a3 uintptr) (r1, r2 uintptr, err syscall.Errno) {
+		if trap != syscall.SYS_PPOLL {
+			return 0, 0, 0
+		}
+		polls++
+		// the first poll times out
+		if polls == 1 {
+			return 0, 0, 0
+		}
+		return 1, 0, 0
+	}})
+	defer SetSyscall(&NativeSyscall{})
+
+	pin := NewDigitalPin(10)
+	_, _, err := WaitForEdge(pin, BOTH, time.Millisecond)
+	gobottest.Assert(t, err, notExportedError)
+
+	gobottest.Assert(t, pin.Export(), nil)
+	_, _, err = pin.(EdgeDetector).WaitForEdge(time.Millisecond)
+	gobottest.Assert(t, err, noEdgeError)
+
+	val, _, err := WaitForEdge(pin, BOTH, time.Millisecond)
+	gobottest.Assert(t, err, nil)
+	gobottest.Assert(t, val, -1)
+	gobottest.Assert(t, fs.Files["/sys/class/gpio/gpio10/edge"].Contents, "both")
+
+	fs.Files["/sys/class/gpio/gpio10/value"].Contents = "1"
+	val, ts, err := WaitForEdge(pin, BOTH, time.Millisecond)
+	gobottest.Assert(t, err, nil)
+	gobottest.Assert(t, val, 1)
+	gobottest.Refute(t, ts.IsZero(), true)
+	gobottest.Assert(t, polls, 2)
+
+	gobottest.Assert(t, pin.Unexport(), nil)
+	_, _, err = pin.(EdgeDetector).WaitForEdge(time.Millisecond)
+	gobottest.Assert(t, err, notExportedError)
+
+	gobottest.Assert(t, pin.Export(), nil)
+	gobottest.Assert(t, pin.(EdgeDetector).Edge(RISING), nil)
+	gobottest.Assert(t, fs.Files["/sys/class/gpio/gpio10/edge"].Contents, "rising")
+	delete(fs.Files, "/sys/class/gpio/gpio10/edge")
+	gobottest.Refute(t, pin.(EdgeDetector).Edge(FALLING), nil)
+}
+
 func TestDigitalPin(t *testing.T) {
 	fs := NewMockFilesystem([]string{
 		"/sys/class/gpio/export",
@@ -106,10 +106,23 @@ func (fs *MockFilesystem) Add(name string) *MockFile {
 		fd:  uintptr(time.Now().UnixNano() & 0xffff),
 		fs:  fs,
 	}
+	// keep the fds unique, so mock syscalls can tell the files apart
+	for fs.hasFd(f.fd) {
+		f.fd++
+	}
 	fs.Files[name] = f
 	return f
 }
 
+func (fs *MockFilesystem) hasFd(fd uintptr) bool {
+	for _, f := range fs.Files {
+		if f.fd == fd {
+			return true
+		}
+	}
+	return false
+}
+
 func (fs *MockFilesystem) next() int {
 	fs.Seq++
 	return fs.Seq
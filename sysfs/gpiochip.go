@@ -0,0 +1,368 @@
+package sysfs
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+const (
+	// GPIOCHIPPATH default linux gpio character device path prefix
+	GPIOCHIPPATH = "/dev/gpiochip"
+
+	GPIO_GET_CHIPINFO_IOCTL       = 0x8044B401
+	GPIO_V2_GET_LINE_IOCTL        = 0xC250B407
+	GPIO_V2_LINE_SET_CONFIG_IOCTL = 0xC110B40D
+	GPIO_V2_LINE_GET_VALUES_IOCTL = 0xC010B40E
+	GPIO_V2_LINE_SET_VALUES_IOCTL = 0xC010B40F
+
+	GPIO_V2_LINE_FLAG_ACTIVE_LOW           = 1 << 1
+	GPIO_V2_LINE_FLAG_INPUT                = 1 << 2
+	GPIO_V2_LINE_FLAG_OUTPUT               = 1 << 3
+	GPIO_V2_LINE_FLAG_EDGE_RISING          = 1 << 4
+	GPIO_V2_LINE_FLAG_EDGE_FALLING         = 1 << 5
+	GPIO_V2_LINE_FLAG_BIAS_PULL_UP         = 1 << 8
+	GPIO_V2_LINE_FLAG_BIAS_PULL_DOWN       = 1 << 9
+	GPIO_V2_LINE_FLAG_BIAS_DISABLED        = 1 << 10
+	GPIO_V2_LINE_FLAG_EVENT_CLOCK_REALTIME = 1 << 11
+
+	GPIO_V2_LINE_EVENT_RISING_EDGE  = 1
+	GPIO_V2_LINE_EVENT_FALLING_EDGE = 2
+)
+
+const biasFlags = GPIO_V2_LINE_FLAG_BIAS_PULL_UP | GPIO_V2_LINE_FLAG_BIAS_PULL_DOWN | GPIO_V2_LINE_FLAG_BIAS_DISABLED
+
+// LineFlag configures a line of a gpio character device
+type LineFlag uint64
+
+const (
+	// ActiveLow inverts the values read from and written to the line
+	ActiveLow LineFlag = GPIO_V2_LINE_FLAG_ACTIVE_LOW
+	// PullUp enables the pull-up resistor of the line
+	PullUp LineFlag = GPIO_V2_LINE_FLAG_BIAS_PULL_UP
+	// PullDown enables the pull-down resistor of the line
+	PullDown LineFlag = GPIO_V2_LINE_FLAG_BIAS_PULL_DOWN
+	// BiasDisabled disables the pull-up and pull-down resistors of the line
+	BiasDisabled LineFlag = GPIO_V2_LINE_FLAG_BIAS_DISABLED
+)
+
+// gpiochipInfo is struct gpiochip_info of linux/gpio.h
+type gpiochipInfo struct {
+	name  [32]byte
+	label [32]byte
+	lines uint32
+}
+
+// maxGpiochips bounds the search for a gpio character device by its label
+const maxGpiochips = 64
+
+type gpioLineAttribute struct {
+	id      uint32
+	padding uint32
+	value   uint64
+}
+
+type gpioLineConfigAttribute struct {
+	attr gpioLineAttribute
+	mask uint64
+}
+
+type gpioLineConfig struct {
+	flags    uint64
+	numAttrs uint32
+	padding  [5]uint32
+	attrs    [10]gpioLineConfigAttribute
+}
+
+type gpioLineRequest struct {
+	offsets         [64]uint32
+	consumer        [32]byte
+	config          gpioLineConfig
+	numLines        uint32
+	eventBufferSize uint32
+	padding         [5]uint32
+	fd              int32
+}
+
+type gpioLineValues struct {
+	bits uint64
+	mask uint64
+}
+
+type gpioLineEvent struct {
+	timestampNs uint64
+	id          uint32
+	offset      uint32
+	seqno       uint32
+	lineSeqno   uint32
+	padding     [6]uint32
+}
+
+type gpiochipDigitalPin struct {
+	chip  string
+	line  int
+	flags uint64
+	edge  uint64
+
+	file File
+	fd   uintptr
+}
+
+// NewGpiochipDigitalPin returns a DigitalPin for a line of a gpio character
+// device, eg. "/dev/gpiochip0", given the offset of the line on the chip.
+// The flags set the bias and active-low of the line, and a line with a bias
+// is requested as an input.
+func NewGpiochipDigitalPin(chip string, line int, flags ...LineFlag) DigitalPin {
+	d := &gpiochipDigitalPin{chip: chip, line: line}
+	for _, flag := range flags {
+		d.flags |= uint64(flag)
+	}
+	return d
+}
+
+func (d *gpiochipDigitalPin) Export() (err error) {
+	if d.file != nil {
+		return nil
+	}
+	if d.file, err = OpenFile(d.chip, os.O_RDWR, 0644); err != nil {
+		d.file = nil
+		return err
+	}
+
+	// the line keeps its current direction until Direction is called, unless
+	// it has a bias, which the kernel only accepts along with a direction
+	req := gpioLineRequest{numLines: 1}
+	req.offsets[0] = uint32(d.line)
+	copy(req.consumer[:], "gobot")
+	req.config.flags = d.flags
+	if d.flags&biasFlags != 0 {
+		req.config.flags |= GPIO_V2_LINE_FLAG_INPUT
+	}
+	_, _, errno := Syscall(
+		syscall.SYS_IOCTL,
+		d.file.Fd(),
+		GPIO_V2_GET_LINE_IOCTL,
+		uintptr(unsafe.Pointer(&req)),
+	)
+	if errno != 0 {
+		d.file.Close()
+		d.file = nil
+		return fmt.Errorf("Requesting line %v of %v failed with syscall.Errno %v", d.line, d.chip, errno)
+	}
+	d.fd = uintptr(req.fd)
+	return nil
+}
+
+func (d *gpiochipDigitalPin) Unexport() error {
+	if d.file == nil {
+		return nil
+	}
+	Syscall(syscall.SYS_CLOSE, d.fd, 0, 0)
+	err := d.file.Close()
+	d.file = nil
+	d.edge = 0
+	return err
+}
+
+func (d *gpiochipDigitalPin) Direction(dir string) error {
+	if d.file == nil {
+		return notExportedError
+	}
+	switch dir {
+	case IN:
+		// inputs keep detecting their edges
+		return d.setConfig(GPIO_V2_LINE_FLAG_INPUT, d.edge)
+	case OUT:
+		return d.setConfig(GPIO_V2_LINE_FLAG_OUTPUT, 0)
+	}
+	return fmt.Errorf("Invalid direction %v", dir)
+}
+
+func (d *gpiochipDigitalPin) Edge(edge string) error {
+	if d.file == nil {
+		return notExportedError
+	}
+	var flags uint64
+	switch edge {
+	case NONE:
+	case RISING:
+		flags = GPIO_V2_LINE_FLAG_EDGE_RISING
+	case FALLING:
+		flags = GPIO_V2_LINE_FLAG_EDGE_FALLING
+	case BOTH:
+		flags = GPIO_V2_LINE_FLAG_EDGE_RISING | GPIO_V2_LINE_FLAG_EDGE_FALLING
+	default:
+		return fmt.Errorf("Invalid edge %v", edge)
+	}
+	if flags == d.edge {
+		return nil
+	}
+	return d.setConfig(GPIO_V2_LINE_FLAG_INPUT, flags)
+}
+
+// setConfig sets the direction and edge flags of the line
+func (d *gpiochipDigitalPin) setConfig(direction uint64, edge uint64) error {
+	config := gpioLineConfig{flags: d.flags | direction | edge}
+	if edge != 0 {
+		config.flags |= GPIO_V2_LINE_FLAG_EVENT_CLOCK_REALTIME
+	}
+	_, _, errno := Syscall(
+		syscall.SYS_IOCTL,
+		d.fd,
+		GPIO_V2_LINE_SET_CONFIG_IOCTL,
+		uintptr(unsafe.Pointer(&config)),
+	)
+	if errno != 0 {
+		return fmt.Errorf("Setting line config failed with syscall.Errno %v", errno)
+	}
+	d.edge = edge
+	return nil
+}
+
+func (d *gpiochipDigitalPin) WaitForEdge(timeout time.Duration) (int, time.Time, error) {
+	if d.file == nil {
+		return -1, time.Time{}, notExportedError
+	}
+	if d.edge == 0 {
+		return -1, time.Time{}, noEdgeError
+	}
+
+	ready, err := pollFd(d.fd, pollIn, timeout)
+	if err != nil || !ready {
+		return -1, time.Time{}, err
+	}
+	var event gpioLineEvent
+	_, _, errno := Syscall(
+		syscall.SYS_READ,
+		d.fd,
+		uintptr(unsafe.Pointer(&event)),
+		unsafe.Sizeof(event),
+	)
+	if errno != 0 {
+		return -1, time.Time{}, fmt.Errorf("Reading line event failed with syscall.Errno %v", errno)
+	}
+
+	val := LOW
+	if event.id == GPIO_V2_LINE_EVENT_RISING_EDGE {
+		val = HIGH
+	}
+	return val, time.Unix(0, int64(event.timestampNs)), nil
+}
+
+func (d *gpiochipDigitalPin) Write(b int) error {
+	if d.file == nil {
+		return notExportedError
+	}
+	values := gpioLineValues{mask: 1}
+	if b != LOW {
+		values.bits = 1
+	}
+	_, _, errno := Syscall(
+		syscall.SYS_IOCTL,
+		d.fd,
+		GPIO_V2_LINE_SET_VALUES_IOCTL,
+		uintptr(unsafe.Pointer(&values)),
+	)
+	if errno != 0 {
+		return fmt.Errorf("Write failed with syscall.Errno %v", errno)
+	}
+	return nil
+}
+
+func (d *gpiochipDigitalPin) Read() (int, error) {
+	if d.file == nil {
+		return 0, notExportedError
+	}
+	values := gpioLineValues{mask: 1}
+	_, _, errno := Syscall(
+		syscall.SYS_IOCTL,
+		d.fd,
+		GPIO_V2_LINE_GET_VALUES_IOCTL,
+		uintptr(unsafe.Pointer(&values)),
+	)
+	if errno != 0 {
+		return 0, fmt.Errorf("Read failed with syscall.Errno %v", errno)
+	}
+	return int(values.bits & 1), nil
+}
+
+// GpiochipRange maps the sysfs gpio numbers from Base to Base+Count-1 to the
+// lines of a gpio character device, found by its Label if it has one, since
+// the numbering of the devices depends on the order the kernel probes them
+type GpiochipRange struct {
+	Chip  string
+	Label string
+	Base  int
+	Count int
+}
+
+// Gpiochips maps sysfs gpio numbers to the lines of gpio character devices,
+// for adaptors which number their pins like the sysfs gpio interface
+type Gpiochips []GpiochipRange
+
+// Line returns the gpio character device and line offset of a sysfs gpio
+// number. Ranges whose label no device has are skipped, so that the chips of
+// several models of a board can be listed together.
+func (g Gpiochips) Line(pin int) (chip string, line int, err error) {
+	err = fmt.Errorf("No gpio character device found for gpio %v", pin)
+	for _, r := range g {
+		if pin < r.Base || pin >= r.Base+r.Count {
+			continue
+		}
+		chip := r.Chip
+		if r.Label != "" {
+			var lerr error
+			if chip, lerr = GpiochipByLabel(r.Label); lerr != nil {
+				err = lerr
+				continue
+			}
+		}
+		return chip, pin - r.Base, nil
+	}
+	return "", 0, err
+}
+
+// NewDigitalPin returns a DigitalPin for the line of a sysfs gpio number
+func (g Gpiochips) NewDigitalPin(pin int, flags ...LineFlag) (DigitalPin, error) {
+	chip, line, err := g.Line(pin)
+	if err != nil {
+		return nil, err
+	}
+	return NewGpiochipDigitalPin(chip, line, flags...), nil
+}
+
+// GpiochipByLabel returns the gpio character device, eg. "/dev/gpiochip0",
+// whose label is label
+func GpiochipByLabel(label string) (string, error) {
+	for n := 0; n < maxGpiochips; n++ {
+		chip := fmt.Sprintf("%v%v", GPIOCHIPPATH, n)
+		file, err := OpenFile(chip, os.O_RDONLY, 0644)
+		if err != nil {
+			continue
+		}
+		info := gpiochipInfo{}
+		_, _, errno := Syscall(
+			syscall.SYS_IOCTL,
+			file.Fd(),
+			GPIO_GET_CHIPINFO_IOCTL,
+			uintptr(unsafe.Pointer(&info)),
+		)
+		file.Close()
+		if errno == 0 && cString(info.label[:]) == label {
+			return chip, nil
+		}
+	}
+	return "", fmt.Errorf("No gpio character device found with the label %q", label)
+}
+
+// cString returns the string in b up to its first NUL byte
+func cString(b []byte) string {
+	for i, c := range b {
+		if c == 0 {
+			return string(b[:i])
+		}
+	}
+	return string(b)
+}
@@ -0,0 +1,232 @@
+package sysfs
+
+import (
+	"syscall"
+	"testing"
+	"time"
+	"unsafe"
+
+	"github.com/hybridgroup/gobot/gobottest"
+)
+
+// mockGpiochip fakes the ioctls of a gpio character device with one line
+type mockGpiochip struct {
+	request gpioLineRequest
+	config  gpioLineConfig
+	value   uint64
+	closed  uintptr
+	errno   syscall.Errno
+	events  []gpioLineEvent
+}
+
+// ioctlArg converts the uintptr argument of an ioctl back to its pointer
+func ioctlArg(a uintptr) unsafe.Pointer {
+	return *(*unsafe.Pointer)(unsafe.Pointer(&a))
+}
+
+func (m *mockGpiochip) Syscall(trap, a1, a2, a3 uintptr) (r1, r2 uintptr, err syscall.Errno) {
+	if trap == syscall.SYS_CLOSE {
+		m.closed = a1
+		return 0, 0, 0
+	}
+	if m.errno != 0 {
+		return 0, 0, m.errno
+	}
+	switch trap {
+	case syscall.SYS_PPOLL:
+		return uintptr(len(m.events)), 0, 0
+	case syscall.SYS_READ:
+		*(*gpioLineEvent)(ioctlArg(a2)) = m.events[0]
+		m.events = m.events[1:]
+		return a3, 0, 0
+	}
+	switch a2 {
+	case GPIO_V2_GET_LINE_IOCTL:
+		req := (*gpioLineRequest)(ioctlArg(a3))
+		req.fd = 42
+		m.request = *req
+	case GPIO_V2_LINE_SET_CONFIG_IOCTL:
+		m.config = *(*gpioLineConfig)(ioctlArg(a3))
+	case GPIO_V2_LINE_SET_VALUES_IOCTL:
+		values := (*gpioLineValues)(ioctlArg(a3))
+		m.value = values.bits & values.mask
+	case GPIO_V2_LINE_GET_VALUES_IOCTL:
+		values := (*gpioLineValues)(ioctlArg(a3))
+		values.bits = m.value & values.mask
+	}
+	return 0, 0, 0
+}
+
+func TestGpiochipIoctlSizes(t *testing.T) {
+	gobottest.Assert(t, unsafe.Sizeof(gpioLineRequest{}), uintptr(592))
+	gobottest.Assert(t, unsafe.Sizeof(gpioLineConfig{}), uintptr(272))
+	gobottest.Assert(t, unsafe.Sizeof(gpioLineValues{}), uintptr(16))
+	gobottest.Assert(t, unsafe.Sizeof(gpioLineEvent{}), uintptr(48))
+	gobottest.Assert(t, unsafe.Sizeof(gpiochipInfo{}), uintptr(68))
+}
+
+func TestGpiochipDigitalPin(t *testing.T) {
+	fs := NewMockFilesystem([]string{"/dev/gpiochip0"})
+	SetFilesystem(fs)
+	chip := &mockGpiochip{}
+	SetSyscall(&MockSyscall{Impl: chip.Syscall})
+	defer SetSyscall(&NativeSyscall{})
+
+	pin := NewGpiochipDigitalPin("/dev/gpiochip0", 17, PullUp, ActiveLow)
+	gobottest.Assert(t, pin.Write(1), notExportedError)
+
+	gobottest.Assert(t, pin.Export(), nil)
+	gobottest.Assert(t, chip.request.offsets[0], uint32(17))
+	gobottest.Assert(t, chip.request.numLines, uint32(1))
+	gobottest.Assert(t, string(chip.request.consumer[:5]), "gobot")
+	gobottest.Assert(t, chip.request.config.flags, uint64(GPIO_V2_LINE_FLAG_BIAS_PULL_UP|GPIO_V2_LINE_FLAG_ACTIVE_LOW|GPIO_V2_LINE_FLAG_INPUT))
+
+	gobottest.Assert(t, pin.Direction(OUT), nil)
+	gobottest.Assert(t, chip.config.flags&GPIO_V2_LINE_FLAG_OUTPUT, uint64(GPIO_V2_LINE_FLAG_OUTPUT))
+	gobottest.Assert(t, chip.config.flags&GPIO_V2_LINE_FLAG_BIAS_PULL_UP, uint64(GPIO_V2_LINE_FLAG_BIAS_PULL_UP))
+	gobottest.Refute(t, pin.Direction("sideways"), nil)
+
+	gobottest.Assert(t, pin.Write(1), nil)
+	gobottest.Assert(t, chip.value, uint64(1))
+	gobottest.Assert(t, pin.Direction(IN), nil)
+	gobottest.Assert(t, chip.config.flags&GPIO_V2_LINE_FLAG_INPUT, uint64(GPIO_V2_LINE_FLAG_INPUT))
+	val, err := pin.Read()
+	gobottest.Assert(t, err, nil)
+	gobottest.Assert(t, val, 1)
+
+	chip.errno = syscall.EBUSY
+	_, err = pin.Read()
+	gobottest.Refute(t, err, nil)
+	gobottest.Refute(t, pin.Write(0), nil)
+	chip.errno = 0
+
+	gobottest.Assert(t, pin.Unexport(), nil)
+	gobottest.Assert(t, chip.closed, uintptr(42))
+	_, err = pin.Read()
+	gobottest.Assert(t, err, notExportedError)
+
+	// requesting a busy line fails
+	chip.errno = syscall.EBUSY
+	gobottest.Refute(t, pin.Export(), nil)
+	gobottest.Assert(t, pin.Direction(IN), notExportedError)
+
+	// unknown chips fail
+	gobottest.Refute(t, NewGpiochipDigitalPin("/dev/gpiochip9", 0).Export(), nil)
+}
+
+func TestGpiochipDigitalPinBias(t *testing.T) {
+	fs := NewMockFilesystem([]string{"/dev/gpiochip0"})
+	SetFilesystem(fs)
+	chip := &mockGpiochip{}
+	SetSyscall(&MockSyscall{Impl: chip.Syscall})
+	defer SetSyscall(&NativeSyscall{})
+
+	// lines without a bias keep their direction
+	pin := NewGpiochipDigitalPin("/dev/gpiochip0", 17, ActiveLow)
+	gobottest.Assert(t, pin.Export(), nil)
+	gobottest.Assert(t, chip.request.config.flags, uint64(GPIO_V2_LINE_FLAG_ACTIVE_LOW))
+
+	// lines with a bias are requested as inputs
+	for _, flag := range []LineFlag{PullUp, PullDown, BiasDisabled} {
+		pin = NewGpiochipDigitalPin("/dev/gpiochip0", 17, flag)
+		gobottest.Assert(t, pin.Export(), nil)
+		gobottest.Assert(t, chip.request.config.flags, uint64(flag)|GPIO_V2_LINE_FLAG_INPUT)
+		gobottest.Assert(t, chip.request.config.flags&GPIO_V2_LINE_FLAG_OUTPUT, uint64(0))
+	}
+}
+
+func TestGpiochipDigitalPinEdge(t *testing.T) {
+	fs := NewMockFilesystem([]string{"/dev/gpiochip0"})
+	SetFilesystem(fs)
+	chip := &mockGpiochip{}
+	SetSyscall(&MockSyscall{Impl: chip.Syscall})
+	defer SetSyscall(&NativeSyscall{})
+
+	pin := NewGpiochipDigitalPin("/dev/gpiochip0", 4, PullDown)
+	gobottest.Assert(t, pin.(EdgeDetector).Edge(BOTH), notExportedError)
+	gobottest.Assert(t, pin.Export(), nil)
+	_, _, err := pin.(EdgeDetector).WaitForEdge(time.Millisecond)
+	gobottest.Assert(t, err, noEdgeError)
+
+	val, _, err := WaitForEdge(pin, BOTH, time.Millisecond)
+	gobottest.Assert(t, err, nil)
+	gobottest.Assert(t, val, -1)
+	gobottest.Assert(t, chip.config.flags, uint64(GPIO_V2_LINE_FLAG_BIAS_PULL_DOWN|GPIO_V2_LINE_FLAG_INPUT|
+		GPIO_V2_LINE_FLAG_EDGE_RISING|GPIO_V2_LINE_FLAG_EDGE_FALLING|GPIO_V2_LINE_FLAG_EVENT_CLOCK_REALTIME))
+
+	// reading the input keeps the edges
+	gobottest.Assert(t, pin.Direction(IN), nil)
+	gobottest.Assert(t, chip.config.flags&GPIO_V2_LINE_FLAG_EDGE_RISING, uint64(GPIO_V2_LINE_FLAG_EDGE_RISING))
+
+	chip.events = []gpioLineEvent{
+		{timestampNs: 1500000000000000000, id: GPIO_V2_LINE_EVENT_RISING_EDGE},
+		{timestampNs: 1500000000000001000, id: GPIO_V2_LINE_EVENT_FALLING_EDGE},
+	}
+	val, ts, err := WaitForEdge(pin, BOTH, time.Millisecond)
+	gobottest.Assert(t, err, nil)
+	gobottest.Assert(t, val, 1)
+	gobottest.Assert(t, ts, time.Unix(1500000000, 0))
+	val, ts, err = WaitForEdge(pin, BOTH, -1)
+	gobottest.Assert(t, err, nil)
+	gobottest.Assert(t, val, 0)
+	gobottest.Assert(t, ts, time.Unix(1500000000, 1000))
+
+	gobottest.Refute(t, pin.(EdgeDetector).Edge("sideways"), nil)
+	gobottest.Assert(t, pin.Direction(OUT), nil)
+	_, _, err = pin.(EdgeDetector).WaitForEdge(time.Millisecond)
+	gobottest.Assert(t, err, noEdgeError)
+}
+
+func TestGpiochips(t *testing.T) {
+	chips := Gpiochips{
+		{Chip: "/dev/gpiochip0", Base: 0, Count: 32},
+		{Chip: "/dev/gpiochip1", Base: 32, Count: 32},
+	}
+
+	chip, line, err := chips.Line(40)
+	gobottest.Assert(t, err, nil)
+	gobottest.Assert(t, chip, "/dev/gpiochip1")
+	gobottest.Assert(t, line, 8)
+
+	_, _, err = chips.Line(64)
+	gobottest.Refute(t, err, nil)
+
+	pin, err := chips.NewDigitalPin(3, PullDown)
+	gobottest.Assert(t, err, nil)
+	gobottest.Assert(t, pin.(*gpiochipDigitalPin).line, 3)
+	gobottest.Assert(t, pin.(*gpiochipDigitalPin).flags, uint64(GPIO_V2_LINE_FLAG_BIAS_PULL_DOWN))
+	_, err = chips.NewDigitalPin(-1)
+	gobottest.Refute(t, err, nil)
+}
+
+func TestGpiochipByLabel(t *testing.T) {
+	fs := NewMockFilesystem([]string{"/dev/gpiochip0", "/dev/gpiochip3"})
+	SetFilesystem(fs)
+	SetSyscall(&MockSyscall{Impl: MockGpiochipLabels(fs, map[string]string{
+		"/dev/gpiochip0": "gpio-0-31",
+		"/dev/gpiochip3": "gpio-32-63",
+	}, nil)})
+	defer SetSyscall(&NativeSyscall{})
+
+	chip, err := GpiochipByLabel("gpio-32-63")
+	gobottest.Assert(t, err, nil)
+	gobottest.Assert(t, chip, "/dev/gpiochip3")
+	_, err = GpiochipByLabel("gpio-64-95")
+	gobottest.Assert(t, err.Error(), "No gpio character device found with the label \"gpio-64-95\"")
+
+	chips := Gpiochips{
+		{Label: "pinctrl-bcm2711", Base: 0, Count: 58},
+		{Label: "gpio-0-31", Base: 0, Count: 32},
+		{Chip: "/dev/gpiochip1", Base: 32, Count: 32},
+		{Label: "gpio-64-95", Base: 64, Count: 32},
+	}
+	chip, line, err := chips.Line(4)
+	gobottest.Assert(t, err, nil)
+	gobottest.Assert(t, chip, "/dev/gpiochip0")
+	gobottest.Assert(t, line, 4)
+	chip, _, err = chips.Line(40)
+	gobottest.Assert(t, err, nil)
+	gobottest.Assert(t, chip, "/dev/gpiochip1")
+	_, _, err = chips.Line(70)
+	gobottest.Assert(t, err.Error(), "No gpio character device found with the label \"gpio-64-95\"")
+}
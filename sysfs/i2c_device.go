@@ -1,6 +1,7 @@
 package sysfs
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -10,15 +11,28 @@ import (
 
 const (
 	I2C_SLAVE                = 0x0703
+	I2C_RDWR                 = 0x0707
 	I2C_SMBUS                = 0x0720
 	I2C_SMBUS_WRITE          = 0
 	I2C_SMBUS_READ           = 1
+	I2C_SMBUS_BYTE_DATA      = 2
+	I2C_SMBUS_WORD_DATA      = 3
 	I2C_SMBUS_I2C_BLOCK_DATA = 8
+	I2C_SMBUS_BLOCK_MAX      = 32
+
+	// Message flags of combined transactions
+	I2C_M_RD = 0x0001
 
 	// Adapter functionality
 	I2C_FUNCS                       = 0x0705
+	I2C_FUNC_I2C                    = 0x00000001
+	I2C_FUNC_SMBUS_READ_BYTE_DATA   = 0x00080000
+	I2C_FUNC_SMBUS_WRITE_BYTE_DATA  = 0x00100000
+	I2C_FUNC_SMBUS_READ_WORD_DATA   = 0x00200000
+	I2C_FUNC_SMBUS_WRITE_WORD_DATA  = 0x00400000
 	I2C_FUNC_SMBUS_READ_BLOCK_DATA  = 0x01000000
 	I2C_FUNC_SMBUS_WRITE_BLOCK_DATA = 0x02000000
+	I2C_FUNC_SMBUS_READ_I2C_BLOCK   = 0x04000000
 )
 
 type i2cSmbusIoctlData struct {
@@ -28,14 +42,100 @@ type i2cSmbusIoctlData struct {
 	data      uintptr
 }
 
+// i2cMsg is struct i2c_msg of linux/i2c.h
+type i2cMsg struct {
+	addr  uint16
+	flags uint16
+	len   uint16
+	buf   uintptr
+}
+
+// i2cRdwrIoctlData is struct i2c_rdwr_ioctl_data of linux/i2c-dev.h
+type i2cRdwrIoctlData struct {
+	msgs  uintptr
+	nmsgs uint32
+}
+
 type I2cDevice interface {
 	io.ReadWriteCloser
 	SetAddress(int) error
+	// ReadByteData reads a byte from register reg
+	ReadByteData(reg byte) (byte, error)
+	// WriteByteData writes val to register reg
+	WriteByteData(reg byte, val byte) error
+	// ReadWordData reads a little-endian word from register reg
+	ReadWordData(reg byte) (uint16, error)
+	// WriteWordData writes val to register reg as a little-endian word
+	WriteWordData(reg byte, val uint16) error
+	// ReadBlockData fills b with the bytes read starting at register reg
+	ReadBlockData(reg byte, b []byte) error
+	// WriteRead writes w and then reads into r in one transaction, with a
+	// repeated start instead of a stop condition in between
+	WriteRead(w []byte, r []byte) error
+}
+
+// I2cReadByteData reads a byte from register reg of the device at address on
+// the bus of d
+func I2cReadByteData(d I2cDevice, address int, reg byte) (val byte, err error) {
+	if err = d.SetAddress(address); err != nil {
+		return
+	}
+	return d.ReadByteData(reg)
+}
+
+// I2cWriteByteData writes val to register reg of the device at address on the
+// bus of d
+func I2cWriteByteData(d I2cDevice, address int, reg byte, val byte) (err error) {
+	if err = d.SetAddress(address); err != nil {
+		return
+	}
+	return d.WriteByteData(reg, val)
+}
+
+// I2cReadWordData reads a little-endian word from register reg of the device
+// at address on the bus of d
+func I2cReadWordData(d I2cDevice, address int, reg byte) (val uint16, err error) {
+	if err = d.SetAddress(address); err != nil {
+		return
+	}
+	return d.ReadWordData(reg)
+}
+
+// I2cWriteWordData writes val as a little-endian word to register reg of the
+// device at address on the bus of d
+func I2cWriteWordData(d I2cDevice, address int, reg byte, val uint16) (err error) {
+	if err = d.SetAddress(address); err != nil {
+		return
+	}
+	return d.WriteWordData(reg, val)
+}
+
+// I2cReadBlockData reads size bytes starting at register reg of the device at
+// address on the bus of d
+func I2cReadBlockData(d I2cDevice, address int, reg byte, size int) (data []byte, err error) {
+	if err = d.SetAddress(address); err != nil {
+		return
+	}
+	data = make([]byte, size)
+	err = d.ReadBlockData(reg, data)
+	return
+}
+
+// I2cWriteRead writes buf to the device at address on the bus of d and then
+// reads size bytes from it, without a stop condition in between
+func I2cWriteRead(d I2cDevice, address int, buf []byte, size int) (data []byte, err error) {
+	if err = d.SetAddress(address); err != nil {
+		return
+	}
+	data = make([]byte, size)
+	err = d.WriteRead(buf, data)
+	return
 }
 
 type i2cDevice struct {
-	file  File
-	funcs uint64 // adapter functionality mask
+	file    File
+	funcs   uint64 // adapter functionality mask
+	address int
 }
 
 // NewI2cDevice returns an io.ReadWriteCloser with the proper ioctrl given
@@ -78,8 +178,9 @@ func (d *i2cDevice) SetAddress(address int) (err error) {
 	)
 
 	if errno != 0 {
-		err = fmt.Errorf("Setting address failed with syscall.Errno %v", errno)
+		return fmt.Errorf("Setting address failed with syscall.Errno %v", errno)
 	}
+	d.address = address
 
 	return
 }
@@ -97,20 +198,7 @@ func (d *i2cDevice) Read(b []byte) (n int, err error) {
 	data := make([]byte, len(b)+1)
 	data[0] = byte(len(b))
 
-	smbus := &i2cSmbusIoctlData{
-		readWrite: I2C_SMBUS_READ,
-		command:   0,
-		size:      I2C_SMBUS_I2C_BLOCK_DATA,
-		data:      uintptr(unsafe.Pointer(&data[0])),
-	}
-	_, _, errno := Syscall(
-		syscall.SYS_IOCTL,
-		d.file.Fd(),
-		I2C_SMBUS,
-		uintptr(unsafe.Pointer(smbus)),
-	)
-
-	if errno != 0 {
+	if errno := d.smbusAccess(I2C_SMBUS_READ, 0, I2C_SMBUS_I2C_BLOCK_DATA, unsafe.Pointer(&data[0])); errno != 0 {
 		return n, fmt.Errorf("Read failed with syscall.Errno %v", errno)
 	}
 
@@ -135,23 +223,164 @@ func (d *i2cDevice) Write(b []byte) (n int, err error) {
 
 	copy(data[1:], buf)
 
-	smbus := &i2cSmbusIoctlData{
-		readWrite: I2C_SMBUS_WRITE,
-		command:   command,
-		size:      I2C_SMBUS_I2C_BLOCK_DATA,
-		data:      uintptr(unsafe.Pointer(&data[0])),
+	if errno := d.smbusAccess(I2C_SMBUS_WRITE, command, I2C_SMBUS_I2C_BLOCK_DATA, unsafe.Pointer(&data[0])); errno != 0 {
+		err = fmt.Errorf("Write failed with syscall.Errno %v", errno)
+	}
+
+	return len(b), err
+}
+
+func (d *i2cDevice) ReadByteData(reg byte) (val byte, err error) {
+	if d.funcs&I2C_FUNC_SMBUS_READ_BYTE_DATA == 0 {
+		// Adapter doesn't support SMBus byte data read
+		buf := make([]byte, 1)
+		err = d.readRegister(reg, buf)
+		return buf[0], err
+	}
+
+	if errno := d.smbusAccess(I2C_SMBUS_READ, reg, I2C_SMBUS_BYTE_DATA, unsafe.Pointer(&val)); errno != 0 {
+		err = fmt.Errorf("Read byte data failed with syscall.Errno %v", errno)
+	}
+	return
+}
+
+func (d *i2cDevice) WriteByteData(reg byte, val byte) (err error) {
+	if d.funcs&I2C_FUNC_SMBUS_WRITE_BYTE_DATA == 0 {
+		// Adapter doesn't support SMBus byte data write
+		_, err = d.file.Write([]byte{reg, val})
+		return
+	}
+
+	if errno := d.smbusAccess(I2C_SMBUS_WRITE, reg, I2C_SMBUS_BYTE_DATA, unsafe.Pointer(&val)); errno != 0 {
+		err = fmt.Errorf("Write byte data failed with syscall.Errno %v", errno)
+	}
+	return
+}
+
+func (d *i2cDevice) ReadWordData(reg byte) (val uint16, err error) {
+	if d.funcs&I2C_FUNC_SMBUS_READ_WORD_DATA == 0 {
+		// Adapter doesn't support SMBus word data read
+		buf := make([]byte, 2)
+		err = d.readRegister(reg, buf)
+		return uint16(buf[0]) | uint16(buf[1])<<8, err
+	}
+
+	// SMBus words are little-endian, as is the union of the ioctl
+	data := make([]byte, 2)
+	if errno := d.smbusAccess(I2C_SMBUS_READ, reg, I2C_SMBUS_WORD_DATA, unsafe.Pointer(&data[0])); errno != 0 {
+		return 0, fmt.Errorf("Read word data failed with syscall.Errno %v", errno)
+	}
+	return uint16(data[0]) | uint16(data[1])<<8, nil
+}
+
+func (d *i2cDevice) WriteWordData(reg byte, val uint16) (err error) {
+	data := []byte{byte(val), byte(val >> 8)}
+
+	if d.funcs&I2C_FUNC_SMBUS_WRITE_WORD_DATA == 0 {
+		// Adapter doesn't support SMBus word data write
+		_, err = d.file.Write(append([]byte{reg}, data...))
+		return
+	}
+
+	if errno := d.smbusAccess(I2C_SMBUS_WRITE, reg, I2C_SMBUS_WORD_DATA, unsafe.Pointer(&data[0])); errno != 0 {
+		err = fmt.Errorf("Write word data failed with syscall.Errno %v", errno)
+	}
+	return
+}
+
+func (d *i2cDevice) ReadBlockData(reg byte, b []byte) (err error) {
+	if len(b) > I2C_SMBUS_BLOCK_MAX {
+		return fmt.Errorf("Read block data of more than %v bytes", I2C_SMBUS_BLOCK_MAX)
+	}
+	if d.funcs&I2C_FUNC_SMBUS_READ_I2C_BLOCK == 0 {
+		// Adapter doesn't support SMBus i2c block read
+		return d.readRegister(reg, b)
+	}
+
+	data := make([]byte, I2C_SMBUS_BLOCK_MAX+2)
+	data[0] = byte(len(b))
+
+	if errno := d.smbusAccess(I2C_SMBUS_READ, reg, I2C_SMBUS_I2C_BLOCK_DATA, unsafe.Pointer(&data[0])); errno != 0 {
+		return fmt.Errorf("Read block data failed with syscall.Errno %v", errno)
+	}
+	// The adapter may report more bytes than were asked for
+	n := int(data[0])
+	if n > len(b) {
+		n = len(b)
+	}
+	copy(b, data[1:n+1])
+
+	return
+}
+
+func (d *i2cDevice) WriteRead(w []byte, r []byte) (err error) {
+	if len(w) == 0 || len(r) == 0 {
+		return errors.New("WriteRead needs bytes to write and to read")
+	}
+	if d.funcs&I2C_FUNC_I2C == 0 {
+		// Adapter doesn't support plain i2c messages, so the best it can do
+		// is a write and a read with a stop condition in between
+		if _, err = d.file.Write(w); err != nil {
+			return
+		}
+		_, err = d.file.Read(r)
+		return
+	}
+
+	msgs := []i2cMsg{
+		{
+			addr: uint16(d.address),
+			len:  uint16(len(w)),
+			buf:  uintptr(unsafe.Pointer(&w[0])),
+		},
+		{
+			addr:  uint16(d.address),
+			flags: I2C_M_RD,
+			len:   uint16(len(r)),
+			buf:   uintptr(unsafe.Pointer(&r[0])),
+		},
+	}
+	rdwr := &i2cRdwrIoctlData{
+		msgs:  uintptr(unsafe.Pointer(&msgs[0])),
+		nmsgs: uint32(len(msgs)),
 	}
 
 	_, _, errno := Syscall(
 		syscall.SYS_IOCTL,
 		d.file.Fd(),
-		I2C_SMBUS,
-		uintptr(unsafe.Pointer(smbus)),
+		I2C_RDWR,
+		uintptr(unsafe.Pointer(rdwr)),
 	)
 
 	if errno != 0 {
-		err = fmt.Errorf("Write failed with syscall.Errno %v", errno)
+		err = fmt.Errorf("WriteRead failed with syscall.Errno %v", errno)
 	}
+	return
+}
 
-	return len(b), err
+// readRegister selects register reg with a write and then reads b from it,
+// for adapters without SMBus support
+func (d *i2cDevice) readRegister(reg byte, b []byte) (err error) {
+	if _, err = d.file.Write([]byte{reg}); err != nil {
+		return
+	}
+	_, err = d.file.Read(b)
+	return
+}
+
+func (d *i2cDevice) smbusAccess(readWrite byte, command byte, size uint32, data unsafe.Pointer) syscall.Errno {
+	smbus := &i2cSmbusIoctlData{
+		readWrite: readWrite,
+		command:   command,
+		size:      size,
+		data:      uintptr(data),
+	}
+
+	_, _, errno := Syscall(
+		syscall.SYS_IOCTL,
+		d.file.Fd(),
+		I2C_SMBUS,
+		uintptr(unsafe.Pointer(smbus)),
+	)
+	return errno
 }
@@ -2,11 +2,64 @@ package sysfs
 
 import (
 	"os"
+	"syscall"
 	"testing"
+	"unsafe"
 
 	"github.com/hybridgroup/gobot/gobottest"
 )
 
+// mockI2cAdapter fakes the ioctls of an i2c adapter with a single device of
+// 256 byte registers, whose register pointer auto-increments on reads
+type mockI2cAdapter struct {
+	funcs     uint64
+	address   uint16
+	registers [256]byte
+	msgs      []i2cMsg
+	blockLen  byte // length reported by block reads, if not 0
+}
+
+func (m *mockI2cAdapter) Syscall(trap, a1, a2, a3 uintptr) (r1, r2 uintptr, err syscall.Errno) {
+	switch a2 {
+	case I2C_FUNCS:
+		*(*uint64)(ioctlArg(a3)) = m.funcs
+	case I2C_SLAVE:
+		m.address = uint16(a3)
+	case I2C_SMBUS:
+		smbus := *(*i2cSmbusIoctlData)(ioctlArg(a3))
+		reg := int(smbus.command)
+		data := (*[I2C_SMBUS_BLOCK_MAX + 2]byte)(ioctlArg(smbus.data))
+		var size int
+		switch smbus.size {
+		case I2C_SMBUS_BYTE_DATA:
+			size = 1
+		case I2C_SMBUS_WORD_DATA:
+			size = 2
+		case I2C_SMBUS_I2C_BLOCK_DATA:
+			size = int(data[0])
+			if m.blockLen != 0 {
+				data[0] = m.blockLen
+			}
+			data = (*[I2C_SMBUS_BLOCK_MAX + 2]byte)(ioctlArg(smbus.data + 1))
+		}
+		for i := 0; i < size; i++ {
+			if smbus.readWrite == I2C_SMBUS_READ {
+				data[i] = m.registers[reg+i]
+			} else {
+				m.registers[reg+i] = data[i]
+			}
+		}
+	case I2C_RDWR:
+		rdwr := *(*i2cRdwrIoctlData)(ioctlArg(a3))
+		m.msgs = append([]i2cMsg{}, (*[2]i2cMsg)(ioctlArg(rdwr.msgs))[:rdwr.nmsgs]...)
+		reg := int(*(*byte)(ioctlArg(m.msgs[0].buf)))
+		for i := 0; i < int(m.msgs[1].len); i++ {
+			*(*byte)(ioctlArg(m.msgs[1].buf + uintptr(i))) = m.registers[reg+i]
+		}
+	}
+	return 0, 0, 0
+}
+
 func TestNewI2cDevice(t *testing.T) {
 	fs := NewMockFilesystem([]string{})
 	SetFilesystem(fs)
@@ -47,3 +100,130 @@ func TestNewI2cDevice(t *testing.T) {
 	gobottest.Assert(t, err, nil)
 
 }
+
+func TestI2cDeviceIoctlSizes(t *testing.T) {
+	gobottest.Assert(t, unsafe.Sizeof(i2cMsg{}), uintptr(16))
+	gobottest.Assert(t, unsafe.Sizeof(i2cRdwrIoctlData{}), uintptr(16))
+}
+
+func TestI2cDeviceSMBus(t *testing.T) {
+	SetFilesystem(NewMockFilesystem([]string{"/dev/i2c-1"}))
+	adapter := &mockI2cAdapter{
+		funcs: I2C_FUNC_I2C | I2C_FUNC_SMBUS_READ_BYTE_DATA |
+			I2C_FUNC_SMBUS_WRITE_BYTE_DATA | I2C_FUNC_SMBUS_READ_WORD_DATA |
+			I2C_FUNC_SMBUS_WRITE_WORD_DATA | I2C_FUNC_SMBUS_READ_I2C_BLOCK,
+	}
+	SetSyscall(&MockSyscall{Impl: adapter.Syscall})
+	defer SetSyscall(&MockSyscall{})
+
+	i, err := NewI2cDevice("/dev/i2c-1", 0x42)
+	gobottest.Assert(t, err, nil)
+	gobottest.Assert(t, adapter.address, uint16(0x42))
+
+	gobottest.Assert(t, i.WriteByteData(0x10, 0xab), nil)
+	gobottest.Assert(t, adapter.registers[0x10], byte(0xab))
+	val, err := i.ReadByteData(0x10)
+	gobottest.Assert(t, err, nil)
+	gobottest.Assert(t, val, byte(0xab))
+
+	gobottest.Assert(t, i.WriteWordData(0x20, 0x1234), nil)
+	gobottest.Assert(t, adapter.registers[0x20], byte(0x34))
+	gobottest.Assert(t, adapter.registers[0x21], byte(0x12))
+	word, err := i.ReadWordData(0x20)
+	gobottest.Assert(t, err, nil)
+	gobottest.Assert(t, word, uint16(0x1234))
+
+	copy(adapter.registers[0x30:], []byte{0x01, 0x02, 0x03})
+	buf := make([]byte, 3)
+	gobottest.Assert(t, i.ReadBlockData(0x30, buf), nil)
+	gobottest.Assert(t, buf, []byte{0x01, 0x02, 0x03})
+	gobottest.Refute(t, i.ReadBlockData(0x30, make([]byte, 33)), nil)
+
+	buf = make([]byte, 2)
+	gobottest.Assert(t, i.WriteRead([]byte{0x31}, buf), nil)
+	gobottest.Assert(t, buf, []byte{0x02, 0x03})
+	gobottest.Assert(t, len(adapter.msgs), 2)
+	gobottest.Assert(t, adapter.msgs[0].addr, uint16(0x42))
+	gobottest.Assert(t, adapter.msgs[0].flags, uint16(0))
+	gobottest.Assert(t, adapter.msgs[1].flags, uint16(I2C_M_RD))
+	gobottest.Refute(t, i.WriteRead([]byte{}, buf), nil)
+}
+
+func TestI2cDeviceBlockDataOverlong(t *testing.T) {
+	SetFilesystem(NewMockFilesystem([]string{"/dev/i2c-1"}))
+	adapter := &mockI2cAdapter{
+		funcs:    I2C_FUNC_SMBUS_READ_I2C_BLOCK,
+		blockLen: 0xff,
+	}
+	SetSyscall(&MockSyscall{Impl: adapter.Syscall})
+	defer SetSyscall(&MockSyscall{})
+
+	i, _ := NewI2cDevice("/dev/i2c-1", 0x42)
+	copy(adapter.registers[0x10:], []byte{0x01, 0x02, 0x03})
+	buf := make([]byte, 2)
+	gobottest.Assert(t, i.ReadBlockData(0x10, buf), nil)
+	gobottest.Assert(t, buf, []byte{0x01, 0x02})
+}
+
+func TestI2cRegisterHelpers(t *testing.T) {
+	SetFilesystem(NewMockFilesystem([]string{"/dev/i2c-1"}))
+	adapter := &mockI2cAdapter{
+		funcs: I2C_FUNC_I2C | I2C_FUNC_SMBUS_READ_BYTE_DATA |
+			I2C_FUNC_SMBUS_WRITE_BYTE_DATA | I2C_FUNC_SMBUS_READ_WORD_DATA |
+			I2C_FUNC_SMBUS_WRITE_WORD_DATA | I2C_FUNC_SMBUS_READ_I2C_BLOCK,
+	}
+	SetSyscall(&MockSyscall{Impl: adapter.Syscall})
+	defer SetSyscall(&MockSyscall{})
+
+	i, _ := NewI2cDevice("/dev/i2c-1", 0x42)
+
+	gobottest.Assert(t, I2cWriteWordData(i, 0x10, 0x01, 0x0302), nil)
+	gobottest.Assert(t, adapter.address, uint16(0x10))
+	gobottest.Assert(t, I2cWriteByteData(i, 0x11, 0x03, 0x04), nil)
+	gobottest.Assert(t, adapter.address, uint16(0x11))
+
+	data, err := I2cReadBlockData(i, 0x12, 0x01, 3)
+	gobottest.Assert(t, err, nil)
+	gobottest.Assert(t, data, []byte{0x02, 0x03, 0x04})
+	gobottest.Assert(t, adapter.address, uint16(0x12))
+
+	val, err := I2cReadByteData(i, 0x13, 0x03)
+	gobottest.Assert(t, err, nil)
+	gobottest.Assert(t, val, byte(0x04))
+	gobottest.Assert(t, adapter.address, uint16(0x13))
+
+	word, err := I2cReadWordData(i, 0x14, 0x02)
+	gobottest.Assert(t, err, nil)
+	gobottest.Assert(t, word, uint16(0x0403))
+	gobottest.Assert(t, adapter.address, uint16(0x14))
+
+	data, err = I2cWriteRead(i, 0x15, []byte{0x01}, 2)
+	gobottest.Assert(t, err, nil)
+	gobottest.Assert(t, data, []byte{0x02, 0x03})
+	gobottest.Assert(t, adapter.msgs[0].addr, uint16(0x15))
+	gobottest.Assert(t, adapter.msgs[1].addr, uint16(0x15))
+}
+
+func TestI2cDeviceWithoutSMBus(t *testing.T) {
+	fs := NewMockFilesystem([]string{"/dev/i2c-1"})
+	SetFilesystem(fs)
+	SetSyscall(&MockSyscall{})
+
+	i, err := NewI2cDevice("/dev/i2c-1", 0x42)
+	gobottest.Assert(t, err, nil)
+
+	gobottest.Assert(t, i.WriteByteData(0x10, 0xab), nil)
+	gobottest.Assert(t, fs.Files["/dev/i2c-1"].Contents, "\x10\xab")
+
+	gobottest.Assert(t, i.WriteWordData(0x20, 0x1234), nil)
+	gobottest.Assert(t, fs.Files["/dev/i2c-1"].Contents, "\x20\x34\x12")
+
+	// the mock file echoes the selected register back
+	val, err := i.ReadByteData(0x10)
+	gobottest.Assert(t, err, nil)
+	gobottest.Assert(t, val, byte(0x10))
+
+	buf := make([]byte, 1)
+	gobottest.Assert(t, i.WriteRead([]byte{0x30}, buf), nil)
+	gobottest.Assert(t, buf, []byte{0x30})
+}
@@ -0,0 +1,8 @@
+package sysfs
+
+// Events of pollFd
+const (
+	pollIn  = 0x1
+	pollPri = 0x2
+	pollErr = 0x8
+)
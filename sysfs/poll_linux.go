@@ -0,0 +1,45 @@
+package sysfs
+
+import (
+	"fmt"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+type pollFdArg struct {
+	fd      int32
+	events  int16
+	revents int16
+}
+
+// pollFd waits at most timeout for one of the events on fd, and returns
+// whether one occurred. A negative timeout waits forever.
+func pollFd(fd uintptr, events int16, timeout time.Duration) (bool, error) {
+	p := pollFdArg{fd: int32(fd), events: events}
+	deadline := time.Now().Add(timeout)
+	for {
+		var ts *syscall.Timespec
+		if timeout >= 0 {
+			remaining := deadline.Sub(time.Now())
+			if remaining < 0 {
+				remaining = 0
+			}
+			t := syscall.NsecToTimespec(int64(remaining))
+			ts = &t
+		}
+		n, _, errno := Syscall(
+			syscall.SYS_PPOLL,
+			uintptr(unsafe.Pointer(&p)),
+			1,
+			uintptr(unsafe.Pointer(ts)),
+		)
+		if errno == syscall.EINTR {
+			continue
+		}
+		if errno != 0 {
+			return false, fmt.Errorf("Poll failed with syscall.Errno %v", errno)
+		}
+		return n > 0, nil
+	}
+}
@@ -0,0 +1,13 @@
+//go:build !linux
+// +build !linux
+
+package sysfs
+
+import (
+	"time"
+)
+
+// pollFd is only supported on linux
+func pollFd(fd uintptr, events int16, timeout time.Duration) (bool, error) {
+	return false, ErrEdgeUnsupported
+}
@@ -0,0 +1,154 @@
+package sysfs
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+const (
+	// PWMPATH default linux pwm chip path
+	PWMPATH = "/sys/class/pwm/pwmchip0"
+	// NORMAL pwm polarity
+	NORMAL = "normal"
+	// INVERSED pwm polarity
+	INVERSED = "inversed"
+)
+
+// PWMPin represents a channel of a pwm chip of the linux sysfs pwm interface.
+// Periods and duty cycles are in nanoseconds.
+type PWMPin struct {
+	// Path is the directory of the pwm chip, PWMPATH by default
+	Path string
+	pin  string
+}
+
+// NewPWMPin returns a PWMPin given the channel number on the pwm chip
+func NewPWMPin(pin int) *PWMPin {
+	return &PWMPin{Path: PWMPATH, pin: strconv.Itoa(pin)}
+}
+
+// Export exports the pwm channel for use by the operating system
+func (p *PWMPin) Export() error {
+	err := p.write("export", p.pin)
+	// If EBUSY then the pin has already been exported
+	if e, ok := err.(*os.PathError); ok && e.Err == syscall.EBUSY {
+		return nil
+	}
+	return err
+}
+
+// Unexport releases the pwm channel from the operating system
+func (p *PWMPin) Unexport() error {
+	err := p.write("unexport", p.pin)
+	// If EINVAL then the pin is not exported
+	if e, ok := err.(*os.PathError); ok && e.Err == syscall.EINVAL {
+		return nil
+	}
+	return err
+}
+
+// Enable starts or stops the pwm output
+func (p *PWMPin) Enable(enable bool) error {
+	if enable {
+		return p.write(p.attr("enable"), "1")
+	}
+	return p.write(p.attr("enable"), "0")
+}
+
+// Period returns the period of the pwm output
+func (p *PWMPin) Period() (uint32, error) {
+	return p.readUint(p.attr("period"))
+}
+
+// SetPeriod sets the period of the pwm output, which must not be shorter than
+// the duty cycle
+func (p *PWMPin) SetPeriod(period uint32) error {
+	return p.write(p.attr("period"), strconv.FormatUint(uint64(period), 10))
+}
+
+// DutyCycle returns the active time of each period of the pwm output
+func (p *PWMPin) DutyCycle() (uint32, error) {
+	return p.readUint(p.attr("duty_cycle"))
+}
+
+// SetDutyCycle sets the active time of each period of the pwm output, which
+// must not be longer than the period
+func (p *PWMPin) SetDutyCycle(duty uint32) error {
+	return p.write(p.attr("duty_cycle"), strconv.FormatUint(uint64(duty), 10))
+}
+
+// SetPeriodAndDutyCycle sets both the period and the duty cycle of the pwm
+// output, in the order which keeps the duty cycle within the period
+func (p *PWMPin) SetPeriodAndDutyCycle(period uint32, duty uint32) error {
+	current, err := p.DutyCycle()
+	if err != nil {
+		return err
+	}
+	if period < current {
+		if err = p.SetDutyCycle(duty); err != nil {
+			return err
+		}
+		return p.SetPeriod(period)
+	}
+	if err = p.SetPeriod(period); err != nil {
+		return err
+	}
+	return p.SetDutyCycle(duty)
+}
+
+// Polarity returns the polarity of the pwm output, NORMAL or INVERSED
+func (p *PWMPin) Polarity() (string, error) {
+	return p.read(p.attr("polarity"))
+}
+
+// SetPolarity sets the polarity of the pwm output, NORMAL or INVERSED. Most
+// chips only change the polarity of a disabled output.
+func (p *PWMPin) SetPolarity(polarity string) error {
+	return p.write(p.attr("polarity"), polarity)
+}
+
+// attr returns the name of an attribute of the pwm channel
+func (p *PWMPin) attr(name string) string {
+	return "pwm" + p.pin + "/" + name
+}
+
+func (p *PWMPin) write(name string, data string) error {
+	f, err := OpenFile(p.Path+"/"+name, os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = f.Write([]byte(data))
+	return err
+}
+
+func (p *PWMPin) read(name string) (string, error) {
+	f, err := OpenFile(p.Path+"/"+name, os.O_RDONLY, 0644)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	buf := make([]byte, 32)
+	n, err := f.Read(buf)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(buf[:n])), nil
+}
+
+func (p *PWMPin) readUint(name string) (uint32, error) {
+	s, err := p.read(name)
+	if err != nil {
+		return 0, err
+	}
+	val, err := strconv.ParseUint(s, 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("Invalid value %q of %v", s, name)
+	}
+	return uint32(val), nil
+}
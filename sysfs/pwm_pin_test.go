@@ -0,0 +1,83 @@
+package sysfs
+
+import (
+	"testing"
+
+	"github.com/hybridgroup/gobot/gobottest"
+)
+
+func TestPWMPin(t *testing.T) {
+	fs := NewMockFilesystem([]string{
+		"/sys/class/pwm/pwmchip0/export",
+		"/sys/class/pwm/pwmchip0/unexport",
+		"/sys/class/pwm/pwmchip0/pwm1/enable",
+		"/sys/class/pwm/pwmchip0/pwm1/period",
+		"/sys/class/pwm/pwmchip0/pwm1/duty_cycle",
+		"/sys/class/pwm/pwmchip0/pwm1/polarity",
+	})
+	SetFilesystem(fs)
+
+	pin := NewPWMPin(1)
+	gobottest.Assert(t, pin.Path, PWMPATH)
+
+	gobottest.Assert(t, pin.Export(), nil)
+	gobottest.Assert(t, fs.Files["/sys/class/pwm/pwmchip0/export"].Contents, "1")
+
+	gobottest.Assert(t, pin.Enable(true), nil)
+	gobottest.Assert(t, fs.Files["/sys/class/pwm/pwmchip0/pwm1/enable"].Contents, "1")
+
+	fs.Files["/sys/class/pwm/pwmchip0/pwm1/period"].Contents = "20000000\n"
+	period, err := pin.Period()
+	gobottest.Assert(t, err, nil)
+	gobottest.Assert(t, period, uint32(20000000))
+
+	gobottest.Assert(t, pin.SetDutyCycle(1500001), nil)
+	duty, _ := pin.DutyCycle()
+	gobottest.Assert(t, duty, uint32(1500001))
+
+	gobottest.Assert(t, pin.SetPolarity(INVERSED), nil)
+	polarity, _ := pin.Polarity()
+	gobottest.Assert(t, polarity, "inversed")
+
+	gobottest.Assert(t, pin.Enable(false), nil)
+	gobottest.Assert(t, fs.Files["/sys/class/pwm/pwmchip0/pwm1/enable"].Contents, "0")
+	gobottest.Assert(t, pin.Unexport(), nil)
+	gobottest.Assert(t, fs.Files["/sys/class/pwm/pwmchip0/unexport"].Contents, "1")
+
+	fs.Files["/sys/class/pwm/pwmchip0/pwm1/period"].Contents = "fast"
+	_, err = pin.Period()
+	gobottest.Refute(t, err, nil)
+
+	// unknown channels fail
+	pin = NewPWMPin(2)
+	_, err = pin.DutyCycle()
+	gobottest.Refute(t, err, nil)
+	gobottest.Refute(t, pin.Enable(true), nil)
+	gobottest.Refute(t, pin.SetPeriodAndDutyCycle(10, 5), nil)
+}
+
+func TestPWMPinSetPeriodAndDutyCycle(t *testing.T) {
+	fs := NewMockFilesystem([]string{
+		"/sys/class/pwm/pwmchip1/pwm0/period",
+		"/sys/class/pwm/pwmchip1/pwm0/duty_cycle",
+	})
+	SetFilesystem(fs)
+
+	pin := NewPWMPin(0)
+	pin.Path = "/sys/class/pwm/pwmchip1"
+	period := fs.Files["/sys/class/pwm/pwmchip1/pwm0/period"]
+	duty := fs.Files["/sys/class/pwm/pwmchip1/pwm0/duty_cycle"]
+	duty.Contents = "0"
+
+	// a longer period is set before the duty cycle
+	gobottest.Assert(t, pin.SetPeriodAndDutyCycle(20000000, 1500000), nil)
+	gobottest.Assert(t, period.Contents, "20000000")
+	gobottest.Assert(t, duty.Contents, "1500000")
+	gobottest.Assert(t, period.Seq < duty.Seq, true)
+
+	// a period shorter than the duty cycle is set after it
+	gobottest.Assert(t, pin.SetPeriodAndDutyCycle(1000000, 500000), nil)
+	gobottest.Assert(t, period.Contents, "1000000")
+	gobottest.Assert(t, duty.Contents, "500000")
+	gobottest.Assert(t, duty.Seq < period.Seq, true)
+}
@@ -0,0 +1,147 @@
+package sysfs
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+const (
+	// SPIPATH default linux spidev device path prefix
+	SPIPATH = "/dev/spidev"
+
+	SPI_IOC_WR_MODE          = 0x40016B01
+	SPI_IOC_WR_BITS_PER_WORD = 0x40016B03
+	SPI_IOC_WR_MAX_SPEED_HZ  = 0x40046B04
+	SPI_IOC_MESSAGE_1        = 0x40206B00
+
+	// SPI modes, the combinations of clock polarity and phase
+	SPI_MODE_0 = 0
+	SPI_MODE_1 = 1
+	SPI_MODE_2 = 2
+	SPI_MODE_3 = 3
+)
+
+// spiIocTransfer is struct spi_ioc_transfer of linux/spi/spidev.h
+type spiIocTransfer struct {
+	txBuf          uint64
+	rxBuf          uint64
+	length         uint32
+	speedHz        uint32
+	delayUsecs     uint16
+	bitsPerWord    uint8
+	csChange       uint8
+	txNbits        uint8
+	rxNbits        uint8
+	wordDelayUsecs uint8
+	pad            uint8
+}
+
+// SPIDevice is a device on a chip select of a spi bus
+type SPIDevice interface {
+	io.Closer
+	// SetMode sets the clock polarity and phase, SPI_MODE_0 to SPI_MODE_3
+	SetMode(mode byte) error
+	// SetBitsPerWord sets the length of the words of transfers
+	SetBitsPerWord(bits byte) error
+	// SetSpeed sets the maximum clock speed of transfers in Hz
+	SetSpeed(speed uint32) error
+	// Transfer writes tx to the device while reading as many bytes into rx
+	Transfer(tx []byte, rx []byte) error
+}
+
+type spiDevice struct {
+	file  File
+	bits  byte
+	speed uint32
+}
+
+// SPIDevicePath returns the spidev path of a chip select of a spi bus
+func SPIDevicePath(bus int, chip int) string {
+	return fmt.Sprintf("%v%v.%v", SPIPATH, bus, chip)
+}
+
+// NewSPIDevice returns a SPIDevice given a spidev location, e.g.
+// /dev/spidev0.0, and the mode, bits per word and maximum speed in Hz of its
+// transfers
+func NewSPIDevice(location string, mode byte, bits byte, speed uint32) (d *spiDevice, err error) {
+	d = &spiDevice{}
+
+	if d.file, err = OpenFile(location, os.O_RDWR, os.ModeExclusive); err != nil {
+		return
+	}
+	if err = d.SetMode(mode); err != nil {
+		return
+	}
+	if err = d.SetBitsPerWord(bits); err != nil {
+		return
+	}
+	err = d.SetSpeed(speed)
+
+	return
+}
+
+func (d *spiDevice) SetMode(mode byte) (err error) {
+	if err = d.ioctl(SPI_IOC_WR_MODE, unsafe.Pointer(&mode)); err != nil {
+		return fmt.Errorf("Setting mode failed with %v", err)
+	}
+	return
+}
+
+func (d *spiDevice) SetBitsPerWord(bits byte) (err error) {
+	if err = d.ioctl(SPI_IOC_WR_BITS_PER_WORD, unsafe.Pointer(&bits)); err != nil {
+		return fmt.Errorf("Setting bits per word failed with %v", err)
+	}
+	d.bits = bits
+	return
+}
+
+func (d *spiDevice) SetSpeed(speed uint32) (err error) {
+	if err = d.ioctl(SPI_IOC_WR_MAX_SPEED_HZ, unsafe.Pointer(&speed)); err != nil {
+		return fmt.Errorf("Setting speed failed with %v", err)
+	}
+	d.speed = speed
+	return
+}
+
+func (d *spiDevice) Transfer(tx []byte, rx []byte) (err error) {
+	if len(tx) != len(rx) {
+		return errors.New("Transfer buffers must have the same length")
+	}
+	if len(tx) == 0 {
+		return
+	}
+
+	transfer := &spiIocTransfer{
+		txBuf:       uint64(uintptr(unsafe.Pointer(&tx[0]))),
+		rxBuf:       uint64(uintptr(unsafe.Pointer(&rx[0]))),
+		length:      uint32(len(tx)),
+		speedHz:     d.speed,
+		bitsPerWord: d.bits,
+	}
+	if err = d.ioctl(SPI_IOC_MESSAGE_1, unsafe.Pointer(transfer)); err != nil {
+		err = fmt.Errorf("Transfer failed with %v", err)
+	}
+	return
+}
+
+func (d *spiDevice) Close() (err error) {
+	return d.file.Close()
+}
+
+func (d *spiDevice) ioctl(request uintptr, arg unsafe.Pointer) error {
+	_, _, errno := Syscall(
+		syscall.SYS_IOCTL,
+		d.file.Fd(),
+		request,
+		uintptr(arg),
+	)
+
+	if errno != 0 {
+		return fmt.Errorf("syscall.Errno %v", errno)
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package sysfs
+
+import (
+	"syscall"
+	"testing"
+	"unsafe"
+
+	"github.com/hybridgroup/gobot/gobottest"
+)
+
+// mockSPIDevice fakes the ioctls of a spidev device which answers each byte
+// with its complement
+type mockSPIDevice struct {
+	mode     byte
+	bits     byte
+	speed    uint32
+	transfer spiIocTransfer
+	errno    syscall.Errno
+}
+
+func (m *mockSPIDevice) Syscall(trap, a1, a2, a3 uintptr) (r1, r2 uintptr, err syscall.Errno) {
+	if m.errno != 0 {
+		return 0, 0, m.errno
+	}
+	switch a2 {
+	case SPI_IOC_WR_MODE:
+		m.mode = *(*byte)(ioctlArg(a3))
+	case SPI_IOC_WR_BITS_PER_WORD:
+		m.bits = *(*byte)(ioctlArg(a3))
+	case SPI_IOC_WR_MAX_SPEED_HZ:
+		m.speed = *(*uint32)(ioctlArg(a3))
+	case SPI_IOC_MESSAGE_1:
+		m.transfer = *(*spiIocTransfer)(ioctlArg(a3))
+		for i := uintptr(0); i < uintptr(m.transfer.length); i++ {
+			tx := *(*byte)(ioctlArg(uintptr(m.transfer.txBuf) + i))
+			*(*byte)(ioctlArg(uintptr(m.transfer.rxBuf) + i)) = ^tx
+		}
+	}
+	return 0, 0, 0
+}
+
+func TestSPIDeviceIoctlSizes(t *testing.T) {
+	gobottest.Assert(t, unsafe.Sizeof(spiIocTransfer{}), uintptr(32))
+}
+
+func TestNewSPIDevice(t *testing.T) {
+	fs := NewMockFilesystem([]string{"/dev/spidev0.1"})
+	SetFilesystem(fs)
+	spi := &mockSPIDevice{}
+	SetSyscall(&MockSyscall{Impl: spi.Syscall})
+	defer SetSyscall(&NativeSyscall{})
+
+	gobottest.Assert(t, SPIDevicePath(0, 1), "/dev/spidev0.1")
+
+	_, err := NewSPIDevice("/dev/spidev1.0", SPI_MODE_0, 8, 1000000)
+	gobottest.Refute(t, err, nil)
+
+	d, err := NewSPIDevice("/dev/spidev0.1", SPI_MODE_3, 8, 1000000)
+	var _ SPIDevice = d
+	gobottest.Assert(t, err, nil)
+	gobottest.Assert(t, spi.mode, byte(SPI_MODE_3))
+	gobottest.Assert(t, spi.bits, byte(8))
+	gobottest.Assert(t, spi.speed, uint32(1000000))
+
+	gobottest.Assert(t, d.SetSpeed(500000), nil)
+	gobottest.Assert(t, spi.speed, uint32(500000))
+
+	rx := make([]byte, 3)
+	gobottest.Assert(t, d.Transfer([]byte{0x01, 0xf0, 0x00}, rx), nil)
+	gobottest.Assert(t, rx, []byte{0xfe, 0x0f, 0xff})
+	gobottest.Assert(t, spi.transfer.length, uint32(3))
+	gobottest.Assert(t, spi.transfer.speedHz, uint32(500000))
+	gobottest.Assert(t, spi.transfer.bitsPerWord, uint8(8))
+
+	gobottest.Refute(t, d.Transfer([]byte{0x01}, rx), nil)
+	gobottest.Assert(t, d.Transfer([]byte{}, []byte{}), nil)
+
+	spi.errno = syscall.EINVAL
+	gobottest.Refute(t, d.SetMode(SPI_MODE_1), nil)
+	gobottest.Refute(t, d.SetBitsPerWord(9), nil)
+	gobottest.Refute(t, d.Transfer([]byte{0x01}, []byte{0x00}), nil)
+
+	gobottest.Assert(t, d.Close(), nil)
+}
@@ -2,6 +2,7 @@ package sysfs
 
 import (
 	"syscall"
+	"unsafe"
 )
 
 // SystemCaller represents a Syscall
@@ -13,7 +14,11 @@ type SystemCaller interface {
 type NativeSyscall struct{}
 
 // MockSyscall represents the mock Syscall
-type MockSyscall struct{}
+type MockSyscall struct {
+	// Impl, if set, is called instead of returning zeros, so tests can fake
+	// the results of ioctls
+	Impl func(trap, a1, a2, a3 uintptr) (r1, r2 uintptr, err syscall.Errno)
+}
 
 var sys SystemCaller = &NativeSyscall{}
 
@@ -34,5 +39,29 @@ func (sys *NativeSyscall) Syscall(trap, a1, a2, a3 uintptr) (r1, r2 uintptr, err
 
 // Syscall implements the SystemCaller interface
 func (sys *MockSyscall) Syscall(trap, a1, a2, a3 uintptr) (r1, r2 uintptr, err syscall.Errno) {
+	if sys.Impl != nil {
+		return sys.Impl(trap, a1, a2, a3)
+	}
 	return 0, 0, 0
 }
+
+// MockGpiochipLabels returns an Impl for MockSyscall which answers
+// GPIO_GET_CHIPINFO_IOCTL for the mock gpio character devices of fs with the
+// labels given by their paths, and passes other syscalls on to impl, if set
+func MockGpiochipLabels(fs *MockFilesystem, labels map[string]string, impl func(trap, a1, a2, a3 uintptr) (r1, r2 uintptr, err syscall.Errno)) func(trap, a1, a2, a3 uintptr) (r1, r2 uintptr, err syscall.Errno) {
+	return func(trap, a1, a2, a3 uintptr) (r1, r2 uintptr, err syscall.Errno) {
+		if trap == syscall.SYS_IOCTL && a2 == GPIO_GET_CHIPINFO_IOCTL {
+			info := (*gpiochipInfo)(*(*unsafe.Pointer)(unsafe.Pointer(&a3)))
+			for chip, label := range labels {
+				if f, ok := fs.Files[chip]; ok && f.Fd() == a1 {
+					copy(info.label[:], label)
+				}
+			}
+			return 0, 0, 0
+		}
+		if impl != nil {
+			return impl(trap, a1, a2, a3)
+		}
+		return 0, 0, 0
+	}
+}
@@ -3,7 +3,6 @@ package gobot
 import (
 	"crypto/rand"
 	"errors"
-	"log"
 	"math"
 	"math/big"
 	"time"
@@ -17,7 +16,7 @@ var (
 var eventError = func(e *Event) (err error) {
 	if e == nil {
 		err = ErrUnknownEvent
-		log.Println(err.Error())
+		DefaultLogger().Error(err.Error())
 		return
 	}
 	return